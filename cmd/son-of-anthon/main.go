@@ -31,9 +31,12 @@ import (
 	"github.com/jony/son-of-anthon/pkg/skills/atc"
 	"github.com/jony/son-of-anthon/pkg/skills/chief"
 	"github.com/jony/son-of-anthon/pkg/skills/coach"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
 	"github.com/jony/son-of-anthon/pkg/skills/monitor"
 	"github.com/jony/son-of-anthon/pkg/skills/research"
+	"github.com/jony/son-of-anthon/pkg/skills/search"
 	"github.com/jony/son-of-anthon/pkg/skills/subagent"
+	"github.com/jony/son-of-anthon/pkg/users"
 	"github.com/jony/son-of-anthon/workspaces"
 )
 
@@ -54,6 +57,10 @@ func main() {
 		gatewayCmd()
 	case "setup":
 		setupCmd()
+	case "status":
+		statusCmd()
+	case "tasks":
+		tasksCmd()
 	case "version", "--version", "-v":
 		fmt.Printf("%s son-of-anthon v1.0.0\n", logo)
 	default:
@@ -71,6 +78,8 @@ func printHelp() {
 	fmt.Println("  agent     Interact with the main agent")
 	fmt.Println("  gateway   Start the background daemon with Telegram/Cron/Heartbeat")
 	fmt.Println("  setup     Run interactive UI to configure API keys and connections")
+	fmt.Println("  status    Show skill health, cache freshness and connectivity (--offline, --user <name>)")
+	fmt.Println("  tasks     Import or export tasks: tasks import <file> [--format f] [--dry-run], tasks export [--format f] [--out file]")
 	fmt.Println("  version   Show version")
 }
 
@@ -117,16 +126,17 @@ func loadConfig() (*config.Config, error) {
 		identityContent := `You are son-of-anthon, a personal multi-agent AI assistant.
 
 Available tools (call as needed, including multiple times in one session):
-- architect: Life admin; CalDAV sync/create/delete tasks on Nextcloud. Commands: sync_deadlines, create_task, delete_task
-- chief: Strategic commander; reads daily briefs, urgent deadlines, morning/evening summaries. Commands: morning_brief, evening_review, urgent_deadlines, status, delegate
-- atc: Task management; reads/writes tasks.xml, daily priorities. Commands: analyze_tasks, read_calendar, update_task, roll_over_tasks, sync_calendar, push_task
+- architect: Life admin; CalDAV sync/create/complete/delete tasks on Nextcloud. Commands: sync_deadlines, create_task, complete_task, delete_task
+- chief: Strategic commander; reads daily briefs, urgent deadlines, morning/evening summaries. Commands: morning_brief, midday_update, evening_review, weekly_review, monthly_review, urgent_deadlines, ack, snooze, mute, unmute, alerts, journal, status, delegate
+- atc: Task management; reads/writes tasks.xml, daily priorities. Commands: analyze_tasks, read_calendar, plan_day, update_task, create_local_task, delete_local_task, roll_over_tasks, stats, find_tasks, import_tasks, export_tasks, start_timer, stop_timer, pomodoro, time_report, sync_tasks, sync_calendar, push_task
 - coach: Learning coach; IELTS prep, habit tracking, Nextcloud integration. Commands: check_habits, fetch_material, generate_practice, evening_review, update_deck, nudge_telegram
 - monitor: News curation; Bangladesh + Tech RSS feeds. Commands: fetch, status, feeds
 - research: Academic paper discovery from ArXiv and HuggingFace. Commands: fetch
+- search: Full-text search over briefs, memory, deadlines, news and papers with agent/type/date filters. Commands: query, reindex, stats
 - subagent: Spawn any of the above as a dedicated subagent with deeper context
 
 IMPORTANT RENDERING RULES:
-- For morning_brief, evening_review, fetch news, search papers: reproduce the full tool output verbatim. Do NOT summarize or wrap in <status> tags.
+- For morning_brief, evening_review, weekly_review, monthly_review, fetch news, search papers: reproduce the full tool output verbatim. Do NOT summarize or wrap in <status> tags.
 - For create/delete/sync actions: a short confirmation is fine.
 - When the user asks for a multi-step task, call tools sequentially as needed.`
 		os.WriteFile(identityPath, []byte(identityContent), 0644)
@@ -227,6 +237,10 @@ func agentCmd() {
 	architectSkill.SetWorkspace(architectWorkspace)
 	toolsRegistry.Register(architectSkill)
 
+	searchSkill := search.NewSkill()
+	searchSkill.SetWorkspace(filepath.Dir(chiefWorkspace))
+	toolsRegistry.Register(searchSkill)
+
 	subagentManager := subagent.NewSubagentManager(provider, workspace, nil)
 	subagentManager.RegisterTool(researchSkill)
 	subagentManager.RegisterTool(monitorSkill)
@@ -234,6 +248,7 @@ func agentCmd() {
 	subagentManager.RegisterTool(atcSkill)
 	subagentManager.RegisterTool(coachSkill)
 	subagentManager.RegisterTool(architectSkill)
+	chiefSkill.SetSubagents(subagentManager)
 	subagentTool := subagent.NewSubagentTool(subagentManager)
 	toolsRegistry.Register(subagentTool)
 
@@ -266,16 +281,17 @@ func processMessage(ctx context.Context, provider providers.LLMProvider, model s
 	systemPrompt := `You are son-of-anthon, a personal multi-agent AI assistant.
 
 Available tools (call as needed, including multiple times in one session):
-- architect: Life admin; CalDAV sync/create/delete tasks on Nextcloud. Commands: sync_deadlines, create_task, delete_task
-- chief: Strategic commander; reads daily briefs, urgent deadlines, morning/evening summaries. Commands: morning_brief, evening_review, urgent_deadlines, status, delegate
-- atc: Task management; reads/writes tasks.xml, daily priorities. Commands: analyze_tasks, read_calendar, update_task, roll_over_tasks, sync_calendar, push_task
+- architect: Life admin; CalDAV sync/create/complete/delete tasks on Nextcloud. Commands: sync_deadlines, create_task, complete_task, delete_task
+- chief: Strategic commander; reads daily briefs, urgent deadlines, morning/evening summaries. Commands: morning_brief, midday_update, evening_review, weekly_review, monthly_review, urgent_deadlines, ack, snooze, mute, unmute, alerts, journal, status, delegate
+- atc: Task management; reads/writes tasks.xml, daily priorities. Commands: analyze_tasks, read_calendar, plan_day, update_task, create_local_task, delete_local_task, roll_over_tasks, stats, find_tasks, import_tasks, export_tasks, start_timer, stop_timer, pomodoro, time_report, sync_tasks, sync_calendar, push_task
 - coach: Learning coach; IELTS prep, habit tracking, Nextcloud integration. Commands: check_habits, fetch_material, generate_practice, evening_review, update_deck, nudge_telegram
 - monitor: News curation; Bangladesh + Tech RSS feeds. Commands: fetch, status, feeds
 - research: Academic paper discovery from ArXiv and HuggingFace. Commands: fetch
+- search: Full-text search over briefs, memory, deadlines, news and papers with agent/type/date filters. Commands: query, reindex, stats
 - subagent: Spawn any of the above as a dedicated subagent with deeper context
 
 IMPORTANT RENDERING RULES:
-- For morning_brief, evening_review, fetch news, search papers: reproduce the full tool output verbatim. Do NOT summarize or wrap in <status> tags.
+- For morning_brief, evening_review, weekly_review, monthly_review, fetch news, search papers: reproduce the full tool output verbatim. Do NOT summarize or wrap in <status> tags.
 - For create/delete/sync actions: a short confirmation is fine.
 - When the user asks for a multi-step task, call tools sequentially as needed.`
 
@@ -420,42 +436,64 @@ func gatewayCmd() {
 		workspace = globalWorkspace
 	}
 
+	// Multi-user mode: each chat listed under "users" in config.json gets its
+	// own skill instances, so tasks, briefs and streaks never cross users.
+	userDir, err := users.Load(configFilePath())
+	if err != nil {
+		fmt.Printf("Error loading users: %v\n", err)
+		os.Exit(1)
+	}
+	sessions := users.NewSessions(userDir, buildUserTools(provider, cfg.Agents.Defaults.Model, msgBus))
+	registerTool := func(t tools.Tool) {
+		if userDir.Len() > 0 {
+			agentLoop.RegisterTool(sessions.Wrap(t))
+			return
+		}
+		agentLoop.RegisterTool(t)
+	}
+
 	toolsRegistry := tools.NewToolRegistry()
 	researchWorkspace := resolveWorkspacePath("workspaces/research")
 	researchSkill := research.NewSkill()
 	researchSkill.SetWorkspace(researchWorkspace)
 	toolsRegistry.Register(researchSkill)
-	agentLoop.RegisterTool(researchSkill)
+	registerTool(researchSkill)
 
 	chiefWorkspace := resolveWorkspacePath("workspaces/chief")
 	chiefSkill := chief.NewSkill()
 	chiefSkill.SetWorkspace(chiefWorkspace)
 	toolsRegistry.Register(chiefSkill)
-	agentLoop.RegisterTool(chiefSkill)
+	registerTool(chiefSkill)
 
 	atcWorkspace := resolveWorkspacePath("workspaces/atc")
 	atcSkill := atc.NewSkill()
 	atcSkill.SetWorkspace(atcWorkspace)
+	atcSkill.SetBus(msgBus)
 	toolsRegistry.Register(atcSkill)
-	agentLoop.RegisterTool(atcSkill)
+	registerTool(atcSkill)
 
 	monitorWorkspace := resolveWorkspacePath("workspaces/monitor")
 	monitorSkill := monitor.NewSkill()
 	monitorSkill.SetWorkspace(monitorWorkspace)
 	toolsRegistry.Register(monitorSkill)
-	agentLoop.RegisterTool(monitorSkill)
+	registerTool(monitorSkill)
 
 	coachWorkspace := resolveWorkspacePath("workspaces/coach")
 	coachSkill := coach.NewSkill()
 	coachSkill.SetWorkspace(coachWorkspace)
 	toolsRegistry.Register(coachSkill)
-	agentLoop.RegisterTool(coachSkill)
+	registerTool(coachSkill)
 
 	architectWorkspace := resolveWorkspacePath("workspaces/architect")
 	architectSkill := architect.NewSkill()
 	architectSkill.SetWorkspace(architectWorkspace)
 	toolsRegistry.Register(architectSkill)
-	agentLoop.RegisterTool(architectSkill)
+	registerTool(architectSkill)
+
+	searchSkill := search.NewSkill()
+	searchSkill.SetWorkspace(filepath.Dir(chiefWorkspace))
+	toolsRegistry.Register(searchSkill)
+	registerTool(searchSkill)
 
 	subagentManager := subagent.NewSubagentManager(provider, workspace, nil)
 	subagentManager.RegisterTool(researchSkill)
@@ -464,14 +502,23 @@ func gatewayCmd() {
 	subagentManager.RegisterTool(atcSkill)
 	subagentManager.RegisterTool(coachSkill)
 	subagentManager.RegisterTool(architectSkill)
+	chiefSkill.SetSubagents(subagentManager)
 	subagentTool := subagent.NewSubagentTool(subagentManager)
 	toolsRegistry.Register(subagentTool)
-	agentLoop.RegisterTool(subagentTool)
+	registerTool(subagentTool)
 
 	fmt.Println("\n📦 Agent Status:")
 	startupInfo := agentLoop.GetStartupInfo()
 	toolsInfo := startupInfo["tools"].(map[string]interface{})
 	fmt.Printf("  • Tools: %d loaded\n", toolsInfo["count"])
+	if userDir.Len() > 0 {
+		fmt.Printf("  • Users: %d isolated profiles\n", userDir.Len())
+		// Build each user's tools now rather than on their first message,
+		// so ATC re-arms pomodoro reminders that were running before a restart.
+		for _, p := range userDir.Profiles() {
+			sessions.Tool(p, "atc")
+		}
+	}
 
 	execTimeout := time.Duration(cfg.Tools.Cron.ExecTimeoutMinutes) * time.Minute
 	cronService := setupCronTool(agentLoop, msgBus, workspace, cfg.Agents.Defaults.RestrictToWorkspace, execTimeout, cfg)
@@ -490,15 +537,29 @@ func gatewayCmd() {
 
 		isUrgent := false
 
-		deadlinesPath := filepath.Join(chiefWorkspace, "memory", "deadlines-today.md")
-		if data, err := os.ReadFile(deadlinesPath); err == nil {
-			content := string(data)
-			if strings.Contains(content, "[P0]") || strings.Contains(content, "[P1]") || strings.Contains(content, "T00:00") {
-				isUrgent = true
+		// Check the workspaces of whoever the heartbeat is addressed to.
+		hbChief, hbATC := chiefWorkspace, atcWorkspace
+		if p, ok := userDir.Lookup(chatID); ok {
+			hbChief, hbATC = p.AgentWorkspace("chief"), p.AgentWorkspace("atc")
+		}
+
+		feed, err := deadlines.Find(
+			filepath.Join(hbChief, "memory"),
+			filepath.Join(filepath.Dir(hbChief), "architect", "memory"),
+		)
+		if err == nil {
+			// Only wake the agent for new or escalated alerts; acknowledged,
+			// snoozed and already-announced deadlines stay quiet.
+			if store, err := deadlines.OpenStore(filepath.Join(hbChief, "memory", deadlines.AlertsFileName)); err == nil {
+				pending, err := store.Pending(feed, time.Now(), deadlines.LoadPolicy(), false)
+				store.Close()
+				if err == nil && len(pending) > 0 {
+					isUrgent = true
+				}
 			}
 		}
 
-		tasksPath := filepath.Join(atcWorkspace, "tasks.xml")
+		tasksPath := filepath.Join(hbATC, "tasks.xml")
 		if data, err := os.ReadFile(tasksPath); err == nil {
 			content := string(data)
 			if (strings.Contains(content, "PRIORITY:0") || strings.Contains(content, "PRIORITY:1")) && !strings.Contains(content, "STATUS:COMPLETED") {
@@ -510,7 +571,7 @@ func gatewayCmd() {
 			return tools.SilentResult("Heartbeat OK")
 		}
 
-		response, err := agentLoop.ProcessHeartbeat(context.Background(), prompt, channel, chatID)
+		response, err := agentLoop.ProcessHeartbeat(users.WithChat(context.Background(), channel, chatID), prompt, channel, chatID)
 		if err != nil {
 			return tools.ErrorResult(fmt.Sprintf("Heartbeat error: %v", err))
 		}
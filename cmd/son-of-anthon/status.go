@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jony/son-of-anthon/pkg/skills/chief"
+)
+
+// statusCmd prints the same health report as Chief's status command.
+//
+//	son-of-anthon status [--offline] [--user <name>]
+func statusCmd() {
+	offline := false
+	userName := ""
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--offline":
+			offline = true
+		case "--user", "-u":
+			if i+1 < len(os.Args) {
+				userName = os.Args[i+1]
+				i++
+			}
+		}
+	}
+
+	chiefSkill := chief.NewSkill()
+	if userName == "" {
+		chiefSkill.SetWorkspace(resolveWorkspacePath("workspaces/chief"))
+	} else {
+		profile, err := findProfile(userName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		chiefSkill.SetUser(profile)
+		chiefSkill.SetWorkspace(profile.AgentWorkspace("chief"))
+	}
+
+	fmt.Print(chiefSkill.StatusReport(context.Background(), offline))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jony/son-of-anthon/pkg/skills/atc"
+)
+
+// tasksCmd moves tasks in and out of ATC's tasks.xml.
+//
+//	son-of-anthon tasks import <file|-> [--format f] [--project p] [--dry-run] [--user <name>]
+//	son-of-anthon tasks export [--format f] [--open] [--out <file>] [--user <name>]
+//
+// Formats: taskwarrior, todoist-csv, todoist-json (import only), csv, ics.
+// Import detects the format when --format is omitted; export writes csv to
+// stdout by default.
+func tasksCmd() {
+	if len(os.Args) < 3 || (os.Args[2] != "import" && os.Args[2] != "export") {
+		fmt.Println("Usage: son-of-anthon tasks import <file|-> [--format f] [--project p] [--dry-run] [--user <name>]")
+		fmt.Println("       son-of-anthon tasks export [--format f] [--open] [--out <file>] [--user <name>]")
+		os.Exit(1)
+	}
+	action := os.Args[2]
+	var file, format, project, out, userName string
+	dryRun, openOnly := false, false
+	for i := 3; i < len(os.Args); i++ {
+		next := func() string {
+			if i+1 < len(os.Args) {
+				i++
+				return os.Args[i]
+			}
+			return ""
+		}
+		switch os.Args[i] {
+		case "--format", "-f":
+			format = next()
+		case "--project":
+			project = next()
+		case "--out", "-o":
+			out = next()
+		case "--user", "-u":
+			userName = next()
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--open":
+			openOnly = true
+		default:
+			file = os.Args[i]
+		}
+	}
+
+	atcSkill := atc.NewSkill()
+	if userName == "" {
+		atcSkill.SetWorkspace(resolveWorkspacePath("workspaces/atc"))
+	} else {
+		profile, err := findProfile(userName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		atcSkill.SetUser(profile)
+		atcSkill.SetWorkspace(profile.AgentWorkspace("atc"))
+	}
+
+	if action == "import" {
+		var data []byte
+		var err error
+		switch file {
+		case "":
+			fmt.Println("Usage: son-of-anthon tasks import <file|-> [--format f] [--project p] [--dry-run]")
+			os.Exit(1)
+		case "-":
+			data, err = io.ReadAll(os.Stdin)
+		default:
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", file, err)
+			os.Exit(1)
+		}
+		msg, err := atcSkill.ImportTasks(data, format, project, dryRun)
+		if err != nil {
+			fmt.Printf("Import failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(msg)
+		return
+	}
+
+	if format == "" {
+		format = "csv"
+	}
+	data, n, err := atcSkill.ExportTasks(format, openOnly)
+	if err != nil {
+		fmt.Printf("Export failed: %v\n", err)
+		os.Exit(1)
+	}
+	if out == "" || out == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d tasks as %s to %s\n", n, format, out)
+}
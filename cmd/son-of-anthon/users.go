@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+
+	"github.com/jony/son-of-anthon/pkg/skills/architect"
+	"github.com/jony/son-of-anthon/pkg/skills/atc"
+	"github.com/jony/son-of-anthon/pkg/skills/chief"
+	"github.com/jony/son-of-anthon/pkg/skills/coach"
+	"github.com/jony/son-of-anthon/pkg/skills/monitor"
+	"github.com/jony/son-of-anthon/pkg/skills/research"
+	"github.com/jony/son-of-anthon/pkg/skills/search"
+	"github.com/jony/son-of-anthon/pkg/skills/subagent"
+	"github.com/jony/son-of-anthon/pkg/users"
+)
+
+// configFilePath returns the active config.json path, honouring PERSONAL_OS_CONFIG.
+func configFilePath() string {
+	if p := os.Getenv("PERSONAL_OS_CONFIG"); p != "" {
+		return p
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".picoclaw", "config.json")
+}
+
+// findProfile looks a configured user up by name.
+func findProfile(name string) (*users.Profile, error) {
+	userDir, err := users.Load(configFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("loading users: %w", err)
+	}
+	for _, p := range userDir.Profiles() {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown user: %s", name)
+}
+
+// buildUserTools gives every configured user their own skill instances,
+// rooted at the user's workspace and bound to their Nextcloud account,
+// Telegram chat and timezone.
+func buildUserTools(provider providers.LLMProvider, model string, msgBus *bus.MessageBus) users.Builder {
+	return func(p *users.Profile) []tools.Tool {
+		if _, err := os.Stat(p.Workspace); os.IsNotExist(err) {
+			os.MkdirAll(p.Workspace, 0755)
+			if err := copyEmbedToDisk(p.Workspace); err != nil {
+				log.Printf("Failed to initialize workspace for %s: %v\n", p.Name, err)
+			} else {
+				log.Printf("Initialized workspace for %s at: %s\n", p.Name, p.Workspace)
+			}
+		}
+
+		researchSkill := research.NewSkill()
+		researchSkill.SetWorkspace(p.AgentWorkspace("research"))
+
+		chiefSkill := chief.NewSkill()
+		chiefSkill.SetUser(p)
+		chiefSkill.SetWorkspace(p.AgentWorkspace("chief"))
+
+		atcSkill := atc.NewSkill()
+		atcSkill.SetUser(p)
+		atcSkill.SetWorkspace(p.AgentWorkspace("atc"))
+		atcSkill.SetBus(msgBus)
+
+		monitorSkill := monitor.NewSkill()
+		monitorSkill.SetWorkspace(p.AgentWorkspace("monitor"))
+
+		coachSkill := coach.NewSkill()
+		coachSkill.SetUser(p)
+		coachSkill.SetWorkspace(p.AgentWorkspace("coach"))
+
+		architectSkill := architect.NewSkill()
+		architectSkill.SetUser(p)
+		architectSkill.SetWorkspace(p.AgentWorkspace("architect"))
+
+		searchSkill := search.NewSkill()
+		searchSkill.SetWorkspace(p.Workspace)
+
+		subagentManager := subagent.NewSubagentManager(provider, p.Workspace, nil)
+		if model != "" {
+			subagentManager.SetModel(model)
+		}
+		subagentManager.RegisterTool(researchSkill)
+		subagentManager.RegisterTool(monitorSkill)
+		subagentManager.RegisterTool(chiefSkill)
+		subagentManager.RegisterTool(atcSkill)
+		subagentManager.RegisterTool(coachSkill)
+		subagentManager.RegisterTool(architectSkill)
+		chiefSkill.SetSubagents(subagentManager)
+
+		return []tools.Tool{
+			researchSkill,
+			chiefSkill,
+			atcSkill,
+			monitorSkill,
+			coachSkill,
+			architectSkill,
+			searchSkill,
+			subagent.NewSubagentTool(subagentManager),
+		}
+	}
+}
@@ -0,0 +1,477 @@
+// Package render turns the Markdown that briefs and agent messages are
+// written in into Telegram MarkdownV2, HTML or plain text.
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Renderers
+//
+// Brief templates produce a small Markdown dialect: "#" headings, "-"/"*"
+// bullets, "---" rules, **bold**, `code` and [text](url) links. Everything
+// else — including stray _ * [ from feed titles — is literal text and is
+// escaped for the target format.
+// ----------------------------------------------------------------------------
+
+// Output formats accepted by the `format` argument.
+const (
+	FormatMarkdown = "markdown" // template output as-is
+	FormatTelegram = "telegram" // Telegram MarkdownV2
+	FormatHTML     = "html"
+	FormatText     = "text"
+)
+
+// TelegramMessageLimit is the maximum length of one Telegram message,
+// counted in UTF-16 code units.
+const TelegramMessageLimit = 4096
+
+// Render converts brief Markdown into the requested format.
+func Render(markdown, format string) (string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return markdown, nil
+	case FormatTelegram:
+		return renderBlocks(markdown, telegramWriter{}), nil
+	case FormatHTML:
+		return renderBlocks(markdown, &htmlWriter{}), nil
+	case FormatText:
+		return renderBlocks(markdown, textWriter{}), nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want markdown, telegram, html or text)", format)
+	}
+}
+
+// Telegram renders Markdown as MarkdownV2 and splits it into messages
+// that fit Telegram's limit.
+func Telegram(markdown string) []string {
+	return SplitMessage(renderBlocks(markdown, telegramWriter{}), TelegramMessageLimit)
+}
+
+// SplitMessage cuts text into chunks of at most limit UTF-16 units.
+// It prefers blank lines (section breaks), then line breaks, and only
+// splits inside a line when a single line is longer than limit.
+func SplitMessage(text string, limit int) []string {
+	text = strings.TrimRight(text, "\n")
+	if limit <= 0 || utf16Len(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	var cur []string
+	curLen := 0
+	flush := func() {
+		if len(cur) > 0 {
+			chunks = append(chunks, strings.Trim(strings.Join(cur, "\n"), "\n"))
+			cur, curLen = nil, 0
+		}
+	}
+
+	for _, section := range strings.Split(text, "\n\n") {
+		sectionLen := utf16Len(section)
+		sep := 0
+		if len(cur) > 0 {
+			sep = 2 // the "\n\n" joining sections
+		}
+		if curLen+sep+sectionLen <= limit {
+			if len(cur) > 0 {
+				cur = append(cur, "")
+				curLen++
+			}
+			cur = append(cur, section)
+			curLen += sectionLen + 1
+			continue
+		}
+
+		// Section does not fit: start a new chunk and fill it line by line.
+		flush()
+		for _, line := range strings.Split(section, "\n") {
+			for utf16Len(line) > limit {
+				flush()
+				head, rest := cutUTF16(line, limit)
+				chunks = append(chunks, head)
+				line = rest
+			}
+			if curLen+utf16Len(line)+1 > limit {
+				flush()
+			}
+			cur = append(cur, line)
+			curLen += utf16Len(line) + 1
+		}
+	}
+	flush()
+	return chunks
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// MarkdownV2 entity a cut can fall into.
+const (
+	inText = iota
+	inBold
+	inCode
+	inLinkText
+	inLinkMid // between "]" and "("
+	inLinkURL
+)
+
+// scanMarkdownV2 walks s up to limit UTF-16 units. end is the byte offset
+// reached; safe is the last offset outside any entity and escape, where a
+// cut leaves valid MarkdownV2 on both sides; state is the entity at end.
+func scanMarkdownV2(s string, limit int) (end, safe, state int) {
+	n, esc := 0, false
+	for i, r := range s {
+		w := 1
+		if r >= 0x10000 {
+			w = 2
+		}
+		if n+w > limit {
+			break
+		}
+		n += w
+		switch {
+		case esc:
+			esc = false
+		case r == '\\':
+			esc = true
+		case r == '*' && state == inText:
+			state = inBold
+		case r == '*' && state == inBold:
+			state = inText
+		case r == '`' && state == inText:
+			state = inCode
+		case r == '`' && state == inCode:
+			state = inText
+		case r == '[' && state == inText:
+			state = inLinkText
+		case r == ']' && state == inLinkText:
+			state = inLinkMid
+		case r == '(' && state == inLinkMid:
+			state = inLinkURL
+		case r == ')' && state == inLinkURL:
+			state = inText
+		}
+		end = i + len(string(r))
+		if state == inText && !esc {
+			safe = end
+		}
+	}
+	return end, safe, state
+}
+
+// cutUTF16 splits a MarkdownV2 line after at most limit UTF-16 units. The
+// cut goes back to the last point outside *bold*, `code` and [text](url)
+// entities, never leaving an escape backslash dangling. A bold or code
+// span longer than limit on its own is closed at the cut and reopened in
+// the rest; a link that long is cut as plain text.
+func cutUTF16(s string, limit int) (string, string) {
+	if _, safe, _ := scanMarkdownV2(s, limit); safe > 0 {
+		return s[:safe], s[safe:]
+	}
+	end, _, state := scanMarkdownV2(s, limit-1) // room for the closing delimiter
+	for end > 0 && s[end-1] == '\\' && (end < 2 || s[end-2] != '\\') {
+		end--
+	}
+	switch state {
+	case inBold:
+		return s[:end] + "*", "*" + s[end:]
+	case inCode:
+		return s[:end] + "`", "`" + s[end:]
+	}
+	return s[:end], s[end:]
+}
+
+// ----------------------------------------------------------------------------
+// Markdown parsing
+// ----------------------------------------------------------------------------
+
+type spanKind int
+
+const (
+	spanText spanKind = iota
+	spanBold
+	spanCode
+	spanLink
+)
+
+type span struct {
+	kind spanKind
+	text string
+	url  string
+}
+
+// parseInline splits a line into text, **bold**, `code` and [text](url) spans.
+func parseInline(line string) []span {
+	var spans []span
+	var buf strings.Builder
+	emit := func(sp span) {
+		if buf.Len() > 0 {
+			spans = append(spans, span{kind: spanText, text: buf.String()})
+			buf.Reset()
+		}
+		spans = append(spans, sp)
+	}
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		switch {
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				emit(span{kind: spanBold, text: rest[2 : 2+end]})
+				i += end + 4
+				continue
+			}
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				emit(span{kind: spanCode, text: rest[1 : 1+end]})
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if mid := strings.Index(rest, "]("); mid > 0 {
+				if end := strings.IndexByte(rest[mid+2:], ')'); end > 0 {
+					url := rest[mid+2 : mid+2+end]
+					if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "mailto:") {
+						emit(span{kind: spanLink, text: rest[1:mid], url: url})
+						i += mid + 3 + end
+						continue
+					}
+				}
+			}
+		}
+		buf.WriteByte(line[i])
+		i++
+	}
+	if buf.Len() > 0 {
+		spans = append(spans, span{kind: spanText, text: buf.String()})
+	}
+	return spans
+}
+
+// blockWriter receives parsed Markdown lines.
+type blockWriter interface {
+	heading(sb *strings.Builder, level int, spans []span)
+	bullet(sb *strings.Builder, spans []span)
+	rule(sb *strings.Builder)
+	paragraph(sb *strings.Builder, spans []span)
+	blank(sb *strings.Builder)
+	finish(sb *strings.Builder)
+}
+
+func renderBlocks(markdown string, w blockWriter) string {
+	var sb strings.Builder
+	for _, raw := range strings.Split(strings.TrimRight(markdown, "\n"), "\n") {
+		line := strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			w.blank(&sb)
+		case isRule(trimmed):
+			w.rule(&sb)
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			text := strings.TrimSpace(trimmed[level:])
+			if level > 6 || !strings.HasPrefix(trimmed[level:], " ") {
+				w.paragraph(&sb, parseInline(line))
+			} else {
+				w.heading(&sb, level, parseInline(text))
+			}
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			w.bullet(&sb, parseInline(strings.TrimSpace(trimmed[2:])))
+		default:
+			w.paragraph(&sb, parseInline(line))
+		}
+	}
+	w.finish(&sb)
+	return sb.String()
+}
+
+func isRule(s string) bool {
+	if len(s) < 3 {
+		return false
+	}
+	return strings.Trim(s, "-") == "" || strings.Trim(s, "*") == "" || strings.Trim(s, "_") == ""
+}
+
+// ----------------------------------------------------------------------------
+// Telegram MarkdownV2
+// ----------------------------------------------------------------------------
+
+type telegramWriter struct{}
+
+// mdv2Special lists characters that must be escaped in MarkdownV2 text.
+const mdv2Special = "_*[]()~`>#+-=|{}.!\\"
+
+func escapeMarkdownV2(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(mdv2Special, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (telegramWriter) spans(sb *strings.Builder, spans []span) {
+	for _, sp := range spans {
+		switch sp.kind {
+		case spanBold:
+			sb.WriteString("*" + escapeMarkdownV2(sp.text) + "*")
+		case spanCode:
+			code := strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(sp.text)
+			sb.WriteString("`" + code + "`")
+		case spanLink:
+			url := strings.NewReplacer("\\", "\\\\", ")", "\\)").Replace(sp.url)
+			sb.WriteString("[" + escapeMarkdownV2(sp.text) + "](" + url + ")")
+		default:
+			sb.WriteString(escapeMarkdownV2(sp.text))
+		}
+	}
+}
+
+// plainSpans flattens spans for contexts that are already bold (headings).
+func plainSpans(spans []span) string {
+	var sb strings.Builder
+	for _, sp := range spans {
+		sb.WriteString(sp.text)
+	}
+	return sb.String()
+}
+
+func (w telegramWriter) heading(sb *strings.Builder, level int, spans []span) {
+	sb.WriteString("*" + escapeMarkdownV2(plainSpans(spans)) + "*\n")
+}
+
+func (w telegramWriter) bullet(sb *strings.Builder, spans []span) {
+	sb.WriteString("• ")
+	w.spans(sb, spans)
+	sb.WriteString("\n")
+}
+
+func (telegramWriter) rule(sb *strings.Builder) {
+	sb.WriteString("──────────\n")
+}
+
+func (w telegramWriter) paragraph(sb *strings.Builder, spans []span) {
+	w.spans(sb, spans)
+	sb.WriteString("\n")
+}
+
+func (telegramWriter) blank(sb *strings.Builder)  { sb.WriteString("\n") }
+func (telegramWriter) finish(sb *strings.Builder) {}
+
+// ----------------------------------------------------------------------------
+// HTML
+// ----------------------------------------------------------------------------
+
+type htmlWriter struct {
+	inList bool
+}
+
+func (w *htmlWriter) closeList(sb *strings.Builder) {
+	if w.inList {
+		sb.WriteString("</ul>\n")
+		w.inList = false
+	}
+}
+
+func (w *htmlWriter) spans(sb *strings.Builder, spans []span) {
+	for _, sp := range spans {
+		switch sp.kind {
+		case spanBold:
+			sb.WriteString("<strong>" + html.EscapeString(sp.text) + "</strong>")
+		case spanCode:
+			sb.WriteString("<code>" + html.EscapeString(sp.text) + "</code>")
+		case spanLink:
+			sb.WriteString(`<a href="` + html.EscapeString(sp.url) + `">` + html.EscapeString(sp.text) + "</a>")
+		default:
+			sb.WriteString(html.EscapeString(sp.text))
+		}
+	}
+}
+
+func (w *htmlWriter) heading(sb *strings.Builder, level int, spans []span) {
+	w.closeList(sb)
+	fmt.Fprintf(sb, "<h%d>", level)
+	w.spans(sb, spans)
+	fmt.Fprintf(sb, "</h%d>\n", level)
+}
+
+func (w *htmlWriter) bullet(sb *strings.Builder, spans []span) {
+	if !w.inList {
+		sb.WriteString("<ul>\n")
+		w.inList = true
+	}
+	sb.WriteString("<li>")
+	w.spans(sb, spans)
+	sb.WriteString("</li>\n")
+}
+
+func (w *htmlWriter) rule(sb *strings.Builder) {
+	w.closeList(sb)
+	sb.WriteString("<hr>\n")
+}
+
+func (w *htmlWriter) paragraph(sb *strings.Builder, spans []span) {
+	w.closeList(sb)
+	sb.WriteString("<p>")
+	w.spans(sb, spans)
+	sb.WriteString("</p>\n")
+}
+
+func (w *htmlWriter) blank(sb *strings.Builder)  { w.closeList(sb) }
+func (w *htmlWriter) finish(sb *strings.Builder) { w.closeList(sb) }
+
+// ----------------------------------------------------------------------------
+// Plain text
+// ----------------------------------------------------------------------------
+
+type textWriter struct{}
+
+func (textWriter) spans(sb *strings.Builder, spans []span) {
+	for _, sp := range spans {
+		if sp.kind == spanLink && sp.text != sp.url {
+			sb.WriteString(sp.text + " (" + sp.url + ")")
+		} else {
+			sb.WriteString(sp.text)
+		}
+	}
+}
+
+func (w textWriter) heading(sb *strings.Builder, level int, spans []span) {
+	text := plainSpans(spans)
+	if level == 1 {
+		text = strings.ToUpper(text)
+	}
+	sb.WriteString(text + "\n")
+}
+
+func (w textWriter) bullet(sb *strings.Builder, spans []span) {
+	sb.WriteString("• ")
+	w.spans(sb, spans)
+	sb.WriteString("\n")
+}
+
+func (textWriter) rule(sb *strings.Builder) { sb.WriteString("----------\n") }
+
+func (w textWriter) paragraph(sb *strings.Builder, spans []span) {
+	w.spans(sb, spans)
+	sb.WriteString("\n")
+}
+
+func (textWriter) blank(sb *strings.Builder)  { sb.WriteString("\n") }
+func (textWriter) finish(sb *strings.Builder) {}
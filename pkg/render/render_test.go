@@ -0,0 +1,149 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleBrief = `# 🎯 Morning Brief — Monday, March 2, 2026
+
+## 🌍 News (Monitor)
+- [news:abc123:world] AI_model *beats* [benchmark] 2.0! | 20260302 | https://x.example/a_(b)
+- [Read more](https://example.com/q?a=1&b=2)
+
+---
+**Ready to roll? 🚀**
+`
+
+func TestRenderTelegramEscapes(t *testing.T) {
+	out, err := Render(sampleBrief, FormatTelegram)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"*🎯 Morning Brief — Monday, March 2, 2026*\n",
+		`• \[news:abc123:world\] AI\_model \*beats\* \[benchmark\] 2\.0\! \| 20260302 \| https://x\.example/a\_\(b\)`,
+		"• [Read more](https://example.com/q?a=1&b=2)\n",
+		"*Ready to roll? 🚀*",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	out, err := Render(sampleBrief, FormatHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"<h1>🎯 Morning Brief — Monday, March 2, 2026</h1>",
+		"<ul>\n<li>[news:abc123:world] AI_model *beats* [benchmark] 2.0!",
+		`<li><a href="https://example.com/q?a=1&amp;b=2">Read more</a></li>` + "\n</ul>",
+		"<hr>",
+		"<p><strong>Ready to roll? 🚀</strong></p>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	out, err := Render(sampleBrief, FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "**") || strings.Contains(out, "## ") {
+		t.Errorf("markup left in plain text:\n%s", out)
+	}
+	if !strings.Contains(out, "• Read more (https://example.com/q?a=1&b=2)") {
+		t.Errorf("link not flattened:\n%s", out)
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := Render(sampleBrief, "pdf"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 40; i++ {
+		sb.WriteString("## Section\n")
+		for j := 0; j < 10; j++ {
+			sb.WriteString("- " + strings.Repeat("x", 20) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	parts := SplitMessage(sb.String(), 1000)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for i, p := range parts {
+		if utf16Len(p) > 1000 {
+			t.Errorf("part %d is %d units", i, utf16Len(p))
+		}
+		if !strings.HasPrefix(p, "## Section") {
+			t.Errorf("part %d does not start at a section boundary: %q", i, p[:20])
+		}
+	}
+	if got := strings.Join(parts, "\n\n"); got != strings.TrimRight(sb.String(), "\n") {
+		t.Error("rejoined parts differ from input")
+	}
+}
+
+func TestSplitMessageLongLine(t *testing.T) {
+	line := strings.Repeat(`a\.`, 2000) // 6000 bytes of escaped text
+	parts := SplitMessage(line, TelegramMessageLimit)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(parts))
+	}
+	for i, p := range parts {
+		if utf16Len(p) > TelegramMessageLimit {
+			t.Errorf("part %d too long: %d", i, utf16Len(p))
+		}
+		if strings.HasSuffix(p, `\`) {
+			t.Errorf("part %d ends with a dangling escape", i)
+		}
+	}
+	if parts[0]+parts[1] != line {
+		t.Error("long line was not split losslessly")
+	}
+}
+
+func TestSplitMessageKeepsEntities(t *testing.T) {
+	// unbalanced reports whether a part opens an entity it does not close.
+	unbalanced := func(p string) bool {
+		_, safe, state := scanMarkdownV2(p, utf16Len(p))
+		return state != inText || safe != len(p)
+	}
+
+	line := strings.TrimSpace(strings.Repeat(`see *bold\.text* and [a link](https://x.example/a\)b) `, 150))
+	parts := SplitMessage(line, 1000)
+	if strings.Join(parts, "") != line {
+		t.Error("line was not split losslessly")
+	}
+	for i, p := range parts {
+		if utf16Len(p) > 1000 || unbalanced(p) {
+			t.Errorf("part %d cuts an entity or is too long (%d): ...%s", i, utf16Len(p), p[len(p)-30:])
+		}
+	}
+
+	// A bold span longer than a message is closed and reopened.
+	long := "*" + strings.Repeat("ab ", 500) + "*"
+	parts = SplitMessage(long, 1000)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(parts))
+	}
+	for i, p := range parts {
+		if utf16Len(p) > 1000 || !strings.HasPrefix(p, "*") || !strings.HasSuffix(p, "*") || unbalanced(p) {
+			t.Errorf("part %d: %q...", i, p[:20])
+		}
+	}
+}
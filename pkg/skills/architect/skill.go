@@ -9,10 +9,15 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jony/son-of-anthon/pkg/skills/atc"
 	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/jony/son-of-anthon/pkg/skills/health"
+	"github.com/jony/son-of-anthon/pkg/users"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
@@ -27,6 +32,7 @@ type ArchitectConfig struct {
 // ArchitectSkill is the subagent responsible for managing recurring life admin via Nextcloud CalDAV.
 type ArchitectSkill struct {
 	workspace string
+	user      *users.Profile // nil in single-user mode
 }
 
 func NewSkill() *ArchitectSkill {
@@ -38,7 +44,7 @@ func (s *ArchitectSkill) Name() string {
 }
 
 func (s *ArchitectSkill) Description() string {
-	return "Life Architect (Sage): Manages your recurring life admin (rent, medicine) via Nextcloud. Can sync deadlines, and natively create complex recurring CalDAV VTODOs."
+	return "Life Architect (Sage): Manages your recurring life admin (rent, medicine) via Nextcloud. Can sync deadlines, natively create complex recurring CalDAV VTODOs, and complete them: complete_task marks only the current occurrence done and moves the task to its next due date, like Nextcloud Tasks."
 }
 
 func (s *ArchitectSkill) SetWorkspace(workspacePath string) {
@@ -49,6 +55,34 @@ func (s *ArchitectSkill) SetWorkspace(workspacePath string) {
 	s.initWorkspace()
 }
 
+// SetUser scopes the skill to one user's Nextcloud account and timezone.
+func (s *ArchitectSkill) SetUser(p *users.Profile) {
+	s.user = p
+}
+
+// loadConfig returns the bound user's Nextcloud credentials, or the
+// global tools.nextcloud section when running single-user. A bound user
+// without an account of their own gets users.Profile.ErrNoNextcloud.
+func (s *ArchitectSkill) loadConfig() (ArchitectConfig, error) {
+	if s.user != nil {
+		nc := s.user.Nextcloud
+		if nc.Host == "" {
+			return ArchitectConfig{}, s.user.ErrNoNextcloud()
+		}
+		return ArchitectConfig{Host: nc.Host, Username: nc.Username, Password: nc.Password, Timeout: nc.Timeout}, nil
+	}
+	return loadArchitectConfig(), nil
+}
+
+// location returns the bound user's timezone. Single-user mode keeps the
+// historical Asia/Dhaka default.
+func (s *ArchitectSkill) location() (*time.Location, error) {
+	if s.user != nil && s.user.Timezone != "" {
+		return s.user.Location(), nil
+	}
+	return time.LoadLocation("Asia/Dhaka")
+}
+
 func (s *ArchitectSkill) initWorkspace() {
 	if s.workspace == "" {
 		return
@@ -102,15 +136,15 @@ func (s *ArchitectSkill) Parameters() map[string]interface{} {
 			"command": map[string]interface{}{
 				"type":        "string",
 				"description": "Command to execute",
-				"enum":        []string{"sync_deadlines", "create_task", "delete_task"},
+				"enum":        []string{"sync_deadlines", "create_task", "delete_task", "complete_task"},
 			},
 			"uuid": map[string]interface{}{
 				"type":        "string",
-				"description": "UUID of the task to delete (from [task_id: ...] in the dashboard). Provide either uuid OR title, not both.",
+				"description": "UUID of the task to delete or complete (from [task_id: ...] in the dashboard). Provide either uuid OR title, not both.",
 			},
 			"title": map[string]interface{}{
 				"type":        "string",
-				"description": "Title/name of the task to delete (e.g. 'Medicine Order'). Will delete ALL tasks matching this name. Provide either title OR uuid, not both. Used in both create_task and delete_task.",
+				"description": "Title/name of the task to delete or complete (e.g. 'Medicine Order'). delete_task removes ALL tasks matching this name; complete_task completes every open one. Provide either title OR uuid, not both. Used in create_task, delete_task and complete_task.",
 			},
 			"task_type": map[string]interface{}{
 				"type":        "string",
@@ -130,8 +164,9 @@ func (s *ArchitectSkill) Parameters() map[string]interface{} {
 	}
 }
 
-func (s *ArchitectSkill) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+func (s *ArchitectSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
 	command, _ := args["command"].(string)
+	defer func() { health.Record(s.workspace, command, result) }()
 
 	switch command {
 	case "sync_deadlines":
@@ -140,6 +175,8 @@ func (s *ArchitectSkill) Execute(ctx context.Context, args map[string]interface{
 		return s.executeCreateTask(ctx, args)
 	case "delete_task":
 		return s.executeDeleteTask(ctx, args)
+	case "complete_task":
+		return s.executeCompleteTask(ctx, args)
 	default:
 		return tools.ErrorResult(fmt.Sprintf("Unknown command: %s", command))
 	}
@@ -154,10 +191,13 @@ func buildCalendarURL(cfg ArchitectConfig) string {
 }
 
 func (s *ArchitectSkill) executeSyncDeadlines(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	cfg := loadArchitectConfig()
-	loc, err := time.LoadLocation("Asia/Dhaka")
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	loc, err := s.location()
 	if err != nil {
-		return tools.ErrorResult("Failed to load timezone Asia/Dhaka")
+		return tools.ErrorResult(fmt.Sprintf("Failed to load timezone: %v", err))
 	}
 	now := time.Now().In(loc)
 
@@ -180,12 +220,40 @@ func (s *ArchitectSkill) executeSyncDeadlines(ctx context.Context, args map[stri
 	var urgent []string
 	var upcoming []string
 	var completed []string
+	feed := &deadlines.Feed{Generated: now, Timezone: loc.String()}
+	taskCount := len(taskHrefs)
 
-	for _, href := range allHrefs {
+	for i, href := range allHrefs {
 		parts := strings.Split(href, "/")
 		filename := parts[len(parts)-1]
 		uuid := strings.TrimSuffix(filename, ".ics")
 
+		if i >= taskCount {
+			// Events are deadlines only until they end; a recurring one
+			// stands for its next occurrence.
+			ics, _, err := s.fetchTaskICS(cfg, href)
+			if err != nil {
+				continue
+			}
+			o, ok := atc.NextEventOccurrence(ics, now)
+			if !ok || o.Summary == "" {
+				continue
+			}
+			uid := o.UID
+			if uid == "" {
+				uid = uuid
+			}
+			feed.Deadlines = append(feed.Deadlines, deadlines.Deadline{
+				UID:     uid,
+				Summary: o.Summary,
+				Due:     o.Start.In(loc),
+				AllDay:  o.AllDay,
+				Source:  "calendar",
+			})
+			urgent, upcoming = classifyDue(urgent, upcoming, uuid, o.Summary, o.Start.In(loc), o.AllDay, now)
+			continue
+		}
+
 		fields, err := s.getTaskFromCalDAV(cfg, href)
 		if err != nil {
 			continue
@@ -197,9 +265,9 @@ func (s *ArchitectSkill) executeSyncDeadlines(ctx context.Context, args map[stri
 		}
 		status := fields["STATUS"]
 		pct := fields["PERCENT-COMPLETE"]
-		dueStr := fields["DUE"]
+		dueStr, dueParams := fields["DUE"], fields["DUE;PARAMS"]
 		if dueStr == "" {
-			dueStr = fields["DTSTART"]
+			dueStr, dueParams = fields["DTSTART"], fields["DTSTART;PARAMS"]
 		}
 
 		isCompleted := status == "COMPLETED" || pct == "100"
@@ -207,23 +275,31 @@ func (s *ArchitectSkill) executeSyncDeadlines(ctx context.Context, args map[stri
 			completed = append(completed, fmt.Sprintf("- [task_id: %s] %s: Marked completed on CalDAV. *Action: Log to MEMORY.md and celebrate.*", uuid, summary))
 			continue
 		}
+		if status == "CANCELLED" || dueStr == "" {
+			continue
+		}
 
-		if dueStr != "" && len(dueStr) >= 8 {
-			dueOnly := dueStr[:8]
-			dueDate, parseErr := time.ParseInLocation("20060102", dueOnly, loc)
-			if parseErr == nil {
-				daysDiff := int(dueDate.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)).Hours() / 24)
-				if daysDiff < 0 {
-					// OVERDUE — embed ISO at T00:00 so Chief always flags it
-					urgent = append(urgent, fmt.Sprintf("- [task_id: %s] %s: OVERDUE by %d days %sT00:00. *Action: Flag as overdue.*", uuid, summary, -daysDiff, dueDate.Format("2006-01-02")))
-				} else if daysDiff == 0 {
-					// DUE TODAY — embed ISO at T09:00 (morning, within Chief's 2h window from 9am)
-					urgent = append(urgent, fmt.Sprintf("- [task_id: %s] %s: DUE TODAY %sT09:00. *Action: Send urgent reminder.*", uuid, summary, dueDate.Format("2006-01-02")))
-				} else if daysDiff <= 7 {
-					upcoming = append(upcoming, fmt.Sprintf("- [task_id: %s] %s: Due in %d days (%s). *Action: Monitor, no reminder needed yet.*", uuid, summary, daysDiff, dueDate.Format("Jan 02")))
-				}
-			}
+		due, allDay, parseErr := caldav.ParseICSTime(dueStr, dueParams, loc)
+		if parseErr != nil {
+			continue
 		}
+		due = due.In(loc)
+
+		uid := fields["UID"]
+		if uid == "" {
+			uid = uuid
+		}
+		priority, _ := strconv.Atoi(fields["PRIORITY"])
+		feed.Deadlines = append(feed.Deadlines, deadlines.Deadline{
+			UID:      uid,
+			Summary:  summary,
+			Due:      due,
+			AllDay:   allDay,
+			Priority: priority,
+			Status:   status,
+			Source:   "tasks",
+		})
+		urgent, upcoming = classifyDue(urgent, upcoming, uuid, summary, due, allDay, now)
 	}
 
 	var md strings.Builder
@@ -272,13 +348,38 @@ func (s *ArchitectSkill) executeSyncDeadlines(ctx context.Context, args map[stri
 		return tools.ErrorResult(fmt.Sprintf("Atomic rename failed for deadlines-today.md: %v", err))
 	}
 
+	// Machine-readable feed for Chief's urgent_deadlines and the gateway heartbeat.
+	if err := deadlines.Write(filepath.Join(memDir, deadlines.FileName), feed); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to write %s: %v", deadlines.FileName, err))
+	}
+
 	return &tools.ToolResult{
 		ForLLM:  md.String(), // Full dashboard with UUIDs — LLM can parse and act on them
-		ForUser: "✅ Synced deadlines. Dashboard updated at memory/deadlines-today.md",
+		ForUser: fmt.Sprintf("✅ Synced deadlines. Dashboard updated at memory/deadlines-today.md (%d open deadlines in memory/%s)", len(feed.Deadlines), deadlines.FileName),
 	}
 }
 
 // propfindHrefs issues a CalDAV PROPFIND Depth:1 and returns all .ics hrefs.
+// classifyDue adds a deadline's line to urgent (overdue or due today) or
+// upcoming (due within a week); later deadlines are left out.
+func classifyDue(urgent, upcoming []string, uuid, summary string, due time.Time, allDay bool, now time.Time) ([]string, []string) {
+	loc := now.Location()
+	when := due.Format("15:04")
+	if allDay {
+		when = "all day"
+	}
+	dueDate := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, loc)
+	daysDiff := int(dueDate.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)).Hours() / 24)
+	if daysDiff < 0 {
+		urgent = append(urgent, fmt.Sprintf("- [task_id: %s] %s: OVERDUE by %d days (due %s). *Action: Flag as overdue.*", uuid, summary, -daysDiff, due.Format("Jan 02")))
+	} else if daysDiff == 0 {
+		urgent = append(urgent, fmt.Sprintf("- [task_id: %s] %s: DUE TODAY (%s). *Action: Send urgent reminder.*", uuid, summary, when))
+	} else if daysDiff <= 7 {
+		upcoming = append(upcoming, fmt.Sprintf("- [task_id: %s] %s: Due in %d days (%s). *Action: Monitor, no reminder needed yet.*", uuid, summary, daysDiff, due.Format("Jan 02")))
+	}
+	return urgent, upcoming
+}
+
 func propfindHrefs(client *http.Client, calURL, username, password string) ([]string, error) {
 	req, err := http.NewRequest("PROPFIND", calURL,
 		strings.NewReader(`<?xml version="1.0"?><propfind xmlns="DAV:"><prop><getetag/></prop></propfind>`))
@@ -310,7 +411,10 @@ func propfindHrefs(client *http.Client, calURL, username, password string) ([]st
 }
 
 func (s *ArchitectSkill) executeDeleteTask(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	cfg := loadArchitectConfig()
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	timeout := 10 * time.Second
 	if cfg.Timeout > 0 {
 		timeout = time.Duration(cfg.Timeout) * time.Second
@@ -362,6 +466,140 @@ func (s *ArchitectSkill) executeDeleteTask(ctx context.Context, args map[string]
 	return tools.ErrorResult("Provide either 'uuid' (exact task ID) or 'title' (task name) to delete.")
 }
 
+// executeCompleteTask ticks off recurring life admin the way Nextcloud
+// Tasks does: only the current occurrence is done, and DTSTART/DUE move to
+// the next one. Each completion is logged in ATC's stats for streaks.
+func (s *ArchitectSkill) executeCompleteTask(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	loc, err := s.location()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to load timezone: %v", err))
+	}
+	timeout := 10 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	tasksURL := buildTasksURL(cfg)
+
+	var hrefs []string
+	uuid, _ := args["uuid"].(string)
+	title, _ := args["title"].(string)
+	switch {
+	case uuid != "":
+		hrefs = []string{tasksURL + uuid + ".ics"}
+	case title != "":
+		all, err := propfindHrefs(client, tasksURL, cfg.Username, cfg.Password)
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("PROPFIND failed: %v", err))
+		}
+		for _, href := range all {
+			fields, err := s.getTaskFromCalDAV(cfg, href)
+			if err != nil || !strings.EqualFold(fields["SUMMARY"], title) {
+				continue
+			}
+			if st := strings.ToUpper(fields["STATUS"]); st == "COMPLETED" || st == "CANCELLED" {
+				continue
+			}
+			hrefs = append(hrefs, href)
+		}
+		if len(hrefs) == 0 {
+			return tools.ErrorResult(fmt.Sprintf("No open tasks named '%s' found in Nextcloud Tasks calendar.", title))
+		}
+	default:
+		return tools.ErrorResult("Provide either 'uuid' (exact task ID) or 'title' (task name) to complete.")
+	}
+
+	now := time.Now().In(loc)
+	var lines, errs []string
+	for _, href := range hrefs {
+		line, err := s.completeOne(client, cfg, href, loc, now)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(errs) > 0 {
+		return tools.ErrorResult(fmt.Sprintf("Completed %d, but %d errors: %s", len(lines), len(errs), strings.Join(errs, "; ")))
+	}
+	return tools.UserResult(strings.Join(lines, "\n"))
+}
+
+// completeOne completes or advances a single task and PUTs it back,
+// guarded by its ETag.
+func (s *ArchitectSkill) completeOne(client *http.Client, cfg ArchitectConfig, href string, loc *time.Location, now time.Time) (string, error) {
+	ics, etag, err := s.fetchTaskICS(cfg, href)
+	if err != nil {
+		return "", err
+	}
+	out, done, err := caldav.CompleteVTodo(ics, loc, now)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", href, err)
+	}
+	tasksURL := buildTasksURL(cfg)
+	target := href
+	if !strings.HasPrefix(href, "http") {
+		target = caldav.FullURL(tasksURL, href)
+	}
+	req, err := http.NewRequest(http.MethodPut, target, strings.NewReader(out))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(cfg.Username, cfg.Password)
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP PUT failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Nextcloud rejected update of %s. Status: %d", href, resp.StatusCode)
+	}
+
+	task, _ := atc.ParseVTodo(out, loc)
+	s.recordCompletion(task, done, now)
+
+	when := func(t time.Time) string {
+		if done.AllDay {
+			return t.Format("Mon 2 Jan 2006")
+		}
+		return t.In(loc).Format("Mon 2 Jan 2006 15:04")
+	}
+	switch {
+	case !done.Recurring:
+		return fmt.Sprintf("✅ '%s' completed.", task.Summary), nil
+	case done.Ended:
+		return fmt.Sprintf("🏁 '%s': last occurrence done, the series is complete.", task.Summary), nil
+	}
+	return fmt.Sprintf("✅ '%s': occurrence of %s done. 🔁 Next due: %s", task.Summary, when(done.Occurrence), when(done.Next)), nil
+}
+
+// recordCompletion writes the completion into ATC's stats database, next
+// to this workspace, so the evening review sees recurring-task streaks.
+// Best effort: a stats failure never fails the completion.
+func (s *ArchitectSkill) recordCompletion(task atc.VTodoProperties, done caldav.Completion, now time.Time) {
+	if s.workspace == "" || task.Uid == "" {
+		return
+	}
+	st, err := atc.OpenStats(filepath.Join(filepath.Dir(s.workspace), "atc", "memory", atc.StatsFileName))
+	if err != nil {
+		return
+	}
+	defer st.Close()
+	if done.Recurring {
+		_ = st.RecordOccurrence(task, done.Occurrence, done.AllDay, now)
+		return
+	}
+	_ = st.Record(task, atc.EventCompleted, now)
+}
+
 func (s *ArchitectSkill) deleteByUUID(client *http.Client, cfg ArchitectConfig, uuid string) *tools.ToolResult {
 	tasksURL := buildTasksURL(cfg)
 	url := tasksURL + uuid + ".ics"
@@ -382,7 +620,8 @@ func (s *ArchitectSkill) deleteByUUID(client *http.Client, cfg ArchitectConfig,
 	return tools.ErrorResult(fmt.Sprintf("Nextcloud rejected DELETE. Status: %d, Response: %s", resp.StatusCode, string(body)))
 }
 
-func (s *ArchitectSkill) getTaskFromCalDAV(cfg ArchitectConfig, href string) (map[string]string, error) {
+// fetchTaskICS GETs one task object by href (or full URL), with its ETag.
+func (s *ArchitectSkill) fetchTaskICS(cfg ArchitectConfig, href string) (string, string, error) {
 	tasksURL := buildTasksURL(cfg)
 	idx := strings.Index(tasksURL, "/remote.php")
 	var fullURL string
@@ -393,7 +632,7 @@ func (s *ArchitectSkill) getTaskFromCalDAV(cfg ArchitectConfig, href string) (ma
 	}
 	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 	req.SetBasicAuth(cfg.Username, cfg.Password)
 
@@ -404,18 +643,29 @@ func (s *ArchitectSkill) getTaskFromCalDAV(cfg ArchitectConfig, href string) (ma
 	client := &http.Client{Timeout: timeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("GET %s returned %d", href, resp.StatusCode)
+	}
+	return string(body), resp.Header.Get("ETag"), nil
+}
+
+func (s *ArchitectSkill) getTaskFromCalDAV(cfg ArchitectConfig, href string) (map[string]string, error) {
+	body, _, err := s.fetchTaskICS(cfg, href)
 	if err != nil {
 		return nil, err
 	}
 
 	fields := map[string]string{}
 	// Normalize line endings and unfold
-	raw := strings.ReplaceAll(string(body), "\r\n", "\n")
+	raw := strings.ReplaceAll(body, "\r\n", "\n")
 	raw = strings.ReplaceAll(raw, "\n ", "")
 	raw = strings.ReplaceAll(raw, "\n\t", "")
 
@@ -425,10 +675,17 @@ func (s *ArchitectSkill) getTaskFromCalDAV(cfg ArchitectConfig, href string) (ma
 		if len(parts) != 2 {
 			continue
 		}
-		key := strings.ToUpper(strings.TrimSpace(strings.SplitN(parts[0], ";", 2)[0]))
+		nameParams := strings.SplitN(parts[0], ";", 2)
+		key := strings.ToUpper(strings.TrimSpace(nameParams[0]))
 		val := strings.TrimSpace(parts[1])
 		switch key {
-		case "SUMMARY", "STATUS", "PERCENT-COMPLETE", "COMPLETED", "LAST-MODIFIED", "DUE", "DTSTART":
+		case "DUE", "DTSTART":
+			// Keep TZID / VALUE=DATE so the real due time can be resolved.
+			if len(nameParams) == 2 {
+				fields[key+";PARAMS"] = nameParams[1]
+			}
+			fields[key] = val
+		case "SUMMARY", "STATUS", "PERCENT-COMPLETE", "COMPLETED", "LAST-MODIFIED", "UID", "PRIORITY":
 			// Unescape
 			val = strings.ReplaceAll(val, "\\,", ",")
 			val = strings.ReplaceAll(val, "\\;", ";")
@@ -453,9 +710,9 @@ func (s *ArchitectSkill) executeCreateTask(ctx context.Context, args map[string]
 		return tools.ErrorResult("Missing 'target_date'")
 	}
 
-	loc, err := time.LoadLocation("Asia/Dhaka")
+	loc, err := s.location()
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to load timezone Asia/Dhaka: %v", err))
+		return tools.ErrorResult(fmt.Sprintf("Failed to load timezone: %v", err))
 	}
 
 	targetDate, err := time.ParseInLocation("2006-01-02", targetDateStr, loc)
@@ -463,7 +720,10 @@ func (s *ArchitectSkill) executeCreateTask(ctx context.Context, args map[string]
 		return tools.ErrorResult(fmt.Sprintf("Invalid target_date format: %v", err))
 	}
 
-	cfg := loadArchitectConfig()
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 
 	nowUTC := time.Now().UTC().Format("20060102T150405Z")
 	uuid := generateUUID()
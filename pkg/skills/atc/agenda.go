@@ -0,0 +1,183 @@
+package atc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+)
+
+// ----------------------------------------------------------------------------
+// Calendar agenda
+//
+// events.xml holds each event once, as synced: recurring events carry
+// their RRULE/EXDATE and edited occurrences are separate events with a
+// RECURRENCE-ID. eventOccurrences expands them into concrete occurrences
+// for a range (with caldav.Occurrences, which Chief's briefs share);
+// read_calendar and plan_day both work from that list.
+// ----------------------------------------------------------------------------
+
+// maxAgendaDays caps read_calendar ranges.
+const maxAgendaDays = 92
+
+// event converts an events.xml VEVENT for occurrence expansion.
+func (p VEventProperties) event() caldav.Event {
+	return caldav.Event{
+		UID: p.Uid, Source: p.Source, Tag: p.Tag, Summary: p.Summary, Location: p.Location, Status: p.Status,
+		Dtstart: p.Dtstart, DtstartDate: p.DtstartDate, Dtend: p.Dtend, DtendDate: p.DtendDate, Duration: p.Duration,
+		RRule: p.RRule, ExDate: p.ExDate, ExDateDate: p.ExDateDate,
+		RecurrenceID: p.RecurrenceID, RecurrenceIDDate: p.RecurrenceIDDate,
+	}
+}
+
+// eventOccurrences lists every occurrence in cal touching [from, to); see
+// caldav.Occurrences.
+func eventOccurrences(cal ICalendar, from, to time.Time) []caldav.Occurrence {
+	events := make([]caldav.Event, 0, len(cal.VCal.Components.VEvents))
+	for _, ev := range cal.VCal.Components.VEvents {
+		events = append(events, ev.Properties.event())
+	}
+	return caldav.Occurrences(events, from, to)
+}
+
+// NextEventOccurrence returns the first occurrence of the events in one
+// .ics body that has not ended by now and starts within a year, expanding
+// recurring events. Floating times are read in now's location. Events that
+// have already ended, or whose series is over, report false.
+func NextEventOccurrence(ics string, now time.Time) (caldav.Occurrence, bool) {
+	lines := strings.Split(strings.ReplaceAll(ics, "\r\n", "\n"), "\n")
+	cal := parseICS(normalizeICSLines(lines), now.Location())
+	occs := eventOccurrences(*cal, now, now.AddDate(1, 0, 0))
+	if len(occs) == 0 {
+		return caldav.Occurrence{}, false
+	}
+	return occs[0], true
+}
+
+// agendaLine renders one occurrence as seen on day (midnight): times,
+// duration, multi-day context, location, a 🔁 for recurring events and the
+// source's tag (or, with showSource, its name).
+func agendaLine(o caldav.Occurrence, day time.Time, showSource bool) string {
+	next := day.AddDate(0, 0, 1)
+	var when string
+	switch {
+	case o.AllDay:
+		total := int(o.End.Sub(o.Start).Hours()/24 + 0.5)
+		when = "All day"
+		if total > 1 {
+			n := int(day.Sub(o.Start).Hours()/24+0.5) + 1
+			when = fmt.Sprintf("All day (day %d of %d)", n, total)
+		}
+	case o.Start.Before(day) && o.End.After(next):
+		when = fmt.Sprintf("All day (continues, %s → %s)", o.Start.Format("Mon 2 Jan 15:04"), o.End.Format("Mon 2 Jan 15:04"))
+	case o.Start.Before(day):
+		when = fmt.Sprintf("until %s (since %s)", o.End.Format("15:04"), o.Start.Format("Mon 2 Jan 15:04"))
+	case o.End.After(next):
+		when = fmt.Sprintf("%s → %s (%s)", o.Start.Format("15:04"), o.End.Format("Mon 2 Jan 15:04"), formatSpan(o.End.Sub(o.Start)))
+	case o.End.After(o.Start):
+		when = fmt.Sprintf("%s–%s (%s)", o.Start.Format("15:04"), o.End.Format("15:04"), formatSpan(o.End.Sub(o.Start)))
+	default:
+		when = o.Start.Format("15:04")
+	}
+	line := fmt.Sprintf("• %s %s", when, o.Summary)
+	if o.Location != "" {
+		line += " @ " + o.Location
+	}
+	if o.Recurring {
+		line += " 🔁"
+	}
+	if o.Tag != "" {
+		line += " " + o.Tag
+	} else if showSource && o.Source != "" {
+		line += " [" + o.Source + "]"
+	}
+	return line
+}
+
+// conflicts lists the overlapping timed events of one day, each pair
+// reported on the day their overlap begins.
+func conflicts(items []caldav.Occurrence, day time.Time) []string {
+	next := day.AddDate(0, 0, 1)
+	var out []string
+	for i := 0; i < len(items); i++ {
+		for j := i + 1; j < len(items); j++ {
+			a, b := items[i], items[j]
+			if a.AllDay || b.AllDay {
+				continue
+			}
+			from, to := a.Start, a.End
+			if b.Start.After(from) {
+				from = b.Start
+			}
+			if b.End.Before(to) {
+				to = b.End
+			}
+			if !to.After(from) || from.Before(day) || !from.Before(next) {
+				continue
+			}
+			out = append(out, fmt.Sprintf("⚠️ Conflict: %s overlaps %s (%s–%s)", a.Summary, b.Summary, from.Format("15:04"), to.Format("15:04")))
+		}
+	}
+	return out
+}
+
+// renderAgenda prints occurrences day by day from the day of from up to
+// to. Empty days are shown only when showEmpty is set (the week view).
+// Source names are shown once events from several sources are mixed.
+func renderAgenda(occs []caldav.Occurrence, from, to time.Time, showEmpty bool) string {
+	sources := map[string]bool{}
+	for _, o := range occs {
+		sources[o.Source] = true
+	}
+	var sb strings.Builder
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		lo := day
+		if from.After(lo) {
+			lo = from
+		}
+		var items []caldav.Occurrence
+		for _, o := range occs {
+			if o.Overlaps(lo, next) {
+				items = append(items, o)
+			}
+		}
+		if len(items) == 0 && !showEmpty {
+			continue
+		}
+		fmt.Fprintf(&sb, "📅 %s\n", day.Format("Mon 2 Jan 2006"))
+		if len(items) == 0 {
+			sb.WriteString("• (nothing scheduled)\n")
+		}
+		for _, o := range items {
+			sb.WriteString(agendaLine(o, day, len(sources) > 1) + "\n")
+		}
+		for _, c := range conflicts(items, day) {
+			sb.WriteString(c + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// parseAgendaTime reads a read_calendar bound: today, tomorrow, a date
+// (midnight in loc) or an RFC3339 time. dateOnly reports a date, so an
+// upper bound can include the whole day.
+func parseAgendaTime(v string, now time.Time) (t time.Time, dateOnly bool, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "today":
+		return today, true, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, now.Location()); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, false, fmt.Errorf("bad date %q (use YYYY-MM-DD or RFC3339)", v)
+	}
+	return t.In(now.Location()), false, nil
+}
@@ -0,0 +1,80 @@
+package atc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventOccurrences(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT", "UID:standup", "SUMMARY:Standup", "LOCATION:Room 4",
+		"DTSTART:20260302T070000Z", "DTEND:20260302T073000Z", "RRULE:FREQ=DAILY",
+		"EXDATE:20260304T070000Z",
+		"BEGIN:VALARM", "TRIGGER:-PT5M", "END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT", "UID:standup", "SUMMARY:Standup (moved)",
+		"RECURRENCE-ID:20260305T070000Z", "DTSTART:20260305T080000Z", "DURATION:PT30M",
+		"END:VEVENT",
+		"BEGIN:VEVENT", "UID:trip", "SUMMARY:Trip", "DTSTART;VALUE=DATE:20260302", "DTEND;VALUE=DATE:20260305", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:review", "SUMMARY:Review", "DTSTART:20260303T071500Z", "DTEND:20260303T080000Z", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:gone", "SUMMARY:Gone", "STATUS:CANCELLED", "DTSTART:20260303T100000Z", "END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	cal := parseICS(strings.Split(ics, "\r\n"), loc)
+	if got := cal.VCal.Components.VEvents[2].Properties.DtstartDate; got != "2026-03-02" {
+		t.Fatalf("all-day start = %q", got)
+	}
+
+	from := time.Date(2026, 3, 3, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 3)
+	occs := eventOccurrences(*cal, from, to)
+	var got []string
+	for _, o := range occs {
+		got = append(got, o.Start.Format("02 15:04")+" "+o.Summary)
+	}
+	want := []string{"02 00:00 Trip", "03 09:00 Standup", "03 09:15 Review", "05 10:00 Standup (moved)"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("occurrences = %q, want %q", got, want)
+	}
+
+	out := renderAgenda(occs, from, to, true)
+	for _, line := range []string{
+		"📅 Tue 3 Mar 2026", "• All day (day 2 of 3) Trip",
+		"• 09:00–09:30 (30m) Standup @ Room 4 🔁",
+		"⚠️ Conflict: Standup overlaps Review (09:15–09:30)",
+		"• All day (day 3 of 3) Trip", "• 10:00–10:30 (30m) Standup (moved) 🔁",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("agenda missing %q:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "Gone") || strings.Count(out, "Trip") != 2 {
+		t.Errorf("agenda:\n%s", out)
+	}
+}
+
+func TestNextEventOccurrence(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, loc)
+	ics := func(lines ...string) string {
+		return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:e", "SUMMARY:E"}, lines...), "END:VEVENT", "END:VCALENDAR"), "\r\n")
+	}
+
+	if _, ok := NextEventOccurrence(ics("DTSTART:20260301T090000Z", "DTEND:20260301T100000Z"), now); ok {
+		t.Error("a meeting that ended last week is still a deadline")
+	}
+	if _, ok := NextEventOccurrence(ics("DTSTART:20260301T090000Z", "DURATION:PT1H", "RRULE:FREQ=WEEKLY;COUNT=1"), now); ok {
+		t.Error("a finished series is still a deadline")
+	}
+	o, ok := NextEventOccurrence(ics("DTSTART:20260302T070000Z", "DTEND:20260302T073000Z", "RRULE:FREQ=WEEKLY"), now)
+	if !ok || !o.Start.Equal(time.Date(2026, 3, 16, 7, 0, 0, 0, time.UTC)) || !o.Recurring {
+		t.Errorf("weekly standup next = %v %v, want Mon 16 Mar 07:00Z", o.Start, ok)
+	}
+	o, ok = NextEventOccurrence(ics("DTSTART;VALUE=DATE:20260310", "DTEND;VALUE=DATE:20260311"), now)
+	if !ok || !o.AllDay || o.Start.Day() != 10 {
+		t.Errorf("today's all-day event = %v %v", o.Start, ok)
+	}
+}
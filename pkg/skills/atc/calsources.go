@@ -0,0 +1,240 @@
+package atc
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+)
+
+// ----------------------------------------------------------------------------
+// Calendar sources
+//
+// sync_calendar merges every configured source into one events.xml. Each
+// event is stamped with its source, so a source can be refreshed (or fail)
+// on its own: its events are replaced only after a good fetch.
+// ----------------------------------------------------------------------------
+
+// CalendarSource is one entry of tools.calendars: an ICS feed (url) or a
+// calendar on the user's Nextcloud (calendar, its URI).
+type CalendarSource struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`      // ICS feed; webcal:// is fetched over https
+	Calendar       string `json:"calendar"` // Nextcloud calendar URI, e.g. "personal"
+	Username       string `json:"username"` // Basic auth for url; Nextcloud calendars use the user's account
+	Password       string `json:"password"`
+	Tag            string `json:"tag"`             // short label shown in read_calendar, e.g. "🎓"
+	Color          string `json:"color"`           // stored as the events' COLOR, e.g. "#1e88e5"
+	RefreshMinutes int    `json:"refresh_minutes"` // 0 fetches on every sync
+}
+
+// calendarSourceState is what sync_calendar remembers per source.
+type calendarSourceState struct {
+	Fetched time.Time `json:"fetched"` // last good fetch
+	Events  int       `json:"events"`
+	Error   string    `json:"error,omitempty"` // last failure, cleared on success
+	Failed  time.Time `json:"failed,omitempty"`
+}
+
+// LoadCalendarSources reads tools.calendars, dropping entries without a
+// name or a place to fetch from, and duplicate names. For a named user it
+// reads their own users[].calendars instead, plus only the calendar
+// entries of tools.calendars: those resolve against the user's own
+// Nextcloud, while the ICS feeds there (and their credentials) are the
+// operator's.
+func LoadCalendarSources(user string) []CalendarSource {
+	var cfg struct {
+		Tools struct {
+			Calendars []CalendarSource `json:"calendars"`
+		} `json:"tools"`
+		Users []struct {
+			Name      string           `json:"name"`
+			Calendars []CalendarSource `json:"calendars"`
+		} `json:"users"`
+	}
+	readConfig(&cfg)
+	srcs := cfg.Tools.Calendars
+	if user != "" {
+		srcs = nil
+		for _, u := range cfg.Users {
+			if u.Name == user {
+				srcs = append(srcs, u.Calendars...)
+			}
+		}
+		for _, src := range cfg.Tools.Calendars {
+			if src.Calendar != "" {
+				src.URL, src.Username, src.Password = "", "", ""
+				srcs = append(srcs, src)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var out []CalendarSource
+	for _, src := range srcs {
+		src.Name = strings.TrimSpace(src.Name)
+		if src.Name == "" || seen[src.Name] || (src.URL == "" && src.Calendar == "") {
+			continue
+		}
+		seen[src.Name] = true
+		if strings.HasPrefix(src.URL, "webcal://") {
+			src.URL = "https://" + strings.TrimPrefix(src.URL, "webcal://")
+		}
+		if src.RefreshMinutes < 0 {
+			src.RefreshMinutes = 0
+		}
+		out = append(out, src)
+	}
+	return out
+}
+
+// calendarSources returns the configured sources, or the single legacy
+// source (the Nextcloud personal calendar or ATC_CALENDAR_URL) when none
+// are configured.
+func (s *ATCSkill) calendarSources() []CalendarSource {
+	user := ""
+	if s.user != nil {
+		user = s.user.Name
+	}
+	if srcs := LoadCalendarSources(user); len(srcs) > 0 {
+		return srcs
+	}
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return nil
+	}
+	if cfg.Host != "" {
+		return []CalendarSource{{Name: "nextcloud", Calendar: "personal"}}
+	}
+	if u := os.Getenv("ATC_CALENDAR_URL"); u != "" {
+		return []CalendarSource{{Name: "nextcloud", URL: u, Username: cfg.Username, Password: cfg.Password}}
+	}
+	return nil
+}
+
+// fetchSource downloads and parses one source, stamping its events.
+func (s *ATCSkill) fetchSource(src CalendarSource) ([]VEvent, error) {
+	url, user, pass := src.URL, src.Username, src.Password
+	if src.Calendar != "" {
+		cfg, err := s.loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Host == "" {
+			return nil, fmt.Errorf("calendar %q needs host under tools.nextcloud", src.Calendar)
+		}
+		url, user, pass = caldav.BuildNamedCalendarURL(cfg.Host, cfg.Username, src.Calendar), cfg.Username, cfg.Password
+	}
+	lines, err := fetchICS(url, user, pass)
+	if err != nil {
+		return nil, err
+	}
+	// A login page or error body must not replace the source's events.
+	isCal := false
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "BEGIN:VCALENDAR" {
+			isCal = true
+			break
+		}
+	}
+	if !isCal {
+		return nil, fmt.Errorf("response is not an iCalendar feed")
+	}
+	events := parseICS(lines, s.now().Location()).VCal.Components.VEvents
+	for i := range events {
+		events[i].Properties.Source = src.Name
+		events[i].Properties.Tag = src.Tag
+		if src.Color != "" {
+			events[i].Properties.Color = src.Color
+		}
+	}
+	return events, nil
+}
+
+// syncCalendarSources refreshes the sources that are due (all of them with
+// force, or just only when set) and merges them into events.xml. A source
+// that fails, or is not due, keeps its events from the last good fetch;
+// events of sources no longer configured are dropped.
+func (s *ATCSkill) syncCalendarSources(srcs []CalendarSource, only string, force bool) (string, error) {
+	memDir := filepath.Join(s.workspace, "memory")
+	eventsPath := filepath.Join(memDir, "events.xml")
+	statePath := filepath.Join(memDir, "calendar_sources.json")
+
+	var cal ICalendar
+	if data, err := os.ReadFile(eventsPath); err == nil {
+		if err := xml.Unmarshal(data, &cal); err != nil {
+			return "", fmt.Errorf("failed to parse events.xml: %w", err)
+		}
+	}
+	cal.VCal.Properties = VCalProperties{Version: "2.0", Prodid: "-//Son of Anthon//ATC Agent Sync//EN"}
+	kept := map[string][]VEvent{}
+	for _, ev := range cal.VCal.Components.VEvents {
+		kept[ev.Properties.Source] = append(kept[ev.Properties.Source], ev)
+	}
+	state := map[string]calendarSourceState{}
+	if data, err := os.ReadFile(statePath); err == nil {
+		json.Unmarshal(data, &state)
+	}
+
+	if only != "" {
+		found := false
+		for _, src := range srcs {
+			found = found || src.Name == only
+		}
+		if !found {
+			return "", fmt.Errorf("no calendar source named %q", only)
+		}
+	}
+
+	now := s.now()
+	var sb strings.Builder
+	var merged []VEvent
+	fetched, failed := 0, 0
+	for _, src := range srcs {
+		st := state[src.Name]
+		due := force || src.RefreshMinutes == 0 || now.Sub(st.Fetched) >= time.Duration(src.RefreshMinutes)*time.Minute
+		if only != "" {
+			due = src.Name == only
+		}
+		if !due {
+			merged = append(merged, kept[src.Name]...)
+			fmt.Fprintf(&sb, "⏭️ %s: %d events, synced %s ago (refresh every %dm)\n",
+				src.Name, len(kept[src.Name]), formatSpan(now.Sub(st.Fetched)), src.RefreshMinutes)
+			continue
+		}
+		events, err := s.fetchSource(src)
+		if err != nil {
+			failed++
+			st.Error, st.Failed = err.Error(), now
+			state[src.Name] = st
+			merged = append(merged, kept[src.Name]...)
+			fmt.Fprintf(&sb, "❌ %s: %v; kept %d events from the last sync\n", src.Name, err, len(kept[src.Name]))
+			continue
+		}
+		fetched++
+		state[src.Name] = calendarSourceState{Fetched: now, Events: len(events)}
+		merged = append(merged, events...)
+		fmt.Fprintf(&sb, "✅ %s: %d events\n", src.Name, len(events))
+	}
+	if data, err := json.MarshalIndent(state, "", "  "); err == nil {
+		os.WriteFile(statePath, data, 0644)
+	}
+	if fetched == 0 && failed > 0 {
+		return "", fmt.Errorf("every calendar source failed:\n%s", strings.TrimRight(sb.String(), "\n"))
+	}
+
+	cal.VCal.Components.VEvents = merged
+	out, err := xml.MarshalIndent(cal, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal synced calendar data: %w", err)
+	}
+	if err := os.WriteFile(eventsPath, append([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"), out...), 0644); err != nil {
+		return "", fmt.Errorf("failed to save events.xml: %w", err)
+	}
+	fmt.Fprintf(&sb, "📅 %d events from %d sources saved to events.xml.", len(merged), len(srcs))
+	return sb.String(), nil
+}
@@ -0,0 +1,114 @@
+package atc
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncCalendarSources(t *testing.T) {
+	t.Setenv("PERSONAL_OS_CONFIG", filepath.Join(t.TempDir(), "none.json"))
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/uni.ics" && down:
+			w.Write([]byte("<html>maintenance</html>"))
+		case r.URL.Path == "/uni.ics":
+			w.Write([]byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Lecture\r\nDTSTART:20260303T080000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+		case r.URL.Path == "/holidays.ics":
+			w.Write([]byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20260303\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	s := &ATCSkill{workspace: ws}
+	srcs := []CalendarSource{
+		{Name: "uni", URL: srv.URL + "/uni.ics", Tag: "🎓"},
+		{Name: "holidays", URL: srv.URL + "/holidays.ics", Color: "#e53935", RefreshMinutes: 1440},
+	}
+	load := func() map[string]VEventProperties {
+		var cal ICalendar
+		data, _ := os.ReadFile(filepath.Join(ws, "memory", "events.xml"))
+		xml.Unmarshal(data, &cal)
+		out := map[string]VEventProperties{}
+		for _, ev := range cal.VCal.Components.VEvents {
+			out[ev.Properties.Source] = ev.Properties
+		}
+		return out
+	}
+
+	if _, err := s.syncCalendarSources(srcs, "", false); err != nil {
+		t.Fatal(err)
+	}
+	got := load()
+	if got["uni"].Summary != "Lecture" || got["uni"].Tag != "🎓" || got["holidays"].Color != "#e53935" {
+		t.Fatalf("events = %+v", got)
+	}
+
+	// uni breaks and holidays is not due: both keep their events.
+	down = true
+	msg, err := s.syncCalendarSources(srcs, "", false)
+	if err == nil || !strings.Contains(err.Error(), "not an iCalendar feed") || !strings.Contains(err.Error(), "⏭️ holidays") {
+		t.Fatalf("sync = %q, %v", msg, err)
+	}
+	if got := load(); len(got) != 2 || got["uni"].Summary != "Lecture" {
+		t.Errorf("events after failure = %+v", got)
+	}
+
+	msg, err = s.syncCalendarSources(srcs, "", true)
+	if err != nil || !strings.Contains(msg, "❌ uni") || !strings.Contains(msg, "✅ holidays: 1 events") {
+		t.Fatalf("forced sync = %q, %v", msg, err)
+	}
+	if got := load(); len(got) != 2 || got["uni"].Summary != "Lecture" {
+		t.Errorf("events after partial failure = %+v", got)
+	}
+
+	// A source dropped from the config loses its events.
+	if _, err := s.syncCalendarSources(srcs[1:], "", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); len(got) != 1 || got["holidays"].Summary != "Holiday" {
+		t.Errorf("events after removing uni = %+v", got)
+	}
+}
+
+func TestLoadCalendarSourcesPerUser(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(cfg, []byte(`{
+		"tools": {"calendars": [
+			{"name": "work", "url": "https://corp.example.com/jony.ics", "username": "jony", "password": "secret"},
+			{"name": "family", "calendar": "family"}
+		]},
+		"users": [
+			{"name": "jony", "calendars": [{"name": "work", "url": "webcal://corp.example.com/jony.ics"}]},
+			{"name": "rafi"}
+		]
+	}`), 0644)
+	t.Setenv("PERSONAL_OS_CONFIG", cfg)
+
+	names := func(srcs []CalendarSource) string {
+		var out []string
+		for _, src := range srcs {
+			out = append(out, src.Name+"="+src.URL+src.Calendar+src.Password)
+		}
+		return strings.Join(out, " ")
+	}
+	if got := names(LoadCalendarSources("")); got != "work=https://corp.example.com/jony.icssecret family=family" {
+		t.Errorf("single-user = %s", got)
+	}
+	if got := names(LoadCalendarSources("jony")); got != "work=https://corp.example.com/jony.ics family=family" {
+		t.Errorf("jony = %s", got)
+	}
+	// Another user never gets the operator's feeds or credentials.
+	if got := names(LoadCalendarSources("rafi")); got != "family=family" {
+		t.Errorf("rafi = %s", got)
+	}
+}
@@ -22,6 +22,26 @@ type ATCCalendarConfig struct {
 	Timeout  int    `json:"timeout_seconds"`
 }
 
+// configPath is the config file ATC reads: $PERSONAL_OS_CONFIG, else
+// ~/.picoclaw/config.json.
+func configPath() string {
+	if path := os.Getenv("PERSONAL_OS_CONFIG"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".picoclaw", "config.json")
+}
+
+// readConfig unmarshals the config file into v, which mirrors the parts of
+// config.json the caller needs. A missing file leaves v as it is.
+func readConfig(v interface{}) error {
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
 // loadATCConfig parses the config file for Nextcloud calendar settings.
 func loadATCConfig() ATCCalendarConfig {
 	var cfg struct {
@@ -29,27 +49,25 @@ func loadATCConfig() ATCCalendarConfig {
 			Nextcloud ATCCalendarConfig `json:"nextcloud"`
 		} `json:"tools"`
 	}
-	home, _ := os.UserHomeDir()
-	path := os.Getenv("PERSONAL_OS_CONFIG")
-	if path == "" {
-		path = filepath.Join(home, ".picoclaw", "config.json")
-	}
-	data, err := os.ReadFile(path)
-	if err == nil {
-		json.Unmarshal(data, &cfg)
-	}
+	readConfig(&cfg)
 	return cfg.Tools.Nextcloud
 }
 
 // TaskOptions holds optional metadata for a CalDAV VTODO
 type TaskOptions struct {
-	Due             string // RFC3339 datetime, e.g. 2026-02-21T17:00:00Z
-	Start           string // RFC3339 datetime for DTSTART
+	Due             string // RFC3339 datetime, e.g. 2026-02-21T17:00:00Z, or a YYYY-MM-DD date
+	Start           string // RFC3339 datetime or YYYY-MM-DD date for DTSTART
 	Priority        int    // 1=High, 5=Medium, 9=Low, 0=None (RFC 5545)
 	PercentComplete int    // 0-100
 	Location        string
 	URL             string
-	Notes           string // DESCRIPTION field
+	Notes           string   // DESCRIPTION field
+	Parent          string   // UID of the parent task (RELATED-TO;RELTYPE=PARENT)
+	DependsOn       []string // UIDs that must be finished first (RELATED-TO;RELTYPE=DEPENDS-ON)
+	Categories      []string
+	RRule           string // e.g. FREQ=MONTHLY; needs Start or Due
+	Status          string // merge only; COMPLETED advances a recurring task
+	Estimate        string // X-ESTIMATE as an ISO 8601 duration, e.g. PT45M
 }
 
 func buildTasksURL(cfg ATCCalendarConfig) string {
@@ -63,11 +81,24 @@ func pushTaskToCalDAV(cfg ATCCalendarConfig, taskUID, summary string, opts TaskO
 
 	// Build VTODO fields conditionally
 	var extra string
-	if opts.Start != "" {
-		extra += "DTSTART:" + formatRFC3339ToICS(opts.Start) + "\r\n"
+	start := opts.Start
+	if start == "" && opts.RRule != "" {
+		start = opts.Due // RFC 5545: a recurring VTODO needs DTSTART
+	}
+	if start != "" {
+		extra += icsTimeProp("DTSTART", start)
 	}
 	if opts.Due != "" {
-		extra += "DUE:" + formatRFC3339ToICS(opts.Due) + "\r\n"
+		extra += icsTimeProp("DUE", opts.Due)
+	}
+	if opts.RRule != "" {
+		extra += "RRULE:" + opts.RRule + "\r\n"
+	}
+	if opts.Estimate != "" {
+		extra += "X-ESTIMATE:" + opts.Estimate + "\r\n"
+	}
+	if len(opts.Categories) > 0 {
+		extra += "CATEGORIES:" + strings.Join(opts.Categories, ",") + "\r\n"
 	}
 	if opts.Priority > 0 {
 		extra += fmt.Sprintf("PRIORITY:%d\r\n", opts.Priority)
@@ -84,6 +115,9 @@ func pushTaskToCalDAV(cfg ATCCalendarConfig, taskUID, summary string, opts TaskO
 	if opts.Notes != "" {
 		extra += "DESCRIPTION:" + strings.ReplaceAll(opts.Notes, "\n", "\\n") + "\r\n"
 	}
+	for _, line := range relatedToLines(withRelations(nil, taskUID, opts.Parent, opts.DependsOn)) {
+		extra += line + "\r\n"
+	}
 
 	icsBody := "BEGIN:VCALENDAR\r\n" +
 		"VERSION:2.0\r\n" +
@@ -122,6 +156,15 @@ func pushTaskToCalDAV(cfg ATCCalendarConfig, taskUID, summary string, opts TaskO
 	return nil
 }
 
+// icsTimeProp renders DTSTART / DUE from an RFC3339 time or a
+// YYYY-MM-DD date (VALUE=DATE).
+func icsTimeProp(name, value string) string {
+	if len(value) == len("2006-01-02") {
+		return name + ";VALUE=DATE:" + strings.ReplaceAll(value, "-", "") + "\r\n"
+	}
+	return name + ":" + formatRFC3339ToICS(value) + "\r\n"
+}
+
 // formatRFC3339ToICS delegates to the shared caldav package.
 func formatRFC3339ToICS(ts string) string {
 	return caldav.FormatRFC3339ToICS(ts)
@@ -207,19 +250,11 @@ func deleteTaskFromCalDAV(cfg ATCCalendarConfig, href string) error {
 	return nil
 }
 
-// getTaskFromCalDAV fetches a single VTODO by its href and returns its parsed fields.
-func getTaskFromCalDAV(cfg ATCCalendarConfig, href string) (map[string]string, error) {
-	tasksURL := buildTasksURL(cfg)
-	idx := strings.Index(tasksURL, "/remote.php")
-	var fullURL string
-	if idx > 0 && !strings.HasPrefix(href, "http") {
-		fullURL = tasksURL[:idx] + href
-	} else {
-		fullURL = href
-	}
-	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
+// getTaskICS fetches a single task object by its href, with its ETag.
+func getTaskICS(cfg ATCCalendarConfig, href string) (string, string, error) {
+	req, err := http.NewRequest(http.MethodGet, caldav.FullURL(buildTasksURL(cfg), href), nil)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 	if cfg.Username != "" {
 		req.SetBasicAuth(cfg.Username, cfg.Password)
@@ -231,104 +266,115 @@ func getTaskFromCalDAV(cfg ATCCalendarConfig, href string) (map[string]string, e
 	client := &http.Client{Timeout: timeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("CalDAV GET returned %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return string(body), resp.Header.Get("ETag"), nil
+}
 
-	var body strings.Builder
-	buf := make([]byte, 4096)
-	for {
-		n, readErr := resp.Body.Read(buf)
-		if n > 0 {
-			body.Write(buf[:n])
-		}
-		if readErr != nil {
-			break
-		}
+// getTaskFromCalDAV fetches a single VTODO by its href and returns its parsed fields.
+func getTaskFromCalDAV(cfg ATCCalendarConfig, href string) (map[string]string, error) {
+	ics, _, err := getTaskICS(cfg, href)
+	if err != nil {
+		return nil, err
 	}
 	fields := map[string]string{}
-	lines := normalizeICSLines(strings.Split(body.String(), "\n"))
+	var rels []RelatedTo
+	lines := normalizeICSLines(strings.Split(ics, "\n"))
 	for _, line := range lines {
 		parts := strings.SplitN(line, ":", 2)
 		if len(parts) != 2 {
 			continue
 		}
-		key := strings.ToUpper(strings.TrimSpace(strings.SplitN(parts[0], ";", 2)[0]))
+		keyPart := strings.SplitN(parts[0], ";", 2)
+		key := strings.ToUpper(strings.TrimSpace(keyPart[0]))
 		val := strings.TrimSpace(parts[1])
+		if key == "RELATED-TO" {
+			params := ""
+			if len(keyPart) == 2 {
+				params = keyPart[1]
+			}
+			rels = append(rels, parseRelatedTo(params, val))
+			continue
+		}
 		switch key {
-		case "SUMMARY", "UID", "STATUS", "PRIORITY", "DUE", "DTSTART", "DESCRIPTION", "LOCATION", "URL", "PERCENT-COMPLETE":
+		case "SUMMARY", "UID", "STATUS", "PRIORITY", "DUE", "DTSTART", "RRULE", "X-ESTIMATE", "X-TIME-SPENT", "DESCRIPTION", "LOCATION", "URL", "PERCENT-COMPLETE":
 			fields[key] = cleanICSString(val)
 		}
 	}
+	if len(rels) > 0 {
+		fields["RELATED-TO"] = formatRelations(rels)
+	}
 	return fields, nil
 }
 
-// mergeTaskOnCalDAV fetches an existing task, overlays changed fields, and PUTs it back.
-func mergeTaskOnCalDAV(cfg ATCCalendarConfig, href string, updates TaskOptions, newSummary string) error {
-	fields, err := getTaskFromCalDAV(cfg, href)
+// mergeTaskOnCalDAV fetches an existing task, overlays changed fields, and
+// PUTs it back. Properties it does not manage (RRULE, alarms, categories)
+// are kept. Completing a recurring task advances it to its next occurrence.
+func mergeTaskOnCalDAV(cfg ATCCalendarConfig, href string, updates TaskOptions, newSummary string, now time.Time) (VTodoProperties, caldav.Completion, error) {
+	var done caldav.Completion
+	ics, etag, err := getTaskICS(cfg, href)
 	if err != nil {
-		return fmt.Errorf("failed to fetch existing task: %w", err)
+		return VTodoProperties{}, done, fmt.Errorf("failed to fetch existing task: %w", err)
+	}
+	cur, ok := parseRemoteTodo(ics, now.Location())
+	if !ok {
+		return VTodoProperties{}, done, fmt.Errorf("no VTODO at %s", href)
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	set := map[string][]string{
+		"LAST-MODIFIED": {"LAST-MODIFIED:" + stamp},
+		"DTSTAMP":       {"DTSTAMP:" + stamp},
 	}
-	uid := fields["UID"]
-	summary := fields["SUMMARY"]
 	if newSummary != "" {
-		summary = newSummary
+		set["SUMMARY"] = []string{"SUMMARY:" + escapeICS(newSummary)}
 	}
 	if updates.Due != "" {
-		fields["DUE"] = formatRFC3339ToICS(updates.Due)
+		set["DUE"] = []string{strings.TrimSuffix(icsTimeProp("DUE", updates.Due), "\r\n")}
 	}
 	if updates.Start != "" {
-		fields["DTSTART"] = formatRFC3339ToICS(updates.Start)
+		set["DTSTART"] = []string{strings.TrimSuffix(icsTimeProp("DTSTART", updates.Start), "\r\n")}
 	}
 	if updates.Notes != "" {
-		fields["DESCRIPTION"] = strings.ReplaceAll(updates.Notes, "\n", "\\n")
+		set["DESCRIPTION"] = []string{"DESCRIPTION:" + escapeICS(updates.Notes)}
 	}
 	if updates.Location != "" {
-		fields["LOCATION"] = updates.Location
+		set["LOCATION"] = []string{"LOCATION:" + escapeICS(updates.Location)}
+	}
+	if updates.Estimate != "" {
+		set["X-ESTIMATE"] = []string{"X-ESTIMATE:" + updates.Estimate}
 	}
 	if updates.Priority > 0 {
-		fields["PRIORITY"] = fmt.Sprintf("%d", updates.Priority)
+		set["PRIORITY"] = []string{fmt.Sprintf("PRIORITY:%d", updates.Priority)}
 	}
-	var extra string
-	for _, k := range []string{"DUE", "DTSTART", "PRIORITY", "PERCENT-COMPLETE", "DESCRIPTION", "LOCATION", "URL"} {
-		if v, ok := fields[k]; ok && v != "" {
-			extra += k + ":" + v + "\r\n"
-		}
+	if updates.Parent != "" || len(updates.DependsOn) > 0 {
+		set["RELATED-TO"] = relatedToLines(withRelations(cur.Props.RelatedTo, cur.Props.Uid, updates.Parent, updates.DependsOn))
 	}
-	icsBody := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Son of Anthon ATC//EN\r\n" +
-		"BEGIN:VTODO\r\nUID:" + uid + "\r\nSUMMARY:" + summary + "\r\nSTATUS:" + fields["STATUS"] + "\r\n" +
-		extra + "END:VTODO\r\nEND:VCALENDAR\r\n"
-
-	tasksURL := buildTasksURL(cfg)
-	idx := strings.Index(tasksURL, "/remote.php")
-	var putURL string
-	if idx > 0 && !strings.HasPrefix(href, "http") {
-		putURL = caldav.FullURL(tasksURL, href)
-	} else {
-		putURL = href
-	}
-	req, err := http.NewRequest(http.MethodPut, putURL, strings.NewReader(icsBody))
-	if err != nil {
-		return err
+	status := strings.ToUpper(updates.Status)
+	if status != "" && status != "COMPLETED" {
+		set["STATUS"] = []string{"STATUS:" + status}
+		set["COMPLETED"], set["PERCENT-COMPLETE"] = nil, nil
 	}
-	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
-	if cfg.Username != "" {
-		req.SetBasicAuth(cfg.Username, cfg.Password)
-	}
-	timeout := 10 * time.Second
-	if cfg.Timeout > 0 {
-		timeout = time.Duration(cfg.Timeout) * time.Second
-	}
-	client := &http.Client{Timeout: timeout}
-	resp2, err := client.Do(req)
-	if err != nil {
-		return err
+	body := caldav.EditVTodo(ics, set)
+	if status == "COMPLETED" {
+		if body, done, err = caldav.CompleteVTodo(body, now.Location(), now); err != nil {
+			return VTodoProperties{}, done, err
+		}
 	}
-	defer resp2.Body.Close()
-	if resp2.StatusCode != http.StatusCreated && resp2.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("CalDAV merge PUT returned %d", resp2.StatusCode)
+
+	if _, _, err := putTodo(cfg, cur.Props.Uid, href, etag, false, body); err != nil {
+		return VTodoProperties{}, done, fmt.Errorf("CalDAV merge PUT: %w", err)
 	}
-	return nil
+	merged, _ := parseRemoteTodo(body, now.Location())
+	return merged.Props, done, nil
 }
 
 // fetchICS grabs the external RFC 5545 iCal data. Supports optional HTTP Basic Auth.
@@ -388,7 +434,8 @@ func normalizeICSLines(rawLines []string) []string {
 }
 
 // parseICS securely translates the flat `.ics` RFC 5545 text lines into our XML `xCal` tree structs.
-func parseICS(lines []string) *ICalendar {
+// Floating times are read in loc; VALARM contents are skipped.
+func parseICS(lines []string, loc *time.Location) *ICalendar {
 	cal := &ICalendar{
 		VCal: VCalendar{
 			Properties: VCalProperties{
@@ -399,7 +446,20 @@ func parseICS(lines []string) *ICalendar {
 	}
 
 	var currentEvent *VEvent
-	inEvent := false
+	depth := 0 // 1 inside a VEVENT, >1 inside its VALARMs
+
+	// icsTime stores a DATE as YYYY-MM-DD and a DATE-TIME (UTC, TZID or
+	// floating in loc) as RFC3339 UTC.
+	icsTime := func(val, params string, date, dateTime *string) {
+		t, allDay, err := caldav.ParseICSTime(val, params, loc)
+		switch {
+		case err != nil:
+		case allDay:
+			*date = t.Format("2006-01-02")
+		default:
+			*dateTime = t.UTC().Format(time.RFC3339)
+		}
+	}
 
 	for _, line := range lines {
 		// RFC specifies keys split by colons or semicolons for parameters.
@@ -409,58 +469,65 @@ func parseICS(lines []string) *ICalendar {
 		}
 
 		keyRaw := parts[0]
-		val := parts[1]
+		val := strings.TrimSpace(parts[1])
 
-		// Strip parameters (e.g., DTSTART;TZID=America/New_York -> DTSTART)
-		keyBase := strings.SplitN(keyRaw, ";", 2)[0]
+		// Split off parameters (e.g., DTSTART;TZID=America/New_York -> DTSTART)
+		keyBase, params, _ := strings.Cut(keyRaw, ";")
 		key := strings.ToUpper(strings.TrimSpace(keyBase))
 
-		switch key {
-		case "BEGIN":
-			if val == "VEVENT" {
-				inEvent = true
+		switch {
+		case key == "BEGIN":
+			if depth > 0 {
+				depth++
+			} else if val == "VEVENT" {
+				depth = 1
 				currentEvent = &VEvent{}
 			}
-		case "END":
-			if val == "VEVENT" && inEvent {
+			continue
+		case key == "END":
+			if depth > 1 {
+				depth--
+			} else if val == "VEVENT" && depth == 1 {
 				cal.VCal.Components.VEvents = append(cal.VCal.Components.VEvents, *currentEvent)
-				inEvent = false
+				depth = 0
 				currentEvent = nil
 			}
+			continue
+		case depth != 1:
+			continue
+		}
+
+		p := &currentEvent.Properties
+		switch key {
 		case "UID":
-			if inEvent {
-				currentEvent.Properties.Uid = val
-			}
+			p.Uid = val
 		case "SUMMARY":
-			if inEvent {
-				currentEvent.Properties.Summary = cleanICSString(val)
-			}
+			p.Summary = cleanICSString(val)
 		case "DESCRIPTION":
-			if inEvent {
-				currentEvent.Properties.Description = cleanICSString(val)
-			}
+			p.Description = cleanICSString(val)
 		case "LOCATION":
-			if inEvent {
-				currentEvent.Properties.Location = cleanICSString(val)
-			}
+			p.Location = cleanICSString(val)
+		case "STATUS":
+			p.Status = strings.ToUpper(val)
 		case "DTSTART":
-			// RFC5545 defines basic dates. For parsing properly, we format it as RFC3339 manually later,
-			// or just supply it verbatim to be caught by time.Parse("20060102T150405Z")
-			if inEvent {
-				if len(val) == 8 {
-					// Date only: 20260220
-					currentEvent.Properties.DtstartDate = formatICSDate(val)
-				} else {
-					// Date-time: 20260220T150000Z
-					currentEvent.Properties.Dtstart = formatICSDateTime(val)
-				}
-			}
+			icsTime(val, params, &p.DtstartDate, &p.Dtstart)
 		case "DTEND":
-			if inEvent {
-				if len(val) == 8 {
-					currentEvent.Properties.DtendDate = formatICSDate(val)
-				} else {
-					currentEvent.Properties.Dtend = formatICSDateTime(val)
+			icsTime(val, params, &p.DtendDate, &p.Dtend)
+		case "DURATION":
+			p.Duration = val
+		case "RRULE":
+			p.RRule = val
+		case "RECURRENCE-ID":
+			icsTime(val, params, &p.RecurrenceIDDate, &p.RecurrenceID)
+		case "EXDATE":
+			for _, v := range strings.Split(val, ",") {
+				var date, dateTime string
+				icsTime(v, params, &date, &dateTime)
+				if date != "" {
+					p.ExDateDate = append(p.ExDateDate, date)
+				}
+				if dateTime != "" {
+					p.ExDate = append(p.ExDate, dateTime)
 				}
 			}
 		}
@@ -475,28 +542,3 @@ func cleanICSString(s string) string {
 	s = strings.ReplaceAll(s, "\\,", ",")
 	return s
 }
-
-// formatICSDate safely migrates 20260220 -> 2026-02-20
-func formatICSDate(val string) string {
-	if len(val) >= 8 {
-		return fmt.Sprintf("%s-%s-%s", val[0:4], val[4:6], val[6:8])
-	}
-	return val
-}
-
-// formatICSDateTime securely translates Basic ISO8601 20260220T150000Z -> RFC3339
-func formatICSDateTime(val string) string {
-	if len(val) >= 15 && strings.Contains(val, "T") {
-		date := formatICSDate(val[:8])
-		timePart := val[9:]
-		if len(timePart) >= 6 {
-			formatted := fmt.Sprintf("%sT%s:%s:%s", date, timePart[0:2], timePart[2:4], timePart[4:6])
-			if strings.HasSuffix(val, "Z") {
-				return formatted + "Z"
-			}
-			// Assumption: local timezone string format parsing handles off-sets.
-			return formatted + "Z"
-		}
-	}
-	return val
-}
@@ -0,0 +1,292 @@
+package atc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+)
+
+// ----------------------------------------------------------------------------
+// Local task editing
+//
+// update_task, create_local_task and delete_local_task change tasks.xml
+// directly, with the fields push_task and merge_task set on Nextcloud.
+// Every edit stamps LAST-MODIFIED so the next sync_tasks pushes it.
+// Completing sets COMPLETED and PERCENT-COMPLETE:100 (or moves a recurring
+// task to its next occurrence); reopening clears them again.
+// ----------------------------------------------------------------------------
+
+// taskEdit holds the fields a command sets; nil leaves a field alone.
+type taskEdit struct {
+	Summary     *string
+	Description *string
+	Due         *string // "none" clears
+	Start       *string // "none" clears
+	Estimate    *string // "none" clears
+	Categories  *[]string
+	Priority    *int
+	Percent     *int
+	Status      string
+	Parent      *string // "none" detaches from the parent
+	DependsOn   []string
+	rrule       string // from quick-add text only
+}
+
+// clearValue is how a field is emptied; an empty string means "not given".
+const clearValue = "none"
+
+var taskStatuses = map[string]bool{"NEEDS-ACTION": true, "IN-PROCESS": true, "COMPLETED": true, "CANCELLED": true}
+
+// parseTaskEdit reads the editable fields from a command's arguments.
+func parseTaskEdit(args map[string]interface{}) (taskEdit, error) {
+	var e taskEdit
+	str := func(key string) *string {
+		if v, ok := args[key].(string); ok && strings.TrimSpace(v) != "" {
+			v = strings.TrimSpace(v)
+			return &v
+		}
+		return nil
+	}
+	e.Summary, e.Due, e.Start, e.Estimate, e.Parent = str("summary"), str("due"), str("start"), str("estimate"), str("parent")
+	e.Description = str("notes")
+	if e.Description == nil {
+		e.Description = str("description")
+	}
+	if v := str("categories"); v != nil && strings.EqualFold(*v, clearValue) {
+		e.Categories = &[]string{}
+	} else if cats := getList(args, "categories"); len(cats) > 0 {
+		e.Categories = &cats
+	}
+	if v, ok := args["priority"].(float64); ok {
+		p := int(v)
+		if p < 0 || p > 9 {
+			return e, fmt.Errorf("priority must be 0 (none) to 9, with 1 the highest")
+		}
+		e.Priority = &p
+	}
+	if v, ok := args["percent_complete"].(float64); ok {
+		p := int(v)
+		if p < 0 || p > 100 {
+			return e, fmt.Errorf("percent_complete must be 0 to 100")
+		}
+		e.Percent = &p
+	}
+	if v := str("status"); v != nil {
+		e.Status = strings.ToUpper(*v)
+		if !taskStatuses[e.Status] {
+			return e, fmt.Errorf("status must be NEEDS-ACTION, IN-PROCESS, COMPLETED or CANCELLED")
+		}
+	}
+	e.DependsOn = getList(args, "depends_on")
+	return e, nil
+}
+
+// fillFrom takes what quick-add text parsed for fields not given explicitly.
+func (e *taskEdit) fillFrom(q QuickTask) {
+	opts := q.Options()
+	set := func(field **string, v string) {
+		if *field == nil && v != "" {
+			*field = &v
+		}
+	}
+	set(&e.Summary, q.Summary)
+	set(&e.Due, opts.Due)
+	set(&e.Start, opts.Start)
+	set(&e.Estimate, opts.Estimate)
+	if e.Priority == nil && opts.Priority > 0 {
+		e.Priority = &opts.Priority
+	}
+	if e.Categories == nil && len(opts.Categories) > 0 {
+		e.Categories = &opts.Categories
+	}
+	e.rrule = opts.RRule
+}
+
+func (e taskEdit) empty() bool {
+	return e.Summary == nil && e.Description == nil && e.Due == nil && e.Start == nil && e.Estimate == nil &&
+		e.Categories == nil && e.Priority == nil && e.Percent == nil && e.Status == "" && e.Parent == nil &&
+		len(e.DependsOn) == 0 && e.rrule == ""
+}
+
+// parseEditTime reads a due or start value: today, tomorrow, a date, a
+// local date and time, or RFC3339.
+func parseEditTime(v string, now time.Time) (time.Time, bool, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(v) {
+	case "today":
+		return today, true, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true, nil
+	}
+	if t, allDay, ok := parseLooseTime(v, now.Location()); ok {
+		return t, allDay, nil
+	}
+	return time.Time{}, false, fmt.Errorf("bad date %q (use today, tomorrow, YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC3339)", v)
+}
+
+// apply changes t and names what changed. known holds the UIDs in
+// tasks.xml, so a parent or dependency must refer to a real task.
+func (e taskEdit) apply(t *VTodoProperties, known map[string]bool, now time.Time) ([]string, caldav.Completion, error) {
+	var changed []string
+	var done caldav.Completion
+	if e.Summary != nil {
+		t.Summary = *e.Summary
+		changed = append(changed, "summary")
+	}
+	if e.Description != nil {
+		if strings.EqualFold(*e.Description, clearValue) {
+			t.Description = ""
+		} else {
+			t.Description = *e.Description
+		}
+		changed = append(changed, "notes")
+	}
+	if e.Priority != nil {
+		t.Priority = *e.Priority
+		changed = append(changed, "priority")
+	}
+	for _, d := range []struct {
+		name           string
+		v              *string
+		dateTime, date *string
+	}{{"due", e.Due, &t.Due, &t.DueDate}, {"start", e.Start, &t.Dtstart, &t.DtstartDate}} {
+		if d.v == nil {
+			continue
+		}
+		*d.dateTime, *d.date = "", ""
+		if !strings.EqualFold(*d.v, clearValue) {
+			ts, allDay, err := parseEditTime(*d.v, now)
+			if err != nil {
+				return nil, done, err
+			}
+			setDate(d.dateTime, d.date, ts, allDay)
+		}
+		changed = append(changed, d.name)
+	}
+	if e.rrule != "" {
+		t.RRule = e.rrule
+		if t.Dtstart == "" && t.DtstartDate == "" {
+			t.Dtstart, t.DtstartDate = t.Due, t.DueDate // RFC 5545: a recurring VTODO needs DTSTART
+		}
+		changed = append(changed, "repeat")
+	}
+	if e.Estimate != nil {
+		t.Estimate = ""
+		if !strings.EqualFold(*e.Estimate, clearValue) {
+			if t.Estimate = formatEstimate(parseEstimate(*e.Estimate)); t.Estimate == "" {
+				return nil, done, fmt.Errorf("bad estimate %q (e.g. 45m, 1h30m or PT45M)", *e.Estimate)
+			}
+		}
+		changed = append(changed, "estimate")
+	}
+	if e.Categories != nil {
+		t.Categories = joinCategories(*e.Categories...)
+		changed = append(changed, "categories")
+	}
+	if e.Parent != nil || len(e.DependsOn) > 0 {
+		parent, refs := "", e.DependsOn
+		if e.Parent != nil && !strings.EqualFold(*e.Parent, clearValue) {
+			parent, refs = *e.Parent, append([]string{*e.Parent}, e.DependsOn...)
+		}
+		for _, uid := range refs {
+			if !known[uid] {
+				return nil, done, fmt.Errorf("task UID %s not found in tasks.xml", uid)
+			}
+		}
+		if e.Parent != nil && parent == "" {
+			var kept []RelatedTo
+			for _, r := range t.RelatedTo {
+				if relType(r) != RelParent {
+					kept = append(kept, r)
+				}
+			}
+			t.RelatedTo = kept
+		}
+		t.RelatedTo = withRelations(t.RelatedTo, t.Uid, parent, e.DependsOn)
+		changed = append(changed, "relations")
+	}
+
+	status := e.Status
+	if status == "" && e.Percent != nil && *e.Percent == 100 {
+		status = "COMPLETED"
+	}
+	switch {
+	case status == "COMPLETED" && strings.EqualFold(t.Status, "COMPLETED") && t.RRule == "":
+		// Already done; keep the original COMPLETED time.
+	case status == "COMPLETED":
+		var err error
+		if done, err = completeLocal(t, now.Location(), now); err != nil {
+			return nil, done, err
+		}
+	case status != "":
+		t.Status, t.Completed = status, ""
+		if t.Percent == 100 {
+			t.Percent = 0
+		}
+	}
+	if e.Percent != nil && status != "COMPLETED" {
+		t.Percent = *e.Percent
+		if strings.EqualFold(t.Status, "COMPLETED") {
+			// Less than 100% done is not done.
+			t.Status, t.Completed = "IN-PROCESS", ""
+		}
+		if status == "" && t.Percent > 0 && (t.Status == "" || strings.EqualFold(t.Status, "NEEDS-ACTION")) {
+			t.Status = "IN-PROCESS"
+		}
+		changed = append(changed, "percent complete")
+	}
+	if status != "" {
+		changed = append(changed, "status "+strings.ToUpper(t.Status))
+	}
+	t.LastModified = time.Now().UTC().Format(time.RFC3339)
+	return changed, done, nil
+}
+
+// taskUIDs indexes the tasks in cal.
+func taskUIDs(cal ICalendar) map[string]bool {
+	known := make(map[string]bool, len(cal.VCal.Components.VTodos))
+	for _, td := range cal.VCal.Components.VTodos {
+		known[td.Properties.Uid] = true
+	}
+	return known
+}
+
+// removeTask deletes uid from cal and drops every relation pointing at it:
+// its subtasks become top-level tasks and tasks waiting on it are freed.
+func removeTask(cal *ICalendar, uid string) (removed VTodoProperties, detached, freed []VTodoProperties, ok bool) {
+	todos := cal.VCal.Components.VTodos
+	kept := todos[:0]
+	for _, td := range todos {
+		if td.Properties.Uid == uid {
+			removed, ok = td.Properties, true
+			continue
+		}
+		kept = append(kept, td)
+	}
+	cal.VCal.Components.VTodos = kept
+	if !ok {
+		return
+	}
+	stamp := time.Now().UTC().Format(time.RFC3339)
+	for i := range kept {
+		t := &kept[i].Properties
+		var rels []RelatedTo
+		for _, r := range t.RelatedTo {
+			if r.UID != uid {
+				rels = append(rels, r)
+				continue
+			}
+			if relType(r) == RelParent {
+				detached = append(detached, *t)
+			} else {
+				freed = append(freed, *t)
+			}
+		}
+		if len(rels) != len(t.RelatedTo) {
+			t.RelatedTo, t.LastModified = rels, stamp
+		}
+	}
+	return
+}
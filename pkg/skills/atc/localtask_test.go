@@ -0,0 +1,82 @@
+package atc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalTaskEditing(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	s := &ATCSkill{workspace: ws}
+	run := func(args map[string]interface{}) string {
+		res := s.Execute(context.Background(), args)
+		if res.IsError {
+			t.Fatalf("%v: %s", args, res.ForLLM)
+		}
+		return res.ForLLM
+	}
+	task := func(uid string) VTodoProperties {
+		cal, _, _ := s.loadTasks()
+		for _, td := range cal.VCal.Components.VTodos {
+			if td.Properties.Uid == uid {
+				return td.Properties
+			}
+		}
+		t.Fatalf("task %s not in tasks.xml", uid)
+		return VTodoProperties{}
+	}
+	uidIn := func(out string) string {
+		i := strings.Index(out, "(UID: ")
+		return out[i+6 : i+6+strings.Index(out[i+6:], ")")]
+	}
+
+	trip := uidIn(run(map[string]interface{}{"command": "create_local_task", "summary": "Plan trip", "categories": "Today,travel"}))
+	visa := uidIn(run(map[string]interface{}{"command": "create_local_task", "text": "Apply for visa 2026-05-04 !1 #travel ~1h",
+		"parent": trip, "notes": "bring photos"}))
+	if v := task(visa); v.Summary != "Apply for visa" || v.DueDate != "2026-05-04" || v.Priority != 1 || v.Categories != "travel" ||
+		v.Estimate != "PT1H" || v.Parent() != trip || v.Description != "bring photos" || v.Status != "NEEDS-ACTION" || v.LastModified == "" {
+		t.Fatalf("created = %+v", v)
+	}
+
+	out := run(map[string]interface{}{"command": "update_task", "task_uid": visa, "summary": "Apply for Schengen visa",
+		"priority": float64(5), "due": "2026-05-01 09:30", "categories": "Tomorrow,travel,admin", "percent_complete": float64(40), "notes": "none"})
+	if v := task(visa); v.Summary != "Apply for Schengen visa" || v.Priority != 5 || v.Due == "" || v.DueDate != "" ||
+		v.Categories != "Tomorrow,travel,admin" || v.Percent != 40 || v.Status != "IN-PROCESS" || v.Description != "" {
+		t.Fatalf("updated = %+v\n%s", v, out)
+	}
+
+	out = run(map[string]interface{}{"command": "update_task", "task_uid": visa, "percent_complete": float64(100)})
+	done := task(visa)
+	if done.Status != "COMPLETED" || done.Completed == "" || done.Percent != 100 || !strings.Contains(out, "📋 Plan trip: 1/1 subtasks done — ready to complete") {
+		t.Fatalf("completed = %+v\n%s", done, out)
+	}
+	// COMPLETED keeps its original time through a sync round trip.
+	ics := buildVTodo(done, done.Categories, nil, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	if r, _ := parseRemoteTodo(ics, nil); r.Props.Completed != done.Completed || r.Props.Percent != 100 {
+		t.Errorf("round trip = %+v\n%s", r.Props, ics)
+	}
+	run(map[string]interface{}{"command": "update_task", "task_uid": visa, "status": "needs-action"})
+	if v := task(visa); v.Status != "NEEDS-ACTION" || v.Completed != "" || v.Percent != 0 {
+		t.Errorf("reopened = %+v", v)
+	}
+
+	if res := s.Execute(context.Background(), map[string]interface{}{"command": "update_task", "task_uid": visa, "depends_on": "nope"}); !res.IsError {
+		t.Errorf("unknown dependency accepted: %s", res.ForLLM)
+	}
+	if res := s.Execute(context.Background(), map[string]interface{}{"command": "update_task", "task_uid": visa}); !res.IsError {
+		t.Errorf("empty update accepted: %s", res.ForLLM)
+	}
+
+	out = run(map[string]interface{}{"command": "delete_local_task", "task_uid": trip})
+	if !strings.Contains(out, "No longer a subtask: Apply for Schengen visa") || task(visa).Parent() != "" {
+		t.Errorf("delete:\n%s", out)
+	}
+	if cal, _, _ := s.loadTasks(); len(cal.VCal.Components.VTodos) != 1 {
+		t.Errorf("tasks left = %d", len(cal.VCal.Components.VTodos))
+	}
+}
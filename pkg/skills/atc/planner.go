@@ -0,0 +1,443 @@
+package atc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+)
+
+// ----------------------------------------------------------------------------
+// Day planner
+//
+// plan_day time-blocks a day: the gaps between calendar events inside the
+// working hours are filled with open tasks, most urgent first, each taking
+// its X-ESTIMATE (or the default) plus a buffer. The blocks can be pushed
+// as VEVENTs to a dedicated Nextcloud calendar. Settings come from
+// tools.planner in the config.
+// ----------------------------------------------------------------------------
+
+// PlannerConfig configures plan_day.
+type PlannerConfig struct {
+	WorkStart              string `json:"work_start"`               // HH:MM
+	WorkEnd                string `json:"work_end"`                 // HH:MM
+	BufferMinutes          int    `json:"buffer_minutes"`           // around events and between blocks
+	DefaultEstimateMinutes int    `json:"default_estimate_minutes"` // for tasks without X-ESTIMATE
+	MinBlockMinutes        int    `json:"min_block_minutes"`        // shorter gaps are left free
+	Calendar               string `json:"calendar"`                 // Nextcloud calendar the blocks are pushed to
+}
+
+// DefaultPlannerConfig is a 09:00-18:00 day with 10-minute buffers.
+func DefaultPlannerConfig() PlannerConfig {
+	return PlannerConfig{
+		WorkStart:              "09:00",
+		WorkEnd:                "18:00",
+		BufferMinutes:          10,
+		DefaultEstimateMinutes: 30,
+		MinBlockMinutes:        15,
+		Calendar:               "time-blocks",
+	}
+}
+
+// LoadPlannerConfig reads tools.planner on top of the defaults.
+func LoadPlannerConfig() PlannerConfig {
+	var cfg struct {
+		Tools struct {
+			Planner PlannerConfig `json:"planner"`
+		} `json:"tools"`
+	}
+	cfg.Tools.Planner = DefaultPlannerConfig()
+	if readConfig(&cfg) != nil {
+		return DefaultPlannerConfig()
+	}
+	p := cfg.Tools.Planner
+	if p.BufferMinutes < 0 {
+		p.BufferMinutes = 0
+	}
+	if p.DefaultEstimateMinutes <= 0 {
+		p.DefaultEstimateMinutes = 30
+	}
+	if p.MinBlockMinutes <= 0 {
+		p.MinBlockMinutes = 15
+	}
+	if p.Calendar == "" {
+		p.Calendar = "time-blocks"
+	}
+	return p
+}
+
+// window returns the working hours on day, in day's location.
+func (p PlannerConfig) window(day time.Time) (time.Time, time.Time, error) {
+	at := func(hhmm string) (time.Time, error) {
+		t, err := time.Parse("15:04", hhmm)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad working hours %q (want HH:MM)", hhmm)
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+	}
+	from, err := at(p.WorkStart)
+	if err != nil {
+		return from, from, err
+	}
+	to, err := at(p.WorkEnd)
+	if err != nil {
+		return from, to, err
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("work_end %s is not after work_start %s", p.WorkEnd, p.WorkStart)
+	}
+	return from, to, nil
+}
+
+// ----------------------------------------------------------------------------
+// Estimates
+// ----------------------------------------------------------------------------
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?)?$`)
+
+// parseEstimate reads an ISO 8601 duration (PT1H30M, as X-ESTIMATE holds
+// it), a Go duration (90m, 1h30m) or plain minutes (45). 0 if invalid.
+func parseEstimate(s string) time.Duration {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if m := isoDurationRe.FindStringSubmatch(s); m != nil && s != "P" && s != "PT" {
+		var d time.Duration
+		for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+			if n, err := strconv.Atoi(m[i+1]); err == nil {
+				d += time.Duration(n) * unit
+			}
+		}
+		return d
+	}
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return time.Duration(n) * time.Minute
+	}
+	if d, err := time.ParseDuration(strings.ToLower(s)); err == nil && d > 0 {
+		return d.Round(time.Minute)
+	}
+	return 0
+}
+
+// formatEstimate renders a duration as X-ESTIMATE, e.g. PT1H30M.
+func formatEstimate(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d <= 0 {
+		return ""
+	}
+	out := "PT"
+	if h := int(d.Hours()); h > 0 {
+		out += fmt.Sprintf("%dH", h)
+	}
+	if m := int(d.Minutes()) % 60; m > 0 {
+		out += fmt.Sprintf("%dM", m)
+	}
+	return out
+}
+
+// ----------------------------------------------------------------------------
+// Planning
+// ----------------------------------------------------------------------------
+
+// busySpan is one timed calendar event.
+type busySpan struct {
+	Start, End time.Time
+	Summary    string
+}
+
+// planBlock is a task placed in the day.
+type planBlock struct {
+	Start, End time.Time
+	Task       VTodoProperties
+	Urgency    int
+	Late       bool // ends after the task's due time
+}
+
+// unplaced is a task that did not get a block, and why.
+type unplaced struct {
+	Task   VTodoProperties
+	Reason string
+}
+
+// dayPlan is the result of planDay.
+type dayPlan struct {
+	From, To time.Time
+	Events   []busySpan
+	AllDay   []string
+	Blocks   []planBlock
+	Skipped  []unplaced
+	Free     time.Duration
+}
+
+type slot struct{ start, end time.Time }
+
+// planDay places ranked tasks into the free time between from and to.
+// Events are padded by the buffer; each task goes, in urgency order, to
+// the earliest gap long enough for its estimate, and the next block starts
+// a buffer later. Blocked tasks are not scheduled.
+func planDay(ranked []RankedTask, g *taskGraph, events []busySpan, from, to time.Time, cfg PlannerConfig) dayPlan {
+	plan := dayPlan{From: from, To: to, Events: events}
+	buffer := time.Duration(cfg.BufferMinutes) * time.Minute
+	minBlock := time.Duration(cfg.MinBlockMinutes) * time.Minute
+
+	free := []slot{{from, to}}
+	for _, ev := range events {
+		busyFrom, busyTo := ev.Start.Add(-buffer), ev.End.Add(buffer)
+		var next []slot
+		for _, s := range free {
+			if !busyFrom.Before(s.end) || !busyTo.After(s.start) {
+				next = append(next, s)
+				continue
+			}
+			if busyFrom.After(s.start) {
+				next = append(next, slot{s.start, busyFrom})
+			}
+			if busyTo.Before(s.end) {
+				next = append(next, slot{busyTo, s.end})
+			}
+		}
+		free = next
+	}
+
+	for _, r := range ranked {
+		if isBlocked(r.Task) {
+			plan.Skipped = append(plan.Skipped, unplaced{r.Task, "marked blocked or waiting"})
+			continue
+		}
+		if g != nil {
+			if b := g.blockers(r.Task); len(b) > 0 {
+				plan.Skipped = append(plan.Skipped, unplaced{r.Task, "blocked by " + summaries(b)})
+				continue
+			}
+		}
+		need := parseEstimate(r.Task.Estimate)
+		if need == 0 {
+			need = time.Duration(cfg.DefaultEstimateMinutes) * time.Minute
+		}
+		placed := false
+		for i, s := range free {
+			if s.end.Sub(s.start) < need {
+				continue
+			}
+			b := planBlock{Start: s.start, End: s.start.Add(need), Task: r.Task, Urgency: r.Urgency.Score}
+			if due, ok := dueTime(r.Task, from.Location()); ok && b.End.After(due) {
+				b.Late = true
+			}
+			plan.Blocks = append(plan.Blocks, b)
+			free[i].start = b.End.Add(buffer)
+			placed = true
+			break
+		}
+		if !placed {
+			longest := time.Duration(0)
+			for _, s := range free {
+				if d := s.end.Sub(s.start); d > longest {
+					longest = d
+				}
+			}
+			gap := "none"
+			if longest > 0 {
+				gap = formatSpan(longest)
+			}
+			plan.Skipped = append(plan.Skipped, unplaced{r.Task, fmt.Sprintf("needs %s, longest free gap %s", formatSpan(need), gap)})
+		}
+	}
+	for _, s := range free {
+		if d := s.end.Sub(s.start); d >= minBlock {
+			plan.Free += d
+		}
+	}
+	sort.Slice(plan.Blocks, func(i, j int) bool { return plan.Blocks[i].Start.Before(plan.Blocks[j].Start) })
+	return plan
+}
+
+// String renders the plan as a timeline of events and task blocks.
+func (p dayPlan) String() string {
+	type row struct {
+		start time.Time
+		text  string
+	}
+	var rows []row
+	for _, ev := range p.Events {
+		rows = append(rows, row{ev.Start, fmt.Sprintf("%s–%s 📅 %s", ev.Start.Format("15:04"), ev.End.Format("15:04"), ev.Summary)})
+	}
+	for _, b := range p.Blocks {
+		text := fmt.Sprintf("%s–%s 🧱 %s [Urgency: %d] (UID: %s)", b.Start.Format("15:04"), b.End.Format("15:04"), b.Task.Summary, b.Urgency, b.Task.Uid)
+		if b.Late {
+			text += " ⚠️ ends after its due time"
+		}
+		rows = append(rows, row{b.Start, text})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].start.Before(rows[j].start) })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🗓️ Plan for %s (%s–%s)\n", p.From.Format("Mon 2 Jan 2006"), p.From.Format("15:04"), p.To.Format("15:04"))
+	for _, a := range p.AllDay {
+		fmt.Fprintf(&sb, "All day 📅 %s\n", a)
+	}
+	if len(rows) == 0 {
+		sb.WriteString("Nothing scheduled.\n")
+	}
+	for _, r := range rows {
+		sb.WriteString(r.text + "\n")
+	}
+	if p.Free > 0 {
+		fmt.Fprintf(&sb, "Free time left: %s\n", formatSpan(p.Free))
+	} else {
+		sb.WriteString("Free time left: none\n")
+	}
+	for _, u := range p.Skipped {
+		fmt.Fprintf(&sb, "⏭️ Not scheduled: %s (UID: %s) — %s\n", u.Task.Summary, u.Task.Uid, u.Reason)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// dayEvents lists the events of events.xml touching [from, to): timed ones
+// as busy spans and all-day ones by summary.
+func dayEvents(cal ICalendar, from, to time.Time) ([]busySpan, []string) {
+	var spans []busySpan
+	var allDay []string
+	for _, o := range eventOccurrences(cal, from, to) {
+		if o.AllDay {
+			allDay = append(allDay, o.Summary)
+			continue
+		}
+		spans = append(spans, busySpan{Start: o.Start, End: o.End, Summary: o.Summary})
+	}
+	return spans, allDay
+}
+
+// tasksForDay picks the open tasks that belong on day: those in its
+// Today/Tomorrow category and any due on or before it.
+func tasksForDay(todos []VTodo, day, today time.Time) []VTodoProperties {
+	want := ""
+	switch day.Format(dayLayout) {
+	case today.Format(dayLayout):
+		want = "today"
+	case today.AddDate(0, 0, 1).Format(dayLayout):
+		want = "tomorrow"
+	}
+	endOfDay := time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, day.Location())
+	var out []VTodoProperties
+	for _, t := range todos {
+		p := t.Properties
+		if isFinished(p.Status) {
+			continue
+		}
+		due, hasDue := dueTime(p, day.Location())
+		if (want != "" && dayCategory(p.Categories) == want) || (hasDue && !due.After(endOfDay)) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ----------------------------------------------------------------------------
+// Pushing blocks to Nextcloud
+// ----------------------------------------------------------------------------
+
+// blockPrefix is the UID prefix of one day's blocks, so a new plan for the
+// same day replaces the old one.
+func blockPrefix(day time.Time) string {
+	return "plan-" + day.Format("20060102") + "-"
+}
+
+// pushTimeBlocks writes the plan's blocks as VEVENTs to the planner
+// calendar, creating the calendar if needed, and removes blocks an earlier
+// plan for the same day left behind. It returns how many were removed.
+func pushTimeBlocks(cfg ATCCalendarConfig, calendar string, plan dayPlan, now time.Time) (int, error) {
+	base := caldav.BuildNamedCalendarURL(cfg.Host, cfg.Username, calendar)
+	client := syncClient(cfg)
+	do := func(method, target, body string, header map[string]string) (*http.Response, error) {
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		if cfg.Username != "" {
+			req.SetBasicAuth(cfg.Username, cfg.Password)
+		}
+		return client.Do(req)
+	}
+
+	resp, err := do("PROPFIND", base, `<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:getetag/></d:prop></d:propfind>`,
+		map[string]string{"Depth": "1", "Content-Type": "application/xml; charset=utf-8"})
+	if err != nil {
+		return 0, fmt.Errorf("PROPFIND failed: %w", err)
+	}
+	var ms multistatus
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		mk := `<?xml version="1.0" encoding="utf-8"?>
+<c:mkcalendar xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:set><d:prop><d:displayname>Time blocks</d:displayname>
+    <c:supported-calendar-component-set><c:comp name="VEVENT"/></c:supported-calendar-component-set>
+  </d:prop></d:set>
+</c:mkcalendar>`
+		resp, err = do("MKCALENDAR", base, mk, map[string]string{"Content-Type": "application/xml; charset=utf-8"})
+		if err != nil {
+			return 0, fmt.Errorf("MKCALENDAR failed: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return 0, fmt.Errorf("could not create calendar %q (status %d)", calendar, resp.StatusCode)
+		}
+	} else {
+		err := xml.NewDecoder(resp.Body).Decode(&ms)
+		resp.Body.Close()
+		if err != nil {
+			return 0, fmt.Errorf("parsing PROPFIND response: %w", err)
+		}
+	}
+
+	prefix := blockPrefix(plan.From)
+	stamp := now.UTC().Format("20060102T150405Z")
+	written := map[string]bool{}
+	for _, b := range plan.Blocks {
+		uid := prefix + b.Task.Uid
+		body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Son of Anthon ATC//EN\r\nBEGIN:VEVENT\r\n" +
+			"UID:" + uid + "\r\n" +
+			"DTSTAMP:" + stamp + "\r\n" +
+			"DTSTART:" + b.Start.UTC().Format("20060102T150405Z") + "\r\n" +
+			"DTEND:" + b.End.UTC().Format("20060102T150405Z") + "\r\n" +
+			"SUMMARY:" + escapeICS(b.Task.Summary) + "\r\n" +
+			"DESCRIPTION:" + escapeICS(fmt.Sprintf("Time block for task %s (urgency %d)", b.Task.Uid, b.Urgency)) + "\r\n" +
+			"RELATED-TO:" + b.Task.Uid + "\r\n" +
+			"CATEGORIES:Time block\r\n" +
+			"TRANSP:OPAQUE\r\n" +
+			"END:VEVENT\r\nEND:VCALENDAR\r\n"
+		resp, err := do(http.MethodPut, base+uid+".ics", body, map[string]string{"Content-Type": "text/calendar; charset=utf-8"})
+		if err != nil {
+			return 0, fmt.Errorf("PUT %s: %w", uid, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("CalDAV returned status %d for block %s", resp.StatusCode, uid)
+		}
+		written[uid+".ics"] = true
+	}
+
+	removed := 0
+	for _, r := range ms.Responses {
+		name := path.Base(strings.TrimSpace(r.Href))
+		if !strings.HasPrefix(name, prefix) || written[name] {
+			continue
+		}
+		resp, err := do(http.MethodDelete, caldav.FullURL(base, strings.TrimSpace(r.Href)), "", nil)
+		if err != nil {
+			return removed, fmt.Errorf("DELETE %s: %w", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,71 @@
+package atc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanDay(t *testing.T) {
+	day := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	cfg := DefaultPlannerConfig()
+	from, to, err := cfg.window(day)
+	if err != nil || !from.Equal(at(9, 0)) || !to.Equal(at(18, 0)) {
+		t.Fatalf("window = %s–%s, %v", from, to, err)
+	}
+
+	var cal ICalendar
+	cal.VCal.Components.VEvents = []VEvent{
+		{Properties: VEventProperties{Summary: "Standup", Dtstart: "2026-03-03T10:00:00Z", Dtend: "2026-03-03T10:30:00Z"}},
+		{Properties: VEventProperties{Summary: "Lunch", Dtstart: "2026-03-03T13:00:00Z", Dtend: "2026-03-03T17:00:00Z"}},
+		{Properties: VEventProperties{Summary: "Holiday", DtstartDate: "2026-03-03"}},
+		{Properties: VEventProperties{Summary: "Tomorrow", Dtstart: "2026-03-04T10:00:00Z", Dtend: "2026-03-04T11:00:00Z"}},
+	}
+	spans, allDay := dayEvents(cal, from, to)
+	if len(spans) != 2 || len(allDay) != 1 {
+		t.Fatalf("events = %+v, all day %v", spans, allDay)
+	}
+
+	todos := []VTodo{
+		{Properties: VTodoProperties{Uid: "held", Summary: "Sign contract", Categories: "Work,waiting"}},
+		{Properties: VTodoProperties{Uid: "deep", Summary: "Write report", Estimate: "PT2H"}},
+		{Properties: VTodoProperties{Uid: "quick", Summary: "Reply to Sam"}},
+		{Properties: VTodoProperties{Uid: "huge", Summary: "Migrate server", Estimate: "PT4H"}},
+		{Properties: VTodoProperties{Uid: "wait", Summary: "Deploy", RelatedTo: []RelatedTo{{RelType: RelDependsOn, UID: "huge"}}}},
+	}
+	var ranked []RankedTask
+	for i, td := range todos {
+		ranked = append(ranked, RankedTask{Task: td.Properties, Urgency: Urgency{Score: 100 - i}})
+	}
+	plan := planDay(ranked, newTaskGraph(todos), spans, from, to, cfg)
+	plan.AllDay = allDay
+
+	// 09:00–09:50 is too short for 2h; 10:40–12:50 fits it.
+	if len(plan.Blocks) != 2 || plan.Blocks[0].Task.Uid != "quick" || !plan.Blocks[0].Start.Equal(at(9, 0)) ||
+		plan.Blocks[1].Task.Uid != "deep" || !plan.Blocks[1].Start.Equal(at(10, 40)) || !plan.Blocks[1].End.Equal(at(12, 40)) {
+		t.Errorf("blocks:\n%s", plan)
+	}
+	out := plan.String()
+	for _, want := range []string{"All day 📅 Holiday", "10:00–10:30 📅 Standup", "10:40–12:40 🧱 Write report",
+		"Not scheduled: Migrate server (UID: huge) — needs 4h 0m", "Not scheduled: Deploy (UID: wait) — blocked by \"Migrate server\"",
+		"Not scheduled: Sign contract (UID: held) — marked blocked or waiting"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseEstimate(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"PT45M": 45 * time.Minute, "PT1H30M": 90 * time.Minute, "P1D": 24 * time.Hour,
+		"90m": 90 * time.Minute, "1h30m": 90 * time.Minute, "45": 45 * time.Minute, "soon": 0, "PT": 0,
+	} {
+		if got := parseEstimate(in); got != want {
+			t.Errorf("parseEstimate(%q) = %s, want %s", in, got, want)
+		}
+	}
+	if got := formatEstimate(90 * time.Minute); got != "PT1H30M" {
+		t.Errorf("formatEstimate = %s", got)
+	}
+}
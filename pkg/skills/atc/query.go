@@ -0,0 +1,488 @@
+package atc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ----------------------------------------------------------------------------
+// Task queries
+//
+// find_tasks filters tasks with a compact syntax. Terms are separated by
+// spaces and must all match; a leading "-" negates one, and a comma list
+// matches any of its values:
+//
+//	status:needs-action,in-process   status (open and done also work)
+//	due<+3d  due:today  due>=2026-03-01  due:none  due:any
+//	start<=tomorrow                  same forms as due
+//	priority<=2  prio:high           1 is highest; high/medium/low/none
+//	cat:home  tag:work               category
+//	is:overdue,blocked,recurring,subtask,parent,open,done,synced
+//	uid:abc  summary:rent            UID prefix, words in the title
+//	"electricity"  bill              words or phrases in title or notes
+//	sort:due|-priority|urgency|summary|modified  limit:20
+//
+// Dates are today, tomorrow, yesterday, now, a weekday (the next one),
+// YYYY-MM-DD or an offset from today: +3d, -1w, +2mo, +1y, or +4h from now.
+// A day compares as the whole day: due<=friday includes Friday evening.
+// ----------------------------------------------------------------------------
+
+// taskRow is one task a query runs over.
+type taskRow struct {
+	Task   VTodoProperties
+	Href   string // Nextcloud href, if synced
+	Remote bool   // only on Nextcloud, not in tasks.xml
+}
+
+// queryEnv is what terms are evaluated against.
+type queryEnv struct {
+	now time.Time
+	g   *taskGraph
+}
+
+type taskPredicate func(r taskRow, env queryEnv) bool
+
+// taskQuery is a parsed find_tasks query.
+type taskQuery struct {
+	preds []taskPredicate
+	sort  string
+	limit int
+}
+
+// queryFields maps accepted field names onto canonical ones.
+var queryFields = map[string]string{
+	"status": "status", "s": "status",
+	"due": "due", "start": "start", "scheduled": "start",
+	"priority": "priority", "prio": "priority", "p": "priority",
+	"cat": "cat", "category": "cat", "categories": "cat", "tag": "cat", "tags": "cat",
+	"is": "is", "uid": "uid", "summary": "summary", "title": "summary", "text": "text",
+	"sort": "sort", "limit": "limit",
+}
+
+// splitQuery tokenises on spaces, keeping "quoted phrases" (also after a
+// field, as in cat:"day off") together.
+func splitQuery(q string) []string {
+	var out []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// parseTaskQuery compiles q; dates resolve against now.
+func parseTaskQuery(q string, now time.Time) (taskQuery, error) {
+	query := taskQuery{sort: "urgency"}
+	for _, tok := range splitQuery(q) {
+		neg := false
+		if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+			neg, tok = true, tok[1:]
+		}
+		i := strings.IndexAny(tok, ":<>=!")
+		field := ""
+		if i > 0 && !strings.HasPrefix(tok, `"`) {
+			field = queryFields[strings.ToLower(tok[:i])]
+			if field == "" {
+				return query, fmt.Errorf("unknown field %q in %q", tok[:i], tok)
+			}
+		}
+		if field == "" {
+			field, i = "text", 0
+		}
+		op, value := "", tok[i:]
+		if field != "text" {
+			for _, o := range []string{"<=", ">=", "!=", "<", ">", "=", ":"} {
+				if strings.HasPrefix(value, o) {
+					op, value = o, value[len(o):]
+					break
+				}
+			}
+		}
+		value = strings.Trim(value, `"`)
+		if op == "!=" {
+			neg, op = !neg, "="
+		}
+
+		switch field {
+		case "sort":
+			query.sort = strings.ToLower(value)
+			continue
+		case "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return query, fmt.Errorf("limit must be a positive number, not %q", value)
+			}
+			query.limit = n
+			continue
+		}
+		var alts []taskPredicate
+		for _, v := range strings.Split(value, ",") {
+			p, err := compileTerm(field, op, strings.TrimSpace(v), now)
+			if err != nil {
+				return query, err
+			}
+			alts = append(alts, p)
+		}
+		pred := func(r taskRow, env queryEnv) bool {
+			for _, p := range alts {
+				if p(r, env) {
+					return true
+				}
+			}
+			return false
+		}
+		if neg {
+			inner := pred
+			pred = func(r taskRow, env queryEnv) bool { return !inner(r, env) }
+		}
+		query.preds = append(query.preds, pred)
+	}
+	switch strings.TrimPrefix(query.sort, "-") {
+	case "urgency", "due", "start", "priority", "summary", "modified":
+	default:
+		return query, fmt.Errorf("cannot sort by %q (due, start, priority, urgency, summary or modified)", query.sort)
+	}
+	return query, nil
+}
+
+// compileTerm builds the predicate for one field/op/value.
+func compileTerm(field, op, value string, now time.Time) (taskPredicate, error) {
+	low := strings.ToLower(value)
+	switch field {
+	case "text", "summary":
+		if low == "" {
+			return nil, fmt.Errorf("empty search text")
+		}
+		return func(r taskRow, _ queryEnv) bool {
+			hay := strings.ToLower(r.Task.Summary)
+			if field == "text" {
+				hay += "\n" + strings.ToLower(r.Task.Description)
+			}
+			return strings.Contains(hay, low)
+		}, nil
+
+	case "uid":
+		return func(r taskRow, _ queryEnv) bool { return strings.HasPrefix(strings.ToLower(r.Task.Uid), low) }, nil
+
+	case "cat":
+		return func(r taskRow, _ queryEnv) bool {
+			for _, c := range strings.Split(strings.ToLower(r.Task.Categories), ",") {
+				if strings.TrimSpace(c) == low {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case "status":
+		want := strings.ToUpper(strings.ReplaceAll(low, "_", "-"))
+		switch want {
+		case "OPEN", "TODO", "PENDING":
+			return func(r taskRow, _ queryEnv) bool { return !isFinished(r.Task.Status) }, nil
+		case "DONE", "FINISHED":
+			return func(r taskRow, _ queryEnv) bool { return isFinished(r.Task.Status) }, nil
+		case "NEEDS-ACTION", "IN-PROCESS", "COMPLETED", "CANCELLED":
+			return func(r taskRow, _ queryEnv) bool { return normStatus(r.Task.Status) == want }, nil
+		}
+		return nil, fmt.Errorf("unknown status %q", value)
+
+	case "is":
+		return compileIs(low)
+
+	case "priority":
+		return compilePriority(op, low)
+
+	case "due", "start":
+		when := func(t VTodoProperties, loc *time.Location) (time.Time, bool) {
+			if field == "due" {
+				return dueTime(t, loc)
+			}
+			return startTime(t, loc)
+		}
+		switch low {
+		case "none":
+			return func(r taskRow, env queryEnv) bool { _, ok := when(r.Task, env.now.Location()); return !ok }, nil
+		case "any":
+			return func(r taskRow, env queryEnv) bool { _, ok := when(r.Task, env.now.Location()); return ok }, nil
+		}
+		lo, hi, err := parseQueryDate(low, now)
+		if err != nil {
+			return nil, err
+		}
+		return func(r taskRow, env queryEnv) bool {
+			t, ok := when(r.Task, env.now.Location())
+			if !ok {
+				return false
+			}
+			switch op {
+			case "<":
+				return t.Before(lo)
+			case "<=":
+				return t.Before(hi) || (lo.Equal(hi) && t.Equal(lo))
+			case ">":
+				return !t.Before(hi) && !(lo.Equal(hi) && t.Equal(lo))
+			case ">=":
+				return !t.Before(lo)
+			}
+			return !t.Before(lo) && (t.Before(hi) || (lo.Equal(hi) && t.Equal(lo)))
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+func compileIs(flag string) (taskPredicate, error) {
+	switch flag {
+	case "open":
+		return func(r taskRow, _ queryEnv) bool { return !isFinished(r.Task.Status) }, nil
+	case "done":
+		return func(r taskRow, _ queryEnv) bool { return isFinished(r.Task.Status) }, nil
+	case "overdue":
+		return func(r taskRow, env queryEnv) bool {
+			due, ok := dueTime(r.Task, env.now.Location())
+			return ok && due.Before(env.now) && !isFinished(r.Task.Status)
+		}, nil
+	case "blocked":
+		return func(r taskRow, env queryEnv) bool { return isBlocked(r.Task) || len(env.g.blockers(r.Task)) > 0 }, nil
+	case "recurring":
+		return func(r taskRow, _ queryEnv) bool { return r.Task.RRule != "" }, nil
+	case "subtask":
+		return func(r taskRow, _ queryEnv) bool { return r.Task.Parent() != "" }, nil
+	case "parent":
+		return func(r taskRow, env queryEnv) bool { _, n := env.g.progress(r.Task.Uid); return n > 0 }, nil
+	case "synced":
+		return func(r taskRow, _ queryEnv) bool { return r.Href != "" }, nil
+	case "remote":
+		return func(r taskRow, _ queryEnv) bool { return r.Remote }, nil
+	}
+	return nil, fmt.Errorf("unknown is:%s (open, done, overdue, blocked, recurring, subtask, parent, synced, remote)", flag)
+}
+
+// compilePriority compares RFC 5545 priorities, where lower is more
+// urgent and 0 is "none": priority<=2 never matches unprioritised tasks.
+func compilePriority(op, value string) (taskPredicate, error) {
+	lo, hi := 0, 0
+	switch value {
+	case "high", "h":
+		lo, hi = 1, 4
+	case "medium", "med", "m":
+		lo, hi = 5, 5
+	case "low", "l":
+		lo, hi = 6, 9
+	case "none":
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 9 {
+			return nil, fmt.Errorf("priority must be 0-9 or high/medium/low/none, not %q", value)
+		}
+		lo, hi = n, n
+	}
+	return func(r taskRow, _ queryEnv) bool {
+		p := r.Task.Priority
+		switch op {
+		case "<":
+			return p > 0 && p < lo
+		case "<=":
+			return p > 0 && p <= hi
+		case ">":
+			return p > hi
+		case ">=":
+			return p >= lo && (lo > 0 || p > 0)
+		}
+		return p >= lo && p <= hi
+	}, nil
+}
+
+// startTime is a task's DTSTART; date-only starts are at midnight in loc.
+func startTime(t VTodoProperties, loc *time.Location) (time.Time, bool) {
+	if ts, err := time.Parse(time.RFC3339, t.Dtstart); err == nil {
+		return ts, true
+	}
+	if d, err := time.ParseInLocation("2006-01-02", t.DtstartDate, loc); err == nil {
+		return d, true
+	}
+	return time.Time{}, false
+}
+
+// parseQueryDate resolves a date expression to [lo, hi): a whole day, or
+// an instant (lo == hi) for now and hour offsets.
+func parseQueryDate(v string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day := func(d time.Time) (time.Time, time.Time, error) { return d, d.AddDate(0, 0, 1), nil }
+	switch v {
+	case "now":
+		return now, now, nil
+	case "today":
+		return day(today)
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1))
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1))
+	}
+	if wd, ok := weekdays[v]; ok {
+		return day(today.AddDate(0, 0, (int(wd)-int(today.Weekday())+6)%7+1))
+	}
+	if wd, ok := weekdayAbbr[v]; ok {
+		return day(today.AddDate(0, 0, (int(wd)-int(today.Weekday())+6)%7+1))
+	}
+	if d, err := time.ParseInLocation("2006-01-02", v, now.Location()); err == nil {
+		return day(d)
+	}
+	if len(v) > 1 && (v[0] == '+' || v[0] == '-') {
+		i := strings.IndexFunc(v[1:], func(r rune) bool { return r < '0' || r > '9' }) + 1
+		n, err := strconv.Atoi(v[1:i])
+		if i > 1 && err == nil {
+			if v[0] == '-' {
+				n = -n
+			}
+			switch v[i:] {
+			case "d":
+				return day(today.AddDate(0, 0, n))
+			case "w":
+				return day(today.AddDate(0, 0, 7*n))
+			case "mo":
+				return day(today.AddDate(0, n, 0))
+			case "y":
+				return day(today.AddDate(n, 0, 0))
+			case "h":
+				t := now.Add(time.Duration(n) * time.Hour)
+				return t, t, nil
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("bad date %q (today, tomorrow, a weekday, YYYY-MM-DD, +3d, -1w, +2mo, +4h)", v)
+}
+
+// run filters and sorts rows and applies the limit. total is the number
+// of matches before the limit.
+func (q taskQuery) run(rows []taskRow, env queryEnv, weights UrgencyWeights, history map[string]TaskHistory) (out []taskRow, urgency map[string]int, total int) {
+	for _, r := range rows {
+		ok := true
+		for _, p := range q.preds {
+			if !p(r, env) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, r)
+		}
+	}
+	total = len(out)
+
+	var tasks []VTodoProperties
+	for _, r := range out {
+		tasks = append(tasks, r.Task)
+	}
+	urgency = map[string]int{}
+	for _, rt := range rankTasks(tasks, env.g, history, weights, env.now) {
+		urgency[rt.Task.Uid] = rt.Urgency.Score
+	}
+
+	desc := strings.HasPrefix(q.sort, "-")
+	key := strings.TrimPrefix(q.sort, "-")
+	loc := env.now.Location()
+	less := func(a, b taskRow) int {
+		switch key {
+		case "due", "start":
+			get := dueTime
+			if key == "start" {
+				get = startTime
+			}
+			ta, okA := get(a.Task, loc)
+			tb, okB := get(b.Task, loc)
+			if okA != okB { // undated last either way
+				if okA {
+					return -1
+				}
+				return 1
+			}
+			return ta.Compare(tb)
+		case "priority":
+			pa, pb := a.Task.Priority, b.Task.Priority
+			if pa == 0 {
+				pa = 10
+			}
+			if pb == 0 {
+				pb = 10
+			}
+			return pa - pb
+		case "summary":
+			return strings.Compare(strings.ToLower(a.Task.Summary), strings.ToLower(b.Task.Summary))
+		case "modified":
+			return strings.Compare(b.Task.LastModified, a.Task.LastModified) // newest first
+		}
+		return urgency[b.Task.Uid] - urgency[a.Task.Uid] // most urgent first
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		c := less(out[i], out[j])
+		if desc {
+			c = -c
+		}
+		return c < 0
+	})
+	if q.limit > 0 && len(out) > q.limit {
+		out = out[:q.limit]
+	}
+	return out, urgency, total
+}
+
+// formatTaskRow is one find_tasks result line.
+func formatTaskRow(r taskRow, urgency int, loc *time.Location) string {
+	box := "[ ]"
+	if isFinished(r.Task.Status) {
+		box = "[x]"
+	}
+	line := fmt.Sprintf("- %s %s (UID: %s)", box, r.Task.Summary, r.Task.Uid)
+	var parts []string
+	if s := normStatus(r.Task.Status); s != "NEEDS-ACTION" && s != "COMPLETED" {
+		parts = append(parts, strings.ToLower(s))
+	}
+	if due, ok := dueTime(r.Task, loc); ok {
+		if r.Task.DueDate != "" {
+			parts = append(parts, "due "+due.Format("Mon 2 Jan 2006"))
+		} else {
+			parts = append(parts, "due "+due.In(loc).Format("Mon 2 Jan 2006 15:04"))
+		}
+	}
+	if r.Task.Priority > 0 {
+		parts = append(parts, fmt.Sprintf("priority %d", r.Task.Priority))
+	}
+	if r.Task.Categories != "" {
+		parts = append(parts, r.Task.Categories)
+	}
+	if r.Task.RRule != "" {
+		parts = append(parts, "🔁")
+	}
+	if !isFinished(r.Task.Status) {
+		parts = append(parts, fmt.Sprintf("urgency %d", urgency))
+	}
+	switch {
+	case r.Remote:
+		parts = append(parts, "☁️ only on Nextcloud, href: "+r.Href)
+	case r.Href != "":
+		parts = append(parts, "href: "+r.Href)
+	}
+	if len(parts) > 0 {
+		line += " · " + strings.Join(parts, " · ")
+	}
+	return line
+}
@@ -0,0 +1,67 @@
+package atc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskQuery(t *testing.T) {
+	now := time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC) // a Tuesday
+	todos := []VTodo{
+		{Properties: VTodoProperties{Uid: "elec", Summary: "Pay electricity", Categories: "home,bills", Priority: 1, DueDate: "2026-03-05"}},
+		{Properties: VTodoProperties{Uid: "gas", Summary: "Gas", Description: "electricity too?", Categories: "home", Priority: 5, Due: "2026-03-02T09:00:00Z"}},
+		{Properties: VTodoProperties{Uid: "gym", Summary: "Gym", Categories: "health", RRule: "FREQ=WEEKLY", DueDate: "2026-03-06"}},
+		{Properties: VTodoProperties{Uid: "old", Summary: "Old electricity bill", Categories: "home", Status: "COMPLETED", Priority: 2}},
+		{Properties: VTodoProperties{Uid: "wait", Summary: "Deploy", RelatedTo: []RelatedTo{{RelType: RelDependsOn, UID: "gas"}}}},
+	}
+	var rows []taskRow
+	for _, td := range todos {
+		rows = append(rows, taskRow{Task: td.Properties})
+	}
+	rows[0].Href = "/tasks/elec.ics"
+	env := queryEnv{now: now, g: newTaskGraph(todos)}
+
+	for q, want := range map[string]string{
+		`status:needs-action due<+3d priority<=2 cat:home "electricity"`: "elec",
+		`"electricity" sort:summary`:                                     "gas,old,elec",
+		`summary:electricity -is:done`:                                   "elec",
+		`due<today`:                                                      "gas",
+		`due<=friday sort:due`:                                           "gas,elec,gym",
+		`due:none`:                                                       "old,wait",
+		`is:overdue`:                                                     "gas",
+		`is:blocked`:                                                     "wait",
+		`is:recurring,synced sort:summary`:                               "gym,elec",
+		`prio:high sort:-priority`:                                       "old,elec",
+		`cat:home,health status:open sort:due`:                           "gas,elec,gym",
+		`priority!=none is:open sort:priority`:                           "elec,gas",
+		`is:open sort:due limit:2`:                                       "gas,elec",
+		`due>=2026-03-05 due<2026-03-06`:                                 "elec",
+		`start:any`:                                                      "",
+		`status:cancelled`:                                               "",
+		`-cat:home sort:summary`:                                         "wait,gym",
+	} {
+		query, err := parseTaskQuery(q, now)
+		if err != nil {
+			t.Errorf("%s: %v", q, err)
+			continue
+		}
+		found, _, _ := query.run(rows, env, DefaultUrgencyWeights(), nil)
+		var got []string
+		for _, r := range found {
+			got = append(got, r.Task.Uid)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("%s = %v, want %s", q, got, want)
+		}
+	}
+
+	for _, bad := range []string{"color:red", "due<soon", "priority:urgent!", "sort:size", "limit:0", "is:lost"} {
+		if _, err := parseTaskQuery(bad, now); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+	if line := formatTaskRow(rows[0], 42, time.UTC); !strings.Contains(line, "(UID: elec) · due Thu 5 Mar 2026 · priority 1 · home,bills · urgency 42 · href: /tasks/elec.ics") {
+		t.Errorf("row = %s", line)
+	}
+}
@@ -0,0 +1,582 @@
+package atc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Quick-add parser
+//
+// ParseQuickAdd turns "pay DESCO bill next Tuesday 5pm !1 #home every month"
+// into task fields without an LLM, so dates are resolved in the user's
+// timezone instead of guessed. Recognised pieces are removed from the text;
+// what is left is the summary.
+//
+//	!1 !2 !3, !high !medium !low   priority 1 / 5 / 9
+//	#home                          category
+//	~45m, ~1h30m                   estimate (X-ESTIMATE, for plan_day)
+//	every day|week|month|year, every 2 weeks, every monday and thursday,
+//	every weekday, daily, weekly, monthly, yearly
+//	today, tomorrow, day after tomorrow, monday, this friday, next tuesday
+//	(the one in next week), next week, next month, in 3 days, in 2 hours,
+//	2026-03-15, 15/3, march 15, 15 mar 2027
+//	5pm, 5:30 pm, 17:00, noon, midnight (optionally after "at")
+//
+// A date or time after "from" or "starting" is the start; any other one
+// (optionally after "on", "by" or "due") is the due date. A time without a
+// date is today, or tomorrow once it has passed; a date without a time is
+// an all-day due date.
+// ----------------------------------------------------------------------------
+
+// QuickTask is what ParseQuickAdd found.
+type QuickTask struct {
+	Summary    string
+	Due        time.Time
+	DueAllDay  bool
+	Start      time.Time
+	StartAll   bool
+	Priority   int
+	Categories []string
+	RRule      string
+	Estimate   time.Duration
+}
+
+var (
+	weekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+		"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	}
+	// Abbreviations double as ordinary words ("sun", "sat"), so they only
+	// count after on / this / next / every / by / due.
+	weekdayAbbr = map[string]time.Weekday{
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+	months = map[string]time.Month{
+		"jan": time.January, "january": time.January, "feb": time.February, "february": time.February,
+		"mar": time.March, "march": time.March, "apr": time.April, "april": time.April, "may": time.May,
+		"jun": time.June, "june": time.June, "jul": time.July, "july": time.July, "aug": time.August,
+		"august": time.August, "sep": time.September, "sept": time.September, "september": time.September,
+		"oct": time.October, "october": time.October, "nov": time.November, "november": time.November,
+		"dec": time.December, "december": time.December,
+	}
+	numberWords = map[string]int{
+		"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "other": 2,
+	}
+	rruleDays = map[time.Weekday]string{
+		time.Sunday: "SU", time.Monday: "MO", time.Tuesday: "TU", time.Wednesday: "WE",
+		time.Thursday: "TH", time.Friday: "FR", time.Saturday: "SA",
+	}
+
+	clockRe    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	isoDateRe  = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	slashRe    = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{2,4}))?$`)
+	dayNumRe   = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?,?$`)
+	yearRe     = regexp.MustCompile(`^(\d{4})$`)
+	priorityRe = regexp.MustCompile(`^!(1|2|3|high|med|medium|low)$`)
+)
+
+// quickParser walks the words of a quick-add phrase.
+type quickParser struct {
+	words []string // original words
+	low   []string // lower-cased, surrounding punctuation trimmed
+	used  []bool
+	now   time.Time
+}
+
+// when is a date and/or time picked out of the text.
+type when struct {
+	date    time.Time // midnight in now's location; zero if none
+	hasTime bool
+	hour    int
+	min     int
+	exact   time.Time // "in 2 hours": a full instant
+}
+
+// ParseQuickAdd parses text relative to now, whose location is the user's
+// timezone.
+func ParseQuickAdd(text string, now time.Time) QuickTask {
+	p := &quickParser{words: strings.Fields(text), now: now}
+	for _, w := range p.words {
+		p.low = append(p.low, strings.Trim(strings.ToLower(w), ".,;"))
+	}
+	p.used = make([]bool, len(p.words))
+
+	var q QuickTask
+	var due, start *when
+	ruleDue := false // due only holds the first day of a recurrence
+	for i := 0; i < len(p.words); i++ {
+		if p.used[i] {
+			continue
+		}
+		w := p.low[i]
+		if m := priorityRe.FindStringSubmatch(w); m != nil {
+			q.Priority = map[string]int{"1": 1, "high": 1, "2": 5, "med": 5, "medium": 5, "3": 9, "low": 9}[m[1]]
+			p.used[i] = true
+			continue
+		}
+		if strings.HasPrefix(w, "~") {
+			if d := parseEstimate(w[1:]); d > 0 {
+				q.Estimate = d
+				p.used[i] = true
+				continue
+			}
+		}
+		if strings.HasPrefix(w, "#") && len(w) > 1 {
+			q.Categories = append(q.Categories, strings.TrimPrefix(p.words[i], "#"))
+			p.used[i] = true
+			continue
+		}
+		if rule, days, n := p.recurrence(i); n > 0 {
+			q.RRule = rule
+			p.take(i, n)
+			// "every monday and thursday" is first due on the sooner one,
+			// at the time given elsewhere ("9am every monday").
+			var first time.Time
+			for _, d := range days {
+				if next := p.nextWeekday(d, false); first.IsZero() || next.Before(first) {
+					first = next
+				}
+			}
+			switch {
+			case first.IsZero():
+			case due == nil:
+				due, ruleDue = &when{date: first}, true
+			case due.date.IsZero() && due.exact.IsZero():
+				due.date = first
+			}
+			continue
+		}
+		isStart := w == "from" || w == "starting"
+		j := i
+		if isStart || w == "on" || w == "by" || w == "due" || w == "at" {
+			j++
+		}
+		keyword := ""
+		if j > i {
+			keyword = w
+		}
+		if wh, n := p.when(j, keyword); n > 0 {
+			p.take(i, j-i+n)
+			switch {
+			case isStart:
+				start = &wh
+			case ruleDue && wh.date.IsZero() && wh.exact.IsZero():
+				due.hasTime, due.hour, due.min = wh.hasTime, wh.hour, wh.min
+				ruleDue = false
+			case due == nil || ruleDue:
+				due, ruleDue = &wh, false
+			}
+			i = j + n - 1
+		}
+	}
+
+	if due != nil {
+		q.Due, q.DueAllDay = p.resolve(*due)
+	}
+	if start != nil {
+		q.Start, q.StartAll = p.resolve(*start)
+	}
+
+	var rest []string
+	for i, w := range p.words {
+		if !p.used[i] {
+			rest = append(rest, w)
+		}
+	}
+	q.Summary = strings.Trim(strings.Join(rest, " "), " ,;-")
+	return q
+}
+
+func (p *quickParser) take(i, n int) {
+	for k := i; k < i+n && k < len(p.used); k++ {
+		p.used[k] = true
+	}
+}
+
+func (p *quickParser) at(i int) string {
+	if i < 0 || i >= len(p.low) || p.used[i] {
+		return ""
+	}
+	return p.low[i]
+}
+
+func (p *quickParser) weekday(i int, prefixed bool) (time.Weekday, bool) {
+	w := p.at(i)
+	if d, ok := weekdays[w]; ok {
+		return d, true
+	}
+	if d, ok := weekdayAbbr[w]; ok && prefixed {
+		return d, true
+	}
+	return 0, false
+}
+
+func (p *quickParser) number(i int) (int, bool) {
+	w := p.at(i)
+	if n, err := strconv.Atoi(w); err == nil && n > 0 {
+		return n, true
+	}
+	n, ok := numberWords[w]
+	return n, ok
+}
+
+func (p *quickParser) today() time.Time {
+	y, m, d := p.now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, p.now.Location())
+}
+
+// nextWeekday is the next d from today (today included), or with
+// nextWeek the d in the week after this one (weeks start on Monday).
+func (p *quickParser) nextWeekday(d time.Weekday, nextWeek bool) time.Time {
+	today := p.today()
+	if nextWeek {
+		toMonday := (8 - int(today.Weekday())) % 7
+		if toMonday == 0 {
+			toMonday = 7
+		}
+		monday := today.AddDate(0, 0, toMonday)
+		return monday.AddDate(0, 0, (int(d)+6)%7)
+	}
+	return today.AddDate(0, 0, (int(d)-int(today.Weekday())+7)%7)
+}
+
+// recurrence reads "every …" / "daily" at i. It returns the RRULE, the
+// weekdays of "every monday and thursday", and how many words it used.
+func (p *quickParser) recurrence(i int) (string, []time.Weekday, int) {
+	switch p.at(i) {
+	case "daily":
+		return "FREQ=DAILY", nil, 1
+	case "weekly":
+		return "FREQ=WEEKLY", nil, 1
+	case "monthly":
+		return "FREQ=MONTHLY", nil, 1
+	case "yearly", "annually":
+		return "FREQ=YEARLY", nil, 1
+	case "weekdays":
+		return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", nil, 1
+	case "every":
+	default:
+		return "", nil, 0
+	}
+
+	n, interval := 1, 1
+	if k, ok := p.number(i + 1); ok && p.at(i+1) != "a" {
+		interval, n = k, 2
+	}
+	unit := strings.TrimSuffix(p.at(i+n), "s")
+	freq := map[string]string{"day": "DAILY", "week": "WEEKLY", "month": "MONTHLY", "year": "YEARLY"}[unit]
+	if freq != "" {
+		rule := "FREQ=" + freq
+		if interval > 1 {
+			rule += ";INTERVAL=" + strconv.Itoa(interval)
+		}
+		return rule, nil, n + 1
+	}
+	if interval == 1 && unit == "weekday" {
+		return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", nil, n + 1
+	}
+
+	// every monday [and|, thursday …]
+	var days []string
+	var weekdays []time.Weekday
+	k := i + n
+	for {
+		d, ok := p.weekday(k, true)
+		if !ok {
+			break
+		}
+		weekdays = append(weekdays, d)
+		days = append(days, rruleDays[d])
+		k++
+		if p.at(k) == "and" {
+			if _, ok := p.weekday(k+1, true); ok {
+				k++
+			}
+		}
+	}
+	if len(days) == 0 || interval > 2 {
+		return "", nil, 0
+	}
+	rule := "FREQ=WEEKLY"
+	if interval == 2 {
+		rule += ";INTERVAL=2"
+	}
+	return rule + ";BYDAY=" + strings.Join(days, ","), weekdays, k - i
+}
+
+// when reads a date, a time, or both (in either order, with an optional
+// "at" between) at i. keyword is the on / by / due / at / from / starting
+// word before i, if any; weekday abbreviations need one.
+func (p *quickParser) when(i int, keyword string) (when, int) {
+	var wh when
+	n := 0
+	prefixed := keyword != "" && keyword != "at"
+
+	if date, k := p.date(i, prefixed); k > 0 {
+		wh.date, n = date, k
+	} else if rel, k := p.relative(i); k > 0 {
+		if !rel.exact.IsZero() {
+			return rel, k
+		}
+		wh.date, n = rel.date, k
+	}
+	j := i + n
+	if p.at(j) == "at" {
+		j++
+	}
+	if h, m, k := p.clock(j); k > 0 {
+		wh.hasTime, wh.hour, wh.min = true, h, m
+		n = j - i + k
+		if wh.date.IsZero() {
+			// "5pm tomorrow"
+			if date, k := p.date(i+n, false); k > 0 {
+				wh.date = date
+				n += k
+			} else if p.at(i+n) == "on" {
+				if date, k := p.date(i+n+1, true); k > 0 {
+					wh.date = date
+					n += k + 1
+				}
+			}
+		}
+	}
+	return wh, n
+}
+
+// date reads a calendar date at i.
+func (p *quickParser) date(i int, prefixed bool) (time.Time, int) {
+	today := p.today()
+	w := p.at(i)
+	switch w {
+	case "today", "tonight":
+		return today, 1
+	case "tomorrow", "tmr", "tmrw":
+		return today.AddDate(0, 0, 1), 1
+	case "day":
+		if p.at(i+1) == "after" && p.at(i+2) == "tomorrow" {
+			return today.AddDate(0, 0, 2), 3
+		}
+	case "next":
+		if d, ok := p.weekday(i+1, true); ok {
+			return p.nextWeekday(d, true), 2
+		}
+		switch p.at(i + 1) {
+		case "week":
+			return p.nextWeekday(time.Monday, true), 2
+		case "month":
+			return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), 2
+		}
+	case "this":
+		if d, ok := p.weekday(i+1, true); ok {
+			return p.nextWeekday(d, false), 2
+		}
+	}
+	if d, ok := p.weekday(i, prefixed); ok {
+		return p.nextWeekday(d, false), 1
+	}
+
+	if m := isoDateRe.FindStringSubmatch(w); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		mo, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		if t, ok := p.mkdate(y, mo, d); ok {
+			return t, 1
+		}
+	}
+	if m := slashRe.FindStringSubmatch(w); m != nil {
+		d, _ := strconv.Atoi(m[1])
+		mo, _ := strconv.Atoi(m[2])
+		y := 0
+		if m[3] != "" {
+			y, _ = strconv.Atoi(m[3])
+			if y < 100 {
+				y += 2000
+			}
+		}
+		if t, ok := p.mkdate(y, mo, d); ok {
+			return t, 1
+		}
+	}
+	// march 15 [2027] / 15 march [2027]
+	if mo, ok := months[w]; ok {
+		if m := dayNumRe.FindStringSubmatch(p.at(i + 1)); m != nil {
+			d, _ := strconv.Atoi(m[1])
+			y, n := p.year(i + 2)
+			if t, ok := p.mkdate(y, int(mo), d); ok {
+				return t, 2 + n
+			}
+		}
+	}
+	if m := dayNumRe.FindStringSubmatch(w); m != nil {
+		if mo, ok := months[p.at(i+1)]; ok {
+			d, _ := strconv.Atoi(m[1])
+			y, n := p.year(i + 2)
+			if t, ok := p.mkdate(y, int(mo), d); ok {
+				return t, 2 + n
+			}
+		}
+	}
+	return time.Time{}, 0
+}
+
+func (p *quickParser) year(i int) (int, int) {
+	if m := yearRe.FindStringSubmatch(p.at(i)); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		return y, 1
+	}
+	return 0, 0
+}
+
+// mkdate validates a date; without a year it is the next such date.
+func (p *quickParser) mkdate(y, mo, d int) (time.Time, bool) {
+	if mo < 1 || mo > 12 || d < 1 || d > 31 {
+		return time.Time{}, false
+	}
+	explicit := y != 0
+	if !explicit {
+		y = p.now.Year()
+	}
+	t := time.Date(y, time.Month(mo), d, 0, 0, 0, 0, p.now.Location())
+	if t.Day() != d {
+		return time.Time{}, false
+	}
+	if !explicit && t.Before(p.today()) {
+		t = t.AddDate(1, 0, 0)
+	}
+	return t, true
+}
+
+// relative reads "in 2 hours" (an instant) or "in 3 days" (a date).
+func (p *quickParser) relative(i int) (when, int) {
+	if p.at(i) != "in" {
+		return when{}, 0
+	}
+	n, ok := p.number(i + 1)
+	if !ok {
+		return when{}, 0
+	}
+	switch strings.TrimSuffix(p.at(i+2), "s") {
+	case "minute", "min":
+		return when{exact: p.now.Add(time.Duration(n) * time.Minute)}, 3
+	case "hour", "hr":
+		return when{exact: p.now.Add(time.Duration(n) * time.Hour)}, 3
+	case "day":
+		return when{date: p.today().AddDate(0, 0, n)}, 3
+	case "week":
+		return when{date: p.today().AddDate(0, 0, 7*n)}, 3
+	case "month":
+		return when{date: p.today().AddDate(0, n, 0)}, 3
+	}
+	return when{}, 0
+}
+
+// clock reads "5pm", "5 pm", "5:30pm", "17:00", "noon" or "midnight".
+func (p *quickParser) clock(i int) (int, int, int) {
+	w := p.at(i)
+	switch w {
+	case "noon":
+		return 12, 0, 1
+	case "midnight":
+		return 23, 59, 1
+	}
+	m := clockRe.FindStringSubmatch(w)
+	if m == nil {
+		return 0, 0, 0
+	}
+	n := 1
+	suffix := m[3]
+	if suffix == "" && (p.at(i+1) == "am" || p.at(i+1) == "pm") {
+		suffix, n = p.at(i+1), 2
+	}
+	// A bare number is only a time with a colon ("17:00"), not "3 apples".
+	if suffix == "" && m[2] == "" {
+		return 0, 0, 0
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	switch {
+	case min > 59, suffix == "" && h > 23, suffix != "" && (h < 1 || h > 12):
+		return 0, 0, 0
+	case suffix == "pm" && h != 12:
+		h += 12
+	case suffix == "am" && h == 12:
+		h = 0
+	}
+	return h, min, n
+}
+
+// resolve turns a parsed when into an instant, or a date when no time was
+// given.
+func (p *quickParser) resolve(wh when) (time.Time, bool) {
+	if !wh.exact.IsZero() {
+		return wh.exact.Truncate(time.Minute), false
+	}
+	if !wh.hasTime {
+		return wh.date, true
+	}
+	date := wh.date
+	if date.IsZero() {
+		date = p.today()
+	}
+	t := time.Date(date.Year(), date.Month(), date.Day(), wh.hour, wh.min, 0, 0, p.now.Location())
+	if wh.date.IsZero() && !t.After(p.now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, false
+}
+
+// Options converts the parsed fields for pushTaskToCalDAV; date-only
+// values use the YYYY-MM-DD form.
+func (q QuickTask) Options() TaskOptions {
+	opts := TaskOptions{Priority: q.Priority, Categories: q.Categories, RRule: q.RRule, Estimate: formatEstimate(q.Estimate)}
+	opts.Due = formatQuickTime(q.Due, q.DueAllDay)
+	opts.Start = formatQuickTime(q.Start, q.StartAll)
+	return opts
+}
+
+func formatQuickTime(t time.Time, allDay bool) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case allDay:
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Preview describes the parsed task for the LLM to confirm.
+func (q QuickTask) Preview() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📝 %s\n", q.Summary)
+	show := func(label string, t time.Time, allDay bool) {
+		if t.IsZero() {
+			return
+		}
+		if allDay {
+			fmt.Fprintf(&sb, "%s %s (all day)\n", label, t.Format("Mon 2 Jan 2006"))
+		} else {
+			fmt.Fprintf(&sb, "%s %s (%s)\n", label, t.Format("Mon 2 Jan 2006 15:04"), t.Location())
+		}
+	}
+	show("📅 Due:", q.Due, q.DueAllDay)
+	show("▶️ Start:", q.Start, q.StartAll)
+	if q.Priority > 0 {
+		fmt.Fprintf(&sb, "⚡ Priority: %d (%s)\n", q.Priority, map[int]string{1: "high", 5: "medium", 9: "low"}[q.Priority])
+	}
+	if len(q.Categories) > 0 {
+		fmt.Fprintf(&sb, "🏷️ %s\n", strings.Join(q.Categories, ", "))
+	}
+	if q.RRule != "" {
+		fmt.Fprintf(&sb, "🔁 %s\n", q.RRule)
+	}
+	if q.Estimate > 0 {
+		fmt.Fprintf(&sb, "⏱️ Estimate: %s\n", formatSpan(q.Estimate))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
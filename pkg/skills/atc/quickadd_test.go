@@ -0,0 +1,65 @@
+package atc
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	dhaka, _ := time.LoadLocation("Asia/Dhaka")
+	now := time.Date(2026, 3, 3, 10, 0, 0, 0, dhaka) // a Tuesday
+	at := func(mo time.Month, d, h, m int) time.Time { return time.Date(2026, mo, d, h, m, 0, 0, dhaka) }
+	day := func(mo time.Month, d int) time.Time { return time.Date(2026, mo, d, 0, 0, 0, 0, dhaka) }
+
+	cases := []struct {
+		text string
+		want QuickTask
+	}{
+		{"pay DESCO bill next Tuesday 5pm !1 #home every month",
+			QuickTask{Summary: "pay DESCO bill", Due: at(3, 10, 17, 0), Priority: 1, Categories: []string{"home"}, RRule: "FREQ=MONTHLY"}},
+		{"call mom tomorrow", QuickTask{Summary: "call mom", Due: day(3, 4), DueAllDay: true}},
+		{"standup at 9:30am", QuickTask{Summary: "standup", Due: at(3, 4, 9, 30)}},
+		{"review PR 5 pm", QuickTask{Summary: "review PR", Due: at(3, 3, 17, 0)}},
+		{"review PR 5 pm ~45m", QuickTask{Summary: "review PR", Due: at(3, 3, 17, 0), Estimate: 45 * time.Minute}},
+		{"gym every monday and thursday !low", QuickTask{Summary: "gym", Due: day(3, 5), DueAllDay: true, Priority: 9, RRule: "FREQ=WEEKLY;BYDAY=MO,TH"}},
+		{"submit report by friday noon", QuickTask{Summary: "submit report", Due: at(3, 6, 12, 0)}},
+		{"renew passport on 15 march 2027", QuickTask{Summary: "renew passport", Due: time.Date(2027, 3, 15, 0, 0, 0, 0, dhaka), DueAllDay: true}},
+		{"tax return 1/3", QuickTask{Summary: "tax return", Due: time.Date(2027, 3, 1, 0, 0, 0, 0, dhaka), DueAllDay: true}},
+		{"water plants every 2 weeks from next week", QuickTask{Summary: "water plants", Start: day(3, 9), StartAll: true, RRule: "FREQ=WEEKLY;INTERVAL=2"}},
+		{"ship release in 3 days at 17:00", QuickTask{Summary: "ship release", Due: at(3, 6, 17, 0)}},
+		{"yoga 7am every weekday", QuickTask{Summary: "yoga", Due: at(3, 4, 7, 0), RRule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"}},
+		{"team sync every wed 15:00", QuickTask{Summary: "team sync", Due: at(3, 4, 15, 0), RRule: "FREQ=WEEKLY;BYDAY=WE"}},
+		{"buy 2 apples and sun cream", QuickTask{Summary: "buy 2 apples and sun cream"}},
+	}
+	for _, c := range cases {
+		got := ParseQuickAdd(c.text, now)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q:\n got %+v\nwant %+v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestPushTaskPreviewUsesExplicitArgs(t *testing.T) {
+	s := &ATCSkill{workspace: t.TempDir()}
+	res := s.Execute(context.Background(), map[string]interface{}{"command": "push_task", "preview": true,
+		"text": "review PR tomorrow 5pm ~45m !low", "due": "2030-01-02", "estimate": "2h", "priority": float64(1)})
+	if res.IsError || !strings.Contains(res.ForLLM, "📅 Due: Wed 2 Jan 2030 (all day)") ||
+		!strings.Contains(res.ForLLM, "⚡ Priority: 1 (high)") || !strings.Contains(res.ForLLM, "⏱️ Estimate: 2h") {
+		t.Errorf("preview:\n%s", res.ForLLM)
+	}
+	res = s.Execute(context.Background(), map[string]interface{}{"command": "push_task", "preview": true, "text": "review PR", "estimate": "soon"})
+	if !res.IsError {
+		t.Errorf("bad estimate accepted: %s", res.ForLLM)
+	}
+}
+
+func TestMergeTaskRejectsBadEstimate(t *testing.T) {
+	s := &ATCSkill{workspace: t.TempDir()}
+	res := s.Execute(context.Background(), map[string]interface{}{"command": "merge_task", "task_href": "/tasks/x.ics", "estimate": "abc"})
+	if !res.IsError || !strings.Contains(res.ForLLM, `bad estimate "abc"`) {
+		t.Errorf("bad estimate accepted: %s", res.ForLLM)
+	}
+}
@@ -0,0 +1,55 @@
+package atc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+)
+
+// ----------------------------------------------------------------------------
+// Recurring tasks
+//
+// Completing a task with an RRULE completes only the current occurrence,
+// as Nextcloud Tasks does: DTSTART and DUE move to the next occurrence and
+// the task stays open. The completed occurrence goes into the stats log.
+// ----------------------------------------------------------------------------
+
+// completeLocal completes a tasks.xml task in place, advancing it when it
+// recurs. It reuses the iCalendar path so local and Nextcloud completions
+// move dates identically.
+func completeLocal(t *VTodoProperties, loc *time.Location, now time.Time) (caldav.Completion, error) {
+	out, c, err := caldav.CompleteVTodo(buildVTodo(*t, t.Categories, nil, now), loc, now)
+	if err != nil {
+		return c, err
+	}
+	r, _ := parseRemoteTodo(out, loc)
+	t.Status = r.Props.Status
+	t.Due, t.DueDate = r.Props.Due, r.Props.DueDate
+	t.Dtstart, t.DtstartDate = r.Props.Dtstart, r.Props.DtstartDate
+	t.RRule = r.Props.RRule
+	t.Completed, t.Percent = r.Props.Completed, r.Props.Percent
+	return c, nil
+}
+
+// recordCompletion logs a completion: one occurrence of a recurring task,
+// or the plain completed event for a one-off.
+func recordCompletion(st *StatsStore, t VTodoProperties, c caldav.Completion, now time.Time) error {
+	if c.Recurring {
+		return st.RecordOccurrence(t, c.Occurrence, c.AllDay, now)
+	}
+	return st.Record(t, EventCompleted, now)
+}
+
+// completionNote tells the user where a recurring task went.
+func completionNote(c caldav.Completion, loc *time.Location) string {
+	switch {
+	case !c.Recurring:
+		return ""
+	case c.Ended:
+		return "\n🏁 That was the last occurrence; the series is complete."
+	case c.AllDay:
+		return fmt.Sprintf("\n🔁 Occurrence done. Next: %s", c.Next.Format("Mon 2 Jan 2006"))
+	}
+	return fmt.Sprintf("\n🔁 Occurrence done. Next: %s", c.Next.In(loc).Format("Mon 2 Jan 2006 15:04"))
+}
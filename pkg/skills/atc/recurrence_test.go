@@ -0,0 +1,63 @@
+package atc
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompleteRecurringTask(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	today := time.Now()
+	var cal ICalendar
+	cal.VCal.Components.VTodos = []VTodo{{Properties: VTodoProperties{
+		Uid: "meds", Summary: "Take meds", Categories: "today",
+		DtstartDate: today.Format("2006-01-02"), DueDate: today.Format("2006-01-02"), RRule: "FREQ=DAILY;COUNT=3",
+	}}}
+	data, _ := xml.MarshalIndent(cal, "", "  ")
+	os.WriteFile(filepath.Join(ws, "memory", "tasks.xml"), data, 0644)
+
+	s := &ATCSkill{workspace: ws}
+	complete := func() string {
+		return s.Execute(context.Background(), map[string]interface{}{"command": "update_task", "task_uid": "meds", "status": "COMPLETED"}).ForLLM
+	}
+	load := func() VTodoProperties {
+		var c ICalendar
+		data, _ := os.ReadFile(filepath.Join(ws, "memory", "tasks.xml"))
+		xml.Unmarshal(data, &c)
+		return c.VCal.Components.VTodos[0].Properties
+	}
+
+	out := complete()
+	got := load()
+	next := today.AddDate(0, 0, 1).Format("2006-01-02")
+	if !strings.Contains(out, "🔁 Occurrence done") || got.Status != "NEEDS-ACTION" || got.DueDate != next ||
+		got.DtstartDate != next || got.RRule != "FREQ=DAILY;COUNT=2" {
+		t.Fatalf("first completion:\n%s\n%+v", out, got)
+	}
+	complete()
+	if out = complete(); !strings.Contains(out, "last occurrence") || load().Status != "COMPLETED" {
+		t.Errorf("last completion:\n%s\n%+v", out, load())
+	}
+
+	st, err := s.openStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	habits, err := st.Habits(today.AddDate(0, 0, -1))
+	if err != nil || len(habits) != 1 || habits[0].Done != 3 || habits[0].Streak != 3 {
+		t.Errorf("habits = %+v, %v", habits, err)
+	}
+	if p, _ := st.Period(today, today); p.Completed != 3 {
+		t.Errorf("completed events = %d, want 3", p.Completed)
+	}
+	if report, _ := st.Report(today); !strings.Contains(report, "Recurring tasks: Take meds 3/3 on time, streak 3") {
+		t.Errorf("report:\n%s", report)
+	}
+}
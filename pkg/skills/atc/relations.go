@@ -0,0 +1,220 @@
+package atc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Subtasks and dependencies
+//
+// Tasks link to each other with RELATED-TO: RELTYPE=PARENT points a subtask
+// at its parent, RELTYPE=DEPENDS-ON at a task that must be finished first.
+// A task is blocked while any task it depends on is still open; a parent's
+// progress is the share of its subtasks that are finished.
+// ----------------------------------------------------------------------------
+
+// RELATED-TO relation types.
+const (
+	RelParent    = "PARENT"
+	RelDependsOn = "DEPENDS-ON"
+)
+
+// relType normalises a RELTYPE; RFC 5545 makes PARENT the default.
+func relType(r RelatedTo) string {
+	if r.RelType == "" {
+		return RelParent
+	}
+	return strings.ToUpper(r.RelType)
+}
+
+// Parent returns the UID of the task's parent, if any.
+func (t VTodoProperties) Parent() string {
+	for _, r := range t.RelatedTo {
+		if relType(r) == RelParent {
+			return r.UID
+		}
+	}
+	return ""
+}
+
+// DependsOn returns the UIDs the task waits for.
+func (t VTodoProperties) DependsOn() []string {
+	var out []string
+	for _, r := range t.RelatedTo {
+		if relType(r) == RelDependsOn {
+			out = append(out, r.UID)
+		}
+	}
+	return out
+}
+
+// withRelations sets the parent (unless empty) and adds dependencies,
+// skipping ones already present and links to the task itself.
+func withRelations(rels []RelatedTo, self, parent string, dependsOn []string) []RelatedTo {
+	if parent != "" && parent != self {
+		kept := rels[:0:0]
+		for _, r := range rels {
+			if relType(r) != RelParent {
+				kept = append(kept, r)
+			}
+		}
+		rels = append([]RelatedTo{{RelType: RelParent, UID: parent}}, kept...)
+	}
+	for _, uid := range dependsOn {
+		uid = strings.TrimSpace(uid)
+		if uid == "" || uid == self {
+			continue
+		}
+		dup := false
+		for _, r := range rels {
+			if relType(r) == RelDependsOn && r.UID == uid {
+				dup = true
+			}
+		}
+		if !dup {
+			rels = append(rels, RelatedTo{RelType: RelDependsOn, UID: uid})
+		}
+	}
+	return rels
+}
+
+// relatedToLines renders RELATED-TO properties for an iCalendar object.
+func relatedToLines(rels []RelatedTo) []string {
+	var out []string
+	for _, r := range rels {
+		if r.UID != "" {
+			out = append(out, "RELATED-TO;RELTYPE="+relType(r)+":"+r.UID)
+		}
+	}
+	return out
+}
+
+// parseRelatedTo reads one RELATED-TO property from its parameter list
+// and value.
+func parseRelatedTo(params, val string) RelatedTo {
+	r := RelatedTo{UID: strings.TrimSpace(val)}
+	for _, p := range strings.Split(params, ";") {
+		if k, v, ok := strings.Cut(p, "="); ok && strings.EqualFold(k, "RELTYPE") {
+			r.RelType = strings.ToUpper(strings.Trim(v, `"`))
+		}
+	}
+	if r.RelType == "" {
+		r.RelType = RelParent
+	}
+	return r
+}
+
+// formatRelations is the one-line form get_task shows and merge_task
+// reads back, e.g. "PARENT:uid1,DEPENDS-ON:uid2".
+func formatRelations(rels []RelatedTo) string {
+	var parts []string
+	for _, r := range rels {
+		parts = append(parts, relType(r)+":"+r.UID)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseRelations(s string) []RelatedTo {
+	var out []RelatedTo
+	for _, part := range strings.Split(s, ",") {
+		if typ, uid, ok := strings.Cut(strings.TrimSpace(part), ":"); ok && uid != "" {
+			out = append(out, RelatedTo{RelType: typ, UID: uid})
+		}
+	}
+	return out
+}
+
+// relationKey is a stable string of a task's links, for change detection.
+func relationKey(rels []RelatedTo) string {
+	keys := make([]string, 0, len(rels))
+	for _, r := range rels {
+		keys = append(keys, relType(r)+":"+r.UID)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// taskGraph indexes tasks by UID and parents by their subtasks.
+type taskGraph struct {
+	byUID    map[string]VTodoProperties
+	children map[string][]string
+	order    []string // UIDs in file order
+}
+
+func newTaskGraph(todos []VTodo) *taskGraph {
+	g := &taskGraph{byUID: map[string]VTodoProperties{}, children: map[string][]string{}}
+	for _, v := range todos {
+		t := v.Properties
+		if t.Uid == "" {
+			continue
+		}
+		g.byUID[t.Uid] = t
+		g.order = append(g.order, t.Uid)
+		if p := t.Parent(); p != "" {
+			g.children[p] = append(g.children[p], t.Uid)
+		}
+	}
+	return g
+}
+
+// blockers returns the open tasks t depends on. Dependencies on tasks that
+// are not in tasks.xml do not block.
+func (g *taskGraph) blockers(t VTodoProperties) []VTodoProperties {
+	if g == nil {
+		return nil
+	}
+	var out []VTodoProperties
+	for _, uid := range t.DependsOn() {
+		if dep, ok := g.byUID[uid]; ok && !isFinished(dep.Status) {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
+
+// progress counts a parent's finished and total subtasks.
+func (g *taskGraph) progress(uid string) (done, total int) {
+	if g == nil {
+		return 0, 0
+	}
+	for _, c := range g.children[uid] {
+		total++
+		if isFinished(g.byUID[c].Status) {
+			done++
+		}
+	}
+	return done, total
+}
+
+// unblockedBy returns the open tasks that depended on uid and are now free
+// to start.
+func (g *taskGraph) unblockedBy(uid string) []VTodoProperties {
+	var out []VTodoProperties
+	for _, id := range g.order {
+		t := g.byUID[id]
+		if isFinished(t.Status) {
+			continue
+		}
+		waited := false
+		for _, d := range t.DependsOn() {
+			if d == uid {
+				waited = true
+			}
+		}
+		if waited && len(g.blockers(t)) == 0 {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// summaries lists task titles for a message line.
+func summaries(tasks []VTodoProperties) string {
+	names := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		names = append(names, fmt.Sprintf("%q", t.Summary))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,56 @@
+package atc
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTaskRelations(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	var cal ICalendar
+	for _, p := range []VTodoProperties{
+		{Uid: "trip", Summary: "Plan trip", Categories: "today"},
+		{Uid: "visa", Summary: "Apply for visa", Categories: "today", Status: "COMPLETED",
+			RelatedTo: []RelatedTo{{UID: "trip"}}},
+		{Uid: "flights", Summary: "Book flights", Categories: "today",
+			RelatedTo: []RelatedTo{{RelType: RelParent, UID: "trip"}, {RelType: RelDependsOn, UID: "passport"}}},
+		{Uid: "passport", Summary: "Renew passport", Categories: "today"},
+	} {
+		cal.VCal.Components.VTodos = append(cal.VCal.Components.VTodos, VTodo{Properties: p})
+	}
+	data, _ := xml.MarshalIndent(cal, "", "  ")
+	if !strings.Contains(string(data), "<text>DEPENDS-ON</text>") {
+		t.Fatalf("relations not written to xCal:\n%s", data)
+	}
+	os.WriteFile(filepath.Join(ws, "memory", "tasks.xml"), data, 0644)
+
+	s := &ATCSkill{workspace: ws}
+	out := s.Execute(context.Background(), map[string]interface{}{"command": "analyze_tasks"}).ForLLM
+	for _, want := range []string{"⛔ Blocked by \"Renew passport\"", "📋 Subtasks: 1/2 done (50%)", "blocked -40"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("analyze_tasks missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "Book flights") < strings.Index(out, "Renew passport") {
+		t.Errorf("blocked task ranked above its blocker:\n%s", out)
+	}
+
+	out = s.Execute(context.Background(), map[string]interface{}{"command": "update_task", "task_uid": "passport", "status": "COMPLETED"}).ForLLM
+	if !strings.Contains(out, "➡️ Now unblocked: Book flights (UID: flights)") {
+		t.Errorf("completing a blocker:\n%s", out)
+	}
+	out = s.Execute(context.Background(), map[string]interface{}{"command": "update_task", "task_uid": "flights", "status": "COMPLETED"}).ForLLM
+	if !strings.Contains(out, "📋 Plan trip: 2/2 subtasks done — ready to complete") {
+		t.Errorf("completing the last subtask:\n%s", out)
+	}
+
+	r, ok := parseRemoteTodo("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:x\r\nRELATED-TO:trip\r\nRELATED-TO;RELTYPE=DEPENDS-ON:visa\r\nEND:VTODO\r\nEND:VCALENDAR\r\n", nil)
+	if !ok || r.Props.Parent() != "trip" || len(r.Props.DependsOn()) != 1 || len(r.Extra) != 0 {
+		t.Errorf("parsed relations = %+v", r)
+	}
+}
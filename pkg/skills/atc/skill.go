@@ -8,14 +8,33 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+	"github.com/jony/son-of-anthon/pkg/skills/health"
+	"github.com/jony/son-of-anthon/pkg/users"
+	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
 type ATCSkill struct {
 	workspace string
+	user      *users.Profile // nil in single-user mode
+
+	bus           *bus.MessageBus // pomodoro reminders; nil outside the gateway
+	originChannel string
+	originChatID  string
+	mu            sync.Mutex // guards alarms
+	alarms        map[int64]*time.Timer
+	tasksMu       sync.Mutex // guards every read-modify-write of tasks.xml
+}
+
+// tasksWriters are the commands that load, change and save tasks.xml. They
+// hold tasksMu throughout, so a pomodoro alarm writing X-TIME-SPENT in the
+// meantime cannot lose their changes, nor they its.
+var tasksWriters = map[string]bool{
+	"update_task": true, "create_local_task": true, "delete_local_task": true,
+	"roll_over_tasks": true, "import_tasks": true, "sync_tasks": true,
 }
 
 func NewSkill() *ATCSkill {
@@ -30,18 +49,30 @@ func (s *ATCSkill) Description() string {
 	return `Air Traffic Controller (ATC) - Task management and calendar integration for Atlas.
 
 Local task commands (operate on tasks.xml and events.xml in workspace memory):
-- analyze_tasks: Parse tasks.xml and return urgency-scored active tasks for today.
-- read_calendar: Parse events.xml for today's events using local timezone.
+- analyze_tasks: Rank today's open tasks by urgency (due date, overdue, priority, age, carry-overs, blocked, category weights), explaining each score. Blocked tasks show what they wait on and parents their subtask progress. With 'top', return the N most urgent open tasks from any category.
+- read_calendar: Show events.xml for today, a week (view=week), the next two weeks (view=agenda) or a from/to range, with recurrences expanded, durations, locations, multi-day spans and conflicts.
+- plan_day: Time-block a day (date: today, tomorrow or YYYY-MM-DD): place open tasks by urgency and estimate into the free gaps between events within working hours, with buffers, and show the timeline. push=true also writes the blocks as events to the planner calendar on Nextcloud.
 - extract_keywords: Extract keywords from 'Tomorrow' tasks for pre-fetching.
-- update_task: Change the status of a task in tasks.xml by UID (e.g. COMPLETED).
-- roll_over_tasks: Move all pending 'Today' tasks to 'Tomorrow' in tasks.xml.
+- update_task: Edit a task in tasks.xml by UID: status, summary, notes, priority, due, start, categories (replaces them), percent_complete, estimate, parent and depends_on; 'none' clears a field. Stamps LAST-MODIFIED, and COMPLETED when it is completed. Completing a task reports the tasks it unblocked and its parent's subtask progress. Completing a recurring task (RRULE) completes only this occurrence and moves its start/due to the next one.
+- create_local_task: Add a task to tasks.xml with the same fields as update_task, or from the user's words as 'text' like push_task (preview=true to confirm first). sync_tasks later takes it to Nextcloud.
+- delete_local_task: Remove a task from tasks.xml by UID. Its subtasks become top-level and tasks waiting on it are no longer blocked; sync_tasks deletes it on Nextcloud too.
+- roll_over_tasks: Move all pending 'Today' tasks to 'Tomorrow' in tasks.xml, then write today's productivity stats for Chief.
+- stats: Show today's productivity stats (completion rate, carry-overs, time to complete, priority mix, zero carry-over streak, week-over-week trend) and refresh stats-today.md.
+- find_tasks: Search tasks with a filter 'query', e.g. 'status:needs-action due<+3d priority<=2 cat:home "electricity"'. Terms (all must match, '-' negates, a,b means either): status:, due</<=/>/>=/:, start:, priority:, cat:, is:overdue/blocked/recurring/subtask/parent/open/done/synced, uid:, summary:, free words or "phrases" (title and notes); dates are today, tomorrow, a weekday, YYYY-MM-DD, +3d, -1w, +2mo, none or any. 'sort' (urgency default, due, start, priority, summary, modified; '-' reverses) and 'limit'. Searches tasks.xml (with each synced task's Nextcloud href); scope=all adds tasks only on Nextcloud. Empty query lists open tasks.
+- import_tasks: Add tasks from a Taskwarrior JSON export, Todoist CSV or JSON backup, generic CSV or .ics file ('path', or its contents as 'text'; 'format' is detected if omitted) to tasks.xml. Priorities, projects/labels (as categories), due dates, recurrence and subtasks are mapped; tasks whose UID is already present are skipped. Use dry_run=true to preview first.
+- start_timer: Start tracking time on a task (task_uid). Only one timer runs at a time; starting one stops the other. Timers survive restarts.
+- stop_timer: Stop the running timer or pomodoro (or only task_uid's) and report the session and the task's total, which is saved on the task as X-TIME-SPENT.
+- pomodoro: Start a focused pomodoro on a task (task_uid; 'minutes' overrides tools.pomodoro). When it ends the user is messaged and a break starts (long after every 4th of the day); another message says when the break is over.
+- time_report: Time tracked per task, category and day, pomodoros completed and what is running. 'from'/'to' pick the range (default last 7 days).
+- export_tasks: Write tasks.xml as taskwarrior, todoist-csv, csv or ics ('format', default csv) to 'path' (default memory/exports/); open_only=true leaves out finished tasks.
 
 Nextcloud CalDAV commands (operate live on Nextcloud via network):
-- sync_calendar: Fetch external .ics calendar from Nextcloud and overwrite events.xml.
-- push_task: Create a new task in Nextcloud with summary, due, start, priority, notes.
+- sync_calendar: Merge every calendar source in tools.calendars (Nextcloud calendars, .ics subscriptions) into events.xml, each event tagged with its source; a failing source keeps its last events. Sources are fetched when their refresh interval is up; force=true fetches all, source=<name> just one.
+- sync_tasks: Two-way sync of tasks.xml with Nextcloud tasks by UID (status, summary, due, priority, notes, Today/Tomorrow category). Deletions propagate; conflicts resolved by 'conflict' (newest, local or remote), and a completed task always stays completed.
+- push_task: Create a new task in Nextcloud with summary, due, start, priority, notes, estimate, parent (subtask of a UID) and depends_on (UIDs to finish first). Prefer passing the user's words as 'text' (e.g. "pay bill next Tuesday 5pm !1 #home every month"): it is parsed deterministically in the user's timezone; use preview=true to confirm first.
 - list_nextcloud_tasks: List all task hrefs in your Nextcloud tasks/ collection.
 - get_task: Fetch a single task's full details from Nextcloud by href.
-- merge_task: Update fields of an existing Nextcloud task by href.
+- merge_task: Update fields of an existing Nextcloud task by href, including its status, parent and added dependencies. status=COMPLETED on a recurring task advances it to the next occurrence, like Nextcloud Tasks.
 - delete_task: Delete a specific Nextcloud task by href.`
 }
 
@@ -52,35 +83,143 @@ func (s *ATCSkill) Parameters() map[string]interface{} {
 			"command": map[string]interface{}{
 				"type":        "string",
 				"description": "Command to execute",
-				"enum":        []string{"analyze_tasks", "read_calendar", "extract_keywords", "update_task", "roll_over_tasks", "sync_calendar", "push_task", "list_nextcloud_tasks", "get_task", "merge_task", "delete_task"},
+				"enum":        []string{"analyze_tasks", "read_calendar", "plan_day", "extract_keywords", "update_task", "create_local_task", "delete_local_task", "roll_over_tasks", "stats", "find_tasks", "import_tasks", "export_tasks", "start_timer", "stop_timer", "pomodoro", "time_report", "sync_tasks", "sync_calendar", "push_task", "list_nextcloud_tasks", "get_task", "merge_task", "delete_task"},
+			},
+			"top": map[string]interface{}{
+				"type":        "integer",
+				"description": "Return only the N most urgent open tasks, from any category (only for analyze_tasks).",
 			},
 			"task_uid": map[string]interface{}{
 				"type":        "string",
-				"description": "The UID of the task to update (only for update_task).",
+				"description": "The UID of the task to update (update_task), delete (delete_local_task) or track time on (start_timer, pomodoro; optional for stop_timer).",
 			},
 			"status": map[string]interface{}{
 				"type":        "string",
-				"description": "The new status (e.g. COMPLETED, IN-PROCESS) (only for update_task).",
+				"description": "The new status: NEEDS-ACTION, IN-PROCESS, COMPLETED or CANCELLED (only for update_task, create_local_task and merge_task).",
 			},
 			"summary": map[string]interface{}{
 				"type":        "string",
-				"description": "Task title/summary (only for push_task).",
+				"description": "Task title/summary (only for push_task, merge_task, update_task and create_local_task).",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "The task as the user said it, e.g. 'pay DESCO bill next Tuesday 5pm !1 #home every month'. Dates, times, !priority, #categories and 'every …' recurrence are parsed in the user's timezone; explicit args override them (only for push_task and create_local_task). For import_tasks: the contents of the file to import.",
+			},
+			"preview": map[string]interface{}{
+				"type":        "boolean",
+				"description": "With text: only show how it was parsed, without creating the task (only for push_task and create_local_task).",
 			},
 			"due": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional due date in RFC3339 format, e.g. 2026-02-21T17:00:00Z (only for push_task).",
+				"description": "Optional due date in RFC3339 format, e.g. 2026-02-21T17:00:00Z, or YYYY-MM-DD; the local commands also take today, tomorrow and YYYY-MM-DD HH:MM, and 'none' clears it (only for push_task, merge_task, update_task and create_local_task).",
 			},
 			"start": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional start date in RFC3339 format (only for push_task).",
+				"description": "Optional start date, in the same forms as due (only for push_task, merge_task, update_task and create_local_task).",
 			},
 			"priority": map[string]interface{}{
 				"type":        "integer",
-				"description": "Priority: 1=High, 5=Medium, 9=Low (only for push_task).",
+				"description": "Priority: 1=High, 5=Medium, 9=Low, 0=none (only for push_task, merge_task, update_task and create_local_task).",
 			},
 			"notes": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional description/notes for the task (only for push_task).",
+				"description": "Optional description/notes for the task; 'none' clears them (only for push_task, merge_task, update_task and create_local_task).",
+			},
+			"categories": map[string]interface{}{
+				"type":        "string",
+				"description": "Comma-separated categories, replacing the current ones, e.g. 'Today,home'; 'none' clears them (only for update_task and create_local_task).",
+			},
+			"percent_complete": map[string]interface{}{
+				"type":        "integer",
+				"description": "How far along the task is, 0-100; 100 completes it and more than 0 marks it IN-PROCESS (only for update_task and create_local_task).",
+			},
+			"estimate": map[string]interface{}{
+				"type":        "string",
+				"description": "How long the task takes, e.g. 45m, 1h30m or PT45M; stored as X-ESTIMATE and used by plan_day (only for push_task, merge_task, update_task and create_local_task).",
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "Day to plan: today (default), tomorrow or YYYY-MM-DD (only for plan_day).",
+			},
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of one tools.calendars source to refresh (only for sync_calendar).",
+			},
+			"force": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Fetch every source even if its refresh interval is not up (only for sync_calendar).",
+			},
+			"view": map[string]interface{}{
+				"type":        "string",
+				"description": "Calendar view: day (default, today), week (7 days) or agenda (next 14 days, busy days only) (only for read_calendar).",
+				"enum":        []string{"day", "week", "agenda"},
+			},
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "Start of the range: today, tomorrow, YYYY-MM-DD or RFC3339 (only for read_calendar and time_report).",
+			},
+			"to": map[string]interface{}{
+				"type":        "string",
+				"description": "End of the range, inclusive for a date: today, tomorrow, YYYY-MM-DD or RFC3339 (only for read_calendar and time_report).",
+			},
+			"minutes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Length of this pomodoro in minutes instead of tools.pomodoro's work_minutes (only for pomodoro).",
+			},
+			"push": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also write the time blocks as events to the planner calendar on Nextcloud, replacing an earlier plan for that day (only for plan_day).",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Filter, e.g. 'status:needs-action due<+3d priority<=2 cat:home \"electricity\"' (only for find_tasks).",
+			},
+			"sort": map[string]interface{}{
+				"type":        "string",
+				"description": "Sort by urgency (default), due, start, priority, summary or modified; prefix '-' to reverse (only for find_tasks).",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Return at most this many matches (only for find_tasks).",
+			},
+			"scope": map[string]interface{}{
+				"type":        "string",
+				"description": "local (default): tasks.xml with Nextcloud hrefs of synced tasks; all: also fetch tasks that only exist on Nextcloud (only for find_tasks).",
+				"enum":        []string{"local", "all"},
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "File format: taskwarrior, todoist-csv, todoist-json (import only), csv or ics (only for import_tasks and export_tasks).",
+				"enum":        []string{"taskwarrior", "todoist-csv", "todoist-json", "csv", "ics"},
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to import from or export to; relative paths are inside the ATC workspace (only for import_tasks and export_tasks).",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Category for the tasks of a Todoist project CSV, which does not name its project (only for import_tasks).",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Show what would be imported without changing tasks.xml (only for import_tasks).",
+			},
+			"open_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Leave completed and cancelled tasks out (only for export_tasks).",
+			},
+			"conflict": map[string]interface{}{
+				"type":        "string",
+				"description": "How sync_tasks resolves a task changed on both sides: newest (default, latest edit wins), local or remote (only for sync_tasks).",
+				"enum":        []string{"newest", "local", "remote"},
+			},
+			"parent": map[string]interface{}{
+				"type":        "string",
+				"description": "UID of the parent task, making this a subtask; 'none' detaches it (only for push_task, merge_task, update_task and create_local_task).",
+			},
+			"depends_on": map[string]interface{}{
+				"type":        "string",
+				"description": "Comma-separated UIDs of tasks that must be finished before this one; merge_task and update_task add to the existing ones (only for push_task, merge_task, update_task and create_local_task).",
 			},
 			"task_href": map[string]interface{}{
 				"type":        "string",
@@ -96,6 +235,33 @@ func (s *ATCSkill) SetWorkspace(ws string) {
 	s.initWorkspace()
 }
 
+// SetUser scopes the skill to one user's Nextcloud account and timezone.
+func (s *ATCSkill) SetUser(p *users.Profile) {
+	s.user = p
+}
+
+// loadConfig returns the bound user's Nextcloud credentials, or the
+// global tools.nextcloud section when running single-user. A bound user
+// without an account of their own gets users.Profile.ErrNoNextcloud.
+func (s *ATCSkill) loadConfig() (ATCCalendarConfig, error) {
+	if s.user != nil {
+		nc := s.user.Nextcloud
+		if nc.Host == "" {
+			return ATCCalendarConfig{}, s.user.ErrNoNextcloud()
+		}
+		return ATCCalendarConfig{Host: nc.Host, Username: nc.Username, Password: nc.Password, Timeout: nc.Timeout}, nil
+	}
+	return loadATCConfig(), nil
+}
+
+// now returns the current time in the bound user's timezone.
+func (s *ATCSkill) now() time.Time {
+	if s.user != nil {
+		return time.Now().In(s.user.Location())
+	}
+	return time.Now()
+}
+
 func (s *ATCSkill) initWorkspace() {
 	if s.workspace == "" {
 		return
@@ -147,20 +313,49 @@ func (s *ATCSkill) initWorkspace() {
 	}
 }
 
-func (s *ATCSkill) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+func (s *ATCSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
 	command, _ := args["command"].(string)
+	defer func() { health.Record(s.workspace, command, result) }()
+	if tasksWriters[command] {
+		s.tasksMu.Lock()
+		defer s.tasksMu.Unlock()
+	}
 
 	switch command {
 	case "analyze_tasks":
 		return s.executeAnalyzeTasks(ctx, args)
 	case "read_calendar":
 		return s.executeReadCalendar(ctx, args)
+	case "plan_day":
+		return s.executePlanDay(ctx, args)
 	case "extract_keywords":
 		return s.executeExtractKeywords(ctx, args)
 	case "update_task":
 		return s.executeUpdateTask(ctx, args)
+	case "create_local_task":
+		return s.executeCreateLocalTask(ctx, args)
+	case "delete_local_task":
+		return s.executeDeleteLocalTask(ctx, args)
 	case "roll_over_tasks":
 		return s.executeRollOverTasks(ctx, args)
+	case "stats":
+		return s.executeStats(ctx, args)
+	case "find_tasks":
+		return s.executeFindTasks(ctx, args)
+	case "import_tasks":
+		return s.executeImportTasks(ctx, args)
+	case "export_tasks":
+		return s.executeExportTasks(ctx, args)
+	case "start_timer":
+		return s.executeStartTimer(ctx, args)
+	case "stop_timer":
+		return s.executeStopTimer(ctx, args)
+	case "pomodoro":
+		return s.executePomodoro(ctx, args)
+	case "time_report":
+		return s.executeTimeReport(ctx, args)
+	case "sync_tasks":
+		return s.executeSyncTasks(ctx, args)
 	case "sync_calendar":
 		return s.executeSyncCalendar(ctx, args)
 	case "push_task":
@@ -195,68 +390,116 @@ func (s *ATCSkill) executeAnalyzeTasks(ctx context.Context, args map[string]inte
 		return tools.ErrorResult(fmt.Sprintf("Failed to parse tasks.xml: %v", err))
 	}
 
-	var result strings.Builder
-
+	var history map[string]TaskHistory
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		if err := st.Observe(cal.VCal.Components.VTodos, now); err != nil {
+			return err
+		}
+		var err error
+		history, err = st.History()
+		return err
+	})
+
+	// Without top: today's open tasks. With top: the N most urgent open
+	// tasks from any category, so an overdue 'Someday' task still surfaces.
+	top := 0
+	if v, ok := args["top"].(float64); ok && v > 0 {
+		top = int(v)
+	}
+	var open []VTodoProperties
 	for _, todo := range cal.VCal.Components.VTodos {
-		// Only analyze active tasks categorized for Today
-		// In a real-world engine, we would parse due dates and today's actual date
-		if strings.Contains(strings.ToLower(todo.Properties.Categories), "today") &&
-			strings.ToUpper(todo.Properties.Status) != "COMPLETED" {
-
-			score := s.calculateUrgency(todo)
-			// Format includes the UID so the LLM knows what to pass to update_task
-			result.WriteString(fmt.Sprintf("- [ ] %s [Urgency: %d] (UID: %s)\n", todo.Properties.Summary, score, todo.Properties.Uid))
+		if isFinished(todo.Properties.Status) {
+			continue
 		}
+		if top == 0 && !strings.Contains(strings.ToLower(todo.Properties.Categories), "today") {
+			continue
+		}
+		open = append(open, todo.Properties)
+	}
+	if len(open) == 0 {
+		output := "No pending tasks found for 'Today' in tasks.xml"
+		if top > 0 {
+			output = "No pending tasks found in tasks.xml"
+		}
+		return &tools.ToolResult{ForLLM: output, ForUser: output}
 	}
 
-	output := result.String()
-	if output == "" {
-		output = "No pending tasks found for 'Today' in tasks.xml"
+	g := newTaskGraph(cal.VCal.Components.VTodos)
+	ranked := rankTasks(open, g, history, LoadUrgencyWeights(), s.now())
+	var result strings.Builder
+	if top > 0 && top < len(ranked) {
+		fmt.Fprintf(&result, "🏁 Top %d of %d open tasks by urgency:\n", top, len(ranked))
+		ranked = ranked[:top]
+	}
+	for _, r := range ranked {
+		// Format includes the UID so the LLM knows what to pass to update_task
+		fmt.Fprintf(&result, "- [ ] %s [Urgency: %d] (UID: %s)\n", r.Task.Summary, r.Urgency.Score, r.Task.Uid)
+		fmt.Fprintf(&result, "  ↳ %s\n", r.Urgency.Explain())
+		if b := g.blockers(r.Task); len(b) > 0 {
+			fmt.Fprintf(&result, "  ⛔ Blocked by %s\n", summaries(b))
+		}
+		if done, total := g.progress(r.Task.Uid); total > 0 {
+			fmt.Fprintf(&result, "  📋 Subtasks: %d/%d done (%d%%)\n", done, total, done*100/total)
+		}
 	}
 
+	output := result.String()
 	return &tools.ToolResult{
 		ForLLM:  output,
 		ForUser: output,
 	}
 }
 
-// calculateUrgency mathematically weighs the xCal properties
-// to instantly prioritize the user's workload without an LLM.
-func (s *ATCSkill) calculateUrgency(t VTodo) int {
-	urgency := 50
-
-	// RFC 5545 / 6321 defines priority: 1 is highest, 9 is lowest, 0 is undefined
-	p := t.Properties.Priority
-	if p == 1 || p == 2 {
-		urgency += 40
-	} else if p >= 3 && p <= 5 {
-		urgency += 20
-	} else if p > 5 {
-		urgency += 5
-	}
-
-	// Add due date pressure
-	if t.Properties.Due != "" || t.Properties.DueDate != "" {
-		urgency += 10
-	}
-
-	if urgency > 100 {
-		return 100
-	}
-	return urgency
-}
-
 // ----------------------------------------------------------------------------
 // TOOL: read_calendar
-// Reads memory/events.xml, parsing xCal VEvents securely via time.Parse.
+// Shows memory/events.xml as a day, week or agenda view, or for an explicit
+// from/to range, with recurring events expanded.
 // ----------------------------------------------------------------------------
 func (s *ATCSkill) executeReadCalendar(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
 	eventsPath := filepath.Join(s.workspace, "memory", "events.xml")
 
 	// Establish local TimeZone boundary for "Today"
-	now := time.Now()
+	now := s.now()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+
+	view := strings.ToLower(getString(args, "view"))
+	from, to := startOfDay, startOfDay.AddDate(0, 0, 1)
+	switch view {
+	case "", "day":
+	case "week":
+		to = startOfDay.AddDate(0, 0, 7)
+	case "agenda":
+		from, to = now, startOfDay.AddDate(0, 0, 14)
+	default:
+		return tools.ErrorResult("view must be day, week or agenda")
+	}
+	if v := getString(args, "from"); v != "" {
+		t, _, err := parseAgendaTime(v, now)
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		span := to.Sub(from)
+		from, to = t, t.Add(span)
+		if view == "agenda" {
+			to = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 14)
+		}
+	}
+	if v := getString(args, "to"); v != "" {
+		t, dateOnly, err := parseAgendaTime(v, now)
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		return tools.ErrorResult("'to' must be after 'from'")
+	}
+	if to.Sub(from) > maxAgendaDays*24*time.Hour {
+		return tools.ErrorResult(fmt.Sprintf("Range too long; read at most %d days at a time.", maxAgendaDays))
+	}
 
 	data, err := os.ReadFile(eventsPath)
 	if err != nil {
@@ -268,40 +511,99 @@ func (s *ATCSkill) executeReadCalendar(ctx context.Context, args map[string]inte
 		return tools.ErrorResult(fmt.Sprintf("Failed to parse events.xml: %v", err))
 	}
 
-	var events strings.Builder
-	for _, event := range cal.VCal.Components.VEvents {
-		dtStartStr := event.Properties.Dtstart
-		if dtStartStr == "" {
-			dtStartStr = event.Properties.DtstartDate
+	output := renderAgenda(eventOccurrences(cal, from, to), from, to, view == "week")
+	if output == "" {
+		output = "No calendar events found for today."
+		if view != "" && view != "day" || getString(args, "from") != "" || getString(args, "to") != "" {
+			output = fmt.Sprintf("No calendar events between %s and %s.", from.Format("Mon 2 Jan 15:04"), to.Format("Mon 2 Jan 15:04"))
 		}
+	}
+
+	return &tools.ToolResult{
+		ForLLM:  output,
+		ForUser: output,
+	}
+}
 
-		// Parse the RFC3339 timestamp securely.
-		dtStart, err := time.Parse(time.RFC3339, dtStartStr)
+// ----------------------------------------------------------------------------
+// TOOL: plan_day
+// Time-blocks the day's tasks into the gaps between events.xml events.
+// ----------------------------------------------------------------------------
+func (s *ATCSkill) executePlanDay(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day := today
+	switch d := strings.ToLower(getString(args, "date")); d {
+	case "", "today":
+	case "tomorrow":
+		day = today.AddDate(0, 0, 1)
+	default:
+		t, err := time.ParseInLocation("2006-01-02", d, now.Location())
 		if err != nil {
-			// Fallback: If it's just a raw date like "2026-02-20", try parsing that.
-			dtStart, err = time.Parse("2006-01-02", dtStartStr)
-			if err != nil {
-				continue
-			}
+			return tools.ErrorResult("date must be today, tomorrow or YYYY-MM-DD")
 		}
+		day = t
+	}
 
-		// Convert UTC parsing to Local TimeZone to match user's perspective.
-		dtStartLocal := dtStart.Local()
-
-		if (dtStartLocal.Equal(startOfDay) || dtStartLocal.After(startOfDay)) && dtStartLocal.Before(endOfDay) {
-			events.WriteString(fmt.Sprintf("• %s - %s\n", dtStartLocal.Format("15:04"), event.Properties.Summary))
-		}
+	cfg := LoadPlannerConfig()
+	from, to, err := cfg.window(day)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Invalid tools.planner config: %v", err))
+	}
+	if day.Equal(today) && now.After(from) {
+		from = now.Truncate(5 * time.Minute).Add(5 * time.Minute)
+	}
+	if !from.Before(to) {
+		return tools.ErrorResult(fmt.Sprintf("Working hours (%s–%s) are over for today; plan date=tomorrow instead.", cfg.WorkStart, cfg.WorkEnd))
 	}
 
-	output := events.String()
-	if output == "" {
-		output = "No calendar events found for today."
+	var tasks ICalendar
+	data, err := os.ReadFile(filepath.Join(s.workspace, "memory", "tasks.xml"))
+	if err != nil {
+		return tools.ErrorResult("tasks.xml file not found.")
+	}
+	if err := xml.Unmarshal(data, &tasks); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to parse tasks.xml: %v", err))
+	}
+	var events ICalendar
+	if data, err := os.ReadFile(filepath.Join(s.workspace, "memory", "events.xml")); err == nil {
+		if err := xml.Unmarshal(data, &events); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to parse events.xml: %v", err))
+		}
 	}
 
-	return &tools.ToolResult{
-		ForLLM:  output,
-		ForUser: output,
+	var history map[string]TaskHistory
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		var err error
+		history, err = st.History()
+		return err
+	})
+	g := newTaskGraph(tasks.VCal.Components.VTodos)
+	ranked := rankTasks(tasksForDay(tasks.VCal.Components.VTodos, day, today), g, history, LoadUrgencyWeights(), now)
+	spans, allDay := dayEvents(events, from, to)
+	plan := planDay(ranked, g, spans, from, to, cfg)
+	plan.AllDay = allDay
+	out := plan.String()
+
+	if push, _ := args["push"].(bool); push && len(plan.Blocks) > 0 {
+		atcCfg, err := s.loadConfig()
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		if atcCfg.Host == "" {
+			return tools.ErrorResult("host not configured in config.json tools.nextcloud")
+		}
+		removed, err := pushTimeBlocks(atcCfg, cfg.Calendar, plan, now)
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("%s\n\nFailed to push time blocks: %v", out, err))
+		}
+		out += fmt.Sprintf("\n📤 Pushed %d block(s) to the '%s' calendar", len(plan.Blocks), cfg.Calendar)
+		if removed > 0 {
+			out += fmt.Sprintf(", replacing %d from an earlier plan", removed)
+		}
+		out += "."
 	}
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
 }
 
 // ----------------------------------------------------------------------------
@@ -347,62 +649,173 @@ func (s *ATCSkill) executeExtractKeywords(ctx context.Context, args map[string]i
 }
 
 // ----------------------------------------------------------------------------
-// TOOL: update_task
-// Edits tasks.xml to change the status of a specific VTodo.
+// TOOL: update_task / create_local_task / delete_local_task
+// Edits tasks.xml directly: any field push_task supports, by UID.
+// See localtask.go.
 // ----------------------------------------------------------------------------
 func (s *ATCSkill) executeUpdateTask(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
 	uid, ok := args["task_uid"].(string)
 	if !ok || uid == "" {
 		return tools.ErrorResult("task_uid parameter is required for update_task")
 	}
-	newStatus, ok := args["status"].(string)
-	if !ok || newStatus == "" {
-		return tools.ErrorResult("status parameter is required for update_task")
-	}
-
-	tasksPath := filepath.Join(s.workspace, "memory", "tasks.xml")
-	data, err := os.ReadFile(tasksPath)
+	edit, err := parseTaskEdit(args)
 	if err != nil {
-		return tools.ErrorResult("tasks.xml file not found.")
+		return tools.ErrorResult(err.Error())
 	}
-
-	var cal ICalendar
-	if err := xml.Unmarshal(data, &cal); err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to parse tasks.xml: %v", err))
+	if edit.empty() {
+		return tools.ErrorResult("update_task needs at least one field to change: status, summary, notes, priority, due, start, categories, percent_complete, estimate, parent or depends_on")
 	}
 
-	found := false
+	cal, tasksPath, err := s.loadTasks()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	var updated *VTodoProperties
 	for i, todo := range cal.VCal.Components.VTodos {
 		if todo.Properties.Uid == uid {
-			cal.VCal.Components.VTodos[i].Properties.Status = strings.ToUpper(newStatus)
-			found = true
+			updated = &cal.VCal.Components.VTodos[i].Properties
 			break
 		}
 	}
-
-	if !found {
+	if updated == nil {
 		return tools.ErrorResult(fmt.Sprintf("Task UID %s not found in XML file.", uid))
 	}
+	wasStatus := updated.Status
 
-	// Dump XML mapping back to string securely.
-	outputBytes, err := xml.MarshalIndent(cal, "", "  ")
+	now := s.now()
+	changed, done, err := edit.apply(updated, taskUIDs(cal), now)
 	if err != nil {
-		return tools.ErrorResult("Failed to marshal updated task data.")
+		return tools.ErrorResult(fmt.Sprintf("Failed to update task %s: %v", uid, err))
 	}
-
-	// Prepend standard XML header
-	finalData := append([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"), outputBytes...)
-	if err := os.WriteFile(tasksPath, finalData, 0644); err != nil {
-		return tools.ErrorResult("Failed to write updated XML to disk.")
+	if err := saveTasks(cal, tasksPath); err != nil {
+		return tools.ErrorResult(err.Error())
 	}
 
-	msg := fmt.Sprintf("Successfully updated task %s to status %s.", uid, newStatus)
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		if err := st.Observe(cal.VCal.Components.VTodos, now); err != nil {
+			return err
+		}
+		switch {
+		case edit.Status == "" && strings.EqualFold(wasStatus, updated.Status):
+			return nil
+		case done.Recurring || strings.EqualFold(updated.Status, "COMPLETED"):
+			return recordCompletion(st, *updated, done, now)
+		}
+		return st.Record(*updated, statusEvent(updated.Status), now)
+	})
+
+	msg := fmt.Sprintf("✏️ Updated %s (UID: %s): %s.", updated.Summary, uid, strings.Join(changed, ", "))
+	msg += completionNote(done, now.Location())
+	if isFinished(updated.Status) {
+		g := newTaskGraph(cal.VCal.Components.VTodos)
+		for _, t := range g.unblockedBy(uid) {
+			msg += fmt.Sprintf("\n➡️ Now unblocked: %s (UID: %s)", t.Summary, t.Uid)
+		}
+		if parent, ok := g.byUID[updated.Parent()]; ok {
+			done, total := g.progress(parent.Uid)
+			msg += fmt.Sprintf("\n📋 %s: %d/%d subtasks done", parent.Summary, done, total)
+			if done == total && !isFinished(parent.Status) {
+				msg += " — ready to complete"
+			}
+		}
+	}
 	return &tools.ToolResult{
 		ForLLM:  msg,
 		ForUser: msg,
 	}
 }
 
+func (s *ATCSkill) executeCreateLocalTask(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	edit, err := parseTaskEdit(args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	now := s.now()
+	var preview string
+	if text := getString(args, "text"); text != "" {
+		q := ParseQuickAdd(text, now)
+		edit.fillFrom(q)
+		preview = q.Preview()
+	}
+	if edit.Summary == nil {
+		return tools.ErrorResult("summary or text parameter is required for create_local_task")
+	}
+	if preview != "" && args["preview"] == true {
+		msg := "Parsed task (not created yet; call create_local_task again without preview to add it):\n" + preview
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+
+	cal, tasksPath, err := s.loadTasks()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	task := VTodoProperties{
+		Uid:     fmt.Sprintf("atc-task-%d", time.Now().UnixNano()),
+		Dtstamp: time.Now().UTC().Format(time.RFC3339),
+		Status:  "NEEDS-ACTION",
+	}
+	if _, _, err := edit.apply(&task, taskUIDs(cal), now); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to create task: %v", err))
+	}
+	if cal.VCal.Properties.Version == "" {
+		cal.VCal.Properties = VCalProperties{Version: "2.0", Prodid: "-//Son of Anthon//ATC Agent//EN"}
+	}
+	cal.VCal.Components.VTodos = append(cal.VCal.Components.VTodos, VTodo{Properties: task})
+	if err := saveTasks(cal, tasksPath); err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		if err := st.Record(task, EventCreated, now); err != nil {
+			return err
+		}
+		return st.Record(task, statusEvent(task.Status), now)
+	})
+
+	msg := fmt.Sprintf("✅ Task '%s' added to tasks.xml (UID: %s). sync_tasks takes it to Nextcloud.", task.Summary, task.Uid)
+	if preview != "" {
+		msg += "\n" + preview
+	}
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func (s *ATCSkill) executeDeleteLocalTask(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	uid := getString(args, "task_uid")
+	if uid == "" {
+		return tools.ErrorResult("task_uid parameter is required for delete_local_task")
+	}
+	cal, tasksPath, err := s.loadTasks()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	removed, detached, freed, ok := removeTask(&cal, uid)
+	if !ok {
+		return tools.ErrorResult(fmt.Sprintf("Task UID %s not found in XML file.", uid))
+	}
+	if err := saveTasks(cal, tasksPath); err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		if _, err := st.StopSessions(uid, now); err != nil {
+			return err
+		}
+		if isFinished(removed.Status) {
+			return nil
+		}
+		return st.Record(removed, EventCancelled, now)
+	})
+
+	msg := fmt.Sprintf("🗑️ Deleted %s (UID: %s) from tasks.xml; sync_tasks deletes it on Nextcloud too.", removed.Summary, uid)
+	for _, t := range detached {
+		msg += fmt.Sprintf("\n↩️ No longer a subtask: %s (UID: %s)", t.Summary, t.Uid)
+	}
+	for _, t := range freed {
+		msg += fmt.Sprintf("\n➡️ No longer waiting on it: %s (UID: %s)", t.Summary, t.Uid)
+	}
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
 // ----------------------------------------------------------------------------
 // TOOL: roll_over_tasks
 // Checks tasks.xml for 'Today' tasks that weren't completed and shifts them.
@@ -419,7 +832,7 @@ func (s *ATCSkill) executeRollOverTasks(ctx context.Context, args map[string]int
 		return tools.ErrorResult(fmt.Sprintf("Failed to parse tasks.xml: %v", err))
 	}
 
-	rolledCount := 0
+	var rolled []VTodoProperties
 	for i, todo := range cal.VCal.Components.VTodos {
 		category := strings.ToLower(todo.Properties.Categories)
 		status := strings.ToUpper(todo.Properties.Status)
@@ -432,9 +845,11 @@ func (s *ATCSkill) executeRollOverTasks(ctx context.Context, args map[string]int
 				newCategory = "tomorrow" // Fallback string rewriting
 			}
 			cal.VCal.Components.VTodos[i].Properties.Categories = newCategory
-			rolledCount++
+			cal.VCal.Components.VTodos[i].Properties.LastModified = time.Now().UTC().Format(time.RFC3339)
+			rolled = append(rolled, todo.Properties)
 		}
 	}
+	rolledCount := len(rolled)
 
 	if rolledCount > 0 {
 		outputBytes, err := xml.MarshalIndent(cal, "", "  ")
@@ -446,6 +861,28 @@ func (s *ATCSkill) executeRollOverTasks(ctx context.Context, args map[string]int
 	}
 
 	msg := fmt.Sprintf("Successfully rolled over %d pending 'Today' tasks into 'Tomorrow'.", rolledCount)
+
+	// Close the day in the event log and leave Chief the evening stats.
+	var report string
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		if err := st.Observe(cal.VCal.Components.VTodos, now); err != nil {
+			return err
+		}
+		for _, t := range rolled {
+			if err := st.Record(t, EventRolledOver, now); err != nil {
+				return err
+			}
+		}
+		if err := st.CloseDay(now); err != nil {
+			return err
+		}
+		var err error
+		report, err = st.WriteReport(s.statsReportPath(), now)
+		return err
+	})
+	if report != "" {
+		msg += "\n\n📊 Today's stats (written to " + StatsReportName + "):\n" + report
+	}
 	return &tools.ToolResult{
 		ForLLM:  msg,
 		ForUser: msg,
@@ -453,50 +890,460 @@ func (s *ATCSkill) executeRollOverTasks(ctx context.Context, args map[string]int
 }
 
 // ----------------------------------------------------------------------------
-// TOOL: sync_calendar
-// Fetches remote generic .ics subscription URLs into local xCal events.xml
+// TOOL: stats
+// Computes productivity stats from the task event log and refreshes
+// stats-today.md in Chief's memory.
+// ----------------------------------------------------------------------------
+func (s *ATCSkill) executeStats(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	st, err := s.openStats()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Stats database unavailable: %v", err))
+	}
+	defer st.Close()
+
+	now := s.now()
+	if data, err := os.ReadFile(filepath.Join(s.workspace, "memory", "tasks.xml")); err == nil {
+		var cal ICalendar
+		if xml.Unmarshal(data, &cal) == nil {
+			st.Observe(cal.VCal.Components.VTodos, now)
+		}
+	}
+	report, err := st.WriteReport(s.statsReportPath(), now)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to compute stats: %v", err))
+	}
+	out := "📊 Productivity stats\n" + report
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
+
 // ----------------------------------------------------------------------------
-func buildCalendarURL(cfg ATCCalendarConfig) string {
-	return caldav.BuildCalendarURL(cfg.Host, cfg.Username)
+// TOOL: find_tasks
+// Filters tasks.xml (and, with scope=all, live Nextcloud tasks) with the
+// query language in query.go.
+// ----------------------------------------------------------------------------
+func (s *ATCSkill) executeFindTasks(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := s.now()
+	q := strings.TrimSpace(getString(args, "query"))
+	if q == "" {
+		q = "is:open"
+	}
+	if v := getString(args, "sort"); v != "" {
+		q += " sort:" + v
+	}
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		q += fmt.Sprintf(" limit:%d", int(v))
+	}
+	query, err := parseTaskQuery(q, now)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Bad query: %v", err))
+	}
+
+	cal, _, err := s.loadTasks()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	hrefs := map[string]string{}
+	syncPath := filepath.Join(s.workspace, "memory", SyncFileName)
+	if _, err := os.Stat(syncPath); err == nil {
+		if store, err := openSyncStore(syncPath); err == nil {
+			if m, err := store.hrefs(); err == nil {
+				hrefs = m
+			}
+			store.Close()
+		}
+	}
+	var rows []taskRow
+	local := map[string]bool{}
+	for _, t := range cal.VCal.Components.VTodos {
+		local[t.Properties.Uid] = true
+		rows = append(rows, taskRow{Task: t.Properties, Href: hrefs[t.Properties.Uid]})
+	}
+	todos := cal.VCal.Components.VTodos
+	if strings.EqualFold(getString(args, "scope"), "all") {
+		cfg, err := s.loadConfig()
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		if cfg.Host == "" {
+			return tools.ErrorResult("scope=all needs host under tools.nextcloud.")
+		}
+		remote, err := fetchRemoteTodos(cfg, now.Location())
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to fetch Nextcloud tasks: %v", err))
+		}
+		for _, r := range remote {
+			if local[r.Props.Uid] {
+				for i := range rows {
+					if rows[i].Task.Uid == r.Props.Uid {
+						rows[i].Href = r.Href
+					}
+				}
+				continue
+			}
+			rows = append(rows, taskRow{Task: r.Props, Href: r.Href, Remote: true})
+			todos = append(todos, VTodo{Properties: r.Props})
+		}
+	}
+
+	var history map[string]TaskHistory
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		var err error
+		history, err = st.History()
+		return err
+	})
+	env := queryEnv{now: now, g: newTaskGraph(todos)}
+	found, urgency, total := query.run(rows, env, LoadUrgencyWeights(), history)
+	if total == 0 {
+		msg := fmt.Sprintf("No tasks match %q (%d searched).", q, len(rows))
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔎 %d of %d tasks match %q", total, len(rows), q)
+	if len(found) < total {
+		fmt.Fprintf(&sb, " (showing %d)", len(found))
+	}
+	sb.WriteString(":\n")
+	for _, r := range found {
+		sb.WriteString(formatTaskRow(r, urgency[r.Task.Uid], now.Location()) + "\n")
+	}
+	output := strings.TrimRight(sb.String(), "\n")
+	return &tools.ToolResult{ForLLM: output, ForUser: output}
 }
 
-func (s *ATCSkill) executeSyncCalendar(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	// Load ATC config - reads calendar_url, calendar_username, calendar_password from config.json
-	atcCfg := loadATCConfig()
+// ----------------------------------------------------------------------------
+// TOOL: import_tasks / export_tasks
+// Moves tasks between tasks.xml and Taskwarrior, Todoist, CSV and .ics files.
+// ----------------------------------------------------------------------------
+func (s *ATCSkill) executeImportTasks(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	var data []byte
+	if path := getString(args, "path"); path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.workspace, path)
+		}
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Cannot read %s: %v", path, err))
+		}
+	} else if text := getString(args, "text"); text != "" {
+		data = []byte(text)
+	} else {
+		return tools.ErrorResult("import_tasks needs 'path' (a file) or 'text' (the file's contents).")
+	}
+	dryRun, _ := args["dry_run"].(bool)
+	msg, err := s.ImportTasks(data, strings.ToLower(getString(args, "format")), getString(args, "project"), dryRun)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Import failed: %v", err))
+	}
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
 
-	// Fall back to environment variable if config is empty
-	calendarURL := buildCalendarURL(atcCfg)
-	if atcCfg.Host == "" {
-		calendarURL = os.Getenv("ATC_CALENDAR_URL")
+func (s *ATCSkill) executeExportTasks(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	format := strings.ToLower(getString(args, "format"))
+	if format == "" {
+		format = "csv"
+	}
+	openOnly, _ := args["open_only"].(bool)
+	data, n, err := s.ExportTasks(format, openOnly)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Export failed: %v", err))
+	}
+	path := getString(args, "path")
+	if path == "" {
+		path = filepath.Join("memory", "exports", fmt.Sprintf("tasks-%s-%s%s", format, s.now().Format("2006-01-02"), taskFormats[format]))
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.workspace, path)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to write %s: %v", path, err))
+	}
+	msg := fmt.Sprintf("📤 Exported %d tasks as %s to %s", n, format, path)
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+// ----------------------------------------------------------------------------
+// TOOL: start_timer / stop_timer / pomodoro / time_report
+// Time tracking per task in stats.db; see timer.go.
+// ----------------------------------------------------------------------------
+
+// timedTask looks up the task a session is for, so its summary and
+// categories are kept even if the task is later renamed or deleted.
+func (s *ATCSkill) timedTask(args map[string]interface{}) (VTodoProperties, error) {
+	uid := getString(args, "task_uid")
+	if uid == "" {
+		return VTodoProperties{}, fmt.Errorf("task_uid is required; use find_tasks or analyze_tasks to get it")
+	}
+	cal, _, err := s.loadTasks()
+	if err != nil {
+		return VTodoProperties{}, err
+	}
+	for _, td := range cal.VCal.Components.VTodos {
+		if td.Properties.Uid == uid {
+			return td.Properties, nil
+		}
+	}
+	return VTodoProperties{}, fmt.Errorf("task UID %s not found in tasks.xml", uid)
+}
+
+// startSession opens sess, closing whatever ran before, and describes both.
+func (s *ATCSkill) startSession(ctx context.Context, sess TimeSession) (TimeSession, string, error) {
+	st, err := s.openStats()
+	if err != nil {
+		return sess, "", err
+	}
+	defer st.Close()
+	sess.Channel, sess.ChatID = s.replyTo(ctx)
+	sess, stopped, err := st.StartSession(sess)
+	if err != nil {
+		return sess, "", err
+	}
+	s.disarm(stopped)
+	return sess, s.describeStopped(st, stopped), nil
+}
+
+// describeStopped saves the totals of the tasks whose sessions just ended
+// and reports one line per session.
+func (s *ATCSkill) describeStopped(st *StatsStore, stopped []TimeSession) string {
+	var sb strings.Builder
+	for _, t := range stopped {
+		if t.Kind == SessionBreak {
+			sb.WriteString("\n☕ Break ended.")
+			continue
+		}
+		total := s.writeTimeSpent(st, t.UID)
+		fmt.Fprintf(&sb, "\n⏹️ Stopped %s on %s after %s (total %s).", t.Kind, t.Summary, formatSpan(t.Spent(t.End)), formatSpan(total))
+	}
+	return sb.String()
+}
+
+func (s *ATCSkill) executeStartTimer(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	task, err := s.timedTask(args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	now := s.now()
+	_, stopped, err := s.startSession(ctx, TimeSession{UID: task.Uid, Summary: task.Summary, Categories: task.Categories, Kind: SessionTimer, Start: now})
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to start timer: %v", err))
+	}
+	msg := fmt.Sprintf("⏱️ Timer started on %s at %s.", task.Summary, now.Format("15:04")) + stopped
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func (s *ATCSkill) executeStopTimer(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	uid := getString(args, "task_uid")
+	st, err := s.openStats()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to open stats.db: %v", err))
+	}
+	defer st.Close()
+	stopped, err := st.StopSessions(uid, s.now())
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to stop timer: %v", err))
+	}
+	s.disarm(stopped)
+	if len(stopped) == 0 {
+		msg := "No timer is running."
+		if uid != "" {
+			msg = fmt.Sprintf("No timer is running for task %s.", uid)
+		}
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+	msg := strings.TrimPrefix(s.describeStopped(st, stopped), "\n")
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func (s *ATCSkill) executePomodoro(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	task, err := s.timedTask(args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	work := time.Duration(LoadPomodoroConfig().WorkMinutes) * time.Minute
+	if v, ok := args["minutes"].(float64); ok && v > 0 {
+		work = time.Duration(v) * time.Minute
+	}
+	now := s.now()
+	sess, stopped, err := s.startSession(ctx, TimeSession{UID: task.Uid, Summary: task.Summary, Categories: task.Categories,
+		Kind: SessionPomodoro, Start: now, PlannedEnd: now.Add(work)})
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to start pomodoro: %v", err))
+	}
+	s.arm(sess)
+	msg := fmt.Sprintf("🍅 Pomodoro started on %s: %s, until %s.", task.Summary, formatSpan(work), sess.PlannedEnd.Format("15:04"))
+	if s.bus != nil && sess.ChatID != "" {
+		msg += " I'll message you when it's time for a break."
+	} else {
+		msg += " No chat is connected, so there will be no reminder; it stops counting at the end."
+	}
+	msg += stopped
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func (s *ATCSkill) executeTimeReport(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from, to := today.AddDate(0, 0, -6), today.AddDate(0, 0, 1)
+	if v := getString(args, "from"); v != "" {
+		t, _, err := parseAgendaTime(v, now)
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		from = t
+	}
+	if v := getString(args, "to"); v != "" {
+		t, dateOnly, err := parseAgendaTime(v, now)
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		return tools.ErrorResult("'to' must be after 'from'")
+	}
+
+	st, err := s.openStats()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to open stats.db: %v", err))
+	}
+	defer st.Close()
+	rep, err := st.TimeReport(from, to, now)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to read time sessions: %v", err))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⏱️ Time tracked %s – %s: %s", from.Format("Mon 2 Jan"), to.Add(-time.Second).Format("Mon 2 Jan"), formatSpan(rep.Total))
+	if rep.Pomodoros > 0 {
+		fmt.Fprintf(&sb, " (%d 🍅)", rep.Pomodoros)
+	}
+	sb.WriteString("\n")
+	if rep.Total > 0 {
+		fmt.Fprintf(&sb, "\nBy day: %s\n", formatTotals(rep.Days, len(rep.Days)))
+		fmt.Fprintf(&sb, "By category: %s\n", formatTotals(rep.Categories, len(rep.Categories)))
+		sb.WriteString("By task:\n")
+		for _, t := range rep.Tasks {
+			fmt.Fprintf(&sb, "- %s (UID: %s): %s\n", t.Label, t.Key, formatSpan(t.Spent))
+		}
+	}
+	for _, t := range rep.Running {
+		switch {
+		case t.Kind == SessionBreak:
+			fmt.Fprintf(&sb, "\n☕ On a break until %s.", t.PlannedEnd.In(now.Location()).Format("15:04"))
+		case t.PlannedEnd.IsZero():
+			fmt.Fprintf(&sb, "\n▶️ Timer running on %s for %s.", t.Summary, formatSpan(t.Spent(now)))
+		default:
+			fmt.Fprintf(&sb, "\n🍅 Pomodoro on %s until %s.", t.Summary, t.PlannedEnd.In(now.Location()).Format("15:04"))
+		}
+	}
+	out := strings.TrimRight(sb.String(), "\n")
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
+
+// ----------------------------------------------------------------------------
+// TOOL: sync_tasks
+// Two-way sync between tasks.xml and the Nextcloud tasks collection.
+// ----------------------------------------------------------------------------
+func (s *ATCSkill) executeSyncTasks(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	policy, _ := args["conflict"].(string)
+	switch policy {
+	case "":
+		policy = ConflictNewest
+	case ConflictNewest, ConflictLocal, ConflictRemote:
+	default:
+		return tools.ErrorResult(fmt.Sprintf("conflict must be %s, %s or %s", ConflictNewest, ConflictLocal, ConflictRemote))
+	}
+
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
 	}
-	if calendarURL == "" {
-		return tools.ErrorResult("No host configured. Set host in config.json under tools.nextcloud, or set the ATC_CALENDAR_URL environment variable.")
+	if cfg.Host == "" {
+		return tools.ErrorResult("Nextcloud is not configured (tools.nextcloud.host).")
 	}
 
-	lines, err := fetchICS(calendarURL, atcCfg.Username, atcCfg.Password)
+	tasksPath := filepath.Join(s.workspace, "memory", "tasks.xml")
+	data, err := os.ReadFile(tasksPath)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to fetch external calendar: %v", err))
+		return tools.ErrorResult("tasks.xml file not found.")
+	}
+	var cal ICalendar
+	if err := xml.Unmarshal(data, &cal); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to parse tasks.xml: %v", err))
 	}
 
-	cal := parseICS(lines)
-	if cal == nil || len(cal.VCal.Components.VEvents) == 0 {
-		return tools.ErrorResult("Failed to parse external iCal data or no events found.")
+	now := s.now()
+	remote, err := fetchRemoteTodos(cfg, now.Location())
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to fetch Nextcloud tasks: %v", err))
 	}
 
-	// Hardcode the absolute workspace path since the LLM executor context might be running under 'monitor' or 'chief'
-	eventsPath := filepath.Join("workspaces", "atc", "memory", "events.xml")
-	outputBytes, err := xml.MarshalIndent(cal, "", "  ")
+	store, err := openSyncStore(filepath.Join(s.workspace, "memory", SyncFileName))
 	if err != nil {
-		return tools.ErrorResult("Failed to marshal synced calendar data.")
+		return tools.ErrorResult(fmt.Sprintf("Sync database unavailable: %v", err))
+	}
+	defer store.Close()
+	state, tombs, err := store.load(now)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to read sync state: %v", err))
 	}
 
-	finalData := append([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"), outputBytes...)
-	if err := os.WriteFile(eventsPath, finalData, 0644); err != nil {
-		return tools.ErrorResult("Failed to locally save synced events.xml.")
+	local := make([]VTodoProperties, 0, len(cal.VCal.Components.VTodos))
+	for _, t := range cal.VCal.Components.VTodos {
+		local = append(local, t.Properties)
 	}
+	plan := reconcile(local, remote, state, tombs, policy, now)
+	res, changed, deleted := applySync(cfg, &cal, store, state, plan, now)
+
+	if len(changed)+len(deleted) > 0 {
+		outputBytes, err := xml.MarshalIndent(cal, "", "  ")
+		if err != nil {
+			return tools.ErrorResult("Failed to marshal synced task data.")
+		}
+		finalData := append([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"), outputBytes...)
+		if err := os.WriteFile(tasksPath, finalData, 0644); err != nil {
+			return tools.ErrorResult("Failed to write updated XML to disk.")
+		}
+		s.recordEvents(func(st *StatsStore, now time.Time) error {
+			if err := st.Observe(cal.VCal.Components.VTodos, now); err != nil {
+				return err
+			}
+			for _, t := range changed {
+				if err := st.Record(t, statusEvent(t.Status), now); err != nil {
+					return err
+				}
+			}
+			for _, t := range deleted {
+				if err := st.Record(t, EventCancelled, now); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	msg := fmt.Sprintf("🔄 Synced %d local and %d Nextcloud tasks (conflicts: %s wins).\n%s", len(local), len(remote), policy, res)
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
 
-	count := len(cal.VCal.Components.VEvents)
-	msg := fmt.Sprintf("Successfully synced %d events from Nextcloud (%s). Saved to events.xml.", count, calendarURL)
+// ----------------------------------------------------------------------------
+// TOOL: sync_calendar
+// Merges the tools.calendars sources (Nextcloud calendars and .ics
+// subscriptions) into the local xCal events.xml, one source at a time.
+// ----------------------------------------------------------------------------
+func (s *ATCSkill) executeSyncCalendar(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	srcs := s.calendarSources()
+	if len(srcs) == 0 {
+		return tools.ErrorResult("No calendar configured. Add sources under tools.calendars, set host under tools.nextcloud, or set the ATC_CALENDAR_URL environment variable.")
+	}
+	force, _ := args["force"].(bool)
+	msg, err := s.syncCalendarSources(srcs, getString(args, "source"), force)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Calendar sync failed: %v", err))
+	}
 	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
 }
 
@@ -506,32 +1353,82 @@ func (s *ATCSkill) executeSyncCalendar(ctx context.Context, args map[string]inte
 // ----------------------------------------------------------------------------
 func (s *ATCSkill) executePushTask(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
 	summary, _ := args["summary"].(string)
-	if summary == "" {
-		return tools.ErrorResult("summary parameter is required for push_task")
+
+	// Quick-add text fills in whatever the explicit args leave empty.
+	now := s.now()
+	text := getString(args, "text")
+	var q QuickTask
+	if text != "" {
+		q = ParseQuickAdd(text, now)
+	}
+	if summary != "" {
+		q.Summary = summary
+	}
+	if q.Summary == "" {
+		return tools.ErrorResult("summary or text parameter is required for push_task")
+	}
+
+	// Explicit LLM args win over parsed ones, before the preview is built,
+	// so the preview describes the task that gets created.
+	for _, f := range []struct {
+		key    string
+		t      *time.Time
+		allDay *bool
+	}{{"due", &q.Due, &q.DueAllDay}, {"start", &q.Start, &q.StartAll}} {
+		if v := getString(args, f.key); v != "" {
+			t, allDay, err := parseEditTime(v, now)
+			if err != nil {
+				return tools.ErrorResult(fmt.Sprintf("%s: %v", f.key, err))
+			}
+			*f.t, *f.allDay = t, allDay
+		}
 	}
+	if v := getString(args, "estimate"); v != "" {
+		if q.Estimate = parseEstimate(v); formatEstimate(q.Estimate) == "" {
+			return tools.ErrorResult(fmt.Sprintf("bad estimate %q (e.g. 45m, 1h30m or PT45M)", v))
+		}
+	}
+	if p, ok := args["priority"].(float64); ok {
+		q.Priority = int(p)
+	}
+	summary = q.Summary
 
-	atcCfg := loadATCConfig()
-	if atcCfg.Host == "" {
-		return tools.ErrorResult("host not configured in config.json tools.nextcloud")
+	var preview string
+	if text != "" {
+		preview = q.Preview()
+	}
+	if preview != "" && args["preview"] == true {
+		msg := "Parsed task (not pushed yet; call push_task again without preview to create it):\n" + preview
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
 	}
 
-	// Build TaskOptions from optional LLM args
-	opts := TaskOptions{
-		Due:   getString(args, "due"),
-		Start: getString(args, "start"),
-		Notes: getString(args, "notes"),
+	atcCfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
 	}
-	if p, ok := args["priority"].(float64); ok {
-		opts.Priority = int(p)
+	if atcCfg.Host == "" {
+		return tools.ErrorResult("host not configured in config.json tools.nextcloud")
 	}
 
+	opts := q.Options()
+	opts.Notes = getString(args, "notes")
+	opts.Parent = getString(args, "parent")
+	opts.DependsOn = getList(args, "depends_on")
+
 	taskUID := fmt.Sprintf("atc-task-%d", time.Now().UnixNano())
 
 	if err := pushTaskToCalDAV(atcCfg, taskUID, summary, opts); err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Failed to push task to Nextcloud: %v", err))
 	}
 
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		return st.Record(VTodoProperties{Uid: taskUID, Summary: summary, Priority: opts.Priority}, EventCreated, now)
+	})
+
 	msg := fmt.Sprintf("✅ Task '%s' successfully pushed to your Nextcloud Tasks (UID: %s).", summary, taskUID)
+	if preview != "" {
+		msg += "\n" + preview
+	}
 	return &tools.ToolResult{
 		ForLLM:  msg,
 		ForUser: msg,
@@ -544,12 +1441,36 @@ func getString(args map[string]interface{}, key string) string {
 	return v
 }
 
+// getList extracts a list of strings given either as an array or as a
+// comma-separated string.
+func getList(args map[string]interface{}, key string) []string {
+	var out []string
+	switch v := args[key].(type) {
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	}
+	return out
+}
+
 // ----------------------------------------------------------------------------
 // TOOL: list_nextcloud_tasks
 // Does a CalDAV PROPFIND to list all task hrefs in Nextcloud tasks/ collection.
 // ----------------------------------------------------------------------------
 func (s *ATCSkill) executeListNextcloudTasks(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	atcCfg := loadATCConfig()
+	atcCfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	if atcCfg.Host == "" {
 		return tools.ErrorResult("host not configured in config.json tools.nextcloud")
 	}
@@ -582,7 +1503,10 @@ func (s *ATCSkill) executeDeleteTask(ctx context.Context, args map[string]interf
 		return tools.ErrorResult("task_href is required. Use list_nextcloud_tasks first to get the href paths.")
 	}
 
-	atcCfg := loadATCConfig()
+	atcCfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	if atcCfg.Host == "" {
 		return tools.ErrorResult("host not configured in config.json tools.nextcloud")
 	}
@@ -591,6 +1515,11 @@ func (s *ATCSkill) executeDeleteTask(ctx context.Context, args map[string]interf
 		return tools.ErrorResult(fmt.Sprintf("Failed to delete task: %v", err))
 	}
 
+	s.recordEvents(func(st *StatsStore, now time.Time) error {
+		uid := strings.TrimSuffix(filepath.Base(href), ".ics")
+		return st.Record(VTodoProperties{Uid: uid}, EventCancelled, now)
+	})
+
 	msg := fmt.Sprintf("🗑️ Task deleted: %s", href)
 	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
 }
@@ -604,14 +1533,17 @@ func (s *ATCSkill) executeGetTask(ctx context.Context, args map[string]interface
 	if href == "" {
 		return tools.ErrorResult("task_href is required. Use list_nextcloud_tasks to get the href paths.")
 	}
-	atcCfg := loadATCConfig()
+	atcCfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	fields, err := getTaskFromCalDAV(atcCfg, href)
 	if err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Failed to get task: %v", err))
 	}
 	var sb strings.Builder
 	sb.WriteString("Task details:\n")
-	for _, k := range []string{"SUMMARY", "UID", "STATUS", "PRIORITY", "DUE", "DTSTART", "DESCRIPTION", "LOCATION", "URL", "PERCENT-COMPLETE"} {
+	for _, k := range []string{"SUMMARY", "UID", "STATUS", "PRIORITY", "DUE", "DTSTART", "RRULE", "X-ESTIMATE", "X-TIME-SPENT", "DESCRIPTION", "LOCATION", "URL", "PERCENT-COMPLETE"} {
 		if v, ok := fields[k]; ok && v != "" {
 			sb.WriteString(fmt.Sprintf("  %s: %s\n", k, v))
 		}
@@ -629,22 +1561,43 @@ func (s *ATCSkill) executeMergeTask(ctx context.Context, args map[string]interfa
 	if href == "" {
 		return tools.ErrorResult("task_href is required. Use list_nextcloud_tasks to get the href paths.")
 	}
-	atcCfg := loadATCConfig()
+	estimate := getString(args, "estimate")
+	if estimate != "" && formatEstimate(parseEstimate(estimate)) == "" {
+		return tools.ErrorResult(fmt.Sprintf("bad estimate %q (e.g. 45m, 1h30m or PT45M)", estimate))
+	}
+	atcCfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	opts := TaskOptions{
-		Due:      getString(args, "due"),
-		Start:    getString(args, "start"),
-		Notes:    getString(args, "notes"),
-		Location: getString(args, "location"),
+		Due:       getString(args, "due"),
+		Start:     getString(args, "start"),
+		Notes:     getString(args, "notes"),
+		Location:  getString(args, "location"),
+		Parent:    getString(args, "parent"),
+		DependsOn: getList(args, "depends_on"),
+		Status:    getString(args, "status"),
+		Estimate:  formatEstimate(parseEstimate(estimate)),
 	}
 	if p, ok := args["priority"].(float64); ok {
 		opts.Priority = int(p)
 	}
 	newSummary := getString(args, "summary")
 
-	if err := mergeTaskOnCalDAV(atcCfg, href, opts, newSummary); err != nil {
+	now := s.now()
+	merged, done, err := mergeTaskOnCalDAV(atcCfg, href, opts, newSummary, now)
+	if err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Failed to merge task: %v", err))
 	}
+	if opts.Status != "" {
+		s.recordEvents(func(st *StatsStore, now time.Time) error {
+			if strings.EqualFold(opts.Status, "COMPLETED") {
+				return recordCompletion(st, merged, done, now)
+			}
+			return st.Record(merged, statusEvent(merged.Status), now)
+		})
+	}
 
-	msg := fmt.Sprintf("✏️ Task updated: %s", href)
+	msg := fmt.Sprintf("✏️ Task updated: %s", href) + completionNote(done, now.Location())
 	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
 }
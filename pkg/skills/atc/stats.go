@@ -0,0 +1,618 @@
+package atc
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+)
+
+// ----------------------------------------------------------------------------
+// Productivity statistics
+//
+// Every task lifecycle change ATC sees is appended to a small event log.
+// Daily and weekly figures are computed from it on demand and written to
+// Chief's memory as stats-today.md for the evening review.
+// ----------------------------------------------------------------------------
+
+const (
+	// StatsFileName is the event database inside ATC's memory directory.
+	StatsFileName = "stats.db"
+	// StatsReportName is the report Chief reads into the evening review.
+	StatsReportName = "stats-today.md"
+)
+
+// Task lifecycle events.
+const (
+	EventCreated    = "created"
+	EventStarted    = "started"
+	EventCompleted  = "completed"
+	EventRolledOver = "rolled_over"
+	EventCancelled  = "cancelled"
+	EventReopened   = "reopened" // a completed or cancelled task set back to NEEDS-ACTION
+
+	// eventDayClosed marks a roll-over run, so a day with nothing carried
+	// over can be told apart from a day ATC never looked at.
+	eventDayClosed = "day_closed"
+)
+
+const dayLayout = "2006-01-02"
+
+// statusEvent maps a VTODO status to the lifecycle event it records.
+func statusEvent(status string) string {
+	switch strings.ToUpper(status) {
+	case "COMPLETED":
+		return EventCompleted
+	case "IN-PROCESS":
+		return EventStarted
+	case "CANCELLED":
+		return EventCancelled
+	case "NEEDS-ACTION":
+		return EventReopened
+	}
+	return ""
+}
+
+// StatsStore is the SQLite-backed task event log.
+type StatsStore struct {
+	db *sql.DB
+}
+
+// OpenStats opens (creating if needed) the event database.
+func OpenStats(path string) (*StatsStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := `
+	CREATE TABLE IF NOT EXISTS task_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		summary TEXT,
+		priority INTEGER DEFAULT 0,
+		event TEXT NOT NULL,
+		at INTEGER NOT NULL,
+		day TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_events_day ON task_events(day, event);
+	CREATE INDEX IF NOT EXISTS idx_task_events_uid ON task_events(uid, event);
+	CREATE TABLE IF NOT EXISTS task_occurrences (
+		uid TEXT NOT NULL,
+		summary TEXT,
+		due TEXT NOT NULL,
+		completed_at INTEGER NOT NULL,
+		on_time INTEGER NOT NULL,
+		PRIMARY KEY (uid, due)
+	);` + timeSessionsSchema
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &StatsStore{db: db}, nil
+}
+
+// Close releases the database.
+func (s *StatsStore) Close() error {
+	return s.db.Close()
+}
+
+// Record appends one event. The day is taken from at's location, so callers
+// pass the user's local time. A task is only ever "created" once, carried
+// over once per day, and repeating its latest status event (completing it
+// twice) is a no-op. "reopened" is only logged for a task whose latest
+// status event is completed or cancelled, so completing it again counts.
+func (s *StatsStore) Record(t VTodoProperties, event string, at time.Time) error {
+	if t.Uid == "" || event == "" {
+		return nil
+	}
+	var n int
+	var err error
+	switch event {
+	case EventCreated:
+		err = s.db.QueryRow("SELECT COUNT(*) FROM task_events WHERE uid = ? AND event = ?", t.Uid, event).Scan(&n)
+	case EventRolledOver:
+		err = s.db.QueryRow("SELECT COUNT(*) FROM task_events WHERE uid = ? AND event = ? AND day = ?",
+			t.Uid, event, at.Format(dayLayout)).Scan(&n)
+	case EventReopened:
+		var last string
+		err = s.db.QueryRow("SELECT event FROM task_events WHERE uid = ? AND event IN (?, ?, ?, ?) ORDER BY id DESC LIMIT 1",
+			t.Uid, EventStarted, EventCompleted, EventCancelled, EventReopened).Scan(&last)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if last != EventCompleted && last != EventCancelled {
+			n = 1
+		}
+	default:
+		err = s.db.QueryRow(`SELECT COUNT(*) FROM task_events WHERE id = (
+			SELECT MAX(id) FROM task_events WHERE uid = ? AND event IN (?, ?, ?, ?)) AND event = ?`,
+			t.Uid, EventStarted, EventCompleted, EventCancelled, EventReopened, event).Scan(&n)
+	}
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = s.db.Exec("INSERT INTO task_events (uid, summary, priority, event, at, day) VALUES (?, ?, ?, ?, ?, ?)",
+		t.Uid, t.Summary, t.Priority, event, at.Unix(), at.Format(dayLayout))
+	return err
+}
+
+// RecordOccurrence logs the completion of one occurrence of a recurring
+// task, identified by its due (or start) time, together with a completed
+// event. Completing the same occurrence twice is a no-op. It is on time if
+// done by the due time, or by the end of the due day for all-day tasks.
+func (s *StatsStore) RecordOccurrence(t VTodoProperties, due time.Time, allDay bool, at time.Time) error {
+	if t.Uid == "" {
+		return nil
+	}
+	key, onTime := due.UTC().Format(time.RFC3339), !at.After(due)
+	if allDay {
+		key, onTime = due.Format(dayLayout), at.Format(dayLayout) <= due.Format(dayLayout)
+	}
+	res, err := s.db.Exec(`INSERT OR IGNORE INTO task_occurrences (uid, summary, due, completed_at, on_time)
+		VALUES (?, ?, ?, ?, ?)`, t.Uid, t.Summary, key, at.Unix(), onTime)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	_, err = s.db.Exec("INSERT INTO task_events (uid, summary, priority, event, at, day) VALUES (?, ?, ?, ?, ?, ?)",
+		t.Uid, t.Summary, t.Priority, EventCompleted, at.Unix(), at.Format(dayLayout))
+	return err
+}
+
+// Habit is the completion history of one recurring task.
+type Habit struct {
+	UID, Summary string
+	Done         int // occurrences completed
+	OnTime       int
+	Streak       int // latest occurrences in a row completed on time
+	Last         time.Time
+}
+
+// Habits returns the recurring tasks with an occurrence completed since
+// since, most recently completed first.
+func (s *StatsStore) Habits(since time.Time) ([]Habit, error) {
+	rows, err := s.db.Query(`SELECT uid, summary, completed_at, on_time FROM task_occurrences
+		WHERE uid IN (SELECT uid FROM task_occurrences WHERE completed_at >= ?)
+		ORDER BY uid, due DESC`, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Habit
+	broken := false
+	for rows.Next() {
+		var uid, summary string
+		var at int64
+		var onTime bool
+		if err := rows.Scan(&uid, &summary, &at, &onTime); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 || out[len(out)-1].UID != uid {
+			out = append(out, Habit{UID: uid, Summary: summary})
+			broken = false
+		}
+		h := &out[len(out)-1]
+		h.Done++
+		if t := time.Unix(at, 0); t.After(h.Last) {
+			h.Last = t
+		}
+		switch {
+		case !onTime:
+			broken = true
+		case !broken:
+			h.OnTime++
+			h.Streak++
+		default:
+			h.OnTime++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Last.After(out[j].Last) })
+	return out, nil
+}
+
+// Observe records a "created" event for every task not seen before, so
+// tasks that arrive in tasks.xml by hand or by sync are counted too.
+func (s *StatsStore) Observe(todos []VTodo, at time.Time) error {
+	for _, t := range todos {
+		if err := s.Record(t.Properties, EventCreated, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseDay marks the day as rolled over.
+func (s *StatsStore) CloseDay(at time.Time) error {
+	_, err := s.db.Exec("INSERT INTO task_events (uid, event, at, day) VALUES ('', ?, ?, ?)",
+		eventDayClosed, at.Unix(), at.Format(dayLayout))
+	return err
+}
+
+// PriorityMix counts completed tasks by RFC 5545 priority band.
+type PriorityMix struct {
+	High, Medium, Low, None int
+}
+
+func (m *PriorityMix) add(p int) {
+	switch {
+	case p >= 1 && p <= 4:
+		m.High++
+	case p == 5:
+		m.Medium++
+	case p >= 6 && p <= 9:
+		m.Low++
+	default:
+		m.None++
+	}
+}
+
+// Period aggregates the events of one or more days.
+type Period struct {
+	From, To    string // YYYY-MM-DD, inclusive
+	Created     int
+	Started     int
+	Completed   int
+	Cancelled   int
+	CarriedOver int
+	Priority    PriorityMix
+	Durations   []time.Duration // time-to-complete of completed tasks with a known start
+}
+
+// Planned is the number of tasks that were due to be done: finished or
+// carried over.
+func (p Period) Planned() int {
+	return p.Completed + p.CarriedOver
+}
+
+// CompletionRate is Completed / Planned, or -1 when nothing was planned.
+func (p Period) CompletionRate() float64 {
+	if p.Planned() == 0 {
+		return -1
+	}
+	return float64(p.Completed) / float64(p.Planned())
+}
+
+// AvgTimeToComplete is the mean of Durations, 0 when unknown.
+func (p Period) AvgTimeToComplete() time.Duration {
+	if len(p.Durations) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range p.Durations {
+		sum += d
+	}
+	return sum / time.Duration(len(p.Durations))
+}
+
+// MedianTimeToComplete is the median of Durations, 0 when unknown.
+func (p Period) MedianTimeToComplete() time.Duration {
+	n := len(p.Durations)
+	if n == 0 {
+		return 0
+	}
+	d := append([]time.Duration(nil), p.Durations...)
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	if n%2 == 1 {
+		return d[n/2]
+	}
+	return (d[n/2-1] + d[n/2]) / 2
+}
+
+// Period aggregates the events between two days, inclusive.
+func (s *StatsStore) Period(from, to time.Time) (Period, error) {
+	p := Period{From: from.Format(dayLayout), To: to.Format(dayLayout)}
+	rows, err := s.db.Query("SELECT uid, priority, event, at FROM task_events WHERE day BETWEEN ? AND ?", p.From, p.To)
+	if err != nil {
+		return p, err
+	}
+	type completion struct {
+		uid string
+		at  int64
+	}
+	var completions []completion
+	for rows.Next() {
+		var uid, event string
+		var priority int
+		var at int64
+		if err := rows.Scan(&uid, &priority, &event, &at); err != nil {
+			rows.Close()
+			return p, err
+		}
+		switch event {
+		case EventCreated:
+			p.Created++
+		case EventStarted:
+			p.Started++
+		case EventCompleted:
+			p.Completed++
+			p.Priority.add(priority)
+			completions = append(completions, completion{uid, at})
+		case EventCancelled:
+			p.Cancelled++
+		case EventRolledOver:
+			p.CarriedOver++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return p, err
+	}
+
+	// Time to complete runs from the first time the task was seen, started
+	// or reopened since its previous completion (recurring and reopened
+	// tasks complete repeatedly).
+	for _, c := range completions {
+		var first sql.NullInt64
+		err := s.db.QueryRow(`SELECT MIN(at) FROM task_events WHERE uid = ? AND event IN (?, ?, ?) AND at <= ?
+			AND at > COALESCE((SELECT MAX(at) FROM task_events WHERE uid = ? AND event = ? AND at < ?), 0)`,
+			c.uid, EventCreated, EventStarted, EventReopened, c.at, c.uid, EventCompleted, c.at).Scan(&first)
+		if err != nil {
+			return p, err
+		}
+		if first.Valid {
+			p.Durations = append(p.Durations, time.Duration(c.at-first.Int64)*time.Second)
+		}
+	}
+	return p, nil
+}
+
+// Streak counts consecutive closed days with nothing carried over, ending
+// at day. A day that has not been rolled over yet does not break the streak.
+func (s *StatsStore) Streak(day time.Time) (int, error) {
+	streak := 0
+	for i := 0; i < 366; i++ {
+		key := day.AddDate(0, 0, -i).Format(dayLayout)
+		var closed, rolled int
+		err := s.db.QueryRow(`SELECT
+			COALESCE(SUM(event = ?), 0), COALESCE(SUM(event = ?), 0)
+			FROM task_events WHERE day = ?`, eventDayClosed, EventRolledOver, key).Scan(&closed, &rolled)
+		if err != nil {
+			return 0, err
+		}
+		if closed == 0 {
+			if i == 0 {
+				continue
+			}
+			break
+		}
+		if rolled > 0 {
+			break
+		}
+		streak++
+	}
+	return streak, nil
+}
+
+// TaskHistory is what the event log knows about one task.
+type TaskHistory struct {
+	FirstSeen time.Time
+	Rollovers int
+}
+
+// History returns every task's first "created" time and carry-over count.
+func (s *StatsStore) History() (map[string]TaskHistory, error) {
+	rows, err := s.db.Query(`SELECT uid,
+		COALESCE(MIN(CASE WHEN event = ? THEN at END), 0), COALESCE(SUM(event = ?), 0)
+		FROM task_events WHERE uid != '' GROUP BY uid`, EventCreated, EventRolledOver)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]TaskHistory{}
+	for rows.Next() {
+		var uid string
+		var first int64
+		var h TaskHistory
+		if err := rows.Scan(&uid, &first, &h.Rollovers); err != nil {
+			return nil, err
+		}
+		if first > 0 {
+			h.FirstSeen = time.Unix(first, 0)
+		}
+		out[uid] = h
+	}
+	return out, rows.Err()
+}
+
+// ----------------------------------------------------------------------------
+// Report
+// ----------------------------------------------------------------------------
+
+// Report renders the stats for day and the trailing week against the week
+// before, as markdown bullet lines for Chief's evening review.
+func (s *StatsStore) Report(day time.Time) (string, error) {
+	today, err := s.Period(day, day)
+	if err != nil {
+		return "", err
+	}
+	week, err := s.Period(day.AddDate(0, 0, -6), day)
+	if err != nil {
+		return "", err
+	}
+	prev, err := s.Period(day.AddDate(0, 0, -13), day.AddDate(0, 0, -7))
+	if err != nil {
+		return "", err
+	}
+	streak, err := s.Streak(day)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- Day: %s\n", day.Format("Mon 2 Jan 2006"))
+	if rate := today.CompletionRate(); rate >= 0 {
+		fmt.Fprintf(&sb, "- Completed: %d of %d planned (%.0f%%)\n", today.Completed, today.Planned(), rate*100)
+	} else {
+		fmt.Fprintf(&sb, "- Completed: %d\n", today.Completed)
+	}
+	fmt.Fprintf(&sb, "- Carried over: %d\n", today.CarriedOver)
+	fmt.Fprintf(&sb, "- Created: %d · Started: %d · Cancelled: %d\n", today.Created, today.Started, today.Cancelled)
+	if avg := today.AvgTimeToComplete(); avg > 0 {
+		fmt.Fprintf(&sb, "- Time to complete: avg %s, median %s\n", formatSpan(avg), formatSpan(today.MedianTimeToComplete()))
+	}
+	if today.Completed > 0 {
+		m := today.Priority
+		fmt.Fprintf(&sb, "- Priority mix: high %d · medium %d · low %d · none %d\n", m.High, m.Medium, m.Low, m.None)
+	}
+	fmt.Fprintf(&sb, "- Zero carry-over streak: %d day(s)\n", streak)
+	habits, err := s.Habits(time.Date(day.Year(), day.Month(), day.Day()-6, 0, 0, 0, 0, day.Location()))
+	if err != nil {
+		return "", err
+	}
+	if len(habits) > 0 {
+		var parts []string
+		for _, h := range habits {
+			parts = append(parts, fmt.Sprintf("%s %d/%d on time, streak %d", h.Summary, h.OnTime, h.Done, h.Streak))
+		}
+		fmt.Fprintf(&sb, "- Recurring tasks: %s\n", strings.Join(parts, " · "))
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	tracked, err := s.TimeReport(start, start.AddDate(0, 0, 1), day)
+	if err != nil {
+		return "", err
+	}
+	if tracked.Total > 0 {
+		fmt.Fprintf(&sb, "- Time tracked: %s", formatSpan(tracked.Total))
+		if tracked.Pomodoros > 0 {
+			fmt.Fprintf(&sb, " (%d 🍅)", tracked.Pomodoros)
+		}
+		fmt.Fprintf(&sb, " · %s\n", formatTotals(tracked.Tasks, 5))
+		fmt.Fprintf(&sb, "- Time by category: %s\n", formatTotals(tracked.Categories, 5))
+	}
+	fmt.Fprintf(&sb, "- Last 7 days vs previous 7: completed %d (%s) · carried over %d (%s) · completion rate %s (%s) · avg time to complete %s (%s)\n",
+		week.Completed, delta(week.Completed-prev.Completed, true),
+		week.CarriedOver, delta(week.CarriedOver-prev.CarriedOver, false),
+		formatRate(week.CompletionRate()), rateDelta(week.CompletionRate(), prev.CompletionRate()),
+		formatSpan(week.AvgTimeToComplete()), spanDelta(week.AvgTimeToComplete(), prev.AvgTimeToComplete()))
+	return sb.String(), nil
+}
+
+// delta formats a change with an arrow; up is good unless higherIsBetter is false.
+func delta(d int, higherIsBetter bool) string {
+	switch {
+	case d == 0:
+		return "="
+	case d > 0:
+		return fmt.Sprintf("↑%d%s", d, mood(higherIsBetter))
+	default:
+		return fmt.Sprintf("↓%d%s", -d, mood(!higherIsBetter))
+	}
+}
+
+func mood(good bool) string {
+	if good {
+		return " 👍"
+	}
+	return ""
+}
+
+func formatRate(r float64) string {
+	if r < 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.0f%%", r*100)
+}
+
+func rateDelta(cur, prev float64) string {
+	if cur < 0 || prev < 0 {
+		return "no comparison"
+	}
+	switch pts := int(math.Round((cur - prev) * 100)); {
+	case pts > 0:
+		return fmt.Sprintf("↑%d pts 👍", pts)
+	case pts < 0:
+		return fmt.Sprintf("↓%d pts", -pts)
+	}
+	return "="
+}
+
+func spanDelta(cur, prev time.Duration) string {
+	if cur == 0 || prev == 0 {
+		return "no comparison"
+	}
+	d := cur - prev
+	switch {
+	case d.Abs() < time.Minute:
+		return "="
+	case d < 0:
+		return "↓" + formatSpan(-d) + " 👍"
+	default:
+		return "↑" + formatSpan(d)
+	}
+}
+
+// formatSpan renders a duration as "2d 3h", "5h 10m" or "12m".
+func formatSpan(d time.Duration) string {
+	if d <= 0 {
+		return "n/a"
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	mins := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	default:
+		return fmt.Sprintf("%dm", mins)
+	}
+}
+
+// WriteReport renders the report for day and writes it atomically to path.
+func (s *StatsStore) WriteReport(path string, day time.Time) (string, error) {
+	report, err := s.Report(day)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(report), 0644); err != nil {
+		return "", err
+	}
+	return report, os.Rename(tmp, path)
+}
+
+// ----------------------------------------------------------------------------
+// Skill wiring
+// ----------------------------------------------------------------------------
+
+func (s *ATCSkill) openStats() (*StatsStore, error) {
+	if s.workspace == "" {
+		return nil, fmt.Errorf("ATC workspace not set")
+	}
+	return OpenStats(filepath.Join(s.workspace, "memory", StatsFileName))
+}
+
+// recordEvents is the best-effort hook the task commands call: statistics
+// must never make a task change fail.
+func (s *ATCSkill) recordEvents(fn func(st *StatsStore, now time.Time) error) {
+	st, err := s.openStats()
+	if err != nil {
+		return
+	}
+	defer st.Close()
+	_ = fn(st, s.now())
+}
+
+// statsReportPath is stats-today.md in Chief's memory, next to the news
+// and research caches.
+func (s *ATCSkill) statsReportPath() string {
+	return filepath.Join(filepath.Dir(s.workspace), "chief", "memory", StatsReportName)
+}
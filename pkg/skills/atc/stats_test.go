@@ -0,0 +1,112 @@
+package atc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsReport(t *testing.T) {
+	st, err := OpenStats(filepath.Join(t.TempDir(), StatsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(d, h int) time.Time { return day.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour) }
+	task := func(uid string, prio int) VTodoProperties {
+		return VTodoProperties{Uid: uid, Summary: "Task " + uid, Priority: prio}
+	}
+
+	// Previous week: one task done, one carried over, day closed.
+	st.Record(task("old", 5), EventCreated, at(-8, 0))
+	st.Record(task("old", 5), EventCompleted, at(-8, 4))
+	st.Record(task("late", 9), EventCreated, at(-8, 0))
+	st.Record(task("late", 9), EventRolledOver, at(-8, 12))
+	st.CloseDay(at(-8, 12))
+
+	// Two clean closed days before today.
+	st.CloseDay(at(-2, 12))
+	st.CloseDay(at(-1, 12))
+
+	// Today: a completed twice (no-op), b started then completed, c carried over.
+	st.Record(task("a", 1), EventCreated, at(0, 0))
+	st.Record(task("a", 1), EventCreated, at(0, 1))
+	st.Record(task("a", 1), EventCompleted, at(0, 2))
+	st.Record(task("a", 1), EventCompleted, at(0, 3))
+	st.Record(task("b", 0), EventStarted, at(0, 1))
+	st.Record(task("b", 0), EventCompleted, at(0, 5))
+	st.Record(task("c", 9), EventCreated, at(0, 0))
+	st.Record(task("c", 9), EventRolledOver, at(0, 12))
+	st.Record(task("c", 9), EventRolledOver, at(0, 13))
+
+	p, err := st.Period(day, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Created != 2 || p.Completed != 2 || p.CarriedOver != 1 || p.Started != 1 {
+		t.Errorf("period = %+v", p)
+	}
+	if p.Priority != (PriorityMix{High: 1, None: 1}) {
+		t.Errorf("priority mix = %+v", p.Priority)
+	}
+	if got := p.AvgTimeToComplete(); got != 3*time.Hour {
+		t.Errorf("avg time to complete = %v, want 3h", got)
+	}
+
+	// Today is still open, so the streak counts the two clean days before.
+	if n, _ := st.Streak(day); n != 2 {
+		t.Errorf("streak = %d, want 2", n)
+	}
+	st.CloseDay(at(0, 13))
+	if n, _ := st.Streak(day); n != 0 {
+		t.Errorf("streak after carrying over = %d, want 0", n)
+	}
+
+	report, err := st.Report(at(0, 13))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"- Completed: 2 of 3 planned (67%)",
+		"- Carried over: 1",
+		"- Time to complete: avg 3h 0m, median 3h 0m",
+		"- Priority mix: high 1 · medium 0 · low 0 · none 1",
+		"- Zero carry-over streak: 0 day(s)",
+		"completed 2 (↑1 👍) · carried over 1 (=) · completion rate 67% (↑17 pts 👍) · avg time to complete 3h 0m (↓1h 0m 👍)",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("missing %q in:\n%s", want, report)
+		}
+	}
+}
+
+func TestStatsReopenedTaskCompletesAgain(t *testing.T) {
+	st, err := OpenStats(filepath.Join(t.TempDir(), StatsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	task := VTodoProperties{Uid: "r", Summary: "Renew passport"}
+	st.Record(task, EventCreated, day)
+	st.Record(task, statusEvent("NEEDS-ACTION"), day.Add(time.Hour)) // never finished: not a reopen
+	st.Record(task, statusEvent("COMPLETED"), day.Add(2*time.Hour))
+	st.Record(task, statusEvent("NEEDS-ACTION"), day.Add(3*time.Hour))
+	st.Record(task, statusEvent("NEEDS-ACTION"), day.Add(4*time.Hour))
+	st.Record(task, statusEvent("COMPLETED"), day.Add(5*time.Hour))
+
+	p, err := st.Period(day, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Completed != 2 {
+		t.Errorf("completed = %d, want 2", p.Completed)
+	}
+	if len(p.Durations) != 2 || p.Durations[0] != 2*time.Hour || p.Durations[1] != 2*time.Hour {
+		t.Errorf("time to complete = %v, want [2h 2h]", p.Durations)
+	}
+}
@@ -0,0 +1,1038 @@
+package atc
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Task import / export
+//
+// Formats:
+//
+//	taskwarrior   `task export` JSON (array or one object per line)
+//	todoist-csv   Todoist project CSV (TYPE, CONTENT, PRIORITY, INDENT, DATE…)
+//	todoist-json  Todoist REST task list or sync/backup JSON (import only)
+//	csv           generic CSV with a header row (uid, summary, due, …)
+//	ics           .ics bundle of VTODOs
+//
+// Everything is mapped onto VTodoProperties: priorities onto 1/5/9,
+// projects, sections, tags and labels onto categories, dates onto DUE/DTSTART
+// (date-only when the source has no time) and repeats onto RRULE. Sources
+// without IDs get a UID derived from the task, so importing the same file
+// twice adds nothing.
+// ----------------------------------------------------------------------------
+
+// taskFormats lists the formats export_tasks can write.
+var taskFormats = map[string]string{"taskwarrior": ".json", "todoist-csv": ".csv", "csv": ".csv", "ics": ".ics"}
+
+// detectTaskFormat guesses the format of an import file.
+func detectTaskFormat(data []byte) string {
+	head := strings.TrimSpace(string(data[:min(len(data), 4096)]))
+	head = strings.TrimPrefix(head, "\ufeff")
+	switch {
+	case strings.HasPrefix(strings.ToUpper(head), "BEGIN:VCALENDAR"):
+		return "ics"
+	case strings.HasPrefix(head, "{") && (strings.Contains(head, `"items"`) || strings.Contains(head, `"projects"`)):
+		return "todoist-json"
+	case strings.HasPrefix(head, "[") && strings.Contains(head, `"content"`):
+		return "todoist-json"
+	case strings.HasPrefix(head, "[") || strings.HasPrefix(head, "{"):
+		return "taskwarrior"
+	case strings.HasPrefix(strings.ToUpper(head), "TYPE,CONTENT"), strings.HasPrefix(strings.ToUpper(head), `"TYPE","CONTENT"`):
+		return "todoist-csv"
+	}
+	return "csv"
+}
+
+// importUID derives a stable UID for a task from a source without IDs.
+func importUID(format string, parts ...string) string {
+	h := sha1.New()
+	h.Write([]byte(format))
+	for _, p := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(strings.ToLower(strings.TrimSpace(p))))
+	}
+	return "import-" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// setDate stores a parsed date as the date-only or UTC form.
+func setDate(dateTime, date *string, t time.Time, allDay bool) {
+	if t.IsZero() {
+		return
+	}
+	if allDay {
+		*date = t.Format("2006-01-02")
+		return
+	}
+	*dateTime = t.UTC().Format(time.RFC3339)
+}
+
+// parseLooseTime reads the date forms the import formats use. Times
+// without a zone are in loc.
+func parseLooseTime(v string, loc *time.Location) (t time.Time, allDay, ok bool) {
+	v = strings.TrimSpace(v)
+	for _, layout := range []string{"2006-01-02", "20060102", "2006/01/02"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, true, true
+		}
+	}
+	for _, layout := range []string{time.RFC3339, "20060102T150405Z"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.In(loc), false, true
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "20060102T150405"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, false, true
+		}
+	}
+	return t, false, false
+}
+
+// parsePriority maps high/medium/low, H/M/L and 1-9 onto RFC 5545.
+func parsePriority(v string) int {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "h", "high", "urgent":
+		return 1
+	case "m", "medium", "med", "normal":
+		return 5
+	case "l", "low":
+		return 9
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n >= 0 && n <= 9 {
+		return n
+	}
+	return 0
+}
+
+func joinCategories(cats ...string) string {
+	seen := map[string]bool{}
+	var out []string
+	for _, c := range cats {
+		c = strings.TrimSpace(c)
+		if c != "" && !seen[strings.ToLower(c)] {
+			seen[strings.ToLower(c)] = true
+			out = append(out, c)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// ----------------------------------------------------------------------------
+// Taskwarrior
+// ----------------------------------------------------------------------------
+
+type twTask struct {
+	UUID        string         `json:"uuid"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Entry       string         `json:"entry,omitempty"`
+	Modified    string         `json:"modified,omitempty"`
+	End         string         `json:"end,omitempty"`
+	Due         string         `json:"due,omitempty"`
+	Scheduled   string         `json:"scheduled,omitempty"`
+	Project     string         `json:"project,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Priority    string         `json:"priority,omitempty"`
+	Recur       string         `json:"recur,omitempty"`
+	Parent      string         `json:"parent,omitempty"`
+	Depends     twDepends      `json:"depends,omitempty"`
+	Annotations []twAnnotation `json:"annotations,omitempty"`
+}
+
+type twAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// twDepends is a list of UUIDs; Taskwarrior 2 writes it as one
+// comma-separated string.
+type twDepends []string
+
+func (d *twDepends) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for _, u := range strings.Split(s, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			*d = append(*d, u)
+		}
+	}
+	return nil
+}
+
+// twRecurRRule maps a Taskwarrior recur period onto an RRULE.
+func twRecurRRule(recur string) (string, bool) {
+	r := strings.ToLower(strings.TrimSpace(recur))
+	switch r {
+	case "daily", "day":
+		return "FREQ=DAILY", true
+	case "weekdays":
+		return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", true
+	case "weekly", "week", "sennight":
+		return "FREQ=WEEKLY", true
+	case "biweekly", "fortnight":
+		return "FREQ=WEEKLY;INTERVAL=2", true
+	case "monthly", "month":
+		return "FREQ=MONTHLY", true
+	case "bimonthly":
+		return "FREQ=MONTHLY;INTERVAL=2", true
+	case "quarterly":
+		return "FREQ=MONTHLY;INTERVAL=3", true
+	case "semiannual":
+		return "FREQ=MONTHLY;INTERVAL=6", true
+	case "yearly", "annual", "year":
+		return "FREQ=YEARLY", true
+	case "biannual", "biyearly":
+		return "FREQ=YEARLY;INTERVAL=2", true
+	}
+	i := strings.IndexFunc(r, func(c rune) bool { return c < '0' || c > '9' })
+	if i <= 0 {
+		return "", false
+	}
+	n, _ := strconv.Atoi(r[:i])
+	freq := map[string]string{"d": "DAILY", "days": "DAILY", "w": "WEEKLY", "wk": "WEEKLY", "weeks": "WEEKLY",
+		"mo": "MONTHLY", "mth": "MONTHLY", "months": "MONTHLY", "q": "MONTHLY", "y": "YEARLY", "yr": "YEARLY", "years": "YEARLY"}[r[i:]]
+	if freq == "" || n <= 0 {
+		return "", false
+	}
+	if r[i:] == "q" {
+		n *= 3
+	}
+	if n == 1 {
+		return "FREQ=" + freq, true
+	}
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", freq, n), true
+}
+
+// rruleTWRecur is the inverse of twRecurRRule for the rules it produces.
+func rruleTWRecur(rule string) (string, bool) {
+	parts := map[string]string{}
+	for _, p := range strings.Split(strings.ToUpper(rule), ";") {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			parts[k] = v
+		}
+	}
+	n, _ := strconv.Atoi(parts["INTERVAL"])
+	if n < 1 {
+		n = 1
+	}
+	unit := map[string]string{"DAILY": "d", "WEEKLY": "w", "MONTHLY": "mo", "YEARLY": "y"}[parts["FREQ"]]
+	switch {
+	case unit == "":
+		return "", false
+	case parts["FREQ"] == "WEEKLY" && parts["BYDAY"] == "MO,TU,WE,TH,FR" && n == 1:
+		return "weekdays", true
+	case parts["BYDAY"] != "" || parts["BYMONTHDAY"] != "":
+		return "", false
+	}
+	return fmt.Sprintf("%d%s", n, unit), true
+}
+
+// importTaskwarrior reads `task export` output. Recurring templates are
+// imported with the due date of their next pending instance; the
+// instances themselves are skipped. Deleted tasks are dropped.
+func importTaskwarrior(data []byte, loc *time.Location) ([]VTodoProperties, []string, error) {
+	var tasks []twTask
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &tasks); err != nil {
+			return nil, nil, fmt.Errorf("taskwarrior JSON: %w", err)
+		}
+	} else {
+		for i, line := range bytes.Split(trimmed, []byte("\n")) {
+			if line = bytes.TrimRight(bytes.TrimSpace(line), ","); len(line) == 0 {
+				continue
+			}
+			var t twTask
+			if err := json.Unmarshal(line, &t); err != nil {
+				return nil, nil, fmt.Errorf("taskwarrior JSON line %d: %w", i+1, err)
+			}
+			tasks = append(tasks, t)
+		}
+	}
+
+	nextDue := map[string]string{}
+	for _, t := range tasks {
+		if t.Parent != "" && t.Status == "pending" && (nextDue[t.Parent] == "" || t.Due < nextDue[t.Parent]) {
+			nextDue[t.Parent] = t.Due
+		}
+	}
+	var out []VTodoProperties
+	var warn []string
+	for _, t := range tasks {
+		if t.Status == "deleted" || (t.Parent != "" && t.Recur != "") || t.UUID == "" {
+			continue
+		}
+		p := VTodoProperties{Uid: t.UUID, Summary: t.Description, Status: "NEEDS-ACTION"}
+		switch t.Status {
+		case "completed":
+			p.Status = "COMPLETED"
+		case "waiting":
+			p.Categories = "waiting"
+		}
+		p.Priority = parsePriority(t.Priority)
+		due := t.Due
+		if t.Recur != "" {
+			if d := nextDue[t.UUID]; d != "" {
+				due = d
+			}
+			if rule, ok := twRecurRRule(t.Recur); ok {
+				p.RRule = rule
+			} else {
+				warn = append(warn, fmt.Sprintf("%s: recurrence %q not supported, imported as a one-off", t.Description, t.Recur))
+			}
+		}
+		for _, f := range []struct {
+			v         string
+			dt, dDate *string
+		}{{due, &p.Due, &p.DueDate}, {t.Scheduled, &p.Dtstart, &p.DtstartDate}} {
+			if ts, err := time.Parse("20060102T150405Z", f.v); err == nil {
+				// Taskwarrior stores `due:2026-03-03` as local midnight.
+				local := ts.In(loc)
+				setDate(f.dt, f.dDate, local, local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0)
+			}
+		}
+		project := strings.ReplaceAll(t.Project, ".", ",")
+		p.Categories = joinCategories(append([]string{p.Categories, project}, t.Tags...)...)
+		var notes []string
+		for _, a := range t.Annotations {
+			notes = append(notes, a.Description)
+		}
+		p.Description = strings.Join(notes, "\n")
+		p.RelatedTo = withRelations(nil, p.Uid, "", t.Depends)
+		if ts, err := time.Parse("20060102T150405Z", t.Modified); err == nil {
+			p.LastModified = ts.UTC().Format(time.RFC3339)
+		}
+		out = append(out, p)
+	}
+	return out, warn, nil
+}
+
+// exportTaskwarrior writes tasks for `task import`.
+func exportTaskwarrior(todos []VTodoProperties, loc *time.Location, now time.Time) ([]byte, error) {
+	twTime := func(dt, date string) string {
+		if t, err := time.Parse(time.RFC3339, dt); err == nil {
+			return t.UTC().Format("20060102T150405Z")
+		}
+		if t, err := time.ParseInLocation("2006-01-02", date, loc); err == nil {
+			return t.UTC().Format("20060102T150405Z")
+		}
+		return ""
+	}
+	out := []twTask{}
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, p := range todos {
+		t := twTask{UUID: p.Uid, Description: p.Summary, Status: "pending", Entry: stamp,
+			Due: twTime(p.Due, p.DueDate), Scheduled: twTime(p.Dtstart, p.DtstartDate), Depends: p.DependsOn()}
+		if m := twTime(p.LastModified, ""); m != "" {
+			t.Modified = m
+		}
+		switch normStatus(p.Status) {
+		case "COMPLETED":
+			t.Status, t.End = "completed", stamp
+		case "CANCELLED":
+			t.Status, t.End = "deleted", stamp
+		}
+		switch {
+		case p.Priority >= 1 && p.Priority <= 4:
+			t.Priority = "H"
+		case p.Priority == 5:
+			t.Priority = "M"
+		case p.Priority >= 6:
+			t.Priority = "L"
+		}
+		for _, c := range strings.Split(p.Categories, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				t.Tags = append(t.Tags, strings.ReplaceAll(c, " ", "_"))
+			}
+		}
+		if recur, ok := rruleTWRecur(p.RRule); ok && t.Due != "" && t.Status == "pending" {
+			t.Recur, t.Status = recur, "recurring"
+		}
+		if p.Description != "" {
+			t.Annotations = []twAnnotation{{Entry: stamp, Description: p.Description}}
+		}
+		out = append(out, t)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// ----------------------------------------------------------------------------
+// Todoist
+// ----------------------------------------------------------------------------
+
+// todoistID accepts both the numeric IDs of old exports and the string
+// IDs of the current API.
+type todoistID string
+
+func (id *todoistID) UnmarshalJSON(b []byte) error {
+	*id = todoistID(strings.Trim(string(b), `"`))
+	if *id == "null" {
+		*id = ""
+	}
+	return nil
+}
+
+type todoistDue struct {
+	Date        string `json:"date"`
+	Datetime    string `json:"datetime"`
+	String      string `json:"string"`
+	IsRecurring bool   `json:"is_recurring"`
+}
+
+type todoistItem struct {
+	ID          todoistID   `json:"id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority"` // 4 is p1, the most urgent
+	ProjectID   todoistID   `json:"project_id"`
+	SectionID   todoistID   `json:"section_id"`
+	ParentID    todoistID   `json:"parent_id"`
+	Labels      []string    `json:"labels"`
+	Due         *todoistDue `json:"due"`
+	Checked     bool        `json:"checked"`
+	IsCompleted bool        `json:"is_completed"`
+	IsDeleted   bool        `json:"is_deleted"`
+	Duration    *struct {
+		Amount int    `json:"amount"`
+		Unit   string `json:"unit"`
+	} `json:"duration"`
+}
+
+type todoistNamed struct {
+	ID   todoistID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// todoistRecurrence reads the RRULE out of a Todoist "every …" phrase.
+func todoistRecurrence(phrase string, now time.Time) string {
+	if phrase == "" {
+		return ""
+	}
+	return ParseQuickAdd("x "+phrase, now).RRule
+}
+
+// importTodoistJSON reads a REST task list or a sync/backup document with
+// items, projects and sections.
+func importTodoistJSON(data []byte, now time.Time) ([]VTodoProperties, []string, error) {
+	var doc struct {
+		Items    []todoistItem  `json:"items"`
+		Projects []todoistNamed `json:"projects"`
+		Sections []todoistNamed `json:"sections"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &doc.Items); err != nil {
+			return nil, nil, fmt.Errorf("todoist JSON: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("todoist JSON: %w", err)
+	}
+	names := map[todoistID]string{}
+	for _, n := range append(doc.Projects, doc.Sections...) {
+		names[n.ID] = n.Name
+	}
+	loc := now.Location()
+	var out []VTodoProperties
+	var warn []string
+	for _, it := range doc.Items {
+		if it.IsDeleted || it.ID == "" {
+			continue
+		}
+		p := VTodoProperties{Uid: "todoist-" + string(it.ID), Summary: it.Content, Description: it.Description, Status: "NEEDS-ACTION"}
+		if it.Checked || it.IsCompleted {
+			p.Status = "COMPLETED"
+		}
+		p.Priority = map[int]int{4: 1, 3: 5, 2: 9}[it.Priority]
+		p.Categories = joinCategories(append([]string{names[it.ProjectID], names[it.SectionID]}, it.Labels...)...)
+		if it.ParentID != "" {
+			p.RelatedTo = withRelations(nil, p.Uid, "todoist-"+string(it.ParentID), nil)
+		}
+		if d := it.Due; d != nil {
+			v := d.Date
+			if d.Datetime != "" {
+				v = d.Datetime
+			}
+			if t, allDay, ok := parseLooseTime(v, loc); ok {
+				setDate(&p.Due, &p.DueDate, t, allDay)
+			}
+			if d.IsRecurring {
+				if p.RRule = todoistRecurrence(d.String, now); p.RRule == "" {
+					warn = append(warn, fmt.Sprintf("%s: recurrence %q not understood, imported as a one-off", it.Content, d.String))
+				}
+			}
+		}
+		if it.Duration != nil && it.Duration.Amount > 0 {
+			m := it.Duration.Amount
+			if it.Duration.Unit == "day" {
+				m *= 24 * 60
+			}
+			p.Estimate = formatEstimate(time.Duration(m) * time.Minute)
+		}
+		out = append(out, p)
+	}
+	return out, warn, nil
+}
+
+// importTodoistCSV reads a Todoist project CSV. Sections become
+// categories, indented tasks subtasks of the task above them, notes
+// descriptions; @labels in the content are categories too.
+func importTodoistCSV(data []byte, project string, now time.Time) ([]VTodoProperties, []string, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+	col := csvColumns(rows[0])
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var out []VTodoProperties
+	var warn []string
+	section := ""
+	parents := map[int]string{} // indent level -> UID of the last task there
+	seen := map[string]bool{}
+	for i, row := range rows[1:] {
+		switch strings.ToLower(get(row, "type")) {
+		case "section":
+			section = get(row, "content")
+			continue
+		case "note":
+			if len(out) > 0 {
+				last := &out[len(out)-1]
+				last.Description = strings.TrimSpace(last.Description + "\n" + get(row, "content"))
+			}
+			continue
+		case "task":
+		default:
+			continue
+		}
+		var labels, words []string
+		for _, w := range strings.Fields(get(row, "content")) {
+			if strings.HasPrefix(w, "@") && len(w) > 1 {
+				labels = append(labels, w[1:])
+			} else {
+				words = append(words, w)
+			}
+		}
+		p := VTodoProperties{Summary: strings.Join(words, " "), Description: get(row, "description"), Status: "NEEDS-ACTION"}
+		if n, err := strconv.Atoi(get(row, "priority")); err == nil {
+			p.Priority = map[int]int{1: 1, 2: 5, 3: 9}[n]
+		}
+		p.Categories = joinCategories(append([]string{project, section}, labels...)...)
+		if date := get(row, "date"); date != "" {
+			q := ParseQuickAdd("x "+date, now)
+			p.RRule = q.RRule
+			if t, allDay, ok := parseLooseTime(date, now.Location()); ok {
+				setDate(&p.Due, &p.DueDate, t, allDay)
+			} else {
+				setDate(&p.Due, &p.DueDate, q.Due, q.DueAllDay)
+			}
+			if q.Due.IsZero() && p.Due == "" && p.DueDate == "" {
+				warn = append(warn, fmt.Sprintf("%s: date %q not understood", p.Summary, date))
+			}
+		}
+		if n, err := strconv.Atoi(get(row, "duration")); err == nil && n > 0 {
+			if strings.EqualFold(get(row, "duration_unit"), "day") {
+				n *= 24 * 60
+			}
+			p.Estimate = formatEstimate(time.Duration(n) * time.Minute)
+		}
+		indent, _ := strconv.Atoi(get(row, "indent"))
+		if indent < 1 {
+			indent = 1
+		}
+		// A repeated title under the same parent (two "Buy milk" rows) also
+		// hashes its row number, so it is not dropped as the first one's
+		// duplicate; the first keeps the UID a re-import finds again.
+		p.Uid = importUID("todoist", project, section, parents[indent-1], p.Summary)
+		if seen[p.Uid] {
+			p.Uid = importUID("todoist", project, section, parents[indent-1], p.Summary, strconv.Itoa(i+2))
+		}
+		seen[p.Uid] = true
+		if parent := parents[indent-1]; indent > 1 && parent != "" {
+			p.RelatedTo = withRelations(nil, p.Uid, parent, nil)
+		}
+		parents[indent] = p.Uid
+		for lvl := range parents {
+			if lvl > indent {
+				delete(parents, lvl)
+			}
+		}
+		out = append(out, p)
+	}
+	return out, warn, nil
+}
+
+// exportTodoistCSV writes a CSV Todoist's "Import from template" reads.
+func exportTodoistCSV(todos []VTodoProperties, loc *time.Location) ([]byte, error) {
+	byParent := map[string][]VTodoProperties{}
+	uids := map[string]bool{}
+	for _, p := range todos {
+		uids[p.Uid] = true
+	}
+	for _, p := range todos {
+		parent := p.Parent()
+		if !uids[parent] {
+			parent = ""
+		}
+		byParent[parent] = append(byParent[parent], p)
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"TYPE", "CONTENT", "DESCRIPTION", "PRIORITY", "INDENT", "AUTHOR", "RESPONSIBLE", "DATE", "DATE_LANG", "TIMEZONE"})
+	var walk func(parent string, indent int)
+	walk = func(parent string, indent int) {
+		for _, p := range byParent[parent] {
+			content := p.Summary
+			for _, c := range strings.Split(p.Categories, ",") {
+				if c = strings.TrimSpace(c); c != "" {
+					content += " @" + strings.ReplaceAll(c, " ", "_")
+				}
+			}
+			prio := 4
+			switch {
+			case p.Priority >= 1 && p.Priority <= 4:
+				prio = 1
+			case p.Priority == 5:
+				prio = 2
+			case p.Priority >= 6:
+				prio = 3
+			}
+			w.Write([]string{"task", content, p.Description, strconv.Itoa(prio), strconv.Itoa(indent), "", "", todoistDate(p, loc), "en", loc.String()})
+			if indent < 5 {
+				walk(p.Uid, indent+1)
+			}
+		}
+	}
+	walk("", 1)
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// todoistDate renders the due date, and a simple RRULE, as Todoist date
+// text: "2026-03-03 17:00" or "every 2 weeks starting 2026-03-03".
+func todoistDate(p VTodoProperties, loc *time.Location) string {
+	date := p.DueDate
+	if t, err := time.Parse(time.RFC3339, p.Due); err == nil {
+		date = t.In(loc).Format("2006-01-02 15:04")
+	}
+	recur, ok := rruleTWRecur(p.RRule)
+	if !ok || date == "" {
+		return date
+	}
+	n, unit := 0, ""
+	fmt.Sscanf(recur, "%d%s", &n, &unit)
+	every := map[string]string{"d": "day", "w": "week", "mo": "month", "y": "year"}[unit]
+	switch {
+	case recur == "weekdays":
+		every = "workday"
+	case n > 1:
+		every = fmt.Sprintf("%d %ss", n, every)
+	}
+	return "every " + every + " starting " + date
+}
+
+// ----------------------------------------------------------------------------
+// Generic CSV
+// ----------------------------------------------------------------------------
+
+// csvAliases maps accepted header names onto fields.
+var csvAliases = map[string]string{
+	"uid": "uid", "id": "uid", "uuid": "uid",
+	"summary": "summary", "title": "summary", "task": "summary", "content": "summary", "name": "summary",
+	"description": "description", "notes": "description", "note": "description",
+	"due": "due", "due_date": "due", "deadline": "due", "date": "due",
+	"start": "start", "dtstart": "start", "scheduled": "start", "priority": "priority",
+	"categories": "categories", "category": "categories", "tags": "categories", "labels": "categories", "project": "categories", "list": "categories",
+	"status": "status", "done": "status", "completed": "status",
+	"rrule": "rrule", "recurrence": "rrule", "repeat": "rrule",
+	"estimate": "estimate", "duration": "estimate",
+	"parent": "parent", "depends_on": "depends_on",
+}
+
+var csvHeader = []string{"uid", "summary", "description", "status", "priority", "due", "start", "categories", "rrule", "estimate", "parent", "depends_on"}
+
+func readCSV(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV: %w", err)
+	}
+	return rows, nil
+}
+
+// csvColumns indexes a header row by lower-cased, underscored name.
+func csvColumns(header []string) map[string]int {
+	col := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(h), " ", "_"))
+		if _, dup := col[h]; !dup {
+			col[h] = i
+		}
+	}
+	return col
+}
+
+// importCSV reads a generic CSV; see csvAliases for the columns. Several
+// category-like columns (project, tags) are merged, split on , ; or |.
+func importCSV(data []byte, now time.Time) ([]VTodoProperties, []string, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+	fields := map[int]string{}
+	hasSummary := false
+	for h, i := range csvColumns(rows[0]) {
+		if f, ok := csvAliases[h]; ok {
+			fields[i] = f
+			hasSummary = hasSummary || f == "summary"
+		}
+	}
+	if !hasSummary {
+		return nil, nil, fmt.Errorf("CSV needs a summary (or title/task/name) column")
+	}
+	loc := now.Location()
+	var out []VTodoProperties
+	var warn []string
+	for n, row := range rows[1:] {
+		p := VTodoProperties{Status: "NEEDS-ACTION"}
+		var cats, depends []string
+		parent := ""
+		for i, v := range row {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			switch fields[i] {
+			case "uid":
+				p.Uid = v
+			case "summary":
+				p.Summary = v
+			case "description":
+				p.Description = v
+			case "priority":
+				p.Priority = parsePriority(v)
+			case "categories":
+				cats = append(cats, strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' || r == '|' })...)
+			case "status":
+				switch strings.ToLower(v) {
+				case "completed", "done", "x", "true", "yes", "1":
+					p.Status = "COMPLETED"
+				case "cancelled", "canceled", "deleted":
+					p.Status = "CANCELLED"
+				case "in-process", "in progress", "started":
+					p.Status = "IN-PROCESS"
+				}
+			case "due", "start":
+				t, allDay, ok := parseLooseTime(v, loc)
+				if !ok {
+					warn = append(warn, fmt.Sprintf("row %d: date %q not understood", n+2, v))
+					continue
+				}
+				if fields[i] == "due" {
+					setDate(&p.Due, &p.DueDate, t, allDay)
+				} else {
+					setDate(&p.Dtstart, &p.DtstartDate, t, allDay)
+				}
+			case "rrule":
+				if rule := strings.TrimPrefix(strings.ToUpper(v), "RRULE:"); strings.HasPrefix(rule, "FREQ=") {
+					p.RRule = rule
+				} else if rule := todoistRecurrence(v, now); rule != "" {
+					p.RRule = rule
+				} else {
+					warn = append(warn, fmt.Sprintf("row %d: recurrence %q not understood", n+2, v))
+				}
+			case "estimate":
+				if d := parseEstimate(v); d > 0 {
+					p.Estimate = formatEstimate(d)
+				}
+			case "parent":
+				parent = v
+			case "depends_on":
+				depends = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' || r == ' ' })
+			}
+		}
+		if p.Summary == "" {
+			continue
+		}
+		p.Categories = joinCategories(cats...)
+		if p.Uid == "" {
+			p.Uid = importUID("csv", p.Summary, normDue(p))
+		}
+		p.RelatedTo = withRelations(nil, p.Uid, parent, depends)
+		out = append(out, p)
+	}
+	return out, warn, nil
+}
+
+// exportCSV writes the columns importCSV reads back.
+func exportCSV(todos []VTodoProperties) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvHeader)
+	for _, p := range todos {
+		due, start := p.DueDate, p.DtstartDate
+		if due == "" {
+			due = p.Due
+		}
+		if start == "" {
+			start = p.Dtstart
+		}
+		prio := ""
+		if p.Priority > 0 {
+			prio = strconv.Itoa(p.Priority)
+		}
+		w.Write([]string{p.Uid, p.Summary, p.Description, normStatus(p.Status), prio, due, start,
+			p.Categories, p.RRule, p.Estimate, p.Parent(), strings.Join(p.DependsOn(), " ")})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// ----------------------------------------------------------------------------
+// iCalendar
+// ----------------------------------------------------------------------------
+
+// importICS reads every VTODO of an .ics bundle.
+func importICS(data []byte, loc *time.Location) ([]VTodoProperties, []string, error) {
+	var out []VTodoProperties
+	var block []string
+	inTodo := false
+	for _, line := range normalizeICSLines(strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")) {
+		switch u := strings.ToUpper(strings.TrimSpace(line)); {
+		case u == "BEGIN:VTODO" && !inTodo:
+			inTodo, block = true, nil
+		case u == "END:VTODO" && inTodo:
+			inTodo = false
+			ics := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n" + strings.Join(block, "\r\n") + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+			if t, ok := parseRemoteTodo(ics, loc); ok {
+				out = append(out, t.Props)
+			}
+			continue
+		}
+		if inTodo && !strings.EqualFold(strings.TrimSpace(line), "BEGIN:VTODO") {
+			block = append(block, line)
+		}
+	}
+	if len(out) == 0 && !strings.Contains(strings.ToUpper(string(data)), "BEGIN:VCALENDAR") {
+		return nil, nil, fmt.Errorf("not an iCalendar file")
+	}
+	return out, nil, nil
+}
+
+// exportICS writes one VCALENDAR holding every task.
+func exportICS(todos []VTodoProperties, now time.Time) []byte {
+	var sb strings.Builder
+	sb.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Son of Anthon ATC//EN\r\n")
+	for _, p := range todos {
+		ics := buildVTodo(p, p.Categories, nil, now)
+		start := strings.Index(ics, "BEGIN:VTODO")
+		end := strings.LastIndex(ics, "END:VTODO")
+		sb.WriteString(ics[start:end] + "END:VTODO\r\n")
+	}
+	sb.WriteString("END:VCALENDAR\r\n")
+	return []byte(sb.String())
+}
+
+// ----------------------------------------------------------------------------
+// tasks.xml side
+// ----------------------------------------------------------------------------
+
+// parseTaskImport dispatches on format ("" or "auto" detects it).
+func parseTaskImport(data []byte, format, project string, now time.Time) ([]VTodoProperties, []string, string, error) {
+	if format == "" || format == "auto" {
+		format = detectTaskFormat(data)
+	}
+	var todos []VTodoProperties
+	var warn []string
+	var err error
+	switch format {
+	case "taskwarrior":
+		todos, warn, err = importTaskwarrior(data, now.Location())
+	case "todoist-json":
+		todos, warn, err = importTodoistJSON(data, now)
+	case "todoist-csv":
+		todos, warn, err = importTodoistCSV(data, project, now)
+	case "csv":
+		todos, warn, err = importCSV(data, now)
+	case "ics":
+		todos, warn, err = importICS(data, now.Location())
+	default:
+		return nil, nil, format, fmt.Errorf("unknown format %q (taskwarrior, todoist-csv, todoist-json, csv or ics)", format)
+	}
+	return todos, warn, format, err
+}
+
+func (s *ATCSkill) loadTasks() (ICalendar, string, error) {
+	tasksPath := filepath.Join(s.workspace, "memory", "tasks.xml")
+	var cal ICalendar
+	data, err := os.ReadFile(tasksPath)
+	if err != nil && !os.IsNotExist(err) {
+		return cal, tasksPath, err
+	}
+	if err == nil {
+		if err := xml.Unmarshal(data, &cal); err != nil {
+			return cal, tasksPath, fmt.Errorf("failed to parse tasks.xml: %w", err)
+		}
+	}
+	return cal, tasksPath, nil
+}
+
+// saveTasks writes cal back to tasks.xml.
+func saveTasks(cal ICalendar, tasksPath string) error {
+	out, err := xml.MarshalIndent(cal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tasks: %w", err)
+	}
+	if err := os.WriteFile(tasksPath, append([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"), out...), 0644); err != nil {
+		return fmt.Errorf("failed to write tasks.xml: %w", err)
+	}
+	return nil
+}
+
+// ImportTasks adds the tasks in data to tasks.xml, skipping UIDs already
+// there. With dryRun nothing is written; the report shows what would be.
+func (s *ATCSkill) ImportTasks(data []byte, format, project string, dryRun bool) (string, error) {
+	now := s.now()
+	todos, warn, format, err := parseTaskImport(data, format, project, now)
+	if err != nil {
+		return "", err
+	}
+	cal, tasksPath, err := s.loadTasks()
+	if err != nil {
+		return "", err
+	}
+	have := map[string]bool{}
+	for _, t := range cal.VCal.Components.VTodos {
+		have[t.Properties.Uid] = true
+	}
+
+	var sb strings.Builder
+	added, dup := 0, 0
+	stamp := now.UTC().Format(time.RFC3339)
+	for _, p := range todos {
+		if have[p.Uid] {
+			dup++
+			if dryRun && dup <= 20 {
+				fmt.Fprintf(&sb, "= %s (UID: %s) already in tasks.xml\n", p.Summary, p.Uid)
+			}
+			continue
+		}
+		have[p.Uid] = true
+		added++
+		if p.Dtstamp == "" {
+			p.Dtstamp = stamp
+		}
+		if p.LastModified == "" {
+			p.LastModified = stamp
+		}
+		cal.VCal.Components.VTodos = append(cal.VCal.Components.VTodos, VTodo{Properties: p})
+		if dryRun && added <= 50 {
+			fmt.Fprintf(&sb, "+ %s\n", importPreview(p))
+		}
+	}
+	for _, w := range warn {
+		fmt.Fprintf(&sb, "⚠️ %s\n", w)
+	}
+
+	if dryRun {
+		return fmt.Sprintf("🔎 Dry run (%s): %d tasks would be added, %d already present.\n%s", format, added, dup, strings.TrimRight(sb.String(), "\n")), nil
+	}
+	if added > 0 {
+		if cal.VCal.Properties.Version == "" {
+			cal.VCal.Properties = VCalProperties{Version: "2.0", Prodid: "-//Son of Anthon//ATC Agent//EN"}
+		}
+		if err := saveTasks(cal, tasksPath); err != nil {
+			return "", err
+		}
+		s.recordEvents(func(st *StatsStore, now time.Time) error {
+			return st.Observe(cal.VCal.Components.VTodos, now)
+		})
+	}
+	msg := fmt.Sprintf("📥 Imported %d tasks (%s); %d already in tasks.xml were skipped.", added, format, dup)
+	if len(warn) > 0 {
+		msg += "\n" + strings.TrimRight(sb.String(), "\n")
+	}
+	return msg, nil
+}
+
+// importPreview is one dry-run line.
+func importPreview(p VTodoProperties) string {
+	line := p.Summary
+	if due := normDue(p); due != "" {
+		line += " · due " + due
+	}
+	if p.Priority > 0 {
+		line += fmt.Sprintf(" · priority %d", p.Priority)
+	}
+	if p.Categories != "" {
+		line += " · " + p.Categories
+	}
+	if p.RRule != "" {
+		line += " · 🔁 " + p.RRule
+	}
+	if isFinished(p.Status) {
+		line += " · " + strings.ToLower(p.Status)
+	}
+	if parent := p.Parent(); parent != "" {
+		line += " · subtask of " + parent
+	}
+	return line + " (UID: " + p.Uid + ")"
+}
+
+// ExportTasks renders tasks.xml in format, optionally only the open tasks.
+func (s *ATCSkill) ExportTasks(format string, openOnly bool) ([]byte, int, error) {
+	if _, ok := taskFormats[format]; !ok {
+		return nil, 0, fmt.Errorf("unknown export format %q (taskwarrior, todoist-csv, csv or ics)", format)
+	}
+	cal, _, err := s.loadTasks()
+	if err != nil {
+		return nil, 0, err
+	}
+	var todos []VTodoProperties
+	for _, t := range cal.VCal.Components.VTodos {
+		if !openOnly || !isFinished(t.Properties.Status) {
+			todos = append(todos, t.Properties)
+		}
+	}
+	now := s.now()
+	var out []byte
+	switch format {
+	case "taskwarrior":
+		out, err = exportTaskwarrior(todos, now.Location(), now)
+	case "todoist-csv":
+		out, err = exportTodoistCSV(todos, now.Location())
+	case "csv":
+		out, err = exportCSV(todos)
+	case "ics":
+		out = exportICS(todos, now)
+	}
+	return out, len(todos), err
+}
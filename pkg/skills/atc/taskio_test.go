@@ -0,0 +1,108 @@
+package atc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportFormats(t *testing.T) {
+	loc := time.FixedZone("UTC+6", 6*3600)
+	now := time.Date(2026, 3, 3, 10, 0, 0, 0, loc) // a Tuesday
+
+	tw := `[
+{"uuid":"a","description":"Pay rent","status":"recurring","recur":"monthly","due":"20260131T180000Z","project":"Home.Bills","tags":["money"],"priority":"H"},
+{"uuid":"a1","description":"Pay rent","status":"pending","parent":"a","recur":"monthly","due":"20260228T180000Z"},
+{"uuid":"b","description":"Deploy","status":"pending","depends":"c","due":"20260305T120000Z","annotations":[{"entry":"x","description":"after review"}]},
+{"uuid":"d","description":"Old","status":"deleted"}]`
+	todos, _, format, err := parseTaskImport([]byte(tw), "", "", now)
+	if err != nil || format != "taskwarrior" || len(todos) != 2 {
+		t.Fatalf("taskwarrior: %s %v %+v", format, err, todos)
+	}
+	if r := todos[0]; r.DueDate != "2026-03-01" || r.RRule != "FREQ=MONTHLY" || r.Priority != 1 || r.Categories != "Home,Bills,money" {
+		t.Errorf("recurring template = %+v", r)
+	}
+	if d := todos[1]; d.Due != "2026-03-05T12:00:00Z" || d.Description != "after review" || len(d.DependsOn()) != 1 {
+		t.Errorf("dependent task = %+v", d)
+	}
+
+	csvData := "TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+		"section,Errands,,,,,,,,\n" +
+		"task,Buy milk @shop,,1,1,,,every monday,en,\n" +
+		"task,Skimmed,,4,2,,,2026-03-04,en,\n" +
+		"note,Not the blue one,,,,,,,,\n"
+	todos, _, format, err = parseTaskImport([]byte(csvData), "", "Home", now)
+	if err != nil || format != "todoist-csv" || len(todos) != 2 {
+		t.Fatalf("todoist csv: %s %v %+v", format, err, todos)
+	}
+	if m := todos[0]; m.Summary != "Buy milk" || m.Priority != 1 || m.Categories != "Home,Errands,shop" ||
+		m.RRule != "FREQ=WEEKLY;BYDAY=MO" || m.DueDate != "2026-03-09" {
+		t.Errorf("todoist task = %+v", m)
+	}
+	if s := todos[1]; s.Parent() != todos[0].Uid || s.DueDate != "2026-03-04" || s.Description != "Not the blue one" || s.Priority != 0 {
+		t.Errorf("todoist subtask = %+v", s)
+	}
+	twice := csvData + "task,Buy milk,,1,1,,,,en,\n"
+	todos, _, _, _ = parseTaskImport([]byte(twice), "", "Home", now)
+	if len(todos) != 3 || todos[2].Uid == todos[0].Uid {
+		t.Errorf("same-titled siblings share a UID: %+v", todos)
+	}
+	if again, _, _, _ := parseTaskImport([]byte(twice), "", "Home", now); len(again) != 3 || again[0].Uid != todos[0].Uid || again[2].Uid != todos[2].Uid {
+		t.Errorf("re-import changed UIDs: %+v", again)
+	}
+
+	js := `{"projects":[{"id":"7","name":"Work"}],"items":[{"id":9,"content":"Standup notes","priority":3,"project_id":"7",
+		"labels":["daily"],"due":{"date":"2026-03-03T09:00:00","string":"every weekday at 9am","is_recurring":true}}]}`
+	todos, _, format, err = parseTaskImport([]byte(js), "", "", now)
+	if err != nil || format != "todoist-json" || len(todos) != 1 {
+		t.Fatalf("todoist json: %s %v %+v", format, err, todos)
+	}
+	if j := todos[0]; j.Uid != "todoist-9" || j.Priority != 5 || j.Categories != "Work,daily" ||
+		j.Due != "2026-03-03T03:00:00Z" || !strings.HasPrefix(j.RRule, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR") {
+		t.Errorf("todoist json task = %+v", j)
+	}
+}
+
+func TestImportExportRoundTrip(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	s := &ATCSkill{workspace: ws}
+	in := "Title,Due,Priority,Tags,Repeat,Done\n" +
+		"File taxes,2026-04-15,high,admin;money,,\n" +
+		"Water plants,2026-03-04 08:00,low,home,every 3 days,\n" +
+		"Old thing,,,,,yes\n"
+
+	preview, err := s.ImportTasks([]byte(in), "", "", true)
+	if err != nil || !strings.Contains(preview, "3 tasks would be added") || !strings.Contains(preview, "🔁 FREQ=DAILY;INTERVAL=3") {
+		t.Fatalf("dry run = %q, %v", preview, err)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "memory", "tasks.xml")); err == nil {
+		t.Fatal("dry run wrote tasks.xml")
+	}
+	if msg, err := s.ImportTasks([]byte(in), "csv", "", false); err != nil || !strings.Contains(msg, "Imported 3 tasks") {
+		t.Fatalf("import = %q, %v", msg, err)
+	}
+	if msg, _ := s.ImportTasks([]byte(in), "csv", "", false); !strings.Contains(msg, "Imported 0 tasks") || !strings.Contains(msg, "3 already") {
+		t.Errorf("re-import = %q", msg)
+	}
+
+	for _, format := range []string{"ics", "csv", "taskwarrior"} {
+		data, n, err := s.ExportTasks(format, true)
+		if err != nil || n != 2 {
+			t.Fatalf("export %s: %d, %v", format, n, err)
+		}
+		back, _, _, err := parseTaskImport(data, "", "", time.Now())
+		if err != nil || len(back) != 2 {
+			t.Fatalf("re-read %s: %v %+v", format, err, back)
+		}
+		if back[0].Summary != "File taxes" || back[0].DueDate != "2026-04-15" || back[0].Priority != 1 ||
+			back[1].RRule != "FREQ=DAILY;INTERVAL=3" || back[1].Due == "" {
+			t.Errorf("%s round trip = %+v", format, back)
+		}
+	}
+	if data, _, _ := s.ExportTasks("todoist-csv", true); !strings.Contains(string(data), "every 3 days starting") {
+		t.Errorf("todoist csv:\n%s", data)
+	}
+}
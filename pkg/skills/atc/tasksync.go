@@ -0,0 +1,926 @@
+package atc
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+)
+
+// ----------------------------------------------------------------------------
+// Two-way task sync
+//
+// sync_tasks reconciles tasks.xml with the Nextcloud tasks collection by
+// UID. memory/sync.db remembers, per task, the href, ETag and a hash of the
+// synced fields as of the last successful sync, so each side's changes can
+// be told apart. Tasks deleted on one side leave a tombstone and are
+// deleted on the other instead of being copied back.
+//
+// Conflicts (both sides changed) are resolved by policy: "newest" compares
+// LAST-MODIFIED with the local edit time, "local" or "remote" always win.
+// Whatever the policy, a task finished (COMPLETED / CANCELLED) on either
+// side stays finished.
+// ----------------------------------------------------------------------------
+
+// SyncFileName is the sync state database inside ATC's memory directory.
+const SyncFileName = "sync.db"
+
+// Conflict policies for sync_tasks.
+const (
+	ConflictNewest = "newest"
+	ConflictLocal  = "local"
+	ConflictRemote = "remote"
+)
+
+// tombstoneTTL is how long a delete is remembered.
+const tombstoneTTL = 30 * 24 * time.Hour
+
+// remoteTodo is one VTODO from the server, in tasks.xml's shape.
+type remoteTodo struct {
+	Href         string
+	ETag         string
+	Props        VTodoProperties // Categories holds the raw CATEGORIES list
+	LastModified time.Time
+	Extra        []string // unmanaged property lines, kept verbatim on write
+}
+
+// syncState is what the last sync saw for one task.
+type syncState struct {
+	Href string
+	ETag string
+	Hash string
+}
+
+// ----------------------------------------------------------------------------
+// Field mapping
+// ----------------------------------------------------------------------------
+
+// dayCategory reduces a category list to the part that is synced: "today",
+// "tomorrow" or "".
+func dayCategory(categories string) string {
+	for _, c := range strings.Split(strings.ToLower(categories), ",") {
+		switch strings.TrimSpace(c) {
+		case "today":
+			return "today"
+		case "tomorrow":
+			return "tomorrow"
+		}
+	}
+	return ""
+}
+
+func isFinished(status string) bool {
+	s := strings.ToUpper(status)
+	return s == "COMPLETED" || s == "CANCELLED"
+}
+
+func normStatus(status string) string {
+	if status == "" {
+		return "NEEDS-ACTION"
+	}
+	return strings.ToUpper(status)
+}
+
+// normDue renders either due field in one comparable form.
+func normDue(t VTodoProperties) string {
+	if t.DueDate != "" {
+		return t.DueDate
+	}
+	if ts, err := time.Parse(time.RFC3339, t.Due); err == nil {
+		return ts.UTC().Format(time.RFC3339)
+	}
+	return t.Due
+}
+
+// normStart is normDue for DTSTART.
+func normStart(t VTodoProperties) string {
+	return normDue(VTodoProperties{Due: t.Dtstart, DueDate: t.DtstartDate})
+}
+
+// syncHash fingerprints the fields both sides share. The recurrence,
+// estimate and time spent are only mixed in when set, so older tasks keep
+// their hash.
+func syncHash(t VTodoProperties) string {
+	h := sha1.New()
+	fields := []string{t.Summary, normStatus(t.Status), strconv.Itoa(t.Priority), normDue(t),
+		strings.TrimSpace(t.Description), dayCategory(t.Categories), relationKey(t.RelatedTo)}
+	if t.RRule != "" {
+		fields = append(fields, t.RRule, normStart(t))
+	}
+	if t.Estimate != "" {
+		fields = append(fields, "estimate:"+t.Estimate)
+	}
+	if t.TimeSpent != "" {
+		fields = append(fields, "spent:"+t.TimeSpent)
+	}
+	if t.Percent > 0 && normStatus(t.Status) != "COMPLETED" {
+		fields = append(fields, "percent:"+strconv.Itoa(t.Percent))
+	}
+	for _, f := range fields {
+		io.WriteString(h, f)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// localTime is when a local task was last edited, zero if unknown.
+func localTime(t VTodoProperties) time.Time {
+	ts, _ := time.Parse(time.RFC3339, t.LastModified)
+	return ts
+}
+
+// fromRemote builds the local copy of a remote task, keeping the local
+// categories other than Today/Tomorrow. New tasks without a day category
+// are placed by their due date.
+func fromRemote(r remoteTodo, local *VTodoProperties, now time.Time) VTodoProperties {
+	t := r.Props
+	t.LastModified = r.LastModified.UTC().Format(time.RFC3339)
+	if r.LastModified.IsZero() {
+		t.LastModified = now.UTC().Format(time.RFC3339)
+	}
+	day := dayCategory(r.Props.Categories)
+	if local != nil {
+		t.Categories = withDayCategory(local.Categories, day)
+		return t
+	}
+	if day == "" {
+		due := normDue(t)
+		if len(due) >= 10 {
+			switch due[:10] {
+			case now.Format("2006-01-02"):
+				day = "today"
+			case now.AddDate(0, 0, 1).Format("2006-01-02"):
+				day = "tomorrow"
+			}
+		}
+	}
+	t.Categories = day
+	return t
+}
+
+// withDayCategory replaces the Today/Tomorrow entry of a category list.
+func withDayCategory(categories, day string) string {
+	var out []string
+	for _, c := range strings.Split(categories, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" || dayCategory(c) != "" {
+			continue
+		}
+		out = append(out, c)
+	}
+	if day != "" {
+		out = append([]string{day}, out...)
+	}
+	return strings.Join(out, ",")
+}
+
+// remoteCategories is the CATEGORIES value to write: the server's own
+// categories with Today/Tomorrow taken from the local task.
+func remoteCategories(local VTodoProperties, remote *remoteTodo) string {
+	existing := ""
+	if remote != nil {
+		existing = remote.Props.Categories
+	}
+	var out []string
+	for _, c := range strings.Split(existing, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" || dayCategory(c) != "" {
+			continue
+		}
+		out = append(out, c)
+	}
+	switch dayCategory(local.Categories) {
+	case "today":
+		out = append([]string{"Today"}, out...)
+	case "tomorrow":
+		out = append([]string{"Tomorrow"}, out...)
+	}
+	return strings.Join(out, ",")
+}
+
+// ----------------------------------------------------------------------------
+// Reconciliation
+// ----------------------------------------------------------------------------
+
+// syncAction is what one sync does for one UID.
+type syncAction struct {
+	UID    string
+	Kind   string           // syncUpdate, syncDeleteLocal, syncDeleteRemote or syncForget
+	Local  *VTodoProperties // new local copy to write, nil to leave tasks.xml alone
+	Remote *VTodoProperties // task to PUT, nil to leave the server alone
+	Create bool             // Remote is a new server object
+	Href   string
+	ETag   string
+	Extra  []string
+	Cats   string // CATEGORIES for the PUT
+	Note   string // why, for conflicts and resurrections
+}
+
+const (
+	syncUpdate       = "update"
+	syncDeleteLocal  = "delete_local"
+	syncDeleteRemote = "delete_remote"
+	syncForget       = "forget"
+)
+
+// reconcile decides what to do for every UID seen locally, remotely or in
+// the sync state. It does no I/O.
+func reconcile(local []VTodoProperties, remote []remoteTodo, state map[string]syncState, tombs map[string]bool, policy string, now time.Time) []syncAction {
+	localBy := map[string]VTodoProperties{}
+	remoteBy := map[string]remoteTodo{}
+	uids := map[string]bool{}
+	for _, t := range local {
+		if t.Uid != "" {
+			localBy[t.Uid] = t
+			uids[t.Uid] = true
+		}
+	}
+	for _, r := range remote {
+		remoteBy[r.Props.Uid] = r
+		uids[r.Props.Uid] = true
+	}
+	for u := range state {
+		uids[u] = true
+	}
+	sorted := make([]string, 0, len(uids))
+	for u := range uids {
+		sorted = append(sorted, u)
+	}
+	sort.Strings(sorted)
+
+	var plan []syncAction
+	for _, uid := range sorted {
+		l, hasL := localBy[uid]
+		r, hasR := remoteBy[uid]
+		st, known := state[uid]
+		a := syncAction{UID: uid, Kind: syncUpdate, Href: r.Href, ETag: r.ETag, Extra: r.Extra}
+
+		// push sends t to the server; pull writes the server copy locally.
+		push := func(t VTodoProperties) {
+			a.Remote = &t
+			if hasR {
+				a.Cats = remoteCategories(t, &r)
+			} else {
+				a.Create, a.Cats = true, remoteCategories(t, nil)
+			}
+		}
+		pull := func() {
+			var prev *VTodoProperties
+			if hasL {
+				prev = &l
+			}
+			t := fromRemote(r, prev, now)
+			a.Local = &t
+		}
+
+		switch {
+		case hasL && hasR:
+			lh, rh := syncHash(l), syncHash(r.Props)
+			localChanged := !known || lh != st.Hash
+			remoteChanged := !known || (st.ETag != r.ETag && rh != st.Hash)
+			switch {
+			case lh == rh:
+				if known && st.ETag == r.ETag && st.Href == r.Href {
+					continue
+				}
+				// Same content; just (re)start tracking it.
+			case !remoteChanged:
+				push(l)
+			case !localChanged:
+				pull()
+			default:
+				winner, note := resolveConflict(l, r, policy)
+				a.Note = note
+				pull()
+				merged := *a.Local
+				if winner == "local" {
+					merged = l
+				}
+				// Finishing a task is never undone by a conflict.
+				switch {
+				case isFinished(l.Status) && !isFinished(merged.Status):
+					merged.Status = l.Status
+				case isFinished(r.Props.Status) && !isFinished(merged.Status):
+					merged.Status = r.Props.Status
+				}
+				merged.LastModified = now.UTC().Format(time.RFC3339)
+				a.Local = nil
+				if syncHash(merged) != lh {
+					a.Local = &merged
+				}
+				if syncHash(merged) != rh {
+					push(merged)
+				}
+			}
+
+		case hasL:
+			switch {
+			case !known && tombs[uid]:
+				a.Kind = syncDeleteLocal
+			case !known:
+				push(l)
+			case syncHash(l) != st.Hash:
+				a.Note = "deleted on Nextcloud but edited locally; re-created"
+				push(l)
+			default:
+				a.Kind = syncDeleteLocal
+			}
+
+		case hasR:
+			switch {
+			case !known && tombs[uid]:
+				a.Kind = syncDeleteRemote
+			case !known:
+				pull()
+			case st.ETag != r.ETag && syncHash(r.Props) != st.Hash:
+				a.Note = "deleted locally but edited on Nextcloud; restored"
+				pull()
+			default:
+				a.Kind = syncDeleteRemote
+			}
+
+		default:
+			a.Kind = syncForget
+		}
+		plan = append(plan, a)
+	}
+	return plan
+}
+
+// resolveConflict picks the side whose fields win when both changed.
+func resolveConflict(l VTodoProperties, r remoteTodo, policy string) (string, string) {
+	switch policy {
+	case ConflictLocal:
+		return "local", "changed on both sides; kept local (policy local)"
+	case ConflictRemote:
+		return "remote", "changed on both sides; kept Nextcloud (policy remote)"
+	}
+	lt := localTime(l)
+	if !lt.IsZero() && lt.After(r.LastModified) {
+		return "local", "changed on both sides; local edit is newer"
+	}
+	return "remote", "changed on both sides; Nextcloud edit is newer"
+}
+
+// syncResult counts what a sync did.
+type syncResult struct {
+	Pushed, CreatedRemote, DeletedRemote int
+	Pulled, CreatedLocal, DeletedLocal   int
+	Notes, Errors                        []string
+}
+
+func (r syncResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Nextcloud: %d updated, %d created, %d deleted\n", r.Pushed, r.CreatedRemote, r.DeletedRemote)
+	fmt.Fprintf(&sb, "Local: %d updated, %d created, %d deleted\n", r.Pulled, r.CreatedLocal, r.DeletedLocal)
+	if len(r.Notes) > 0 {
+		fmt.Fprintf(&sb, "\n⚖️ Conflicts (%d):\n", len(r.Notes))
+		for _, n := range r.Notes {
+			sb.WriteString("- " + n + "\n")
+		}
+	}
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(&sb, "\n⚠️ Errors (%d):\n", len(r.Errors))
+		for _, e := range r.Errors {
+			sb.WriteString("- " + e + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// applySync carries out a plan against the server, cal and the sync state.
+// A failed server write leaves that task untracked so the next sync retries
+// it. changed lists the local tasks written from the server, deleted those
+// removed because they went away on Nextcloud.
+func applySync(cfg ATCCalendarConfig, cal *ICalendar, store *syncStore, state map[string]syncState, plan []syncAction, now time.Time) (res syncResult, changed, deleted []VTodoProperties) {
+	todos := cal.VCal.Components.VTodos
+	index := func(uid string) int {
+		for i, t := range todos {
+			if t.Properties.Uid == uid {
+				return i
+			}
+		}
+		return -1
+	}
+	fail := func(a syncAction, err error) {
+		res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", a.UID, err))
+	}
+
+	for _, a := range plan {
+		switch a.Kind {
+		case syncForget:
+			store.forget(a.UID)
+
+		case syncDeleteLocal:
+			if i := index(a.UID); i >= 0 {
+				deleted = append(deleted, todos[i].Properties)
+				todos = append(todos[:i], todos[i+1:]...)
+				res.DeletedLocal++
+			}
+			if err := store.bury(a.UID, state[a.UID].Href, "nextcloud", now); err != nil {
+				fail(a, err)
+			}
+
+		case syncDeleteRemote:
+			if err := deleteTodo(cfg, a.Href, a.ETag); err != nil {
+				fail(a, err)
+				continue
+			}
+			res.DeletedRemote++
+			if err := store.bury(a.UID, a.Href, "local", now); err != nil {
+				fail(a, err)
+			}
+
+		case syncUpdate:
+			href, etag := a.Href, a.ETag
+			if a.Remote != nil {
+				var err error
+				href, etag, err = putTodo(cfg, a.UID, a.Href, a.ETag, a.Create, buildVTodo(*a.Remote, a.Cats, a.Extra, now))
+				if err != nil {
+					fail(a, err)
+					continue
+				}
+				if a.Create {
+					res.CreatedRemote++
+				} else {
+					res.Pushed++
+				}
+			}
+			final := VTodoProperties{}
+			if i := index(a.UID); i >= 0 {
+				final = todos[i].Properties
+			}
+			if a.Local != nil {
+				if i := index(a.UID); i >= 0 {
+					todos[i].Properties = *a.Local
+					res.Pulled++
+				} else {
+					todos = append(todos, VTodo{Properties: *a.Local})
+					res.CreatedLocal++
+				}
+				changed = append(changed, *a.Local)
+				final = *a.Local
+			}
+			if a.Remote != nil {
+				final = *a.Remote
+			}
+			if a.Note != "" {
+				res.Notes = append(res.Notes, fmt.Sprintf("%q: %s", final.Summary, a.Note))
+			}
+			if err := store.track(a.UID, syncState{Href: href, ETag: etag, Hash: syncHash(final)}, now); err != nil {
+				fail(a, err)
+			}
+		}
+	}
+	cal.VCal.Components.VTodos = todos
+	return res, changed, deleted
+}
+
+// ----------------------------------------------------------------------------
+// State
+// ----------------------------------------------------------------------------
+
+type syncStore struct {
+	db *sql.DB
+}
+
+func openSyncStore(path string) (*syncStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := `
+	CREATE TABLE IF NOT EXISTS task_sync (
+		uid TEXT PRIMARY KEY,
+		href TEXT,
+		etag TEXT,
+		hash TEXT,
+		synced_at INTEGER
+	);
+	CREATE TABLE IF NOT EXISTS tombstones (
+		uid TEXT PRIMARY KEY,
+		href TEXT,
+		side TEXT,
+		deleted_at INTEGER
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &syncStore{db: db}, nil
+}
+
+func (s *syncStore) Close() error {
+	return s.db.Close()
+}
+
+// load reads the sync state and tombstones for sync_tasks, first expiring
+// tombstones older than tombstoneTTL. Only sync_tasks may call it, under
+// tasksMu; read-only callers use hrefs.
+func (s *syncStore) load(now time.Time) (map[string]syncState, map[string]bool, error) {
+	if _, err := s.db.Exec("DELETE FROM tombstones WHERE deleted_at < ?", now.Add(-tombstoneTTL).Unix()); err != nil {
+		return nil, nil, err
+	}
+	state := map[string]syncState{}
+	rows, err := s.db.Query("SELECT uid, href, etag, hash FROM task_sync")
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var uid string
+		var st syncState
+		if err := rows.Scan(&uid, &st.Href, &st.ETag, &st.Hash); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		state[uid] = st
+	}
+	rows.Close()
+
+	tombs := map[string]bool{}
+	rows, err = s.db.Query("SELECT uid FROM tombstones")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, nil, err
+		}
+		tombs[uid] = true
+	}
+	return state, tombs, rows.Err()
+}
+
+// hrefs maps each synced UID to its Nextcloud href without changing the
+// store.
+func (s *syncStore) hrefs() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT uid, href FROM task_sync")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var uid, href string
+		if err := rows.Scan(&uid, &href); err != nil {
+			return nil, err
+		}
+		out[uid] = href
+	}
+	return out, rows.Err()
+}
+
+func (s *syncStore) track(uid string, st syncState, now time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO task_sync (uid, href, etag, hash, synced_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(uid) DO UPDATE SET href = excluded.href, etag = excluded.etag, hash = excluded.hash, synced_at = excluded.synced_at`,
+		uid, st.Href, st.ETag, st.Hash, now.Unix())
+	return err
+}
+
+// bury forgets a task and remembers that it was deleted on side.
+func (s *syncStore) bury(uid, href, side string, now time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM task_sync WHERE uid = ?", uid); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO tombstones (uid, href, side, deleted_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(uid) DO UPDATE SET href = excluded.href, side = excluded.side, deleted_at = excluded.deleted_at`,
+		uid, href, side, now.Unix())
+	return err
+}
+
+func (s *syncStore) forget(uid string) error {
+	_, err := s.db.Exec("DELETE FROM task_sync WHERE uid = ?", uid)
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// CalDAV
+// ----------------------------------------------------------------------------
+
+func syncClient(cfg ATCCalendarConfig) *http.Client {
+	timeout := 10 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// multistatus is the subset of a CalDAV REPORT response we read.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ETag         string `xml:"getetag"`
+				CalendarData string `xml:"calendar-data"`
+			} `xml:"prop"`
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// fetchRemoteTodos loads every VTODO with its ETag in one calendar-query REPORT.
+func fetchRemoteTodos(cfg ATCCalendarConfig, loc *time.Location) ([]remoteTodo, error) {
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO"/></c:comp-filter></c:filter>
+</c:calendar-query>`
+	req, err := http.NewRequest("REPORT", buildTasksURL(cfg), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	resp, err := syncClient(cfg).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("REPORT failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CalDAV REPORT returned %d", resp.StatusCode)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("parsing REPORT response: %w", err)
+	}
+	var out []remoteTodo
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstat {
+			if ps.Prop.CalendarData == "" {
+				continue
+			}
+			if t, ok := parseRemoteTodo(ps.Prop.CalendarData, loc); ok {
+				t.Href = strings.TrimSpace(r.Href)
+				t.ETag = strings.TrimSpace(ps.Prop.ETag)
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+// ParseVTodo reads the first VTODO of an iCalendar object as tasks.xml
+// properties, for other skills that fetch tasks from CalDAV themselves.
+func ParseVTodo(ics string, loc *time.Location) (VTodoProperties, bool) {
+	t, ok := parseRemoteTodo(ics, loc)
+	return t.Props, ok
+}
+
+// parseRemoteTodo reads the first VTODO of an iCalendar object.
+func parseRemoteTodo(ics string, loc *time.Location) (remoteTodo, bool) {
+	var t remoteTodo
+	var cats []string
+	depth := 0 // 1 inside VTODO, >1 inside its sub-components (VALARM)
+	found := false
+	for _, line := range normalizeICSLines(strings.Split(strings.ReplaceAll(ics, "\r\n", "\n"), "\n")) {
+		line = strings.TrimRight(line, "\r")
+		keyPart, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, params, _ := strings.Cut(keyPart, ";")
+		key = strings.ToUpper(strings.TrimSpace(key))
+
+		if depth == 0 {
+			if key == "BEGIN" && strings.EqualFold(val, "VTODO") && !found {
+				depth, found = 1, true
+			}
+			continue
+		}
+		if key == "END" && strings.EqualFold(val, "VTODO") && depth == 1 {
+			depth = 0
+			continue
+		}
+		if depth > 1 || key == "BEGIN" || key == "END" {
+			switch key {
+			case "BEGIN":
+				depth++
+			case "END":
+				depth--
+			}
+			t.Extra = append(t.Extra, line)
+			continue
+		}
+
+		switch key {
+		case "UID":
+			t.Props.Uid = val
+		case "SUMMARY":
+			t.Props.Summary = unescapeICS(val)
+		case "DESCRIPTION":
+			t.Props.Description = unescapeICS(val)
+		case "STATUS":
+			t.Props.Status = strings.ToUpper(val)
+		case "PRIORITY":
+			t.Props.Priority, _ = strconv.Atoi(strings.TrimSpace(val))
+		case "CATEGORIES":
+			for _, c := range strings.Split(val, ",") {
+				if c = strings.TrimSpace(unescapeICS(c)); c != "" {
+					cats = append(cats, c)
+				}
+			}
+		case "DUE":
+			if ts, allDay, err := caldav.ParseICSTime(val, params, loc); err == nil {
+				if allDay {
+					t.Props.DueDate = ts.Format("2006-01-02")
+				} else {
+					t.Props.Due = ts.UTC().Format(time.RFC3339)
+				}
+			}
+		case "DTSTART":
+			if ts, allDay, err := caldav.ParseICSTime(val, params, loc); err == nil {
+				if allDay {
+					t.Props.DtstartDate = ts.Format("2006-01-02")
+				} else {
+					t.Props.Dtstart = ts.UTC().Format(time.RFC3339)
+				}
+			}
+		case "RRULE":
+			t.Props.RRule = strings.TrimSpace(val)
+		case "X-ESTIMATE":
+			t.Props.Estimate = strings.TrimSpace(val)
+		case "X-TIME-SPENT":
+			t.Props.TimeSpent = strings.TrimSpace(val)
+		case "RELATED-TO":
+			t.Props.RelatedTo = append(t.Props.RelatedTo, parseRelatedTo(params, val))
+		case "LAST-MODIFIED":
+			if ts, _, err := caldav.ParseICSTime(val, params, loc); err == nil {
+				t.LastModified = ts
+			}
+		case "DTSTAMP":
+			if ts, _, err := caldav.ParseICSTime(val, params, loc); err == nil {
+				t.Props.Dtstamp = ts.UTC().Format(time.RFC3339)
+			}
+		case "COMPLETED":
+			if ts, _, err := caldav.ParseICSTime(val, params, loc); err == nil {
+				t.Props.Completed = ts.UTC().Format(time.RFC3339)
+			}
+		case "PERCENT-COMPLETE":
+			t.Props.Percent, _ = strconv.Atoi(strings.TrimSpace(val))
+		default:
+			t.Extra = append(t.Extra, line)
+		}
+	}
+	t.Props.Categories = strings.Join(cats, ",")
+	return t, found && t.Props.Uid != ""
+}
+
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`).Replace(s)
+}
+
+// buildVTodo renders a task as an iCalendar object.
+func buildVTodo(t VTodoProperties, categories string, extra []string, now time.Time) string {
+	stamp := now.UTC().Format("20060102T150405Z")
+	var sb strings.Builder
+	sb.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Son of Anthon ATC//EN\r\nBEGIN:VTODO\r\n")
+	sb.WriteString("UID:" + t.Uid + "\r\n")
+	sb.WriteString("DTSTAMP:" + stamp + "\r\n")
+	sb.WriteString("LAST-MODIFIED:" + stamp + "\r\n")
+	sb.WriteString("SUMMARY:" + escapeICS(t.Summary) + "\r\n")
+	status := normStatus(t.Status)
+	sb.WriteString("STATUS:" + status + "\r\n")
+	switch {
+	case status == "COMPLETED" && t.Completed != "":
+		sb.WriteString("COMPLETED:" + formatRFC3339ToICS(t.Completed) + "\r\nPERCENT-COMPLETE:100\r\n")
+	case status == "COMPLETED":
+		sb.WriteString("COMPLETED:" + stamp + "\r\nPERCENT-COMPLETE:100\r\n")
+	case t.Percent > 0:
+		sb.WriteString(fmt.Sprintf("PERCENT-COMPLETE:%d\r\n", t.Percent))
+	}
+	if t.Priority > 0 {
+		sb.WriteString(fmt.Sprintf("PRIORITY:%d\r\n", t.Priority))
+	}
+	switch {
+	case t.DueDate != "":
+		sb.WriteString("DUE;VALUE=DATE:" + strings.ReplaceAll(t.DueDate, "-", "") + "\r\n")
+	case t.Due != "":
+		sb.WriteString("DUE:" + formatRFC3339ToICS(t.Due) + "\r\n")
+	}
+	switch {
+	case t.DtstartDate != "":
+		sb.WriteString("DTSTART;VALUE=DATE:" + strings.ReplaceAll(t.DtstartDate, "-", "") + "\r\n")
+	case t.Dtstart != "":
+		sb.WriteString("DTSTART:" + formatRFC3339ToICS(t.Dtstart) + "\r\n")
+	}
+	if t.RRule != "" {
+		sb.WriteString("RRULE:" + t.RRule + "\r\n")
+	}
+	if t.Estimate != "" {
+		sb.WriteString("X-ESTIMATE:" + t.Estimate + "\r\n")
+	}
+	if t.TimeSpent != "" {
+		sb.WriteString("X-TIME-SPENT:" + t.TimeSpent + "\r\n")
+	}
+	if t.Description != "" {
+		sb.WriteString("DESCRIPTION:" + escapeICS(t.Description) + "\r\n")
+	}
+	for _, line := range relatedToLines(t.RelatedTo) {
+		sb.WriteString(line + "\r\n")
+	}
+	if categories != "" {
+		var parts []string
+		for _, c := range strings.Split(categories, ",") {
+			parts = append(parts, escapeICS(strings.TrimSpace(c)))
+		}
+		sb.WriteString("CATEGORIES:" + strings.Join(parts, ",") + "\r\n")
+	}
+	for _, line := range extra {
+		sb.WriteString(line + "\r\n")
+	}
+	sb.WriteString("END:VTODO\r\nEND:VCALENDAR\r\n")
+	return sb.String()
+}
+
+// putTodo writes a task, to href or, for a new one, to <uid>.ics. With
+// etag set the write only succeeds if the server copy is unchanged; create
+// refuses to overwrite an existing object. It returns the href written and
+// the new ETag ("" if the server sent none).
+func putTodo(cfg ATCCalendarConfig, uid, href, etag string, create bool, body string) (string, string, error) {
+	target := caldav.FullURL(buildTasksURL(cfg), href)
+	if href == "" {
+		target = buildTasksURL(cfg) + uid + ".ics"
+		if u, err := url.Parse(target); err == nil {
+			href = u.Path
+		}
+	}
+	req, err := http.NewRequest(http.MethodPut, target, strings.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	switch {
+	case create:
+		req.Header.Set("If-None-Match", "*")
+	case etag != "":
+		req.Header.Set("If-Match", etag)
+	}
+	if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	resp, err := syncClient(cfg).Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("PUT failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return href, resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed:
+		return "", "", fmt.Errorf("changed on Nextcloud during sync; will retry next run")
+	}
+	return "", "", fmt.Errorf("CalDAV PUT returned %d", resp.StatusCode)
+}
+
+// deleteTodo removes a task, guarded by its ETag.
+func deleteTodo(cfg ATCCalendarConfig, href, etag string) error {
+	req, err := http.NewRequest(http.MethodDelete, caldav.FullURL(buildTasksURL(cfg), href), nil)
+	if err != nil {
+		return err
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	resp, err := syncClient(cfg).Do(req)
+	if err != nil {
+		return fmt.Errorf("DELETE failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("changed on Nextcloud during sync; will retry next run")
+	}
+	return fmt.Errorf("CalDAV DELETE returned %d", resp.StatusCode)
+}
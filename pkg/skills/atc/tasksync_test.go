@@ -0,0 +1,218 @@
+package atc
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTasks is a minimal CalDAV tasks collection: REPORT, PUT and DELETE
+// with ETag preconditions.
+type fakeTasks struct {
+	mu    sync.Mutex
+	objs  map[string]string // href -> ics
+	etags map[string]string
+	n     int
+}
+
+func (f *fakeTasks) put(href, ics string) {
+	f.n++
+	f.objs[href] = ics
+	f.etags[href] = fmt.Sprintf(`"%d"`, f.n)
+}
+
+func (f *fakeTasks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	href := r.URL.Path
+	switch r.Method {
+	case "REPORT":
+		var buf bytes.Buffer
+		buf.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		hrefs := make([]string, 0, len(f.objs))
+		for h := range f.objs {
+			hrefs = append(hrefs, h)
+		}
+		sort.Strings(hrefs)
+		for _, h := range hrefs {
+			fmt.Fprintf(&buf, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag><cal:calendar-data>`, h, f.etags[h])
+			xml.EscapeText(&buf, []byte(f.objs[h]))
+			buf.WriteString(`</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		}
+		buf.WriteString(`</d:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write(buf.Bytes())
+	case http.MethodPut:
+		_, exists := f.objs[href]
+		if (r.Header.Get("If-None-Match") == "*" && exists) ||
+			(r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != f.etags[href]) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.put(href, string(body))
+		w.Header().Set("ETag", f.etags[href])
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		delete(f.objs, href)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func remoteICS(uid, summary, status, categories string, modified time.Time) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\nSUMMARY:" + summary +
+		"\r\nSTATUS:" + status + "\r\nCATEGORIES:" + categories +
+		"\r\nLAST-MODIFIED:" + modified.UTC().Format("20060102T150405Z") +
+		"\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+}
+
+func TestSyncTasks(t *testing.T) {
+	fake := &fakeTasks{objs: map[string]string{}, etags: map[string]string{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(cfgPath, []byte(`{"tools":{"nextcloud":{"host":"`+srv.URL+`","username":"me"}}}`), 0644)
+	t.Setenv("PERSONAL_OS_CONFIG", cfgPath)
+
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	tasksPath := filepath.Join(ws, "memory", "tasks.xml")
+	writeTasks := func(todos ...VTodoProperties) {
+		var cal ICalendar
+		for _, p := range todos {
+			cal.VCal.Components.VTodos = append(cal.VCal.Components.VTodos, VTodo{Properties: p})
+		}
+		data, _ := xml.MarshalIndent(cal, "", "  ")
+		os.WriteFile(tasksPath, data, 0644)
+	}
+	readTasks := func() map[string]VTodoProperties {
+		data, _ := os.ReadFile(tasksPath)
+		var cal ICalendar
+		xml.Unmarshal(data, &cal)
+		out := map[string]VTodoProperties{}
+		for _, v := range cal.VCal.Components.VTodos {
+			out[v.Properties.Uid] = v.Properties
+		}
+		return out
+	}
+	s := &ATCSkill{workspace: ws}
+	runSync := func() string {
+		res := s.Execute(context.Background(), map[string]interface{}{"command": "sync_tasks"})
+		if res.IsError {
+			t.Fatalf("sync_tasks: %s", res.ForLLM)
+		}
+		return res.ForLLM
+	}
+
+	const base = "/remote.php/dav/calendars/me/tasks/"
+	old := time.Now().Add(-time.Hour)
+	writeTasks(VTodoProperties{Uid: "t1", Summary: "Write report", Status: "NEEDS-ACTION", Categories: "today"})
+	fake.put(base+"r1.ics", remoteICS("r1", "Call bank", "NEEDS-ACTION", "Tomorrow,Errands", old))
+
+	// First sync: each side gets the other's task.
+	out := runSync()
+	if !strings.Contains(out, "Nextcloud: 0 updated, 1 created") || !strings.Contains(out, "Local: 0 updated, 1 created") {
+		t.Errorf("first sync:\n%s", out)
+	}
+	if got := readTasks()["r1"]; got.Summary != "Call bank" || got.Categories != "tomorrow" {
+		t.Errorf("pulled task = %+v", got)
+	}
+	if ics := fake.objs[base+"t1.ics"]; !strings.Contains(ics, "CATEGORIES:Today") {
+		t.Errorf("pushed task:\n%s", ics)
+	}
+	if out := runSync(); !strings.Contains(out, "Nextcloud: 0 updated, 0 created, 0 deleted\nLocal: 0 updated, 0 created, 0 deleted") {
+		t.Errorf("second sync not a no-op:\n%s", out)
+	}
+
+	// Completed on the phone: completed locally.
+	fake.put(base+"t1.ics", remoteICS("t1", "Write report", "COMPLETED", "Today", time.Now()))
+	runSync()
+	if got := readTasks()["t1"]; got.Status != "COMPLETED" {
+		t.Errorf("remote completion not pulled: %+v", got)
+	}
+
+	// Both sides changed r1; the local edit is newer but the phone
+	// completed it, so it stays completed with the local category.
+	fake.put(base+"r1.ics", remoteICS("r1", "Call bank", "COMPLETED", "Tomorrow,Errands", old))
+	s.Execute(context.Background(), map[string]interface{}{"command": "update_task", "task_uid": "r1", "status": "IN-PROCESS"})
+	tasks := readTasks()
+	r1 := tasks["r1"]
+	r1.Categories = "today"
+	writeTasks(tasks["t1"], r1)
+	out = runSync()
+	if !strings.Contains(out, "Conflicts (1)") || !strings.Contains(out, "local edit is newer") {
+		t.Errorf("conflict not reported:\n%s", out)
+	}
+	if got := readTasks()["r1"]; got.Status != "COMPLETED" || got.Categories != "today" {
+		t.Errorf("conflict result local = %+v", got)
+	}
+	ics := fake.objs[base+"r1.ics"]
+	for _, want := range []string{"STATUS:COMPLETED", "PERCENT-COMPLETE:100", "CATEGORIES:Today,Errands", "BEGIN:VALARM"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("conflict result remote missing %q:\n%s", want, ics)
+		}
+	}
+
+	// Deleted on Nextcloud: deleted locally, and a stale copy does not come back.
+	delete(fake.objs, base+"t1.ics")
+	runSync()
+	if _, ok := readTasks()["t1"]; ok {
+		t.Error("remote delete not applied locally")
+	}
+	writeTasks(append([]VTodoProperties{{Uid: "t1", Summary: "Write report", Status: "COMPLETED"}}, readTasks()["r1"])...)
+	runSync()
+	if _, ok := fake.objs[base+"t1.ics"]; ok {
+		t.Error("tombstoned task re-created on Nextcloud")
+	}
+
+	// Deleted locally: deleted on Nextcloud.
+	writeTasks()
+	runSync()
+	if len(fake.objs) != 0 {
+		t.Errorf("local delete not pushed: %v", fake.objs)
+	}
+}
+
+func TestParseVTodoUnfoldsAndIgnoresParams(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:abc-\r\n 123\r\nSUMMARY;LANGUAGE=en:Renew passport\\, visa\r\nPRIORITY:2\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	p, ok := ParseVTodo(ics, time.UTC)
+	if !ok || p.Uid != "abc-123" || p.Summary != "Renew passport, visa" || p.Priority != 2 {
+		t.Errorf("ParseVTodo = %+v, %v", p, ok)
+	}
+}
+
+func TestSyncStoreHrefsLeavesTombstones(t *testing.T) {
+	store, err := openSyncStore(filepath.Join(t.TempDir(), SyncFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	store.track("t1", syncState{Href: "/tasks/t1.ics"}, now)
+	store.bury("t2", "/tasks/t2.ics", "local", now.Add(-2*tombstoneTTL))
+
+	hrefs, err := store.hrefs()
+	if err != nil || hrefs["t1"] != "/tasks/t1.ics" {
+		t.Fatalf("hrefs = %v, %v", hrefs, err)
+	}
+	var n int
+	store.db.QueryRow("SELECT COUNT(*) FROM tombstones").Scan(&n)
+	if n != 1 {
+		t.Fatalf("hrefs expired tombstones: %d left", n)
+	}
+	if _, tombs, _ := store.load(now); tombs["t2"] {
+		t.Error("load kept an expired tombstone")
+	}
+}
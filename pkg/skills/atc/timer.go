@@ -0,0 +1,547 @@
+package atc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/users"
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+// ----------------------------------------------------------------------------
+// Time tracking
+//
+// start_timer, stop_timer and pomodoro log work sessions per task UID in
+// stats.db, so a running timer survives a gateway restart. Only one session
+// runs at a time: starting another stops the current one. Pomodoro and
+// break ends are announced over the message bus to the chat that started
+// them. Totals per task, category and day go into stats-today.md for the
+// evening review, and a task's running total is written back to its VTODO
+// as X-TIME-SPENT. Pomodoro lengths come from tools.pomodoro.
+// ----------------------------------------------------------------------------
+
+// Session kinds. Breaks are logged but never count as time spent.
+const (
+	SessionTimer    = "timer"
+	SessionPomodoro = "pomodoro"
+	SessionBreak    = "break"
+)
+
+// PomodoroConfig configures the pomodoro command.
+type PomodoroConfig struct {
+	WorkMinutes       int `json:"work_minutes"`
+	ShortBreakMinutes int `json:"short_break_minutes"`
+	LongBreakMinutes  int `json:"long_break_minutes"`
+	LongBreakEvery    int `json:"long_break_every"` // long break after every Nth pomodoro of the day
+}
+
+// DefaultPomodoroConfig is the classic 25/5 with a 15-minute break every fourth.
+func DefaultPomodoroConfig() PomodoroConfig {
+	return PomodoroConfig{WorkMinutes: 25, ShortBreakMinutes: 5, LongBreakMinutes: 15, LongBreakEvery: 4}
+}
+
+// LoadPomodoroConfig reads tools.pomodoro on top of the defaults.
+func LoadPomodoroConfig() PomodoroConfig {
+	var cfg struct {
+		Tools struct {
+			Pomodoro PomodoroConfig `json:"pomodoro"`
+		} `json:"tools"`
+	}
+	cfg.Tools.Pomodoro = DefaultPomodoroConfig()
+	if readConfig(&cfg) != nil {
+		return DefaultPomodoroConfig()
+	}
+	p, def := cfg.Tools.Pomodoro, DefaultPomodoroConfig()
+	if p.WorkMinutes <= 0 {
+		p.WorkMinutes = def.WorkMinutes
+	}
+	if p.ShortBreakMinutes <= 0 {
+		p.ShortBreakMinutes = def.ShortBreakMinutes
+	}
+	if p.LongBreakMinutes <= 0 {
+		p.LongBreakMinutes = def.LongBreakMinutes
+	}
+	if p.LongBreakEvery <= 0 {
+		p.LongBreakEvery = def.LongBreakEvery
+	}
+	return p
+}
+
+// breakAfter is the break that follows the nth pomodoro of the day.
+func (p PomodoroConfig) breakAfter(n int) time.Duration {
+	if n > 0 && n%p.LongBreakEvery == 0 {
+		return time.Duration(p.LongBreakMinutes) * time.Minute
+	}
+	return time.Duration(p.ShortBreakMinutes) * time.Minute
+}
+
+// TimeSession is one tracked stretch of work, or a pomodoro break, on a task.
+type TimeSession struct {
+	ID         int64
+	UID        string
+	Summary    string
+	Categories string
+	Kind       string
+	Start      time.Time
+	End        time.Time // zero while running
+	PlannedEnd time.Time // pomodoros and breaks only
+	Channel    string    // where phase reminders are sent
+	ChatID     string
+}
+
+// Running reports whether the session is still open.
+func (t TimeSession) Running() bool {
+	return t.End.IsZero()
+}
+
+// Spent is the session's length. A running session counts up to now, but
+// never past its planned end: an unattended pomodoro is still 25 minutes.
+func (t TimeSession) Spent(now time.Time) time.Duration {
+	end := t.End
+	if end.IsZero() {
+		end = now
+		if !t.PlannedEnd.IsZero() && end.After(t.PlannedEnd) {
+			end = t.PlannedEnd
+		}
+	}
+	if d := end.Sub(t.Start); d > 0 {
+		return d
+	}
+	return 0
+}
+
+const timeSessionsSchema = `
+	CREATE TABLE IF NOT EXISTS time_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		summary TEXT NOT NULL DEFAULT '',
+		categories TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER,
+		planned_end INTEGER,
+		channel TEXT NOT NULL DEFAULT '',
+		chat_id TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_time_sessions_uid ON time_sessions(uid);
+	CREATE INDEX IF NOT EXISTS idx_time_sessions_start ON time_sessions(started_at);`
+
+const sessionColumns = "id, uid, summary, categories, kind, started_at, ended_at, planned_end, channel, chat_id"
+
+func scanSessions(rows *sql.Rows) ([]TimeSession, error) {
+	defer rows.Close()
+	var out []TimeSession
+	for rows.Next() {
+		var t TimeSession
+		var start int64
+		var end, planned sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.UID, &t.Summary, &t.Categories, &t.Kind, &start, &end, &planned, &t.Channel, &t.ChatID); err != nil {
+			return nil, err
+		}
+		t.Start = time.Unix(start, 0)
+		if end.Valid {
+			t.End = time.Unix(end.Int64, 0)
+		}
+		if planned.Valid {
+			t.PlannedEnd = time.Unix(planned.Int64, 0)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func nullUnix(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Unix()
+}
+
+// StartSession stops whatever is running at t.Start and opens t, returning
+// it with its ID together with the sessions it stopped.
+func (s *StatsStore) StartSession(t TimeSession) (TimeSession, []TimeSession, error) {
+	stopped, err := s.StopSessions("", t.Start)
+	if err != nil {
+		return t, stopped, err
+	}
+	res, err := s.db.Exec(`INSERT INTO time_sessions (uid, summary, categories, kind, started_at, planned_end, channel, chat_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.UID, t.Summary, t.Categories, t.Kind, t.Start.Unix(), nullUnix(t.PlannedEnd), t.Channel, t.ChatID)
+	if err != nil {
+		return t, stopped, err
+	}
+	t.ID, err = res.LastInsertId()
+	return t, stopped, err
+}
+
+// StopSessions ends the running sessions of uid (all of them if uid is
+// empty) at `at`, or at their planned end if that came first.
+func (s *StatsStore) StopSessions(uid string, at time.Time) ([]TimeSession, error) {
+	running, err := s.RunningSessions()
+	if err != nil {
+		return nil, err
+	}
+	var stopped []TimeSession
+	for _, t := range running {
+		if uid != "" && t.UID != uid {
+			continue
+		}
+		end := at
+		if !t.PlannedEnd.IsZero() && t.PlannedEnd.Before(end) {
+			end = t.PlannedEnd
+		}
+		if end.Before(t.Start) {
+			end = t.Start
+		}
+		ok, err := s.endSession(t.ID, end)
+		if err != nil {
+			return stopped, err
+		}
+		if ok {
+			t.End = end
+			stopped = append(stopped, t)
+		}
+	}
+	return stopped, nil
+}
+
+// endSession closes a running session; false if it had already ended, so a
+// pomodoro alarm and a stop_timer racing each other close it only once.
+func (s *StatsStore) endSession(id int64, end time.Time) (bool, error) {
+	res, err := s.db.Exec("UPDATE time_sessions SET ended_at = ? WHERE id = ? AND ended_at IS NULL", end.Unix(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RunningSessions returns the open sessions, oldest first.
+func (s *StatsStore) RunningSessions() ([]TimeSession, error) {
+	rows, err := s.db.Query("SELECT " + sessionColumns + " FROM time_sessions WHERE ended_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return scanSessions(rows)
+}
+
+// Sessions returns the sessions overlapping [from, to), running ones included.
+func (s *StatsStore) Sessions(from, to time.Time) ([]TimeSession, error) {
+	rows, err := s.db.Query("SELECT "+sessionColumns+` FROM time_sessions
+		WHERE started_at < ? AND (ended_at IS NULL OR ended_at > ?) ORDER BY started_at, id`, to.Unix(), from.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return scanSessions(rows)
+}
+
+// PomodorosDone counts the pomodoros that ran their full length in [from, to).
+func (s *StatsStore) PomodorosDone(from, to time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM time_sessions
+		WHERE kind = ? AND ended_at = planned_end AND ended_at >= ? AND ended_at < ?`,
+		SessionPomodoro, from.Unix(), to.Unix()).Scan(&n)
+	return n, err
+}
+
+// TimeSpent is everything ever tracked on uid.
+func (s *StatsStore) TimeSpent(uid string, now time.Time) (time.Duration, error) {
+	rows, err := s.db.Query("SELECT "+sessionColumns+" FROM time_sessions WHERE uid = ? AND kind != ?", uid, SessionBreak)
+	if err != nil {
+		return 0, err
+	}
+	sessions, err := scanSessions(rows)
+	var total time.Duration
+	for _, t := range sessions {
+		total += t.Spent(now)
+	}
+	return total, err
+}
+
+// TimeTotal is the tracked time of one task, category or day.
+type TimeTotal struct {
+	Key   string // UID, category or YYYY-MM-DD
+	Label string // task summary, category or day name
+	Spent time.Duration
+}
+
+// TimeReport sums the work tracked in a period. Tasks and categories are
+// biggest first, days in order. A task in several categories counts
+// fully toward each.
+type TimeReport struct {
+	Total      time.Duration
+	Pomodoros  int
+	Tasks      []TimeTotal
+	Categories []TimeTotal
+	Days       []TimeTotal
+	Running    []TimeSession
+}
+
+// TimeReport totals the sessions in [from, to), clipped to the period and
+// split at midnight in from's location.
+func (s *StatsStore) TimeReport(from, to, now time.Time) (TimeReport, error) {
+	var rep TimeReport
+	sessions, err := s.Sessions(from, to)
+	if err != nil {
+		return rep, err
+	}
+	loc := from.Location()
+	tasks, cats, days := map[string]*TimeTotal{}, map[string]*TimeTotal{}, map[string]*TimeTotal{}
+	add := func(m map[string]*TimeTotal, key, label string, d time.Duration) {
+		if m[key] == nil {
+			m[key] = &TimeTotal{Key: key, Label: label}
+		}
+		m[key].Spent += d
+	}
+	for _, t := range sessions {
+		if t.Running() {
+			rep.Running = append(rep.Running, t)
+		}
+		if t.Kind == SessionBreak {
+			continue
+		}
+		start, end := t.Start.In(loc), t.Start.Add(t.Spent(now)).In(loc)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+		d := end.Sub(start)
+		rep.Total += d
+		add(tasks, t.UID, t.Summary, d)
+		for _, c := range timeCategories(t.Categories) {
+			add(cats, c, c, d)
+		}
+		for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+			lo, hi := start, end
+			if day.After(lo) {
+				lo = day
+			}
+			if next := day.AddDate(0, 0, 1); next.Before(hi) {
+				hi = next
+			}
+			if hi.After(lo) {
+				add(days, day.Format(dayLayout), day.Format("Mon 2 Jan"), hi.Sub(lo))
+			}
+		}
+	}
+	if rep.Pomodoros, err = s.PomodorosDone(from, to); err != nil {
+		return rep, err
+	}
+	rep.Tasks, rep.Categories, rep.Days = sortedTotals(tasks, true), sortedTotals(cats, true), sortedTotals(days, false)
+	return rep, nil
+}
+
+// timeCategories are the categories a task's time is booked to; the
+// Today/Tomorrow scheduling markers are not categories of work.
+func timeCategories(categories string) []string {
+	var out []string
+	for _, c := range strings.Split(categories, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" || dayCategory(c) != "" {
+			continue
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		out = []string{"uncategorised"}
+	}
+	return out
+}
+
+func sortedTotals(m map[string]*TimeTotal, bySpent bool) []TimeTotal {
+	out := make([]TimeTotal, 0, len(m))
+	for _, t := range m {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if bySpent && out[i].Spent != out[j].Spent {
+			return out[i].Spent > out[j].Spent
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// formatTotals renders up to max totals as "label 1h 5m · label 20m".
+func formatTotals(ts []TimeTotal, max int) string {
+	var parts []string
+	for i, t := range ts {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("+%d more", len(ts)-max))
+			break
+		}
+		parts = append(parts, t.Label+" "+formatSpan(t.Spent))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ----------------------------------------------------------------------------
+// Skill wiring
+// ----------------------------------------------------------------------------
+
+// SetBus lets pomodoro reminders reach the chat, and re-arms the ones still
+// pending from before a restart.
+func (s *ATCSkill) SetBus(b *bus.MessageBus) {
+	s.bus = b
+	s.resumePomodoros()
+}
+
+// SetContext records the chat that timer reminders are sent back to. A chat
+// in the call's context (users.WithChat) takes precedence.
+func (s *ATCSkill) SetContext(channel, chatID string) {
+	s.originChannel = channel
+	s.originChatID = chatID
+}
+
+// replyTo is the chat of the current request, or the bound user's own chat.
+func (s *ATCSkill) replyTo(ctx context.Context) (string, string) {
+	if chat, ok := users.ChatFrom(ctx); ok && chat.ChatID != "" {
+		return chat.Channel, chat.ChatID
+	}
+	if s.originChatID != "" {
+		return s.originChannel, s.originChatID
+	}
+	if s.user != nil && s.user.ChatID() != "" {
+		return "telegram", s.user.ChatID()
+	}
+	return "", ""
+}
+
+// arm schedules the end-of-phase reminder of a pomodoro or break.
+func (s *ATCSkill) arm(t TimeSession) {
+	if s.bus == nil || t.PlannedEnd.IsZero() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.alarms == nil {
+		s.alarms = make(map[int64]*time.Timer)
+	}
+	if old, ok := s.alarms[t.ID]; ok {
+		old.Stop()
+	}
+	s.alarms[t.ID] = time.AfterFunc(time.Until(t.PlannedEnd), func() { s.phaseOver(t) })
+}
+
+// disarm cancels the reminders of sessions that were stopped early.
+func (s *ATCSkill) disarm(sessions []TimeSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range sessions {
+		if a, ok := s.alarms[t.ID]; ok {
+			a.Stop()
+			delete(s.alarms, t.ID)
+		}
+	}
+}
+
+// resumePomodoros re-arms the reminders of sessions still running after a
+// restart. A pomodoro or break whose planned end passed while the gateway
+// was down is closed at that time without a message: a "break over" hours
+// late helps nobody.
+func (s *ATCSkill) resumePomodoros() {
+	if s.bus == nil || s.workspace == "" {
+		return
+	}
+	st, err := s.openStats()
+	if err != nil {
+		return
+	}
+	defer st.Close()
+	running, err := st.RunningSessions()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, t := range running {
+		if t.PlannedEnd.IsZero() || t.PlannedEnd.After(now) {
+			s.arm(t)
+			continue
+		}
+		if ended, err := st.endSession(t.ID, t.PlannedEnd); err == nil && ended && t.Kind == SessionPomodoro {
+			s.writeTimeSpent(st, t.UID)
+		}
+	}
+}
+
+// phaseOver ends a pomodoro or break at its planned time. A finished
+// pomodoro starts its break; a finished break just says so. A session
+// stopped or replaced in the meantime is left alone.
+func (s *ATCSkill) phaseOver(t TimeSession) {
+	s.mu.Lock()
+	delete(s.alarms, t.ID)
+	s.mu.Unlock()
+
+	st, err := s.openStats()
+	if err != nil {
+		return
+	}
+	defer st.Close()
+	if ended, err := st.endSession(t.ID, t.PlannedEnd); err != nil || !ended {
+		return
+	}
+	loc := s.now().Location()
+	switch t.Kind {
+	case SessionPomodoro:
+		end := t.PlannedEnd.In(loc)
+		day := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
+		n, _ := st.PomodorosDone(day, day.AddDate(0, 0, 1))
+		pause := LoadPomodoroConfig().breakAfter(n)
+		brk := TimeSession{UID: t.UID, Summary: t.Summary, Categories: t.Categories, Kind: SessionBreak,
+			Start: t.PlannedEnd, PlannedEnd: t.PlannedEnd.Add(pause), Channel: t.Channel, ChatID: t.ChatID}
+		brk, _, err := st.StartSession(brk)
+		s.writeTimeSpent(st, t.UID)
+		s.notify(t, fmt.Sprintf("🍅 Pomodoro %d done: %s (%s focused). Take a %s break until %s.",
+			n, t.Summary, formatSpan(t.PlannedEnd.Sub(t.Start)), formatSpan(pause), brk.PlannedEnd.In(loc).Format("15:04")))
+		if err == nil {
+			s.arm(brk)
+		}
+	case SessionBreak:
+		s.notify(t, fmt.Sprintf("⏰ Break over. Start the next pomodoro on %s when you're ready.", t.Summary))
+	}
+}
+
+func (s *ATCSkill) notify(t TimeSession, text string) {
+	if s.bus == nil || t.ChatID == "" {
+		return
+	}
+	s.bus.PublishOutbound(bus.OutboundMessage{Channel: t.Channel, ChatID: t.ChatID, Content: text})
+}
+
+// writeTimeSpent stores uid's tracked total on its VTODO as X-TIME-SPENT,
+// from where sync_tasks carries it to Nextcloud. Best effort, like the
+// stats hooks: a task missing from tasks.xml is simply not updated.
+func (s *ATCSkill) writeTimeSpent(st *StatsStore, uid string) time.Duration {
+	spent, err := st.TimeSpent(uid, s.now())
+	if err != nil {
+		return 0
+	}
+	s.tasksMu.Lock()
+	defer s.tasksMu.Unlock()
+	cal, path, err := s.loadTasks()
+	if err != nil {
+		return spent
+	}
+	for i := range cal.VCal.Components.VTodos {
+		t := &cal.VCal.Components.VTodos[i].Properties
+		if t.Uid != uid {
+			continue
+		}
+		if v := formatEstimate(spent); v != t.TimeSpent {
+			t.TimeSpent = v
+			t.LastModified = time.Now().UTC().Format(time.RFC3339)
+			saveTasks(cal, path)
+		}
+		break
+	}
+	return spent
+}
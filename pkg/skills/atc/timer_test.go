@@ -0,0 +1,90 @@
+package atc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestTimeTracking(t *testing.T) {
+	t.Setenv("PERSONAL_OS_CONFIG", filepath.Join(t.TempDir(), "none.json"))
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	s := &ATCSkill{workspace: ws}
+	cal := ICalendar{}
+	cal.VCal.Components.VTodos = []VTodo{
+		{Properties: VTodoProperties{Uid: "tax", Summary: "File taxes", Categories: "Today,admin,money"}},
+		{Properties: VTodoProperties{Uid: "gym", Summary: "Gym"}},
+	}
+	if err := saveTasks(cal, filepath.Join(ws, "memory", "tasks.xml")); err != nil {
+		t.Fatal(err)
+	}
+	st, err := s.openStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	loc := time.UTC
+	at := func(day, h, m int) time.Time { return time.Date(2026, 3, day, h, m, 0, 0, loc) }
+
+	// A timer running past midnight, then a pomodoro that replaces it.
+	if _, _, err := st.StartSession(TimeSession{UID: "tax", Summary: "File taxes", Categories: "Today,admin,money", Kind: SessionTimer, Start: at(2, 23, 30)}); err != nil {
+		t.Fatal(err)
+	}
+	pomo, stopped, err := st.StartSession(TimeSession{UID: "gym", Summary: "Gym", Kind: SessionPomodoro, Start: at(3, 0, 15), PlannedEnd: at(3, 0, 40)})
+	if err != nil || len(stopped) != 1 || stopped[0].Spent(stopped[0].End) != 45*time.Minute {
+		t.Fatalf("switching tasks stopped %+v, %v", stopped, err)
+	}
+
+	// The pomodoro ends: it counts its full length and a break starts.
+	s.phaseOver(pomo)
+	running, _ := st.RunningSessions()
+	if len(running) != 1 || running[0].Kind != SessionBreak || !running[0].PlannedEnd.Equal(at(3, 0, 45)) {
+		t.Fatalf("after pomodoro: %+v", running)
+	}
+	if cfg := DefaultPomodoroConfig(); cfg.breakAfter(4) != 15*time.Minute || cfg.breakAfter(3) != 5*time.Minute {
+		t.Errorf("breaks = %v, %v", cfg.breakAfter(3), cfg.breakAfter(4))
+	}
+
+	rep, err := st.TimeReport(at(3, 0, 0), at(4, 0, 0), at(3, 12, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Total != 40*time.Minute || rep.Pomodoros != 1 || len(rep.Running) != 1 ||
+		formatTotals(rep.Tasks, 5) != "Gym 25m · File taxes 15m" ||
+		formatTotals(rep.Categories, 5) != "uncategorised 25m · admin 15m · money 15m" {
+		t.Errorf("report for 3 Mar = %+v", rep)
+	}
+	rep, _ = st.TimeReport(at(2, 0, 0), at(4, 0, 0), at(3, 12, 0))
+	if formatTotals(rep.Days, 5) != "Mon 2 Mar 30m · Tue 3 Mar 40m" {
+		t.Errorf("days = %s", formatTotals(rep.Days, 5))
+	}
+
+	// Stopping the break is not work; X-TIME-SPENT holds the task's total.
+	if stopped, _ := st.StopSessions("", at(3, 0, 50)); len(stopped) != 1 || !stopped[0].End.Equal(at(3, 0, 45)) {
+		t.Errorf("break stopped at %+v", stopped)
+	}
+	if spent := s.writeTimeSpent(st, "tax"); spent != 45*time.Minute {
+		t.Errorf("tax spent = %v", spent)
+	}
+	if cal, _, _ := s.loadTasks(); cal.VCal.Components.VTodos[0].Properties.TimeSpent != "PT45M" || cal.VCal.Components.VTodos[1].Properties.TimeSpent != "PT25M" {
+		t.Errorf("tasks = %+v", cal.VCal.Components.VTodos)
+	}
+
+	// A pomodoro that ended while the gateway was down is closed quietly.
+	if _, _, err := st.StartSession(TimeSession{UID: "gym", Summary: "Gym", Kind: SessionPomodoro, Start: at(4, 9, 0), PlannedEnd: at(4, 9, 25)}); err != nil {
+		t.Fatal(err)
+	}
+	s.SetBus(bus.NewMessageBus())
+	if running, _ := st.RunningSessions(); len(running) != 0 || len(s.alarms) != 0 {
+		t.Errorf("stale pomodoro resumed: %+v", running)
+	}
+	if ics := buildVTodo(VTodoProperties{Uid: "tax", TimeSpent: "PT45M"}, "", nil, at(3, 1, 0)); !strings.Contains(ics, "X-TIME-SPENT:PT45M\r\n") {
+		t.Errorf("ics:\n%s", ics)
+	}
+}
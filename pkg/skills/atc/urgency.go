@@ -0,0 +1,256 @@
+package atc
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Urgency scoring
+//
+// A task's urgency is a sum of points, each explained: priority, pressure
+// from the due date (doubling every half-life as it approaches), a penalty
+// once overdue that grows per day, age, how often it was carried over,
+// whether it is blocked, and per-category weights. All weights come from
+// tools.urgency in the config; missing keys keep their defaults.
+// ----------------------------------------------------------------------------
+
+// UrgencyWeights configures calculateUrgency.
+type UrgencyWeights struct {
+	Base     int `json:"base"`
+	Priority struct {
+		High   int `json:"high"`   // PRIORITY 1-2
+		Medium int `json:"medium"` // PRIORITY 3-5
+		Low    int `json:"low"`    // PRIORITY 6-9
+	} `json:"priority"`
+	DueMax           int            `json:"due_max"`             // points when due right now
+	DueHalfLifeHours float64        `json:"due_half_life_hours"` // due pressure halves per this much time left
+	Overdue          int            `json:"overdue"`             // once past due
+	OverduePerDay    int            `json:"overdue_per_day"`
+	OverdueMax       int            `json:"overdue_max"` // cap on the whole overdue penalty
+	AgePerDay        int            `json:"age_per_day"`
+	AgeMax           int            `json:"age_max"`
+	RolloverEach     int            `json:"rollover_each"`
+	RolloverMax      int            `json:"rollover_max"`
+	Blocked          int            `json:"blocked"`    // usually negative
+	Categories       map[string]int `json:"categories"` // lower-case category -> points
+}
+
+// DefaultUrgencyWeights keeps the old scale: 50 plus up to 40 for priority.
+func DefaultUrgencyWeights() UrgencyWeights {
+	w := UrgencyWeights{
+		Base:             50,
+		DueMax:           40,
+		DueHalfLifeHours: 24,
+		Overdue:          30,
+		OverduePerDay:    5,
+		OverdueMax:       50,
+		AgePerDay:        1,
+		AgeMax:           10,
+		RolloverEach:     5,
+		RolloverMax:      20,
+		Blocked:          -40,
+		Categories:       map[string]int{},
+	}
+	w.Priority.High, w.Priority.Medium, w.Priority.Low = 40, 20, 5
+	return w
+}
+
+// LoadUrgencyWeights reads tools.urgency on top of the defaults.
+func LoadUrgencyWeights() UrgencyWeights {
+	var cfg struct {
+		Tools struct {
+			Urgency UrgencyWeights `json:"urgency"`
+		} `json:"tools"`
+	}
+	cfg.Tools.Urgency = DefaultUrgencyWeights()
+	if readConfig(&cfg) != nil {
+		return DefaultUrgencyWeights()
+	}
+	w := cfg.Tools.Urgency
+	if w.DueHalfLifeHours <= 0 {
+		w.DueHalfLifeHours = 24
+	}
+	lower := make(map[string]int, len(w.Categories))
+	for k, v := range w.Categories {
+		lower[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	w.Categories = lower
+	return w
+}
+
+// TaskFacts is what scoring needs beyond the task itself.
+type TaskFacts struct {
+	FirstSeen time.Time // zero if unknown
+	Rollovers int
+	Blocked   bool
+}
+
+// UrgencyPart is one explained contribution to a score.
+type UrgencyPart struct {
+	Label  string
+	Points int
+}
+
+// Urgency is a score and where it came from.
+type Urgency struct {
+	Score int
+	Parts []UrgencyPart
+}
+
+// Explain renders the non-zero parts, e.g. "priority high +40 · due in 5h +35".
+func (u Urgency) Explain() string {
+	var parts []string
+	for _, p := range u.Parts {
+		if p.Points == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %+d", p.Label, p.Points))
+	}
+	if len(parts) == 0 {
+		return "no urgency factors"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// dueTime is when a task is due; date-only tasks are due at the end of
+// that day in loc.
+func dueTime(t VTodoProperties, loc *time.Location) (time.Time, bool) {
+	if t.Due != "" {
+		if ts, err := time.Parse(time.RFC3339, t.Due); err == nil {
+			return ts, true
+		}
+	}
+	if t.DueDate != "" {
+		if d, err := time.ParseInLocation("2006-01-02", t.DueDate, loc); err == nil {
+			return d.AddDate(0, 0, 1).Add(-time.Minute), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// calculateUrgency scores one task at now.
+func calculateUrgency(t VTodoProperties, f TaskFacts, w UrgencyWeights, now time.Time) Urgency {
+	u := Urgency{Parts: []UrgencyPart{{Label: "base", Points: w.Base}}}
+	add := func(label string, points int) {
+		u.Parts = append(u.Parts, UrgencyPart{Label: label, Points: points})
+	}
+
+	// RFC 5545 / 6321 defines priority: 1 is highest, 9 is lowest, 0 is undefined
+	switch p := t.Priority; {
+	case p == 1 || p == 2:
+		add("priority high", w.Priority.High)
+	case p >= 3 && p <= 5:
+		add("priority medium", w.Priority.Medium)
+	case p > 5:
+		add("priority low", w.Priority.Low)
+	}
+
+	if due, ok := dueTime(t, now.Location()); ok {
+		left := due.Sub(now)
+		if left >= 0 {
+			label := "due now"
+			if left >= time.Minute {
+				label = "due in " + formatSpan(left)
+			}
+			pressure := float64(w.DueMax) * math.Pow(0.5, left.Hours()/w.DueHalfLifeHours)
+			add(label, int(math.Round(pressure)))
+		} else {
+			days := int(-left.Hours() / 24)
+			penalty := w.Overdue + days*w.OverduePerDay
+			if w.OverdueMax > 0 && penalty > w.OverdueMax {
+				penalty = w.OverdueMax
+			}
+			add("overdue "+formatSpan(-left), penalty)
+		}
+	}
+
+	if !f.FirstSeen.IsZero() {
+		days := int(now.Sub(f.FirstSeen).Hours() / 24)
+		if days > 0 {
+			points := days * w.AgePerDay
+			if w.AgeMax > 0 && points > w.AgeMax {
+				points = w.AgeMax
+			}
+			add(fmt.Sprintf("age %dd", days), points)
+		}
+	}
+
+	if f.Rollovers > 0 {
+		points := f.Rollovers * w.RolloverEach
+		if w.RolloverMax > 0 && points > w.RolloverMax {
+			points = w.RolloverMax
+		}
+		add(fmt.Sprintf("rolled over %d×", f.Rollovers), points)
+	}
+
+	if f.Blocked {
+		add("blocked", w.Blocked)
+	}
+
+	for _, c := range strings.Split(strings.ToLower(t.Categories), ",") {
+		c = strings.TrimSpace(c)
+		if points, ok := w.Categories[c]; ok && c != "" {
+			add("#"+c, points)
+		}
+	}
+
+	for _, p := range u.Parts {
+		u.Score += p.Points
+	}
+	if u.Score < 0 {
+		u.Score = 0
+	}
+	return u
+}
+
+// isBlocked reports tasks parked in a blocked or waiting category.
+func isBlocked(t VTodoProperties) bool {
+	for _, c := range strings.Split(strings.ToLower(t.Categories), ",") {
+		switch strings.TrimSpace(c) {
+		case "blocked", "waiting":
+			return true
+		}
+	}
+	return false
+}
+
+// RankedTask is a task with its urgency.
+type RankedTask struct {
+	Task    VTodoProperties
+	Urgency Urgency
+}
+
+// rankTasks scores tasks and sorts them most urgent first; ties go to the
+// earlier due date, then the title. g, if set, marks tasks waiting on an
+// open dependency as blocked.
+func rankTasks(tasks []VTodoProperties, g *taskGraph, history map[string]TaskHistory, w UrgencyWeights, now time.Time) []RankedTask {
+	ranked := make([]RankedTask, 0, len(tasks))
+	for _, t := range tasks {
+		h := history[t.Uid]
+		f := TaskFacts{FirstSeen: h.FirstSeen, Rollovers: h.Rollovers, Blocked: isBlocked(t) || len(g.blockers(t)) > 0}
+		if f.FirstSeen.IsZero() {
+			f.FirstSeen, _ = time.Parse(time.RFC3339, t.Dtstamp)
+		}
+		ranked = append(ranked, RankedTask{Task: t, Urgency: calculateUrgency(t, f, w, now)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Urgency.Score != b.Urgency.Score {
+			return a.Urgency.Score > b.Urgency.Score
+		}
+		da, okA := dueTime(a.Task, now.Location())
+		db, okB := dueTime(b.Task, now.Location())
+		if okA != okB {
+			return okA
+		}
+		if okA && !da.Equal(db) {
+			return da.Before(db)
+		}
+		return a.Task.Summary < b.Task.Summary
+	})
+	return ranked
+}
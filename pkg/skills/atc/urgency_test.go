@@ -0,0 +1,57 @@
+package atc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCalculateUrgency(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	w := DefaultUrgencyWeights()
+	w.Categories["work"] = 10
+
+	cases := []struct {
+		name    string
+		task    VTodoProperties
+		facts   TaskFacts
+		score   int
+		explain string
+	}{
+		{"plain", VTodoProperties{}, TaskFacts{}, 50, "base +50"},
+		{"due in a day", VTodoProperties{Priority: 1, Due: "2026-03-11T12:00:00Z"}, TaskFacts{},
+			110, "base +50 · priority high +40 · due in 1d 0h +20"},
+		{"due in a year", VTodoProperties{Due: "2027-03-10T12:00:00Z"}, TaskFacts{}, 50, "base +50"},
+		{"overdue", VTodoProperties{Priority: 9, DueDate: "2026-03-07", Categories: "Today,Work"}, TaskFacts{},
+			105, "base +50 · priority low +5 · overdue 2d 12h +40 · #work +10"},
+		{"old, carried and blocked", VTodoProperties{Priority: 5}, TaskFacts{FirstSeen: now.AddDate(0, 0, -30), Rollovers: 6, Blocked: true},
+			60, "base +50 · priority medium +20 · age 30d +10 · rolled over 6× +20 · blocked -40"},
+	}
+	for _, c := range cases {
+		u := calculateUrgency(c.task, c.facts, w, now)
+		if u.Score != c.score || u.Explain() != c.explain {
+			t.Errorf("%s: got %d %q, want %d %q", c.name, u.Score, u.Explain(), c.score, c.explain)
+		}
+	}
+
+	ranked := rankTasks([]VTodoProperties{
+		{Uid: "a", Summary: "Someday"},
+		{Uid: "b", Summary: "Soon", Due: "2026-03-10T14:00:00Z"},
+		{Uid: "c", Summary: "Carried"},
+	}, nil, map[string]TaskHistory{"c": {Rollovers: 1}}, w, now)
+	if ranked[0].Task.Uid != "b" || ranked[1].Task.Uid != "c" || ranked[2].Task.Uid != "a" {
+		t.Errorf("rank order = %s, %s, %s", ranked[0].Task.Uid, ranked[1].Task.Uid, ranked[2].Task.Uid)
+	}
+}
+
+func TestLoadUrgencyWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"tools":{"urgency":{"blocked":-10,"priority":{"high":60},"categories":{"Work":15}}}}`), 0644)
+	t.Setenv("PERSONAL_OS_CONFIG", path)
+
+	w := LoadUrgencyWeights()
+	if w.Blocked != -10 || w.Priority.High != 60 || w.Priority.Medium != 20 || w.Base != 50 || w.Categories["work"] != 15 {
+		t.Errorf("weights = %+v", w)
+	}
+}
@@ -38,6 +38,18 @@ type VEventProperties struct {
 	Summary     string `xml:"summary>text"`
 	Description string `xml:"description>text"`
 	Location    string `xml:"location>text"`
+
+	Status           string   `xml:"status>text,omitempty"` // CANCELLED events are not shown
+	RRule            string   `xml:"rrule>text,omitempty"`
+	ExDate           []string `xml:"exdate>date-time,omitempty"`        // skipped occurrences (RFC3339)
+	ExDateDate       []string `xml:"exdate>date,omitempty"`             // skipped all-day occurrences
+	RecurrenceID     string   `xml:"recurrence-id>date-time,omitempty"` // set on a moved/edited occurrence
+	RecurrenceIDDate string   `xml:"recurrence-id>date,omitempty"`
+	Duration         string   `xml:"duration>duration,omitempty"` // instead of DTEND, e.g. PT1H
+
+	Source string `xml:"x-source>text,omitempty"` // tools.calendars source the event was synced from
+	Tag    string `xml:"x-source-tag>text,omitempty"`
+	Color  string `xml:"color>text,omitempty"` // RFC 7986 COLOR
 }
 
 // VTodo represents a task
@@ -55,4 +67,21 @@ type VTodoProperties struct {
 	Due         string `xml:"due>date-time"`    // Deadline
 	DueDate     string `xml:"due>date"`         // Deadline (date only)
 	Categories  string `xml:"categories>text"`  // e.g., Today, Tomorrow, Someday
+
+	Dtstart      string      `xml:"dtstart>date-time,omitempty"`        // start of the current occurrence
+	DtstartDate  string      `xml:"dtstart>date,omitempty"`             // start (date only)
+	RRule        string      `xml:"rrule>text,omitempty"`               // e.g. FREQ=WEEKLY;BYDAY=MO; completing advances the dates
+	Estimate     string      `xml:"x-estimate>duration,omitempty"`      // X-ESTIMATE, e.g. PT45M; plan_day's block length
+	TimeSpent    string      `xml:"x-time-spent>duration,omitempty"`    // X-TIME-SPENT, total tracked by timers and pomodoros
+	Completed    string      `xml:"completed>date-time,omitempty"`      // RFC3339 UTC, when a one-off task was completed
+	Percent      int         `xml:"percent-complete>integer,omitempty"` // PERCENT-COMPLETE, 0-100
+	RelatedTo    []RelatedTo `xml:"related-to,omitempty"`               // parent task and dependencies
+	LastModified string      `xml:"last-modified>date-time,omitempty"`  // RFC3339 UTC, set on every local edit
+}
+
+// RelatedTo links a task to another by UID. RelType is PARENT (the
+// RFC 5545 default when empty) or DEPENDS-ON (RFC 9253).
+type RelatedTo struct {
+	RelType string `xml:"parameters>reltype>text,omitempty"`
+	UID     string `xml:"text"`
 }
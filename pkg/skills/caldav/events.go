@@ -0,0 +1,195 @@
+package caldav
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one VEVENT as events.xml stores it: recurring events carry
+// their RRULE/EXDATE and edited occurrences are separate events with a
+// RECURRENCE-ID. Times are RFC3339 (DATE-TIME) or YYYY-MM-DD (DATE).
+type Event struct {
+	UID      string
+	Source   string // tools.calendars source name
+	Tag      string // the source's label
+	Summary  string
+	Location string
+	Status   string // CANCELLED events have no occurrences
+
+	Dtstart, DtstartDate string
+	Dtend, DtendDate     string
+	Duration             string // instead of DTEND, e.g. PT1H
+
+	RRule                          string
+	ExDate, ExDateDate             []string
+	RecurrenceID, RecurrenceIDDate string
+}
+
+// Occurrence is one event on the timeline. End is exclusive; for all-day
+// events it is midnight after the last day.
+type Occurrence struct {
+	UID       string
+	Source    string
+	Tag       string
+	Summary   string
+	Location  string
+	Start     time.Time
+	End       time.Time
+	AllDay    bool
+	Recurring bool
+}
+
+// Overlaps reports whether the occurrence touches [from, to). Events
+// without a duration count at their start instant.
+func (o Occurrence) Overlaps(from, to time.Time) bool {
+	if !o.Start.Before(to) {
+		return false
+	}
+	if o.End.After(o.Start) {
+		return o.End.After(from)
+	}
+	return !o.Start.Before(from)
+}
+
+var icsDurationRe = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration reads an RFC 5545 DURATION such as PT1H30M or P2D.
+// e.g. "PT45M" → 45m
+func ParseDuration(s string) (time.Duration, bool) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "+")
+	m := icsDurationRe.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, false
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.Atoi(m[i+1]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, true
+}
+
+// EventSpan resolves an event's start and end in loc. Timed events end at
+// DTEND, or DTSTART plus DURATION, or at DTSTART; all-day events last until
+// DTEND (exclusive) or one day.
+func EventSpan(e Event, loc *time.Location) (start, end time.Time, allDay, ok bool) {
+	if e.Dtstart != "" {
+		t, err := time.Parse(time.RFC3339, e.Dtstart)
+		if err != nil {
+			return start, end, false, false
+		}
+		start, end = t.In(loc), t.In(loc)
+		if x, err := time.Parse(time.RFC3339, e.Dtend); err == nil && x.After(t) {
+			end = x.In(loc)
+		} else if d, ok := ParseDuration(e.Duration); ok && d > 0 {
+			end = start.Add(d)
+		}
+		return start, end, false, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", e.DtstartDate, loc)
+	if err != nil {
+		return start, end, true, false
+	}
+	start, end = t, t.AddDate(0, 0, 1)
+	if x, err := time.ParseInLocation("2006-01-02", e.DtendDate, loc); err == nil && x.After(t) {
+		end = x
+	} else if d, ok := ParseDuration(e.Duration); ok && d >= 24*time.Hour {
+		end = t.AddDate(0, 0, int(d/(24*time.Hour)))
+	}
+	return start, end, true, true
+}
+
+// recurrenceKey is the instant an occurrence is known by in EXDATE and
+// RECURRENCE-ID: a UTC time or, for all-day events, midnight in loc.
+func recurrenceKey(dateTime, date string, loc *time.Location) (int64, bool) {
+	if dateTime != "" {
+		t, err := time.Parse(time.RFC3339, dateTime)
+		return t.Unix(), err == nil
+	}
+	if date != "" {
+		t, err := time.ParseInLocation("2006-01-02", date, loc)
+		return t.Unix(), err == nil
+	}
+	return 0, false
+}
+
+// Occurrences lists every occurrence touching [from, to) in from's
+// location, sorted by start with all-day events first. Recurring events
+// are expanded, minus EXDATEs and occurrences replaced by an edited (or
+// cancelled) copy; cancelled events are left out. UIDs are matched within
+// a source, as two feeds may reuse one.
+func Occurrences(events []Event, from, to time.Time) []Occurrence {
+	loc := from.Location()
+	replaced := map[string]map[int64]bool{}
+	for _, e := range events {
+		if k, ok := recurrenceKey(e.RecurrenceID, e.RecurrenceIDDate, loc); ok {
+			id := e.Source + "/" + e.UID
+			if replaced[id] == nil {
+				replaced[id] = map[int64]bool{}
+			}
+			replaced[id][k] = true
+		}
+	}
+
+	var out []Occurrence
+	for _, e := range events {
+		if strings.EqualFold(e.Status, "CANCELLED") {
+			continue
+		}
+		start, end, allDay, ok := EventSpan(e, loc)
+		if !ok {
+			continue
+		}
+		base := Occurrence{UID: e.UID, Source: e.Source, Tag: e.Tag, Summary: e.Summary, Location: e.Location, Start: start, End: end, AllDay: allDay}
+		_, isOverride := recurrenceKey(e.RecurrenceID, e.RecurrenceIDDate, loc)
+		rule, err := ParseRRule(e.RRule, loc)
+		if e.RRule == "" || isOverride || err != nil {
+			base.Recurring = isOverride
+			if base.Overlaps(from, to) {
+				out = append(out, base)
+			}
+			continue
+		}
+
+		skip := map[int64]bool{}
+		for k := range replaced[e.Source+"/"+e.UID] {
+			skip[k] = true
+		}
+		for _, v := range e.ExDate {
+			if k, ok := recurrenceKey(v, "", loc); ok {
+				skip[k] = true
+			}
+		}
+		for _, v := range e.ExDateDate {
+			if k, ok := recurrenceKey("", v, loc); ok {
+				skip[k] = true
+			}
+		}
+		days := int(end.Sub(start).Hours()/24 + 0.5)
+		for _, t := range rule.Between(start, from.Add(-end.Sub(start)), to, 1000) {
+			if skip[t.Unix()] {
+				continue
+			}
+			o := base
+			o.Start, o.End, o.Recurring = t, t.Add(end.Sub(start)), true
+			if allDay {
+				o.End = t.AddDate(0, 0, days)
+			}
+			if o.Overlaps(from, to) {
+				out = append(out, o)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.AllDay && !b.AllDay
+	})
+	return out
+}
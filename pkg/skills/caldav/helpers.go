@@ -24,8 +24,14 @@ func BuildTasksURL(host, username string) string {
 // BuildCalendarURL constructs the CalDAV personal calendar URL.
 // e.g. https://host/remote.php/dav/calendars/user/personal/
 func BuildCalendarURL(host, username string) string {
+	return BuildNamedCalendarURL(host, username, "personal")
+}
+
+// BuildNamedCalendarURL constructs the URL of any calendar by its URI.
+// e.g. https://host/remote.php/dav/calendars/user/time-blocks/
+func BuildNamedCalendarURL(host, username, calendar string) string {
 	base := strings.TrimRight(host, "/")
-	return fmt.Sprintf("%s/remote.php/dav/calendars/%s/personal/", base, url.PathEscape(username))
+	return fmt.Sprintf("%s/remote.php/dav/calendars/%s/%s/", base, url.PathEscape(username), url.PathEscape(calendar))
 }
 
 // BuildFilesURL constructs the WebDAV files base URL.
@@ -66,3 +72,29 @@ func FullURL(tasksURL, href string) string {
 	}
 	return href
 }
+
+// ParseICSTime parses an ICS DATE or DATE-TIME value. params is the raw
+// property parameter list (e.g. "TZID=Asia/Dhaka" or "VALUE=DATE");
+// floating times and dates are interpreted in loc.
+// e.g. ("20260224T200000", "TZID=Asia/Dhaka") → 2026-02-24 20:00 +06
+func ParseICSTime(value, params string, loc *time.Location) (t time.Time, allDay bool, err error) {
+	value = strings.TrimSpace(value)
+	for _, p := range strings.Split(params, ";") {
+		if k, v, ok := strings.Cut(p, "="); ok && strings.EqualFold(k, "TZID") {
+			if tz, lerr := time.LoadLocation(strings.Trim(v, `"`)); lerr == nil {
+				loc = tz
+			}
+		}
+	}
+	switch {
+	case len(value) == 8:
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+		return t, false, err
+	}
+}
@@ -0,0 +1,451 @@
+package caldav
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is the subset of an RFC 5545 RRULE that Nextcloud Tasks and
+// our own tools write: FREQ, INTERVAL, BYDAY (with an optional ordinal for
+// MONTHLY, e.g. -1FR), BYMONTHDAY, COUNT and UNTIL.
+type Recurrence struct {
+	Freq       string // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int       // 0 = unlimited
+	Until      time.Time // zero = unlimited
+	raw        map[string]string
+}
+
+// WeekdayNum is a BYDAY entry: N is the ordinal within the month (0 = every).
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// ErrUnsupportedRRule is returned for rules Next cannot expand.
+var ErrUnsupportedRRule = errors.New("unsupported RRULE")
+
+// ParseRRule parses an RRULE value such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH".
+// UNTIL dates without a zone are read in loc.
+func ParseRRule(rule string, loc *time.Location) (Recurrence, error) {
+	r := Recurrence{Interval: 1, raw: map[string]string{}}
+	for _, part := range strings.Split(strings.TrimSpace(rule), ";") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		k, v = strings.ToUpper(strings.TrimSpace(k)), strings.ToUpper(strings.TrimSpace(v))
+		r.raw[k] = v
+		switch k {
+		case "FREQ":
+			r.Freq = v
+		case "INTERVAL":
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				r.Interval = n
+			}
+		case "COUNT":
+			r.Count, _ = strconv.Atoi(v)
+		case "UNTIL":
+			t, _, err := ParseICSTime(v, "", loc)
+			if err != nil {
+				return r, fmt.Errorf("bad UNTIL %q: %w", v, err)
+			}
+			r.Until = t
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				if len(d) < 2 {
+					continue
+				}
+				day, ok := icsWeekdays[d[len(d)-2:]]
+				if !ok {
+					return r, fmt.Errorf("%w: BYDAY=%s", ErrUnsupportedRRule, v)
+				}
+				n := 0
+				if ord := d[:len(d)-2]; ord != "" {
+					n, _ = strconv.Atoi(strings.TrimPrefix(ord, "+"))
+				}
+				r.ByDay = append(r.ByDay, WeekdayNum{N: n, Day: day})
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(v, ",") {
+				if n, err := strconv.Atoi(d); err == nil && n != 0 {
+					r.ByMonthDay = append(r.ByMonthDay, n)
+				}
+			}
+		case "BYSETPOS", "BYYEARDAY", "BYWEEKNO", "BYHOUR", "BYMINUTE", "BYSECOND":
+			return r, fmt.Errorf("%w: %s", ErrUnsupportedRRule, k)
+		}
+	}
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return r, fmt.Errorf("%w: FREQ=%s", ErrUnsupportedRRule, r.Freq)
+	}
+	return r, nil
+}
+
+// withCount renders the rule with COUNT replaced, keeping the other parts
+// in a stable order.
+func (r Recurrence) withCount(count int) string {
+	raw := map[string]string{}
+	for k, v := range r.raw {
+		raw[k] = v
+	}
+	if count > 0 {
+		raw["COUNT"] = strconv.Itoa(count)
+	}
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		if k != "FREQ" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := []string{"FREQ=" + raw["FREQ"]}
+	for _, k := range keys {
+		parts = append(parts, k+"="+raw[k])
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series starting at start that
+// falls strictly after after, or false when COUNT or UNTIL ended the
+// series first. start itself is the first occurrence.
+func (r Recurrence) Next(start, after time.Time) (time.Time, bool) {
+	n := 0
+	for period := 0; period < 10000; period++ {
+		for _, t := range r.period(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			n++
+			if r.Count > 0 && n > r.Count {
+				return time.Time{}, false
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return time.Time{}, false
+			}
+			if t.After(after) {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Between lists the occurrences of the series starting at start that fall
+// in [from, to), in order. Expansion stops after limit occurrences.
+func (r Recurrence) Between(start, from, to time.Time, limit int) []time.Time {
+	var out []time.Time
+	n := 0
+	for period := 0; period < 10000; period++ {
+		for _, t := range r.period(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			n++
+			if (r.Count > 0 && n > r.Count) || (!r.Until.IsZero() && t.After(r.Until)) || !t.Before(to) {
+				return out
+			}
+			if !t.Before(from) {
+				if out = append(out, t); len(out) >= limit {
+					return out
+				}
+			}
+		}
+	}
+	return out
+}
+
+// period lists the candidate occurrences of the k-th interval, in order.
+func (r Recurrence) period(start time.Time, k int) []time.Time {
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+	step := k * r.Interval
+	switch r.Freq {
+	case "DAILY":
+		return []time.Time{start.AddDate(0, 0, step)}
+
+	case "WEEKLY":
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []WeekdayNum{{Day: start.Weekday()}}
+		}
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*step)
+		var out []time.Time
+		for _, d := range days {
+			t := monday.AddDate(0, 0, (int(d.Day)+6)%7)
+			out = append(out, at(t.Year(), t.Month(), t.Day()))
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		return out
+
+	case "MONTHLY":
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, start.Location())
+		y, m := first.Year(), first.Month()
+		last := first.AddDate(0, 1, -1).Day()
+		var out []time.Time
+		switch {
+		case len(r.ByDay) > 0:
+			for d := 1; d <= last; d++ {
+				t := at(y, m, d)
+				for _, wd := range r.ByDay {
+					if t.Weekday() != wd.Day {
+						continue
+					}
+					nth, fromEnd := (d-1)/7+1, -((last-d)/7 + 1)
+					if wd.N == 0 || wd.N == nth || wd.N == fromEnd {
+						out = append(out, t)
+					}
+				}
+			}
+		default:
+			days := r.ByMonthDay
+			if len(days) == 0 {
+				days = []int{start.Day()}
+			}
+			for _, d := range days {
+				if d < 0 {
+					d = last + 1 + d
+				}
+				if d >= 1 && d <= last { // the 31st is skipped in shorter months
+					out = append(out, at(y, m, d))
+				}
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		return out
+
+	case "YEARLY":
+		y := start.Year() + step
+		t := at(y, start.Month(), start.Day())
+		if t.Month() != start.Month() { // Feb 29 in a common year
+			return nil
+		}
+		return []time.Time{t}
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Completing a VTODO
+// ----------------------------------------------------------------------------
+
+// Completion describes what CompleteVTodo did.
+type Completion struct {
+	Recurring  bool
+	Occurrence time.Time // the completed occurrence's due (or start) time
+	AllDay     bool
+	Next       time.Time // the new due (or start) time; zero if the series ended
+	Ended      bool      // recurring, but that was the last occurrence
+}
+
+// CompleteVTodo marks the first VTODO in ics as done, the way task apps do
+// it: a one-off task becomes COMPLETED; a recurring one moves DTSTART and
+// DUE to the next occurrence of its RRULE and stays open, with COUNT
+// reduced by one. Only when the series has no further occurrence is it
+// completed for good. Other properties are kept as they are. Floating and
+// UTC times are expanded on loc's wall clock.
+func CompleteVTodo(ics string, loc *time.Location, now time.Time) (string, Completion, error) {
+	var c Completion
+	lines := unfoldICS(ics)
+	start, hasStart := findProp(lines, "DTSTART")
+	due, hasDue := findProp(lines, "DUE")
+	rule, hasRule := findProp(lines, "RRULE")
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	done := map[string][]string{
+		"STATUS":           {"STATUS:COMPLETED"},
+		"COMPLETED":        {"COMPLETED:" + stamp},
+		"PERCENT-COMPLETE": {"PERCENT-COMPLETE:100"},
+		"LAST-MODIFIED":    {"LAST-MODIFIED:" + stamp},
+		"DTSTAMP":          {"DTSTAMP:" + stamp},
+	}
+
+	anchor := due
+	if !hasDue {
+		anchor = start
+	}
+	if !hasRule || (!hasStart && !hasDue) {
+		if hasDue || hasStart {
+			c.Occurrence, c.AllDay, _ = ParseICSTime(anchor.value, anchor.params, loc)
+		}
+		return EditVTodo(ics, done), c, nil
+	}
+	c.Recurring = true
+
+	r, err := ParseRRule(rule.value, loc)
+	if err != nil {
+		return "", c, err
+	}
+	dtstart := start
+	if !hasStart {
+		dtstart = due
+	}
+	first, allDay, err := ParseICSTime(dtstart.value, dtstart.params, loc)
+	if err != nil {
+		return "", c, fmt.Errorf("bad DTSTART: %w", err)
+	}
+	if strings.HasSuffix(dtstart.value, "Z") && loc != nil {
+		first = first.In(loc) // BYDAY and friends follow the user's wall clock
+	}
+	c.AllDay = allDay
+	c.Occurrence, _, _ = ParseICSTime(anchor.value, anchor.params, loc)
+
+	next, ok := r.Next(first, first)
+	if !ok {
+		c.Ended = true
+		return EditVTodo(ics, done), c, nil
+	}
+
+	set := map[string][]string{
+		"STATUS":           {"STATUS:NEEDS-ACTION"},
+		"COMPLETED":        nil,
+		"PERCENT-COMPLETE": nil,
+		"LAST-MODIFIED":    {"LAST-MODIFIED:" + stamp},
+		"DTSTAMP":          {"DTSTAMP:" + stamp},
+	}
+	if r.Count > 0 {
+		set["RRULE"] = []string{"RRULE:" + r.withCount(r.Count-1)}
+	}
+	shift := func(p icsProp) (string, time.Time) {
+		t, _, _ := ParseICSTime(p.value, p.params, loc)
+		var moved time.Time
+		if allDay {
+			days := int(next.Sub(first).Hours()/24 + 0.5)
+			moved = t.AddDate(0, 0, days)
+		} else {
+			moved = next.Add(t.Sub(first)).In(t.Location())
+		}
+		return p.render(moved), moved
+	}
+	c.Next = next
+	if hasStart {
+		line, _ := shift(start)
+		set["DTSTART"] = []string{line}
+	}
+	if hasDue {
+		line, moved := shift(due)
+		set["DUE"] = []string{line}
+		c.Next = moved
+	}
+	return EditVTodo(ics, set), c, nil
+}
+
+// icsProp is one property line split into name, parameters and value.
+type icsProp struct {
+	name, params, value string
+}
+
+// render writes t back in the property's original form: a DATE, a UTC
+// time, a TZID-local time or a floating time.
+func (p icsProp) render(t time.Time) string {
+	head := p.name
+	if p.params != "" {
+		head += ";" + p.params
+	}
+	switch {
+	case len(p.value) == 8:
+		return head + ":" + t.Format("20060102")
+	case strings.HasSuffix(p.value, "Z"):
+		return head + ":" + t.UTC().Format("20060102T150405Z")
+	}
+	return head + ":" + t.Format("20060102T150405")
+}
+
+func unfoldICS(ics string) []string {
+	raw := strings.Split(strings.ReplaceAll(ics, "\r\n", "\n"), "\n")
+	var out []string
+	for _, line := range raw {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(out) > 0 {
+			out[len(out)-1] += line[1:]
+			continue
+		}
+		out = append(out, strings.TrimRight(line, "\r"))
+	}
+	return out
+}
+
+// findProp returns a property of the first VTODO, outside any VALARM.
+func findProp(lines []string, name string) (icsProp, bool) {
+	depth := 0
+	for _, line := range lines {
+		head, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, params, _ := strings.Cut(head, ";")
+		key = strings.ToUpper(key)
+		switch {
+		case key == "BEGIN":
+			if depth > 0 || strings.EqualFold(value, "VTODO") {
+				depth++
+			}
+		case key == "END" && depth > 0:
+			depth--
+			if depth == 0 {
+				return icsProp{}, false
+			}
+		case depth == 1 && key == name:
+			return icsProp{name: key, params: params, value: strings.TrimSpace(value)}, true
+		}
+	}
+	return icsProp{}, false
+}
+
+// EditVTodo rewrites properties of the first VTODO in ics: every property
+// named in set is replaced by the given lines, or removed when the list is
+// empty. Properties not yet present are added before END:VTODO. Everything
+// else, including VALARMs, is kept.
+func EditVTodo(ics string, set map[string][]string) string {
+	var out []string
+	depth := 0
+	seen := false
+	for _, line := range unfoldICS(ics) {
+		head, value, _ := strings.Cut(line, ":")
+		key, _, _ := strings.Cut(head, ";")
+		key = strings.ToUpper(key)
+		if key == "BEGIN" && (depth > 0 || (!seen && strings.EqualFold(value, "VTODO"))) {
+			depth++
+			seen = true
+		}
+		if depth == 1 && key == "END" && strings.EqualFold(value, "VTODO") {
+			keys := make([]string, 0, len(set))
+			for k := range set {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				out = append(out, set[k]...)
+			}
+			depth = 0
+			out = append(out, line)
+			continue
+		}
+		if depth > 1 && key == "END" {
+			depth--
+		}
+		if depth == 1 {
+			if _, ok := set[key]; ok {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\r\n") + "\r\n"
+}
@@ -0,0 +1,82 @@
+package caldav
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceNext(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		rule  string
+		start time.Time
+		want  time.Time // zero = series ended
+	}{
+		{"FREQ=DAILY;INTERVAL=3", day(2026, 3, 3), day(2026, 3, 6)},
+		{"FREQ=WEEKLY", day(2026, 3, 3), day(2026, 3, 10)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", day(2026, 3, 5), day(2026, 3, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", day(2026, 3, 5), day(2026, 3, 17)},
+		{"FREQ=MONTHLY", day(2026, 1, 31), day(2026, 3, 31)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", day(2026, 1, 31), day(2026, 2, 28)},
+		{"FREQ=MONTHLY;BYDAY=-1FR", day(2026, 3, 27), day(2026, 4, 24)},
+		{"FREQ=MONTHLY;BYDAY=2MO", day(2026, 3, 9), day(2026, 4, 13)},
+		{"FREQ=YEARLY", day(2024, 2, 29), day(2028, 2, 29)},
+		{"FREQ=DAILY;COUNT=1", day(2026, 3, 3), time.Time{}},
+		{"FREQ=DAILY;UNTIL=20260303T235959Z", day(2026, 3, 3), time.Time{}},
+	}
+	for _, c := range cases {
+		r, err := ParseRRule(c.rule, time.UTC)
+		if err != nil {
+			t.Fatalf("%s: %v", c.rule, err)
+		}
+		got, ok := r.Next(c.start, c.start)
+		if ok != !c.want.IsZero() || !got.Equal(c.want) {
+			t.Errorf("%s from %s: got %s %v, want %s", c.rule, c.start.Format("2006-01-02"), got, ok, c.want)
+		}
+	}
+	r, _ := ParseRRule("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5", time.UTC)
+	got := r.Between(day(2026, 3, 2), day(2026, 3, 4), day(2026, 4, 1), 10)
+	if len(got) != 4 || !got[0].Equal(day(2026, 3, 4)) || !got[3].Equal(day(2026, 3, 16)) {
+		t.Errorf("Between = %v", got)
+	}
+	if _, err := ParseRRule("FREQ=HOURLY", time.UTC); err == nil {
+		t.Error("FREQ=HOURLY should be unsupported")
+	}
+}
+
+func TestCompleteVTodo(t *testing.T) {
+	now := time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR", "BEGIN:VTODO", "UID:rent",
+		"DTSTART;VALUE=DATE:20260301", "DUE;VALUE=DATE:20260303",
+		"RRULE:FREQ=MONTHLY;COUNT=3", "STATUS:IN-PROCESS", "PERCENT-COMPLETE:50",
+		"BEGIN:VALARM", "TRIGGER:-PT1H", "END:VALARM",
+		"END:VTODO", "END:VCALENDAR", "",
+	}, "\r\n")
+
+	out, c, err := CompleteVTodo(ics, time.UTC, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"DTSTART;VALUE=DATE:20260401", "DUE;VALUE=DATE:20260403",
+		"RRULE:FREQ=MONTHLY;COUNT=2", "STATUS:NEEDS-ACTION", "TRIGGER:-PT1H"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("advanced VTODO missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "PERCENT-COMPLETE") || !c.Recurring || !c.AllDay || c.Next.Day() != 3 || c.Next.Month() != 4 {
+		t.Errorf("completion = %+v\n%s", c, out)
+	}
+
+	out, _, _ = CompleteVTodo(out, time.UTC, now)
+	out, c, _ = CompleteVTodo(out, time.UTC, now)
+	if !c.Ended || !strings.Contains(out, "STATUS:COMPLETED") || !strings.Contains(out, "DUE;VALUE=DATE:20260503") {
+		t.Errorf("last occurrence = %+v\n%s", c, out)
+	}
+
+	out, c, _ = CompleteVTodo("BEGIN:VTODO\r\nUID:x\r\nDUE:20260303T170000Z\r\nEND:VTODO\r\n", time.UTC, now)
+	if c.Recurring || !strings.Contains(out, "STATUS:COMPLETED") || !strings.Contains(out, "COMPLETED:20260304T080000Z") {
+		t.Errorf("one-off completion = %+v\n%s", c, out)
+	}
+}
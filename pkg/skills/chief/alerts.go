@@ -0,0 +1,103 @@
+package chief
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// ----------------------------------------------------------------------------
+// ALERT STATE (ack / snooze / mute)
+// ----------------------------------------------------------------------------
+
+func (s *ChiefSkill) openAlerts() (*deadlines.Store, error) {
+	return deadlines.OpenStore(filepath.Join(s.workspace, "memory", deadlines.AlertsFileName))
+}
+
+func (s *ChiefSkill) executeAlertAction(ctx context.Context, args map[string]interface{}, command string) *tools.ToolResult {
+	ref, _ := args["ref"].(string)
+	if strings.TrimSpace(ref) == "" {
+		return tools.ErrorResult(fmt.Sprintf("ref is required for %s (alert id or part of the title)", command))
+	}
+
+	store, err := s.openAlerts()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to open alert store: %v", err))
+	}
+	defer store.Close()
+
+	now := s.now()
+	var summary, msg string
+	switch command {
+	case "ack":
+		summary, err = store.Ack(ref, now)
+		msg = "✅ Acknowledged: %s"
+	case "snooze":
+		durStr, _ := args["duration"].(string)
+		if durStr == "" {
+			durStr = "1h"
+		}
+		dur, perr := deadlines.ParseSnooze(durStr)
+		if perr != nil {
+			return tools.ErrorResult(perr.Error())
+		}
+		until := now.Add(dur)
+		summary, err = store.Snooze(ref, until)
+		msg = "😴 Snoozed until " + until.Format("Jan 2 15:04") + ": %s"
+	case "mute":
+		summary, err = store.Mute(ref, now)
+		msg = "🔕 Muted: %s"
+	case "unmute":
+		summary, err = store.Unmute(ref)
+		msg = "🔔 Unmuted: %s"
+	}
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+
+	out := fmt.Sprintf(msg, summary)
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
+
+func (s *ChiefSkill) executeListAlerts(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	store, err := s.openAlerts()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to open alert store: %v", err))
+	}
+	defer store.Close()
+
+	list, err := store.List()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to read alerts: %v", err))
+	}
+	if len(list) == 0 {
+		msg := "No alerts announced yet."
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+
+	now := s.now()
+	var sb strings.Builder
+	sb.WriteString("🔔 **Alerts**\n\n")
+	for _, a := range list {
+		state := "announced " + a.LastAlerted.In(now.Location()).Format("Jan 2 15:04")
+		switch {
+		case a.Muted:
+			state = "muted"
+		case a.Acked:
+			state = "acknowledged"
+		case a.SnoozedUntil.After(now):
+			state = "snoozed until " + a.SnoozedUntil.In(now.Location()).Format("Jan 2 15:04")
+		}
+		due := ""
+		if !a.Due.IsZero() {
+			due = ", due " + a.Due.In(now.Location()).Format("Jan 2 15:04")
+		}
+		fmt.Fprintf(&sb, "- [%s] %s%s — %s\n", a.Ref, a.Summary, due, state)
+	}
+	out := sb.String()
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
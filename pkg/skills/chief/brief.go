@@ -0,0 +1,324 @@
+package chief
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+)
+
+// ----------------------------------------------------------------------------
+// Brief data model
+//
+// BriefData is what templates in <workspace>/templates/*.md.tmpl receive.
+// Every slice is complete; templates decide how many items to show with
+// the `limit` function. See workspaces/chief/templates/README.md.
+// ----------------------------------------------------------------------------
+
+// BriefData is the root object passed to morning_brief and evening_review templates.
+type BriefData struct {
+	Date time.Time
+	User string // empty in single-user mode
+
+	Tasks     []Task               // open tasks categorised "today"
+	Completed []Task               // tasks with STATUS:COMPLETED
+	Deadlines []string             // lines of Architect's deadlines-today.md
+	Upcoming  []deadlines.Deadline // open deadlines from Architect's deadlines.json, soonest first
+	Events    []Event              // today's calendar events from ATC events.xml
+	Habits    []Habit              // Coach streaks
+
+	News   []skills.Record // Monitor news cache (today, else yesterday)
+	Papers []skills.Record // Research paper cache (today, else yesterday)
+
+	Learning         []string // lines of Coach's learning-today.md
+	Stats            []string // lines of ATC's stats-today.md
+	TomorrowResearch []string // lines of tomorrow/research.md
+	TomorrowNews     []string // lines of tomorrow/news.md
+	Prompts          []string // reflection questions (tools.journal.prompts)
+
+	// Errors holds a message per section that failed to load, keyed by
+	// section name ("tasks", "events", "habits").
+	Errors map[string]string
+}
+
+// Task is a VTODO from ATC's tasks.xml.
+type Task struct {
+	UID        string
+	Summary    string
+	Status     string
+	Priority   int // 0 undefined, 1 highest … 9 lowest
+	Due        string
+	Categories string
+	Completed  string // COMPLETED, RFC 3339; empty for open tasks and older files
+}
+
+// Event is a VEVENT from ATC's events.xml.
+type Event struct {
+	UID       string
+	Summary   string
+	Location  string
+	Start     time.Time
+	End       time.Time // zero when the event has no DTEND
+	AllDay    bool
+	Recurring bool // one occurrence of a recurring series; UID is the series'
+}
+
+// Habit is one row of Coach's streaks table.
+type Habit struct {
+	Name          string
+	Streak        int
+	LastCompleted string // YYYY-MM-DD
+	DoneToday     bool
+}
+
+// collectBriefData gathers every section once; templates pick what they show.
+func (s *ChiefSkill) collectBriefData(now time.Time) *BriefData {
+	d := &BriefData{
+		Date:   now,
+		Errors: map[string]string{},
+	}
+	if s.user != nil {
+		d.User = s.user.Name
+	}
+
+	if all, err := s.loadTasks(); err != nil {
+		d.Errors["tasks"] = err.Error()
+	} else {
+		for _, t := range all {
+			status := strings.ToLower(t.Status)
+			if status == "completed" {
+				d.Completed = append(d.Completed, t)
+			} else if strings.Contains(strings.ToLower(t.Categories), "today") {
+				d.Tasks = append(d.Tasks, t)
+			}
+		}
+	}
+
+	if events, err := s.loadEvents(now); err != nil {
+		d.Errors["events"] = err.Error()
+	} else {
+		d.Events = events
+	}
+
+	if habits, err := s.loadHabits(now); err != nil {
+		d.Errors["habits"] = err.Error()
+	} else {
+		d.Habits = habits
+	}
+
+	d.Deadlines = splitLines(s.readMemoryFile("deadlines-today.md", ""))
+	if feed, err := s.loadDeadlineFeed(); err == nil {
+		d.Upcoming = feed.Deadlines
+	}
+	d.Learning = splitLines(s.readMemoryFile("learning-today.md", ""))
+	d.Stats = splitLines(s.readMemoryFile("stats-today.md", ""))
+	d.TomorrowResearch = splitLines(s.readMemoryFile("tomorrow/research.md", ""))
+	d.TomorrowNews = splitLines(s.readMemoryFile("tomorrow/news.md", ""))
+	for _, p := range loadJournalPrompts() {
+		d.Prompts = append(d.Prompts, p.Prompt)
+	}
+
+	d.News = s.loadRecords("news", now)
+	d.Papers = s.loadRecords("research", now)
+	return d
+}
+
+// loadTasks parses ATC's tasks.xml.
+func (s *ChiefSkill) loadTasks() ([]Task, error) {
+	tasksPath := filepath.Join(s.workspace, "..", "atc", "memory", "tasks.xml")
+	data, err := os.ReadFile(tasksPath)
+	if err != nil {
+		return nil, errors.New("ATC tasks.xml not found. Run `atc analyze_tasks` first.")
+	}
+
+	// Minimal inline xCal parse — just what Chief needs
+	type prop struct {
+		Text string `xml:",chardata"`
+	}
+	type vtodoProp struct {
+		Uid        prop `xml:"uid>text"`
+		Summary    prop `xml:"summary>text"`
+		Status     prop `xml:"status>text"`
+		Priority   int  `xml:"priority>integer"`
+		Due        prop `xml:"due>date-time"`
+		DueDate    prop `xml:"due>date"`
+		Categories prop `xml:"categories>text"`
+		Completed  prop `xml:"completed>date-time"`
+	}
+	type vtodo struct {
+		Properties vtodoProp `xml:"properties"`
+	}
+	type components struct {
+		VTodos []vtodo `xml:"vtodo"`
+	}
+	type vcal struct {
+		Components components `xml:"components"`
+	}
+	type ical struct {
+		VCal vcal `xml:"vcalendar"`
+	}
+
+	var cal ical
+	if err := xml.Unmarshal(data, &cal); err != nil {
+		return nil, fmt.Errorf("Failed to parse tasks.xml: %v", err)
+	}
+
+	tasks := make([]Task, 0, len(cal.VCal.Components.VTodos))
+	for _, todo := range cal.VCal.Components.VTodos {
+		p := todo.Properties
+		due := p.Due.Text
+		if due == "" {
+			due = p.DueDate.Text
+		}
+		tasks = append(tasks, Task{
+			UID:        p.Uid.Text,
+			Summary:    p.Summary.Text,
+			Status:     p.Status.Text,
+			Priority:   p.Priority,
+			Due:        due,
+			Categories: p.Categories.Text,
+			Completed:  p.Completed.Text,
+		})
+	}
+	return tasks, nil
+}
+
+// loadEvents returns today's events from ATC's events.xml, sorted by start.
+// A missing file is not an error; the calendar may simply not be synced.
+func (s *ChiefSkill) loadEvents(now time.Time) ([]Event, error) {
+	eventsPath := filepath.Join(s.workspace, "..", "atc", "memory", "events.xml")
+	data, err := os.ReadFile(eventsPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The events.xml fields ATC's read_calendar expands occurrences from.
+	type veventProp struct {
+		Uid              string   `xml:"uid>text"`
+		Summary          string   `xml:"summary>text"`
+		Location         string   `xml:"location>text"`
+		Dtstart          string   `xml:"dtstart>date-time"`
+		DtstartDate      string   `xml:"dtstart>date"`
+		Dtend            string   `xml:"dtend>date-time"`
+		DtendDate        string   `xml:"dtend>date"`
+		Duration         string   `xml:"duration>duration"`
+		Status           string   `xml:"status>text"`
+		RRule            string   `xml:"rrule>text"`
+		ExDate           []string `xml:"exdate>date-time"`
+		ExDateDate       []string `xml:"exdate>date"`
+		RecurrenceID     string   `xml:"recurrence-id>date-time"`
+		RecurrenceIDDate string   `xml:"recurrence-id>date"`
+		Source           string   `xml:"x-source>text"`
+	}
+	type vevent struct {
+		Properties veventProp `xml:"properties"`
+	}
+	type components struct {
+		VEvents []vevent `xml:"vevent"`
+	}
+	type vcal struct {
+		Components components `xml:"components"`
+	}
+	type ical struct {
+		VCal vcal `xml:"vcalendar"`
+	}
+
+	var cal ical
+	if err := xml.Unmarshal(data, &cal); err != nil {
+		return nil, fmt.Errorf("Failed to parse events.xml: %v", err)
+	}
+
+	var evs []caldav.Event
+	for _, ev := range cal.VCal.Components.VEvents {
+		p := ev.Properties
+		evs = append(evs, caldav.Event{
+			UID: p.Uid, Source: p.Source, Summary: p.Summary, Location: p.Location, Status: p.Status,
+			Dtstart: p.Dtstart, DtstartDate: p.DtstartDate, Dtend: p.Dtend, DtendDate: p.DtendDate, Duration: p.Duration,
+			RRule: p.RRule, ExDate: p.ExDate, ExDateDate: p.ExDateDate,
+			RecurrenceID: p.RecurrenceID, RecurrenceIDDate: p.RecurrenceIDDate,
+		})
+	}
+
+	// Today's occurrences as read_calendar lists them: recurring events
+	// expanded, cancelled ones dropped, multi-day events on each day.
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var events []Event
+	for _, o := range caldav.Occurrences(evs, day, day.AddDate(0, 0, 1)) {
+		e := Event{UID: o.UID, Summary: o.Summary, Location: o.Location, Start: o.Start, AllDay: o.AllDay, Recurring: o.Recurring}
+		if o.End.After(o.Start) {
+			e.End = o.End
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// loadHabits reads Coach's streaks table. A missing database is not an error.
+func (s *ChiefSkill) loadHabits(now time.Time) ([]Habit, error) {
+	dbPath := filepath.Join(s.workspace, "..", "coach", "memory", "momentum.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT category, current_streak, COALESCE(last_completed_date, '') FROM streaks ORDER BY category")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	today := now.Format("2006-01-02")
+	var habits []Habit
+	for rows.Next() {
+		var h Habit
+		if err := rows.Scan(&h.Name, &h.Streak, &h.LastCompleted); err != nil {
+			return nil, err
+		}
+		h.DoneToday = h.LastCompleted == today
+		habits = append(habits, h)
+	}
+	return habits, rows.Err()
+}
+
+// loadRecords reads an RFC cache (news-/research-YYYYMMDD.md) for today,
+// falling back to yesterday.
+func (s *ChiefSkill) loadRecords(prefix string, now time.Time) []skills.Record {
+	for _, d := range []string{now.Format("20060102"), now.AddDate(0, 0, -1).Format("20060102")} {
+		path := filepath.Join(s.workspace, "memory", prefix+"-"+d+".md")
+		lines, err := skills.ParseRFCFile(path, 0)
+		if err == nil && len(lines) > 0 {
+			records := make([]skills.Record, 0, len(lines))
+			for _, line := range lines {
+				records = append(records, skills.DecodeRecord(line))
+			}
+			return records
+		}
+	}
+	return nil
+}
+
+// splitLines turns a memory file into non-empty lines.
+func splitLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return lines
+}
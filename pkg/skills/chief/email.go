@@ -0,0 +1,374 @@
+package chief
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/render"
+)
+
+// ----------------------------------------------------------------------------
+// Email delivery
+//
+// Briefs listed under tools.email.briefs are mailed after they are
+// generated: multipart/alternative text + HTML, plus today's events from
+// ATC's events.xml as an .ics attachment.
+// ----------------------------------------------------------------------------
+
+// EmailConfig is the tools.email section of config.json.
+type EmailConfig struct {
+	Host       string                 `json:"host"`
+	Port       int                    `json:"port"` // 587 (STARTTLS) by default; 465 uses implicit TLS
+	Username   string                 `json:"username"`
+	Password   string                 `json:"password"`
+	From       string                 `json:"from"`
+	To         []string               `json:"to"`          // default recipients
+	RequireTLS bool                   `json:"require_tls"` // fail instead of sending in clear when STARTTLS is not offered
+	Timeout    int                    `json:"timeout_seconds"`
+	Briefs     map[string]EmailTarget `json:"briefs"` // keyed by brief type: morning_brief, evening_review
+}
+
+// EmailTarget configures delivery of one brief type.
+type EmailTarget struct {
+	To      []string `json:"to"`      // overrides EmailConfig.To in single-user mode
+	Subject string   `json:"subject"` // defaults to the brief's first heading
+}
+
+func loadEmailConfig() EmailConfig {
+	var cfg struct {
+		Tools struct {
+			Email EmailConfig `json:"email"`
+		} `json:"tools"`
+	}
+	readConfig(&cfg)
+	return cfg.Tools.Email
+}
+
+// emailBrief mails a rendered brief when its type is configured for email.
+// It returns a one-line status for the LLM, or "" when email is not configured.
+func (s *ChiefSkill) emailBrief(briefType, markdown string, events []Event, date time.Time) string {
+	cfg := loadEmailConfig()
+	target, ok := cfg.Briefs[briefType]
+	if !ok || cfg.Host == "" {
+		return ""
+	}
+
+	// A bound user's brief only ever goes to them; the configured
+	// recipients are the single-user defaults.
+	var to []string
+	switch {
+	case s.user != nil && s.user.Email != "":
+		to = []string{s.user.Email}
+	case s.user != nil:
+		return fmt.Sprintf("⚠️ Email not sent: no email set for user %s.", s.user.Name)
+	case len(target.To) > 0:
+		to = target.To
+	default:
+		to = cfg.To
+	}
+	if len(to) == 0 {
+		return "⚠️ Email not sent: no recipients configured."
+	}
+
+	subject := target.Subject
+	if subject == "" {
+		subject = briefSubject(markdown)
+	}
+
+	var attachments []emailAttachment
+	if len(events) > 0 {
+		attachments = append(attachments, emailAttachment{
+			Name:        "today-" + date.Format("2006-01-02") + ".ics",
+			ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+			Data:        buildICS(events, date),
+		})
+	}
+
+	msg, err := buildBriefEmail(cfg.From, to, subject, markdown, attachments, date)
+	if err != nil {
+		return fmt.Sprintf("⚠️ Email not sent: %v", err)
+	}
+	if err := sendMail(cfg, to, msg); err != nil {
+		return fmt.Sprintf("⚠️ Email not sent: %v", err)
+	}
+	return fmt.Sprintf("📧 Emailed to %s.", strings.Join(to, ", "))
+}
+
+// briefSubject uses the brief's first heading as the subject line.
+func briefSubject(markdown string) string {
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+	}
+	return "Brief"
+}
+
+type emailAttachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// buildBriefEmail assembles a MIME message:
+//
+//	multipart/mixed
+//	├── multipart/alternative (text/plain, text/html)
+//	└── attachments…
+func buildBriefEmail(from string, to []string, subject, markdown string, attachments []emailAttachment, date time.Time) ([]byte, error) {
+	text, _ := render.Render(markdown, render.FormatText)
+	body, _ := render.Render(markdown, render.FormatHTML)
+	htmlDoc := "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"></head>\n" +
+		"<body style=\"font-family:-apple-system,Segoe UI,Roboto,sans-serif;line-height:1.5;max-width:680px\">\n" +
+		body + "</body></html>\n"
+
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + mixed.Boundary(),
+	}
+	var msg bytes.Buffer
+	msg.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	// Alternative text/HTML bodies
+	var altBuf bytes.Buffer
+	alt := multipart.NewWriter(&altBuf)
+	for _, part := range []struct{ ctype, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", htmlDoc},
+	} {
+		w, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.ctype},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(strings.ReplaceAll(part.content, "\n", "\r\n")))
+		qp.Close()
+	}
+	alt.Close()
+
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alt.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(altBuf.Bytes())
+
+	for _, a := range attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", a.ContentType, a.Name)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Name)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64Lines(w, a.Data)
+	}
+	mixed.Close()
+
+	msg.Write(buf.Bytes())
+	return msg.Bytes(), nil
+}
+
+// writeBase64Lines writes base64 wrapped at 76 characters (RFC 2045).
+func writeBase64Lines(w io.Writer, data []byte) {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		w.Write([]byte(enc[:76] + "\r\n"))
+		enc = enc[76:]
+	}
+	w.Write([]byte(enc + "\r\n"))
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%x@%s>", b, domain)
+}
+
+// sendMail delivers msg over SMTP. Port 465 uses implicit TLS; any other
+// port upgrades with STARTTLS when the server offers it.
+func sendMail(cfg EmailConfig, to []string, msg []byte) error {
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	timeout := 30 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %v", cfg.From, err)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: cfg.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect %s: %v", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+				return fmt.Errorf("STARTTLS: %v", err)
+			}
+		} else if cfg.RequireTLS {
+			return fmt.Errorf("%s does not offer STARTTLS and require_tls is set", cfg.Host)
+		}
+	}
+
+	if cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("%s does not support AUTH", cfg.Host)
+		}
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("auth: %v", err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %v", rcpt, err)
+		}
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// ----------------------------------------------------------------------------
+// .ics attachment
+// ----------------------------------------------------------------------------
+
+// buildICS serialises events as an RFC 5545 VCALENDAR. An occurrence of a
+// recurring event gets a UID of its own: with the series UID, a client
+// importing it would replace the whole series with this one event.
+func buildICS(events []Event, now time.Time) []byte {
+	var sb strings.Builder
+	line := func(s string) { sb.WriteString(foldICSLine(s) + "\r\n") }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//son-of-anthon//chief//EN")
+	line("METHOD:PUBLISH")
+	stamp := now.UTC().Format("20060102T150405Z")
+	for i, e := range events {
+		uid := e.UID
+		switch {
+		case uid == "":
+			uid = fmt.Sprintf("%s-%d@son-of-anthon", e.Start.Format("20060102T150405"), i)
+		case e.Recurring:
+			uid = fmt.Sprintf("%s-%s@son-of-anthon", uid, e.Start.UTC().Format("20060102T150405Z"))
+		}
+		line("BEGIN:VEVENT")
+		line("UID:" + escapeICSText(uid))
+		line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			if !e.End.IsZero() {
+				line("DTEND;VALUE=DATE:" + e.End.Format("20060102"))
+			}
+		} else {
+			line("DTSTART:" + e.Start.UTC().Format("20060102T150405Z"))
+			if !e.End.IsZero() {
+				line("DTEND:" + e.End.UTC().Format("20060102T150405Z"))
+			}
+		}
+		line("SUMMARY:" + escapeICSText(e.Summary))
+		if e.Location != "" {
+			line("LOCATION:" + escapeICSText(e.Location))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return []byte(sb.String())
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine folds content lines longer than 75 octets without splitting
+// a UTF-8 sequence.
+func foldICSLine(s string) string {
+	if len(s) <= 75 {
+		return s
+	}
+	var sb strings.Builder
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
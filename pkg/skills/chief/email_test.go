@@ -0,0 +1,247 @@
+package chief
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/users"
+)
+
+// fakeSMTP is a minimal SMTP server that records one session.
+type fakeSMTP struct {
+	ln   net.Listener
+	auth string
+	from string
+	rcpt []string
+	data chan string
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeSMTP{ln: ln, data: make(chan string, 1)}
+	t.Cleanup(func() { ln.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeSMTP) port() int { return f.ln.Addr().(*net.TCPAddr).Port }
+
+func (f *fakeSMTP) serve() {
+	conn, err := f.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { fmt.Fprintf(conn, "%s\r\n", s) }
+
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH PLAIN"):
+			raw, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			f.auth = string(raw)
+			reply("235 OK")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			f.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			f.rcpt = append(f.rcpt, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var sb strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				sb.WriteString(strings.TrimPrefix(l, "."))
+			}
+			f.data <- sb.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailBriefOverSMTP(t *testing.T) {
+	srv := startFakeSMTP(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`{"tools": {"email": {
+		"host": "127.0.0.1", "port": %d,
+		"username": "bot", "password": "secret",
+		"from": "Chief <chief@example.com>",
+		"to": ["me@example.com"],
+		"briefs": {"morning_brief": {"subject": "Your day"}}
+	}}}`, srv.port())), 0644)
+	t.Setenv("PERSONAL_OS_CONFIG", cfgPath)
+
+	day := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	data := &BriefData{
+		Date: day,
+		Events: []Event{{
+			UID:     "standup-1",
+			Summary: "Standup, team A",
+			Start:   day.Add(2 * time.Hour),
+			End:     day.Add(2*time.Hour + 15*time.Minute),
+		}},
+	}
+	md := "# 🎯 Morning Brief\n\n## News\n- AI_model *beats* [benchmark]\n"
+
+	s := &ChiefSkill{workspace: t.TempDir()}
+	if note := s.emailBrief("morning_brief", md, data.Events, data.Date); !strings.HasPrefix(note, "📧") {
+		t.Fatalf("unexpected status: %s", note)
+	}
+	if note := s.emailBrief("evening_review", md, data.Events, data.Date); note != "" {
+		t.Errorf("unconfigured brief type should not be emailed, got %q", note)
+	}
+	// A user's brief never falls back to the operator's recipients.
+	rafi := &ChiefSkill{workspace: t.TempDir(), user: &users.Profile{Name: "rafi"}}
+	if note := rafi.emailBrief("morning_brief", md, data.Events, data.Date); note != "⚠️ Email not sent: no email set for user rafi." {
+		t.Errorf("user without email: %q", note)
+	}
+
+	var raw string
+	select {
+	case raw = <-srv.data:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	if srv.auth != "\x00bot\x00secret" {
+		t.Errorf("auth = %q", srv.auth)
+	}
+	if srv.from != "chief@example.com" || len(srv.rcpt) != 1 || srv.rcpt[0] != "me@example.com" {
+		t.Errorf("envelope from=%s rcpt=%v", srv.from, srv.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if subj, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subj != "Your day" {
+		t.Errorf("subject = %q", subj)
+	}
+
+	parts := map[string]string{}
+	collectParts(t, msg.Header.Get("Content-Type"), msg.Body, parts)
+
+	if !strings.Contains(parts["text/plain"], "• AI_model *beats* [benchmark]") {
+		t.Errorf("text part: %q", parts["text/plain"])
+	}
+	if !strings.Contains(parts["text/html"], "<h1>🎯 Morning Brief</h1>") {
+		t.Errorf("html part: %q", parts["text/html"])
+	}
+	ics := parts["text/calendar"]
+	for _, want := range []string{"BEGIN:VEVENT", "UID:standup-1", `SUMMARY:Standup\, team A`, "DTSTART:20260302T100000Z", "DTEND:20260302T101500Z"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ics missing %q:\n%s", want, ics)
+		}
+	}
+}
+
+// collectParts walks a MIME tree and stores decoded leaf bodies by media type.
+func collectParts(t *testing.T, contentType string, body io.Reader, out map[string]string) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("content type %q: %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, _ := io.ReadAll(body)
+		out[mediaType] = string(data)
+		return
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		var r io.Reader = p // multipart.Reader decodes quoted-printable itself
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			r = base64.NewDecoder(base64.StdEncoding, p)
+		}
+		collectParts(t, p.Header.Get("Content-Type"), r, out)
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	long := "SUMMARY:" + strings.Repeat("é", 60)
+	folded := foldICSLine(long)
+	for _, l := range strings.Split(folded, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line longer than 75 octets: %d", len(l))
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != long {
+		t.Error("unfolding does not restore the original line")
+	}
+}
+
+func TestBuildICSGivesOccurrencesTheirOwnUID(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "atc", "memory"), 0755)
+	os.WriteFile(filepath.Join(root, "atc", "memory", "events.xml"), []byte(`<icalendar><vcalendar><components>
+<vevent><properties><uid><text>standup</text></uid><summary><text>Standup</text></summary>
+  <dtstart><date-time>2026-03-02T07:00:00Z</date-time></dtstart><dtend><date-time>2026-03-02T07:30:00Z</date-time></dtend>
+  <rrule><text>FREQ=DAILY</text></rrule></properties></vevent>
+<vevent><properties><uid><text>dentist</text></uid><summary><text>Dentist</text></summary>
+  <dtstart><date-time>2026-03-04T10:00:00Z</date-time></dtstart></properties></vevent>
+</components></vcalendar></icalendar>`), 0644)
+
+	s := &ChiefSkill{workspace: filepath.Join(root, "chief")}
+	now := time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+	events, err := s.loadEvents(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ics := string(buildICS(events, now))
+	for _, want := range []string{
+		"UID:standup-20260304T070000Z@son-of-anthon\r\n",
+		"UID:dentist\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ics missing %q:\n%s", want, ics)
+		}
+	}
+	if strings.Contains(ics, "UID:standup\r\n") {
+		t.Errorf("occurrence reuses the series UID:\n%s", ics)
+	}
+}
@@ -0,0 +1,480 @@
+package chief
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// ----------------------------------------------------------------------------
+// JOURNAL
+//
+// The evening review ends with reflection prompts. The user's reply is
+// stored as one entry per day in memory/journal.db and mirrored to
+// memory/journal/YYYY-MM-DD.md. Weekly and monthly reviews summarise the
+// entries, and a short digest is kept in every agent's memory/MEMORY.md so
+// subagents see how the user has been doing.
+// ----------------------------------------------------------------------------
+
+const (
+	journalDBName  = "journal.db"
+	journalDigest  = 7 // days summarised in MEMORY.md
+	journalBegin   = "<!-- journal:begin -->"
+	journalEnd     = "<!-- journal:end -->"
+	journalDateFmt = "2006-01-02"
+)
+
+// journalAgents are the workspaces whose MEMORY.md carries the digest.
+var journalAgents = []string{"architect", "atc", "chief", "coach", "monitor", "research"}
+
+// JournalPrompt is one reflection question and the entry field its answer fills.
+type JournalPrompt struct {
+	Field  string `json:"field"` // mood, wins, blockers or text
+	Prompt string `json:"prompt"`
+}
+
+var defaultJournalPrompts = []JournalPrompt{
+	{Field: "mood", Prompt: "How was today, from 1 (rough) to 5 (great)?"},
+	{Field: "wins", Prompt: "What went well?"},
+	{Field: "blockers", Prompt: "What got in the way?"},
+	{Field: "text", Prompt: "Anything else on your mind?"},
+}
+
+// loadJournalPrompts reads tools.journal.prompts from config.json:
+//
+//	"journal": {"prompts": [{"field": "mood", "prompt": "Energy today, 1-5?"}, …]}
+func loadJournalPrompts() []JournalPrompt {
+	var cfg struct {
+		Tools struct {
+			Journal struct {
+				Prompts []JournalPrompt `json:"prompts"`
+			} `json:"journal"`
+		} `json:"tools"`
+	}
+	readConfig(&cfg)
+	var prompts []JournalPrompt
+	for _, p := range cfg.Tools.Journal.Prompts {
+		if strings.TrimSpace(p.Prompt) == "" {
+			continue
+		}
+		switch p.Field {
+		case "mood", "wins", "blockers", "text":
+		default:
+			p.Field = "text"
+		}
+		prompts = append(prompts, p)
+	}
+	if len(prompts) == 0 {
+		return defaultJournalPrompts
+	}
+	return prompts
+}
+
+// JournalEntry is one day's reflection.
+type JournalEntry struct {
+	Date     string // YYYY-MM-DD
+	Mood     int    // 1–5, 0 when not given
+	Wins     []string
+	Blockers []string
+	Text     string
+}
+
+func (e JournalEntry) empty() bool {
+	return e.Mood == 0 && len(e.Wins) == 0 && len(e.Blockers) == 0 && strings.TrimSpace(e.Text) == ""
+}
+
+// merge overlays the fields set in o.
+func (e *JournalEntry) merge(o JournalEntry) {
+	if o.Mood != 0 {
+		e.Mood = o.Mood
+	}
+	if len(o.Wins) > 0 {
+		e.Wins = o.Wins
+	}
+	if len(o.Blockers) > 0 {
+		e.Blockers = o.Blockers
+	}
+	if strings.TrimSpace(o.Text) != "" {
+		e.Text = o.Text
+	}
+}
+
+// Markdown renders the entry as saved in memory/journal/.
+func (e JournalEntry) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# 📝 Journal — %s\n\n", e.Date)
+	if e.Mood > 0 {
+		fmt.Fprintf(&sb, "**Mood:** %d/5\n\n", e.Mood)
+	}
+	if len(e.Wins) > 0 {
+		sb.WriteString("## Wins\n")
+		for _, w := range e.Wins {
+			sb.WriteString("- " + w + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	if len(e.Blockers) > 0 {
+		sb.WriteString("## Blockers\n")
+		for _, b := range e.Blockers {
+			sb.WriteString("- " + b + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	if t := strings.TrimSpace(e.Text); t != "" {
+		sb.WriteString("## Notes\n" + t + "\n")
+	}
+	return sb.String()
+}
+
+// ----------------------------------------------------------------------------
+// Parsing free-form replies
+// ----------------------------------------------------------------------------
+
+var (
+	journalLabel = regexp.MustCompile(`(?i)^\s*(?:[-*]\s*)?(mood|score|rating|energy|wins?|went well|good|blockers?|blocked|got in the way|struggles?|notes?|other|text)\s*[:=-]\s*(.*)$`)
+	journalScore = regexp.MustCompile(`^\s*([1-5])(?:\s*/\s*5)?\b`)
+)
+
+// ParseReflection turns a chat reply into an entry. Lines may be labelled
+// ("mood: 4", "wins: shipped the report; gym", "blockers: meetings"); a
+// line that is only a 1–5 score sets the mood; anything else becomes text.
+// Items under a label may continue on following "-" bullet lines.
+func ParseReflection(reply string) JournalEntry {
+	var e JournalEntry
+	var notes []string
+	field := ""
+	add := func(f, v string) {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return
+		}
+		switch f {
+		case "mood":
+			if m := journalScore.FindStringSubmatch(v); m != nil {
+				e.Mood, _ = strconv.Atoi(m[1])
+			}
+		case "wins":
+			e.Wins = append(e.Wins, splitItems(v)...)
+		case "blockers":
+			e.Blockers = append(e.Blockers, splitItems(v)...)
+		default:
+			notes = append(notes, v)
+		}
+	}
+
+	for _, line := range strings.Split(reply, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			field = ""
+			continue
+		}
+		if m := journalLabel.FindStringSubmatch(trimmed); m != nil {
+			field = journalField(m[1])
+			add(field, m[2])
+			continue
+		}
+		if e.Mood == 0 && field == "" {
+			if m := journalScore.FindStringSubmatch(trimmed); m != nil && len(strings.Fields(trimmed)) <= 2 {
+				e.Mood, _ = strconv.Atoi(m[1])
+				continue
+			}
+		}
+		if (field == "wins" || field == "blockers") && (strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "*")) {
+			add(field, strings.TrimLeft(trimmed, "-* "))
+			continue
+		}
+		field = ""
+		add("text", trimmed)
+	}
+	e.Text = strings.Join(notes, "\n")
+	return e
+}
+
+func journalField(label string) string {
+	switch l := strings.ToLower(label); {
+	case l == "mood" || l == "score" || l == "rating" || l == "energy":
+		return "mood"
+	case strings.HasPrefix(l, "win") || l == "went well" || l == "good":
+		return "wins"
+	case strings.HasPrefix(l, "block") || l == "got in the way" || strings.HasPrefix(l, "struggle"):
+		return "blockers"
+	}
+	return "text"
+}
+
+// splitItems splits a list on ";", or on "," when there is no ";", so
+// "calls, emails and slides; gym" keeps the first item whole.
+func splitItems(v string) []string {
+	sep := ";"
+	if !strings.Contains(v, ";") {
+		sep = ","
+	}
+	var out []string
+	for _, part := range strings.Split(v, sep) {
+		if p := strings.TrimSpace(part); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ----------------------------------------------------------------------------
+// Storage
+// ----------------------------------------------------------------------------
+
+type journalStore struct {
+	db *sql.DB
+}
+
+func openJournal(path string) (*journalStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := `
+	CREATE TABLE IF NOT EXISTS journal (
+		date TEXT PRIMARY KEY,
+		mood INTEGER DEFAULT 0,
+		wins TEXT,
+		blockers TEXT,
+		text TEXT,
+		updated_at INTEGER
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &journalStore{db: db}, nil
+}
+
+func (j *journalStore) Close() error {
+	return j.db.Close()
+}
+
+func (j *journalStore) get(date string) (JournalEntry, bool, error) {
+	entries, err := j.between(date, date)
+	if err != nil || len(entries) == 0 {
+		return JournalEntry{Date: date}, false, err
+	}
+	return entries[0], true, nil
+}
+
+func (j *journalStore) save(e JournalEntry, now time.Time) error {
+	wins, _ := json.Marshal(e.Wins)
+	blockers, _ := json.Marshal(e.Blockers)
+	_, err := j.db.Exec(`INSERT INTO journal (date, mood, wins, blockers, text, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET mood = excluded.mood, wins = excluded.wins,
+		blockers = excluded.blockers, text = excluded.text, updated_at = excluded.updated_at`,
+		e.Date, e.Mood, string(wins), string(blockers), e.Text, now.Unix())
+	return err
+}
+
+// between returns entries from..to (YYYY-MM-DD, inclusive), oldest first.
+func (j *journalStore) between(from, to string) ([]JournalEntry, error) {
+	rows, err := j.db.Query("SELECT date, mood, wins, blockers, text FROM journal WHERE date BETWEEN ? AND ? ORDER BY date", from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []JournalEntry
+	for rows.Next() {
+		var e JournalEntry
+		var wins, blockers sql.NullString
+		if err := rows.Scan(&e.Date, &e.Mood, &wins, &blockers, &e.Text); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(wins.String), &e.Wins)
+		json.Unmarshal([]byte(blockers.String), &e.Blockers)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *ChiefSkill) openJournal() (*journalStore, error) {
+	if s.workspace == "" {
+		return nil, fmt.Errorf("chief workspace not set")
+	}
+	return openJournal(filepath.Join(s.workspace, "memory", journalDBName))
+}
+
+// journalEntries loads the entries of a period; errors read as no entries.
+func (s *ChiefSkill) journalEntries(from, to time.Time) []JournalEntry {
+	j, err := s.openJournal()
+	if err != nil {
+		return nil
+	}
+	defer j.Close()
+	entries, _ := j.between(from.Format(journalDateFmt), to.Format(journalDateFmt))
+	return entries
+}
+
+// ----------------------------------------------------------------------------
+// journal command
+// ----------------------------------------------------------------------------
+
+func (s *ChiefSkill) executeJournal(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := s.now()
+	date := now.Format(journalDateFmt)
+	if d, _ := args["date"].(string); d != "" {
+		if _, err := time.ParseInLocation(journalDateFmt, d, now.Location()); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD", d))
+		}
+		date = d
+	}
+
+	var in JournalEntry
+	if reply, _ := args["reply"].(string); strings.TrimSpace(reply) != "" {
+		in = ParseReflection(reply)
+	}
+	if m, ok := args["mood"].(float64); ok {
+		if m < 1 || m > 5 {
+			return tools.ErrorResult("mood must be between 1 and 5")
+		}
+		in.Mood = int(m)
+	}
+	if v, _ := args["wins"].(string); strings.TrimSpace(v) != "" {
+		in.Wins = splitItems(v)
+	}
+	if v, _ := args["blockers"].(string); strings.TrimSpace(v) != "" {
+		in.Blockers = splitItems(v)
+	}
+	if v, _ := args["text"].(string); strings.TrimSpace(v) != "" {
+		in.Text = v
+	}
+
+	j, err := s.openJournal()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to open journal: %v", err))
+	}
+	defer j.Close()
+
+	if in.empty() {
+		return s.listJournal(j, now)
+	}
+
+	entry, existed, err := j.get(date)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to read journal: %v", err))
+	}
+	entry.merge(in)
+	if err := j.save(entry, now); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to save journal entry: %v", err))
+	}
+
+	md := filepath.Join(s.workspace, "memory", "journal", date+".md")
+	os.MkdirAll(filepath.Dir(md), 0755)
+	os.WriteFile(md, []byte(entry.Markdown()), 0644)
+
+	recent, _ := j.between(now.AddDate(0, 0, -(journalDigest-1)).Format(journalDateFmt), now.Format(journalDateFmt))
+	s.updateJournalDigest(recent)
+
+	verb := "Saved"
+	if existed {
+		verb = "Updated"
+	}
+	msg := fmt.Sprintf("📝 %s journal entry for %s.\n\n%s", verb, date, entry.Markdown())
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func (s *ChiefSkill) listJournal(j *journalStore, now time.Time) *tools.ToolResult {
+	entries, err := j.between(now.AddDate(0, 0, -(journalDigest-1)).Format(journalDateFmt), now.Format(journalDateFmt))
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to read journal: %v", err))
+	}
+	if len(entries) == 0 {
+		msg := "📝 No journal entries in the last 7 days. Answer the evening review's reflection prompts to start one."
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+	out := "📝 Journal, last 7 days:\n" + journalDigestLines(entries)
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
+
+// ----------------------------------------------------------------------------
+// MEMORY.md digest
+// ----------------------------------------------------------------------------
+
+func journalDigestLines(entries []JournalEntry) string {
+	var sb strings.Builder
+	if avg, n := averageMood(entries); n > 0 {
+		fmt.Fprintf(&sb, "- Average mood: %.1f/5 over %d day(s)\n", avg, n)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		parts := []string{e.Date}
+		if e.Mood > 0 {
+			parts = append(parts, fmt.Sprintf("mood %d/5", e.Mood))
+		}
+		if len(e.Wins) > 0 {
+			parts = append(parts, "wins: "+strings.Join(e.Wins, "; "))
+		}
+		if len(e.Blockers) > 0 {
+			parts = append(parts, "blockers: "+strings.Join(e.Blockers, "; "))
+		}
+		if t := strings.TrimSpace(e.Text); t != "" {
+			parts = append(parts, "notes: "+strings.Join(strings.Fields(t), " "))
+		}
+		sb.WriteString("- " + strings.Join(parts, " · ") + "\n")
+	}
+	return sb.String()
+}
+
+func averageMood(entries []JournalEntry) (float64, int) {
+	sum, n := 0, 0
+	for _, e := range entries {
+		if e.Mood > 0 {
+			sum += e.Mood
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return float64(sum) / float64(n), n
+}
+
+// updateJournalDigest rewrites the journal block in each agent's
+// memory/MEMORY.md, leaving the rest of the file alone.
+func (s *ChiefSkill) updateJournalDigest(recent []JournalEntry) {
+	block := journalBegin + "\n## Recent Journal (from Chief)\n" + journalDigestLines(recent) + journalEnd + "\n"
+	base := filepath.Dir(s.workspace)
+	for _, agent := range journalAgents {
+		ws := filepath.Join(base, agent)
+		if _, err := os.Stat(ws); err != nil {
+			continue
+		}
+		path := filepath.Join(ws, "memory", "MEMORY.md")
+		existing, _ := os.ReadFile(path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(replaceBlock(string(existing), block)), 0644)
+	}
+}
+
+// replaceBlock swaps the journal block in content, appending it when absent.
+func replaceBlock(content, block string) string {
+	start := strings.Index(content, journalBegin)
+	end := strings.Index(content, journalEnd)
+	if start >= 0 && end > start {
+		rest := strings.TrimPrefix(content[end+len(journalEnd):], "\n")
+		return content[:start] + block + rest
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block
+}
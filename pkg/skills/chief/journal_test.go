@@ -0,0 +1,83 @@
+package chief
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReflection(t *testing.T) {
+	e := ParseReflection("4/5\nWins: shipped the report; gym\n- called mom\nBlockers: too many meetings\n\nTired but happy.")
+	want := JournalEntry{
+		Mood:     4,
+		Wins:     []string{"shipped the report", "gym", "called mom"},
+		Blockers: []string{"too many meetings"},
+		Text:     "Tired but happy.",
+	}
+	if !reflect.DeepEqual(e, want) {
+		t.Errorf("ParseReflection = %+v, want %+v", e, want)
+	}
+
+	if e := ParseReflection("Mood: 2\nnothing went right"); e.Mood != 2 || e.Text != "nothing went right" {
+		t.Errorf("labelled mood = %+v", e)
+	}
+}
+
+func TestJournalCaptureAndDigest(t *testing.T) {
+	root := t.TempDir()
+	ws := filepath.Join(root, "chief")
+	os.MkdirAll(filepath.Join(root, "coach", "memory"), 0755)
+	os.WriteFile(filepath.Join(root, "coach", "memory", "MEMORY.md"), []byte("# Coach memory\nIELTS target 7.5\n"), 0644)
+	s := &ChiefSkill{workspace: ws}
+
+	today := time.Now().Format("2006-01-02")
+	res := s.Execute(context.Background(), map[string]interface{}{"command": "journal", "reply": "mood: 3\nwins: finished slides"})
+	if res.IsError {
+		t.Fatalf("journal: %s", res.ForLLM)
+	}
+	// A later answer fills in the rest of the same day's entry.
+	s.Execute(context.Background(), map[string]interface{}{"command": "journal", "mood": float64(4), "blockers": "slow laptop"})
+
+	md, err := os.ReadFile(filepath.Join(ws, "memory", "journal", today+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"**Mood:** 4/5", "- finished slides", "## Blockers\n- slow laptop"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("journal markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	coach, _ := os.ReadFile(filepath.Join(root, "coach", "memory", "MEMORY.md"))
+	if !strings.HasPrefix(string(coach), "# Coach memory\nIELTS target 7.5\n\n"+journalBegin) ||
+		!strings.Contains(string(coach), "- "+today+" · mood 4/5 · wins: finished slides · blockers: slow laptop") {
+		t.Errorf("coach MEMORY.md:\n%s", coach)
+	}
+	// Rewriting replaces the block instead of appending another.
+	s.Execute(context.Background(), map[string]interface{}{"command": "journal", "text": "early night"})
+	coach, _ = os.ReadFile(filepath.Join(root, "coach", "memory", "MEMORY.md"))
+	if strings.Count(string(coach), journalBegin) != 1 || !strings.Contains(string(coach), "notes: early night") {
+		t.Errorf("digest not replaced:\n%s", coach)
+	}
+	if _, err := os.Stat(filepath.Join(root, "atc", "memory", "MEMORY.md")); err == nil {
+		t.Error("digest written for an agent without a workspace")
+	}
+
+	r := s.collectReviewData("weekly", s.now())
+	if r.Totals.JournalDays != 1 || r.Totals.Mood != 4 || len(r.Wins) != 1 || len(r.Blockers) != 1 {
+		t.Errorf("review journal = %+v / %+v", r.Totals, r.Journal)
+	}
+	out, err := s.renderBrief("weekly_review", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"- Mood: 4.0/5 over 1 journal entries", "- 🏆 finished slides", "- 🧱 slow laptop"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("weekly review missing %q:\n%s", want, out)
+		}
+	}
+}
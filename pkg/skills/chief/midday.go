@@ -0,0 +1,292 @@
+package chief
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/render"
+	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// ----------------------------------------------------------------------------
+// MIDDAY UPDATE
+//
+// morning_brief saves the full BriefData it rendered next to the day
+// snapshot (memory/snapshots/YYYY-MM-DD-morning.json). midday_update
+// collects the same data again and reports only what changed since then.
+// ----------------------------------------------------------------------------
+
+// middayTopStories is how far down the current news list a headline may
+// sit and still count as a new top story.
+const middayTopStories = 10
+
+// MiddayData is the root object passed to the midday_update template.
+type MiddayData struct {
+	Date  time.Time // now
+	Since time.Time // when the morning brief ran
+	User  string
+
+	Completed   []Task       // completed since the morning
+	Added       []Task       // new tasks for today
+	Dropped     []Task       // left today's list without being completed
+	Rescheduled []TaskChange // due date changed
+
+	NewEvents       []Event
+	CancelledEvents []Event
+	MovedEvents     []EventChange
+
+	NewUrgent      []deadlines.Deadline // urgent now, not urgent this morning
+	Resolved       []deadlines.Deadline // gone from Architect's feed
+	MovedDeadlines []DeadlineChange
+
+	NewStories []skills.Record // top headlines not in the morning brief
+}
+
+// TaskChange is a task whose due date moved.
+type TaskChange struct {
+	Task
+	WasDue string
+}
+
+// EventChange is an event whose time moved.
+type EventChange struct {
+	Event
+	WasStart time.Time
+	WasEnd   time.Time
+}
+
+// DeadlineChange is a deadline whose due time moved.
+type DeadlineChange struct {
+	deadlines.Deadline
+	WasDue time.Time
+}
+
+// Changes counts every reported difference; zero means stay silent.
+func (m *MiddayData) Changes() int {
+	return len(m.Completed) + len(m.Added) + len(m.Dropped) + len(m.Rescheduled) +
+		len(m.NewEvents) + len(m.CancelledEvents) + len(m.MovedEvents) +
+		len(m.NewUrgent) + len(m.Resolved) + len(m.MovedDeadlines) +
+		len(m.NewStories)
+}
+
+func (s *ChiefSkill) morningBaselinePath(day time.Time) string {
+	return filepath.Join(s.workspace, "memory", "snapshots", day.Format("2006-01-02")+"-morning.json")
+}
+
+// saveMorningBaseline keeps what the morning brief showed for midday_update.
+func (s *ChiefSkill) saveMorningBaseline(d *BriefData) {
+	if s.workspace == "" {
+		return
+	}
+	path := s.morningBaselinePath(d.Date)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+func (s *ChiefSkill) loadMorningBaseline(day time.Time) (*BriefData, error) {
+	data, err := os.ReadFile(s.morningBaselinePath(day))
+	if err != nil {
+		return nil, err
+	}
+	var d BriefData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *ChiefSkill) executeMiddayUpdate(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := s.now()
+	morning, err := s.loadMorningBaseline(now)
+	if err != nil {
+		msg := "No morning brief saved today, so there is nothing to compare against. Run morning_brief first."
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+
+	cur := s.collectBriefData(now)
+	loc := now.Location()
+	if feed, err := s.loadDeadlineFeed(); err == nil {
+		loc = feed.Location()
+	}
+	m := diffBriefs(morning, cur, deadlines.LoadPolicy().Window, loc)
+
+	if m.Changes() == 0 {
+		msg := fmt.Sprintf("✅ Nothing material changed since the morning brief (%s). Silent OK.", m.Since.In(now.Location()).Format("15:04"))
+		return &tools.ToolResult{ForLLM: msg, Silent: true}
+	}
+
+	markdown, err := s.renderBrief("midday_update", m)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to render midday update template: %v", err))
+	}
+	s.saveBrief(markdown, "midday-update", now)
+
+	format, _ := args["format"].(string)
+	output, err := render.Render(markdown, format)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	return &tools.ToolResult{ForLLM: output, ForUser: output}
+}
+
+// diffBriefs compares the morning's data with the current data.
+func diffBriefs(morning, cur *BriefData, window time.Duration, loc *time.Location) *MiddayData {
+	m := &MiddayData{Date: cur.Date, Since: morning.Date, User: cur.User}
+
+	// A section that failed to load on either side is not compared, or
+	// everything in it would look added or removed.
+	failed := func(section string) bool {
+		return morning.Errors[section] != "" || cur.Errors[section] != ""
+	}
+
+	if !failed("tasks") {
+		diffTasks(m, morning, cur)
+	}
+	if !failed("events") {
+		diffEvents(m, morning, cur)
+	}
+	diffDeadlines(m, morning, cur, window, loc)
+
+	// News
+	seen := map[string]bool{}
+	for _, r := range morning.News {
+		seen[recordKey(r)] = true
+	}
+	for i, r := range cur.News {
+		if i >= middayTopStories {
+			break
+		}
+		if !seen[recordKey(r)] {
+			m.NewStories = append(m.NewStories, r)
+		}
+	}
+	return m
+}
+
+func diffTasks(m *MiddayData, morning, cur *BriefData) {
+	wasOpen := taskIndex(morning.Tasks)
+	wasDone := taskIndex(morning.Completed)
+	isOpen := taskIndex(cur.Tasks)
+	isDone := taskIndex(cur.Completed)
+	for _, t := range cur.Completed {
+		if _, ok := wasDone[t.UID]; !ok {
+			m.Completed = append(m.Completed, t)
+		}
+	}
+	for _, t := range cur.Tasks {
+		old, open := wasOpen[t.UID]
+		_, done := wasDone[t.UID]
+		switch {
+		case !open && !done:
+			m.Added = append(m.Added, t)
+		case open && old.Due != t.Due:
+			m.Rescheduled = append(m.Rescheduled, TaskChange{Task: t, WasDue: old.Due})
+		}
+	}
+	for _, t := range morning.Tasks {
+		_, open := isOpen[t.UID]
+		_, done := isDone[t.UID]
+		if !open && !done {
+			m.Dropped = append(m.Dropped, t)
+		}
+	}
+}
+
+func diffEvents(m *MiddayData, morning, cur *BriefData) {
+	before := eventIndex(morning.Events)
+	after := eventIndex(cur.Events)
+	for _, e := range cur.Events {
+		old, ok := before[eventKey(e)]
+		switch {
+		case !ok:
+			m.NewEvents = append(m.NewEvents, e)
+		case !old.Start.Equal(e.Start) || !old.End.Equal(e.End):
+			m.MovedEvents = append(m.MovedEvents, EventChange{Event: e, WasStart: old.Start, WasEnd: old.End})
+		}
+	}
+	for _, e := range morning.Events {
+		if _, ok := after[eventKey(e)]; !ok {
+			m.CancelledEvents = append(m.CancelledEvents, e)
+		}
+	}
+}
+
+func diffDeadlines(m *MiddayData, morning, cur *BriefData, window time.Duration, loc *time.Location) {
+	tz := loc.String()
+	urgentBefore := deadlineIndex((&deadlines.Feed{Timezone: tz, Deadlines: morning.Upcoming}).Urgent(morning.Date, window))
+	for _, d := range (&deadlines.Feed{Timezone: tz, Deadlines: cur.Upcoming}).Urgent(cur.Date, window) {
+		if _, ok := urgentBefore[d.UID]; !ok {
+			m.NewUrgent = append(m.NewUrgent, d)
+		}
+	}
+	upcomingBefore := deadlineIndex(morning.Upcoming)
+	upcomingNow := deadlineIndex(cur.Upcoming)
+	for _, d := range cur.Upcoming {
+		if old, ok := upcomingBefore[d.UID]; ok && !old.Due.Equal(d.Due) {
+			m.MovedDeadlines = append(m.MovedDeadlines, DeadlineChange{Deadline: d, WasDue: old.Due})
+		}
+	}
+	if cur.Upcoming == nil {
+		return // feed unreadable now; nothing was resolved
+	}
+	for _, d := range morning.Upcoming {
+		if _, ok := upcomingNow[d.UID]; !ok {
+			m.Resolved = append(m.Resolved, d)
+		}
+	}
+}
+
+func taskIndex(tasks []Task) map[string]Task {
+	idx := make(map[string]Task, len(tasks))
+	for _, t := range tasks {
+		idx[t.UID] = t
+	}
+	return idx
+}
+
+// eventKey identifies an event across syncs; events without a UID fall
+// back to their title.
+func eventKey(e Event) string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return "summary:" + e.Summary
+}
+
+func eventIndex(events []Event) map[string]Event {
+	idx := make(map[string]Event, len(events))
+	for _, e := range events {
+		if _, dup := idx[eventKey(e)]; !dup {
+			idx[eventKey(e)] = e
+		}
+	}
+	return idx
+}
+
+func deadlineIndex(list []deadlines.Deadline) map[string]deadlines.Deadline {
+	idx := make(map[string]deadlines.Deadline, len(list))
+	for _, d := range list {
+		idx[d.UID] = d
+	}
+	return idx
+}
+
+func recordKey(r skills.Record) string {
+	if r.URL != "" {
+		return r.URL
+	}
+	return r.Title
+}
@@ -0,0 +1,123 @@
+package chief
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+)
+
+func TestMiddayDiff(t *testing.T) {
+	morningAt := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	noonAt := morningAt.Add(5 * time.Hour)
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 2, h, m, 0, 0, time.UTC) }
+	story := func(url, title string) skills.Record {
+		return skills.DecodeRecord(skills.EncodeRecord("news", url, title, "world", "20260302"))
+	}
+
+	morning := &BriefData{
+		Date:  morningAt,
+		Tasks: []Task{{UID: "t1", Summary: "Write report"}, {UID: "t2", Summary: "Call bank"}, {UID: "t3", Summary: "Gym"}},
+		Events: []Event{
+			{UID: "e1", Summary: "Standup", Start: at(9, 0), End: at(9, 15)},
+			{UID: "e2", Summary: "Dentist", Start: at(15, 0)},
+		},
+		Upcoming: []deadlines.Deadline{
+			{UID: "d1", Summary: "Tax return", Due: at(14, 0)},
+			{UID: "d2", Summary: "Visa form", Due: at(23, 0)},
+		},
+		News: []skills.Record{story("https://a.example", "Old story")},
+	}
+	cur := &BriefData{
+		Date:      noonAt,
+		Tasks:     []Task{{UID: "t2", Summary: "Call bank", Due: "2026-03-03"}, {UID: "t4", Summary: "Buy milk"}},
+		Completed: []Task{{UID: "t1", Summary: "Write report", Status: "COMPLETED"}},
+		Events: []Event{
+			{UID: "e1", Summary: "Standup", Start: at(9, 0), End: at(9, 15)},
+			{UID: "e3", Summary: "Lunch with Sam", Start: at(13, 30)},
+		},
+		Upcoming: []deadlines.Deadline{{UID: "d2", Summary: "Visa form", Due: at(14, 30)}},
+		News:     []skills.Record{story("https://b.example", "Breaking"), story("https://a.example", "Old story")},
+	}
+
+	m := diffBriefs(morning, cur, 2*time.Hour, time.UTC)
+	check := func(name string, got, want int) {
+		if got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	check("completed", len(m.Completed), 1)
+	check("added", len(m.Added), 1)
+	check("dropped", len(m.Dropped), 1)
+	check("rescheduled", len(m.Rescheduled), 1)
+	check("new events", len(m.NewEvents), 1)
+	check("cancelled events", len(m.CancelledEvents), 1)
+	check("moved events", len(m.MovedEvents), 0)
+	check("new urgent", len(m.NewUrgent), 1)
+	check("resolved", len(m.Resolved), 1)
+	check("moved deadlines", len(m.MovedDeadlines), 1)
+	check("new stories", len(m.NewStories), 1)
+
+	s := &ChiefSkill{workspace: t.TempDir()}
+	out, err := s.renderBrief("midday_update", m)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, want := range []string{
+		"# ☀️ Midday Update — Monday, March 2 (since 08:00)",
+		"- ✅ Write report",
+		"- 🆕 Buy milk",
+		"- 🔁 Call bank: due unset → 2026-03-03",
+		"- ↪️ Gym moved off today",
+		"- 🆕 13:30 Lunch with Sam",
+		"- ❌ 15:00 Dentist",
+		"- ⚠️ Visa form — due Mon 14:30",
+		"- ✅ Tax return",
+		"- [Breaking](https://b.example)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if n := diffBriefs(cur, cur, 2*time.Hour, time.UTC).Changes(); n != 0 {
+		t.Errorf("unchanged data reported %d changes", n)
+	}
+
+	// A section that failed to load now is not reported as removed.
+	broken := &BriefData{Date: noonAt, Errors: map[string]string{"tasks": "tasks.xml not found", "events": "events.xml not found"}}
+	if m := diffBriefs(morning, broken, 2*time.Hour, time.UTC); len(m.Dropped)+len(m.CancelledEvents)+len(m.Resolved) != 0 {
+		t.Errorf("load errors reported as changes: %+v", m)
+	}
+}
+
+func TestLoadEventsExpandsOccurrences(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "atc", "memory"), 0755)
+	os.WriteFile(filepath.Join(root, "atc", "memory", "events.xml"), []byte(`<icalendar><vcalendar><components>
+<vevent><properties><uid><text>standup</text></uid><summary><text>Standup</text></summary>
+  <dtstart><date-time>2026-03-02T07:00:00Z</date-time></dtstart><dtend><date-time>2026-03-02T07:30:00Z</date-time></dtend>
+  <rrule><text>FREQ=DAILY</text></rrule></properties></vevent>
+<vevent><properties><uid><text>trip</text></uid><summary><text>Trip</text></summary>
+  <dtstart><date>2026-03-02</date></dtstart><dtend><date>2026-03-05</date></dtend></properties></vevent>
+<vevent><properties><uid><text>gone</text></uid><summary><text>Gone</text></summary><status><text>CANCELLED</text></status>
+  <dtstart><date-time>2026-03-04T10:00:00Z</date-time></dtstart></properties></vevent>
+</components></vcalendar></icalendar>`), 0644)
+
+	s := &ChiefSkill{workspace: filepath.Join(root, "chief")}
+	events, err := s.loadEvents(time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Start.Format("02 15:04")+" "+e.Summary)
+	}
+	if want := "02 00:00 Trip|04 07:00 Standup"; strings.Join(got, "|") != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
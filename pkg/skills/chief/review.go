@@ -0,0 +1,493 @@
+package chief
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+)
+
+// ----------------------------------------------------------------------------
+// WEEKLY / MONTHLY REVIEW
+//
+// Each morning_brief / evening_review also stores a small JSON snapshot in
+// memory/snapshots/YYYY-MM-DD.json. Reviews aggregate those snapshots (the
+// RFC news/paper caches expire within a day, tasks.xml only holds the
+// current state) and compare the period with the one before it.
+// ----------------------------------------------------------------------------
+
+// daySnapshot is what Chief remembers about one day.
+type daySnapshot struct {
+	Date       string         `json:"date"`
+	Completed  []string       `json:"completed"`   // tasks completed that day
+	RolledOver int            `json:"rolled_over"` // open "today" tasks at evening review time
+	Evening    bool           `json:"evening"`     // true once an evening review ran
+	Habits     map[string]int `json:"habits"`      // habit → streak
+	Deadlines  []string       `json:"deadlines"`
+	News       []string       `json:"news"`   // titles
+	Papers     []string       `json:"papers"` // titles
+}
+
+// ReviewData is the root object passed to weekly_review and monthly_review templates.
+type ReviewData struct {
+	Kind string // "weekly" or "monthly"
+	From time.Time
+	To   time.Time
+	User string
+
+	Completed      []string // unique task summaries completed in the period
+	Hit            []Task   // tasks and recurring occurrences due in the period, completed on time
+	Missed         []Task   // tasks due in the period, completed late or past due and still open
+	Habits         []HabitChange
+	NewsByCategory []CategoryCount // from Monitor's database when available
+	Papers         []string        // unique paper titles saved in the period
+	Journal        []JournalEntry  // journal entries in the period, oldest first
+	Wins           []string        // every win journalled in the period
+	Blockers       []string        // unique blockers journalled in the period
+
+	Totals   ReviewTotals
+	Previous ReviewTotals // the same totals for the preceding period
+}
+
+// ReviewTotals are the counters compared between periods.
+type ReviewTotals struct {
+	ActiveDays  int // days with at least one brief
+	Completed   int // completions, each occurrence of a recurring task counted
+	RolledOver  int
+	Hit         int
+	Missed      int
+	News        int
+	Papers      int
+	JournalDays int     // days with a journal entry
+	Mood        float64 // average mood score, 0 without entries
+}
+
+// HabitChange is a streak at the start and end of the period.
+type HabitChange struct {
+	Name  string
+	Start int
+	End   int
+	Delta int
+}
+
+// CategoryCount is the number of news items saved in one category.
+type CategoryCount struct {
+	Category string
+	Count    int
+}
+
+// saveSnapshot records today's brief data for later reviews. Morning and
+// evening runs merge into the same file; the latest task state wins.
+func (s *ChiefSkill) saveSnapshot(d *BriefData, evening bool) {
+	if s.workspace == "" {
+		return
+	}
+	dir := filepath.Join(s.workspace, "memory", "snapshots")
+	os.MkdirAll(dir, 0755)
+	path := filepath.Join(dir, d.Date.Format("2006-01-02")+".json")
+
+	snap := daySnapshot{Date: d.Date.Format("2006-01-02")}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &snap)
+	}
+
+	// Only tasks finished that day; tasks.xml keeps completed tasks around
+	// until they are purged, so STATUS alone says nothing about when.
+	day := time.Date(d.Date.Year(), d.Date.Month(), d.Date.Day(), 0, 0, 0, 0, d.Date.Location())
+	snap.Completed = snap.Completed[:0]
+	for _, t := range d.Completed {
+		if completedIn(t, day, day) {
+			snap.Completed = append(snap.Completed, t.Summary)
+		}
+	}
+	if evening {
+		snap.Evening = true
+		snap.RolledOver = len(d.Tasks)
+	}
+	if len(d.Habits) > 0 {
+		snap.Habits = make(map[string]int, len(d.Habits))
+		for _, h := range d.Habits {
+			snap.Habits[h.Name] = h.Streak
+		}
+	}
+	if len(d.Deadlines) > 0 {
+		snap.Deadlines = d.Deadlines
+	}
+	for _, r := range d.News {
+		snap.News = appendUnique(snap.News, r.Title)
+	}
+	for _, r := range d.Papers {
+		snap.Papers = appendUnique(snap.Papers, r.Title)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// collectReviewData aggregates the period ending on `end` (inclusive).
+func (s *ChiefSkill) collectReviewData(kind string, end time.Time) *ReviewData {
+	days := 7
+	if kind == "monthly" {
+		days = 30
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())
+	from := end.AddDate(0, 0, -(days - 1))
+
+	r := &ReviewData{Kind: kind, From: from, To: end}
+	if s.user != nil {
+		r.User = s.user.Name
+	}
+
+	tasks, _ := s.loadTasks()
+	now := s.now()
+
+	cur := s.aggregate(from, end, tasks, now)
+	r.Completed = cur.completed
+	r.Hit = cur.hit
+	r.Missed = cur.missed
+	r.Habits = cur.habits
+	r.Papers = cur.papers
+	r.NewsByCategory = s.newsByCategory(from, end.AddDate(0, 0, 1))
+	r.Totals = cur.totals(r.NewsByCategory)
+	r.Journal = s.journalEntries(from, end)
+	for _, e := range r.Journal {
+		r.Wins = append(r.Wins, e.Wins...)
+		for _, b := range e.Blockers {
+			r.Blockers = appendUnique(r.Blockers, b)
+		}
+	}
+	r.Totals.JournalDays = len(r.Journal)
+	r.Totals.Mood, _ = averageMood(r.Journal)
+
+	prevEnd := from.AddDate(0, 0, -1)
+	prevFrom := prevEnd.AddDate(0, 0, -(days - 1))
+	prev := s.aggregate(prevFrom, prevEnd, tasks, now)
+	r.Previous = prev.totals(s.newsByCategory(prevFrom, from))
+	prevJournal := s.journalEntries(prevFrom, prevEnd)
+	r.Previous.JournalDays = len(prevJournal)
+	r.Previous.Mood, _ = averageMood(prevJournal)
+	return r
+}
+
+// periodAgg is the raw aggregation of one period.
+type periodAgg struct {
+	activeDays int
+	rolledOver int
+	completed  []string
+	doneCount  int // completions, counting each occurrence of a recurring task
+	news       []string
+	papers     []string
+	hit        []Task
+	missed     []Task
+	habits     []HabitChange
+}
+
+// totals prefers Monitor's database for news counts; snapshot titles are
+// only a lower bound because the RFC cache is capped.
+func (p *periodAgg) totals(news []CategoryCount) ReviewTotals {
+	t := ReviewTotals{
+		ActiveDays: p.activeDays,
+		Completed:  p.doneCount,
+		RolledOver: p.rolledOver,
+		Hit:        len(p.hit),
+		Missed:     len(p.missed),
+		News:       len(p.news),
+		Papers:     len(p.papers),
+	}
+	if len(news) > 0 {
+		t.News = 0
+		for _, c := range news {
+			t.News += c.Count
+		}
+	}
+	return t
+}
+
+// aggregate walks the snapshots between from and to (inclusive).
+//
+// Completions come from the completed events in ATC's stats.db, which
+// also log each occurrence of a recurring task. Periods stats.db has no
+// completions for fall back to the COMPLETED date of tasks still in
+// tasks.xml; snapshot and evening-review lists then only add tasks that
+// have since left tasks.xml, since older ones listed every completed task
+// on every day.
+func (s *ChiefSkill) aggregate(from, to time.Time, tasks []Task, now time.Time) *periodAgg {
+	p := &periodAgg{}
+	first := map[string]int{}
+	last := map[string]int{}
+
+	done, occurrences := s.statsCompletions(from, to)
+	for _, summary := range done {
+		p.completed = appendUnique(p.completed, summary)
+	}
+	p.doneCount = len(done)
+
+	known := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		known[strings.TrimSpace(t.Summary)] = true
+		if len(done) == 0 && completedIn(t, from, to) {
+			p.completed = appendUnique(p.completed, t.Summary)
+		}
+	}
+	addCompleted := func(summaries []string) {
+		if len(done) > 0 {
+			return
+		}
+		for _, c := range summaries {
+			if !known[strings.TrimSpace(c)] {
+				p.completed = appendUnique(p.completed, c)
+			}
+		}
+	}
+
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		snap, ok := s.loadSnapshot(d)
+		if !ok {
+			// Days before snapshots existed: fall back to the saved evening review.
+			done := s.completedFromReview(d)
+			if len(done) > 0 || s.briefExists(d) {
+				p.activeDays++
+			}
+			addCompleted(done)
+			continue
+		}
+		p.activeDays++
+		p.rolledOver += snap.RolledOver
+		addCompleted(snap.Completed)
+		for _, n := range snap.News {
+			p.news = appendUnique(p.news, n)
+		}
+		for _, t := range snap.Papers {
+			p.papers = appendUnique(p.papers, t)
+		}
+		for name, streak := range snap.Habits {
+			if _, seen := first[name]; !seen {
+				first[name] = streak
+			}
+			last[name] = streak
+		}
+	}
+
+	if len(done) == 0 {
+		p.doneCount = len(p.completed)
+	}
+
+	periodEnd := to.AddDate(0, 0, 1)
+	for _, t := range tasks {
+		due, ok := parseDue(t.Due, now.Location())
+		if !ok || due.Before(from) || !due.Before(periodEnd) {
+			continue
+		}
+		if strings.EqualFold(t.Status, "completed") {
+			if completedOnTime(t, due, now.Location()) {
+				p.hit = append(p.hit, t)
+			} else {
+				p.missed = append(p.missed, t)
+			}
+		} else if due.Before(now) && !strings.EqualFold(t.Status, "cancelled") {
+			p.missed = append(p.missed, t)
+		}
+	}
+	// Recurring tasks move on to their next due date when completed, so
+	// their past occurrences are only in stats.db.
+	for _, o := range occurrences {
+		if o.onTime {
+			p.hit = append(p.hit, o.task)
+		} else {
+			p.missed = append(p.missed, o.task)
+		}
+	}
+
+	names := make([]string, 0, len(first))
+	for name := range first {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.habits = append(p.habits, HabitChange{Name: name, Start: first[name], End: last[name], Delta: last[name] - first[name]})
+	}
+	return p
+}
+
+func (s *ChiefSkill) loadSnapshot(day time.Time) (daySnapshot, bool) {
+	var snap daySnapshot
+	path := filepath.Join(s.workspace, "memory", "snapshots", day.Format("2006-01-02")+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, false
+	}
+	return snap, json.Unmarshal(data, &snap) == nil
+}
+
+func (s *ChiefSkill) briefExists(day time.Time) bool {
+	_, err := os.Stat(filepath.Join(s.workspace, "memory", "morning-brief-"+day.Format("2006-01-02")+".md"))
+	return err == nil
+}
+
+// completedFromReview reads "✅" bullets from a saved evening review.
+func (s *ChiefSkill) completedFromReview(day time.Time) []string {
+	data, err := os.ReadFile(filepath.Join(s.workspace, "memory", "evening-review-"+day.Format("2006-01-02")+".md"))
+	if err != nil {
+		return nil
+	}
+	var done []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "- ✅ ") {
+			done = append(done, strings.TrimSpace(strings.TrimPrefix(line, "- ✅ ")))
+		}
+	}
+	return done
+}
+
+// newsByCategory counts items Monitor ingested in [from, to).
+func (s *ChiefSkill) newsByCategory(from, to time.Time) []CategoryCount {
+	dbPath := filepath.Join(s.workspace, "..", "monitor", "monitor.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT COALESCE(category, ''), COUNT(*) FROM items WHERE ingested_at >= ? AND ingested_at < ? GROUP BY category ORDER BY COUNT(*) DESC, category",
+		from.Unix(), to.Unix())
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var counts []CategoryCount
+	for rows.Next() {
+		var c CategoryCount
+		if rows.Scan(&c.Category, &c.Count) == nil {
+			counts = append(counts, c)
+		}
+	}
+	return counts
+}
+
+// completedOnTime reports whether a completed task was finished by its due
+// time, or by the end of its due day for date-only deadlines. Tasks with no
+// COMPLETED date count as on time.
+func completedOnTime(t Task, due time.Time, loc *time.Location) bool {
+	done, ok := parseDue(t.Completed, loc)
+	if !ok {
+		return true
+	}
+	if len(t.Due) == len("2006-01-02") {
+		return done.Format("2006-01-02") <= t.Due
+	}
+	return !done.After(due)
+}
+
+// occurrence is one completed occurrence of a recurring task.
+type occurrence struct {
+	task   Task // Due is the occurrence's due date
+	onTime bool
+}
+
+// statsCompletions reads ATC's stats.db: the summary of every completion
+// logged in [from, to], and the recurring occurrences due in that period.
+// Both are empty when the database is missing.
+func (s *ChiefSkill) statsCompletions(from, to time.Time) ([]string, []occurrence) {
+	dbPath := filepath.Join(s.workspace, "..", "atc", "memory", "stats.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		return nil, nil
+	}
+	defer db.Close()
+
+	var done []string
+	rows, err := db.Query("SELECT COALESCE(summary, '') FROM task_events WHERE event = 'completed' AND day >= ? AND day <= ? ORDER BY id",
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, nil
+	}
+	for rows.Next() {
+		var summary string
+		if rows.Scan(&summary) == nil {
+			done = append(done, summary)
+		}
+	}
+	rows.Close()
+
+	var occ []occurrence
+	rows, err = db.Query("SELECT uid, COALESCE(summary, ''), due, on_time FROM task_occurrences ORDER BY due")
+	if err != nil {
+		return done, nil
+	}
+	defer rows.Close()
+	periodEnd := to.AddDate(0, 0, 1)
+	for rows.Next() {
+		var o occurrence
+		if rows.Scan(&o.task.UID, &o.task.Summary, &o.task.Due, &o.onTime) != nil {
+			continue
+		}
+		due, ok := parseDue(o.task.Due, from.Location())
+		if !ok || due.Before(from) || !due.Before(periodEnd) {
+			continue
+		}
+		o.task.Status = "COMPLETED"
+		occ = append(occ, o)
+	}
+	return done, occ
+}
+
+// completedIn reports whether t was completed on a day in [from, to].
+// Tasks without a COMPLETED date are never counted.
+func completedIn(t Task, from, to time.Time) bool {
+	if !strings.EqualFold(t.Status, "completed") {
+		return false
+	}
+	done, ok := parseDue(t.Completed, from.Location())
+	return ok && !done.Before(from) && done.Before(to.AddDate(0, 0, 1))
+}
+
+// parseDue accepts xCal date-time (RFC 3339) and date values.
+func parseDue(s string, loc *time.Location) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("20060102T150405Z", s, time.UTC); err == nil {
+		return t.In(loc), true
+	}
+	if t, err := time.ParseInLocation("20060102", s, loc); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func appendUnique(list []string, v string) []string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return list
+	}
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
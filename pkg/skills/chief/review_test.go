@@ -0,0 +1,170 @@
+package chief
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/atc"
+)
+
+func writeSnapshot(t *testing.T, ws string, snap daySnapshot) {
+	t.Helper()
+	dir := filepath.Join(ws, "memory", "snapshots")
+	os.MkdirAll(dir, 0755)
+	data, _ := json.Marshal(snap)
+	if err := os.WriteFile(filepath.Join(dir, snap.Date+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWeeklyReviewAggregatesSnapshots(t *testing.T) {
+	root := t.TempDir()
+	ws := filepath.Join(root, "chief")
+	os.MkdirAll(filepath.Join(root, "atc", "memory"), 0755)
+
+	// Previous week: one completed task.
+	writeSnapshot(t, ws, daySnapshot{Date: "2026-02-20", Completed: []string{"Old task"}, RolledOver: 4, Evening: true})
+
+	// This week (Feb 24 – Mar 2).
+	writeSnapshot(t, ws, daySnapshot{
+		Date: "2026-02-24", Completed: []string{"Write report"}, RolledOver: 2, Evening: true,
+		Habits: map[string]int{"IELTS": 3}, Papers: []string{"Paper A"},
+	})
+	// "Done long ago" stands in for an older snapshot that listed every
+	// completed task; its COMPLETED date in tasks.xml keeps it out.
+	writeSnapshot(t, ws, daySnapshot{
+		Date: "2026-03-01", Completed: []string{"Write report", "Ship release", "Done long ago"}, RolledOver: 1, Evening: true,
+		Habits: map[string]int{"IELTS": 8}, Papers: []string{"Paper A", "Paper B"},
+	})
+
+	tasks := `<?xml version="1.0" encoding="utf-8"?>
+<icalendar><vcalendar><components>
+<vtodo><properties><summary><text>Ship release</text></summary><status><text>COMPLETED</text></status><due><date-time>2026-02-27T12:00:00Z</date-time></due><completed><date-time>2026-02-26T16:00:00Z</date-time></completed></properties></vtodo>
+<vtodo><properties><summary><text>Done long ago</text></summary><status><text>COMPLETED</text></status><completed><date-time>2025-11-03T09:00:00Z</date-time></completed></properties></vtodo>
+<vtodo><properties><summary><text>File taxes</text></summary><status><text>NEEDS-ACTION</text></status><due><date>2026-02-26</date></due></properties></vtodo>
+<vtodo><properties><summary><text>Next month</text></summary><status><text>NEEDS-ACTION</text></status><due><date>2026-04-01</date></due></properties></vtodo>
+</components></vcalendar></icalendar>`
+	os.WriteFile(filepath.Join(root, "atc", "memory", "tasks.xml"), []byte(tasks), 0644)
+
+	s := &ChiefSkill{workspace: ws}
+	r := s.collectReviewData("weekly", time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC))
+
+	if r.Totals.Completed != 2 || r.Previous.Completed != 1 {
+		t.Errorf("completed = %d (prev %d), want 2 (prev 1)", r.Totals.Completed, r.Previous.Completed)
+	}
+	if r.Totals.RolledOver != 3 || r.Previous.RolledOver != 4 {
+		t.Errorf("rolled over = %d (prev %d), want 3 (prev 4)", r.Totals.RolledOver, r.Previous.RolledOver)
+	}
+	if r.Totals.Hit != 1 || r.Totals.Missed != 1 || r.Missed[0].Summary != "File taxes" {
+		t.Errorf("deadlines hit=%d missed=%v", r.Totals.Hit, r.Missed)
+	}
+	if len(r.Habits) != 1 || r.Habits[0].Delta != 5 {
+		t.Errorf("habits = %+v", r.Habits)
+	}
+	if r.Totals.Papers != 2 {
+		t.Errorf("papers = %d, want 2", r.Totals.Papers)
+	}
+
+	out, err := s.renderBrief("weekly_review", r)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, want := range []string{
+		"# 📆 Weekly Review — Feb 24 – Mar 2, 2026",
+		"- Tasks completed: 2 (↑ 1)",
+		"- Tasks carried over: 3 (↓ 1)",
+		"- ❌ File taxes (due 2026-02-26)",
+		"- IELTS: 3 → 8 days (+5)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestSnapshotMergesMorningAndEvening(t *testing.T) {
+	ws := t.TempDir()
+	s := &ChiefSkill{workspace: ws}
+	day := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	s.saveSnapshot(&BriefData{Date: day, Tasks: []Task{{Summary: "a"}, {Summary: "b"}}}, false)
+	s.saveSnapshot(&BriefData{Date: day, Tasks: []Task{{Summary: "b"}}, Completed: []Task{
+		{Summary: "a", Status: "COMPLETED", Completed: "2026-03-02T07:30:00Z"},
+		{Summary: "old", Status: "COMPLETED", Completed: "2026-01-15T07:30:00Z"},
+		{Summary: "undated", Status: "COMPLETED"},
+	}}, true)
+
+	snap, ok := s.loadSnapshot(day)
+	if !ok {
+		t.Fatal("snapshot not written")
+	}
+	if !snap.Evening || snap.RolledOver != 1 || len(snap.Completed) != 1 || snap.Completed[0] != "a" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+func TestReviewForPastDateKeepsItsOwnFile(t *testing.T) {
+	ws := filepath.Join(t.TempDir(), "chief")
+	s := &ChiefSkill{workspace: ws}
+
+	res := s.Execute(context.Background(), map[string]interface{}{"command": "weekly_review", "date": "2026-02-22"})
+	if res.IsError {
+		t.Fatalf("weekly_review: %s", res.ForLLM)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "memory", "weekly-review-2026-02-22.md")); err != nil {
+		t.Errorf("review not saved under its end date: %v", err)
+	}
+	today := filepath.Join(ws, "memory", "weekly-review-"+time.Now().Format("2006-01-02")+".md")
+	if _, err := os.Stat(today); err == nil {
+		t.Errorf("past review overwrote today's file %s", today)
+	}
+}
+
+func TestReviewCountsRecurringCompletionsFromStats(t *testing.T) {
+	root := t.TempDir()
+	ws := filepath.Join(root, "chief")
+	st, err := atc.OpenStats(filepath.Join(root, "atc", "memory", atc.StatsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meds := atc.VTodoProperties{Uid: "meds", Summary: "Take meds"}
+	for day, late := range map[int]bool{24: false, 25: false, 26: true} {
+		due := time.Date(2026, 2, day, 9, 0, 0, 0, time.UTC)
+		at := due.Add(-time.Hour)
+		if late {
+			at = due.Add(3 * time.Hour)
+		}
+		if err := st.RecordOccurrence(meds, due, false, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := atc.VTodoProperties{Uid: "report", Summary: "Write report"}
+	if err := st.Record(report, atc.EventCompleted, time.Date(2026, 2, 27, 18, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	// Completing an occurrence leaves the recurring task open in tasks.xml;
+	// the report was due on the 26th and finished a day late.
+	tasks := `<?xml version="1.0" encoding="utf-8"?>
+<icalendar><vcalendar><components>
+<vtodo><properties><uid><text>meds</text></uid><summary><text>Take meds</text></summary><status><text>NEEDS-ACTION</text></status><due><date-time>2026-03-05T09:00:00Z</date-time></due></properties></vtodo>
+<vtodo><properties><uid><text>report</text></uid><summary><text>Write report</text></summary><status><text>COMPLETED</text></status><due><date>2026-02-26</date></due><completed><date-time>2026-02-27T18:00:00Z</date-time></completed></properties></vtodo>
+</components></vcalendar></icalendar>`
+	os.WriteFile(filepath.Join(root, "atc", "memory", "tasks.xml"), []byte(tasks), 0644)
+
+	s := &ChiefSkill{workspace: ws}
+	r := s.collectReviewData("weekly", time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC))
+
+	if r.Totals.Completed != 4 || len(r.Completed) != 2 {
+		t.Errorf("completed = %d %v, want 4 completions of 2 tasks", r.Totals.Completed, r.Completed)
+	}
+	if r.Totals.Hit != 2 || r.Totals.Missed != 2 {
+		t.Errorf("hit = %v, missed = %v; want 2 on-time occurrences, 1 late occurrence and the late report", r.Hit, r.Missed)
+	}
+}
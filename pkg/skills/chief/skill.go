@@ -2,19 +2,27 @@ package chief
 
 import (
 	"context"
-	"encoding/xml"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/render"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/jony/son-of-anthon/pkg/skills/health"
+	"github.com/jony/son-of-anthon/pkg/skills/subagent"
+	"github.com/jony/son-of-anthon/pkg/users"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
 type ChiefSkill struct {
-	workspace string
+	workspace     string
+	user          *users.Profile // nil in single-user mode
+	subagents     *subagent.SubagentManager
+	originChannel string
+	originChatID  string
 }
 
 func NewSkill() *ChiefSkill {
@@ -29,11 +37,20 @@ func (s *ChiefSkill) Description() string {
 	return `Chief of Staff - Strategic orchestrator who aggregates all agent outputs into briefings.
 
 Commands:
-- morning_brief: Compile today's tasks (ATC), news (Monitor), research (Research), deadlines (Architect) into a single morning brief and save it.
-- evening_review: Compile completed tasks (ATC), learning (Coach), productivity stats, and tomorrow's prep into an evening review.
-- urgent_deadlines: Check deadlines-today.md for items due within 2 hours and return alert or silent OK.
-- delegate: Route a task to the appropriate specialist agent (returns guidance for subagent tool).
-- status: Show which agent workspaces are active.`
+- morning_brief: Compile today's tasks (ATC), news (Monitor), research (Research), deadlines (Architect) into a single morning brief and save it. Optional format: markdown (default), telegram, html, text.
+- midday_update: Compare now with this morning's brief and report only what changed: tasks completed, added or moved off today, calendar events added, cancelled or moved, newly urgent, resolved or moved deadlines, and new top stories. Stays silent when nothing material changed. Optional format as for morning_brief.
+- evening_review: Compile completed tasks (ATC), learning (Coach), productivity stats, and tomorrow's prep into an evening review, ending with reflection prompts (tools.journal.prompts). Optional format as for morning_brief.
+- journal: Save the user's answer to the reflection prompts as today's journal entry. Pass reply (their message verbatim) and/or mood (1-5), wins, blockers (';'-separated) and text; date picks another day. Without any of these, lists the last 7 days of entries. Entries feed weekly and monthly reviews and every agent's MEMORY.md.
+- weekly_review: Retrospective of the last 7 days — completed tasks, carry-overs, deadlines hit/missed, habit streak changes, news and papers saved, journal mood, wins and blockers — with trends vs the week before. Optional date (YYYY-MM-DD) ends the period.
+- monthly_review: Same retrospective over the last 30 days.
+- urgent_deadlines: Check Architect's deadlines.json for items overdue or due within the alert window (tools.deadlines.alert_window_minutes, default 120; override with window_minutes) and return alert or silent OK. Only new alerts and escalations (tools.deadlines.escalate_minutes, default 60 and 15 minutes before due; overdue items repeat every repeat_overdue_minutes, default 1440) are returned, each tagged with a short [id].
+- ack: Acknowledge an alert (ref = id or part of the title); no more alerts for that occurrence.
+- snooze: Silence an alert for a while (ref, duration e.g. 30m, 2h, 1d).
+- mute: Never alert about this task again, including future occurrences (ref).
+- unmute: Undo mute (ref).
+- alerts: List announced, acknowledged, snoozed and muted alerts.
+- delegate: Run a task on the best specialist agent as a subagent and return its result. The agent is picked by scoring the task against each agent's role and TOOLS.md (confidence reported; unclear tasks fall back to atc) unless agent is given.
+- status: System health — per-skill last success and last error, cache freshness vs TTL (news, research, deadlines, events.xml), Nextcloud and feed reachability, database sizes and pending subagent tasks. offline=true skips the network probes.`
 }
 
 func (s *ChiefSkill) Parameters() map[string]interface{} {
@@ -43,7 +60,52 @@ func (s *ChiefSkill) Parameters() map[string]interface{} {
 			"command": map[string]interface{}{
 				"type":        "string",
 				"description": "Command to execute",
-				"enum":        []string{"morning_brief", "evening_review", "urgent_deadlines", "delegate", "status"},
+				"enum":        []string{"morning_brief", "midday_update", "evening_review", "weekly_review", "monthly_review", "urgent_deadlines", "ack", "snooze", "mute", "unmute", "alerts", "journal", "delegate", "status"},
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "Output format for briefs and reviews (default markdown). telegram = escaped MarkdownV2.",
+				"enum":        []string{render.FormatMarkdown, render.FormatTelegram, render.FormatHTML, render.FormatText},
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "Last day of the review period, YYYY-MM-DD (weekly_review / monthly_review; default today), or the day a journal entry is for",
+			},
+			"window_minutes": map[string]interface{}{
+				"type":        "number",
+				"description": "Alert window in minutes for urgent_deadlines (default from config, 120)",
+			},
+			"ref": map[string]interface{}{
+				"type":        "string",
+				"description": "Alert id shown in brackets, full UID, or part of the title (ack / snooze / mute / unmute)",
+			},
+			"duration": map[string]interface{}{
+				"type":        "string",
+				"description": "Snooze length, e.g. 30m, 2h, 1d (snooze; default 1h)",
+			},
+			"offline": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Skip Nextcloud and feed reachability checks (status)",
+			},
+			"reply": map[string]interface{}{
+				"type":        "string",
+				"description": "The user's reply to the reflection prompts, verbatim (journal)",
+			},
+			"mood": map[string]interface{}{
+				"type":        "number",
+				"description": "Mood score 1 (rough) to 5 (great) (journal)",
+			},
+			"wins": map[string]interface{}{
+				"type":        "string",
+				"description": "What went well, items separated by ';' (journal)",
+			},
+			"blockers": map[string]interface{}{
+				"type":        "string",
+				"description": "What got in the way, items separated by ';' (journal)",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Free-text reflection (journal)",
 			},
 			"task": map[string]interface{}{
 				"type":        "string",
@@ -64,6 +126,51 @@ func (s *ChiefSkill) SetWorkspace(ws string) {
 	s.initWorkspace()
 }
 
+// SetUser scopes briefs and reviews to one user's timezone.
+func (s *ChiefSkill) SetUser(p *users.Profile) {
+	s.user = p
+}
+
+// SetSubagents lets delegate dispatch work to specialist subagents.
+func (s *ChiefSkill) SetSubagents(m *subagent.SubagentManager) {
+	s.subagents = m
+}
+
+// SetContext records where delegated results should be reported. A chat in
+// the call's context (users.WithChat) takes precedence.
+func (s *ChiefSkill) SetContext(channel, chatID string) {
+	s.originChannel = channel
+	s.originChatID = chatID
+}
+
+// now returns the current time in the bound user's timezone.
+func (s *ChiefSkill) now() time.Time {
+	if s.user != nil {
+		return time.Now().In(s.user.Location())
+	}
+	return time.Now()
+}
+
+// configPath is the config file Chief reads: $PERSONAL_OS_CONFIG, else
+// ~/.picoclaw/config.json.
+func configPath() string {
+	if path := os.Getenv("PERSONAL_OS_CONFIG"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".picoclaw", "config.json")
+}
+
+// readConfig unmarshals the config file into v, which mirrors the parts of
+// config.json the caller needs. A missing file leaves v as it is.
+func readConfig(v interface{}) error {
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
 func (s *ChiefSkill) initWorkspace() {
 	if s.workspace == "" {
 		return
@@ -103,16 +210,29 @@ Chief of Staff who coordinates specialist agents, synthesizes their outputs, and
 	os.MkdirAll(memDir, 0755)
 }
 
-func (s *ChiefSkill) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+func (s *ChiefSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
 	command, _ := args["command"].(string)
+	defer func() { health.Record(s.workspace, command, result) }()
 
 	switch command {
 	case "morning_brief":
 		return s.executeMorningBrief(ctx, args)
+	case "midday_update":
+		return s.executeMiddayUpdate(ctx, args)
 	case "evening_review":
 		return s.executeEveningReview(ctx, args)
+	case "journal":
+		return s.executeJournal(ctx, args)
+	case "weekly_review":
+		return s.executeReview(ctx, args, "weekly")
+	case "monthly_review":
+		return s.executeReview(ctx, args, "monthly")
 	case "urgent_deadlines":
 		return s.executeUrgentDeadlines(ctx, args)
+	case "ack", "snooze", "mute", "unmute":
+		return s.executeAlertAction(ctx, args, command)
+	case "alerts":
+		return s.executeListAlerts(ctx, args)
 	case "delegate":
 		return s.executeDelegate(ctx, args)
 	case "status":
@@ -127,209 +247,86 @@ func (s *ChiefSkill) Execute(ctx context.Context, args map[string]interface{}) *
 // ----------------------------------------------------------------------------
 
 func (s *ChiefSkill) executeMorningBrief(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	now := time.Now()
-	var brief strings.Builder
-
-	brief.WriteString(fmt.Sprintf("# 🎯 Morning Brief — %s\n\n", now.Format("Monday, January 2, 2006")))
-
-	brief.WriteString("## ✈️ Today's Tasks (ATC)\n")
-	brief.WriteString(s.getTodaysFocus())
-	brief.WriteString("\n\n")
-
-	brief.WriteString("## 📋 Urgent Deadlines (Architect)\n")
-	brief.WriteString(s.getDeadlinesFile())
-	brief.WriteString("\n\n")
-
-	brief.WriteString("## 🌍 News (Monitor)\n")
-	brief.WriteString(s.getNewsHighlights(now))
-	brief.WriteString("\n\n")
-
-	brief.WriteString("## 🔬 Research (Research)\n")
-	brief.WriteString(s.getResearchUpdates(now))
-	brief.WriteString("\n\n")
-
-	brief.WriteString("## 📚 Learning (Coach)\n")
-	brief.WriteString(s.getLearningFile("learning-today.md"))
-	brief.WriteString("\n\n")
-
-	brief.WriteString("---\n**Ready to roll? 🚀**\n")
-
-	output := brief.String()
-	s.saveBrief(output, "morning-brief")
-
-	return &tools.ToolResult{ForLLM: output, ForUser: output}
-}
-
-// getTodaysFocus parses ATC's tasks.xml and returns urgency-scored Today tasks.
-func (s *ChiefSkill) getTodaysFocus() string {
-	tasksPath := filepath.Join(s.workspace, "..", "atc", "memory", "tasks.xml")
-	data, err := os.ReadFile(tasksPath)
+	data := s.collectBriefData(s.now())
+	markdown, err := s.renderBrief("morning_brief", data)
 	if err != nil {
-		return "- ⚠️ ATC tasks.xml not found. Run `atc analyze_tasks` first.\n"
-	}
-
-	// Minimal inline xCal parse — just what Chief needs
-	type prop struct {
-		Text string `xml:",chardata"`
-	}
-	type vtodoProp struct {
-		Summary    prop `xml:"summary>text"`
-		Status     prop `xml:"status>text"`
-		Categories prop `xml:"categories>text"`
-	}
-	type vtodo struct {
-		Properties vtodoProp `xml:"properties"`
-	}
-	type components struct {
-		VTodos []vtodo `xml:"vtodo"`
-	}
-	type vcal struct {
-		Components components `xml:"components"`
-	}
-	type ical struct {
-		VCal vcal `xml:"vcalendar"`
+		return tools.ErrorResult(fmt.Sprintf("Failed to render morning brief template: %v", err))
 	}
+	s.saveBrief(markdown, "morning-brief", data.Date)
+	s.saveSnapshot(data, false)
+	s.saveMorningBaseline(data)
 
-	var cal ical
-	if err := xml.Unmarshal(data, &cal); err != nil {
-		return fmt.Sprintf("- ⚠️ Failed to parse tasks.xml: %v\n", err)
+	format, _ := args["format"].(string)
+	output, err := render.Render(markdown, format)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
 	}
 
-	var sb strings.Builder
-	count := 0
-	for _, todo := range cal.VCal.Components.VTodos {
-		cat := strings.ToLower(todo.Properties.Categories.Text)
-		status := strings.ToLower(todo.Properties.Status.Text)
-		if strings.Contains(cat, "today") && status != "completed" {
-			sb.WriteString(fmt.Sprintf("- %s\n", todo.Properties.Summary.Text))
-			count++
-		}
-	}
-	if count == 0 {
-		return "- No active tasks for today in tasks.xml.\n"
+	forLLM := output
+	if note := s.emailBrief("morning_brief", markdown, data.Events, data.Date); note != "" {
+		forLLM += "\n\n" + note
 	}
-	return sb.String()
+	return &tools.ToolResult{ForLLM: forLLM, ForUser: output}
 }
 
-// getDeadlinesFile reads the Architect-written deadlines file.
-func (s *ChiefSkill) getDeadlinesFile() string {
-	return s.readMemoryFile("deadlines-today.md", "- No deadlines file found. Architect hasn't written one yet.\n")
-}
+// ----------------------------------------------------------------------------
+// EVENING REVIEW
+// ----------------------------------------------------------------------------
 
-// getLearningFile reads Coach-written learning files by name.
-func (s *ChiefSkill) getLearningFile(name string) string {
-	return s.readMemoryFile(name, "- No learning data (Coach not yet configured).\n")
-}
+func (s *ChiefSkill) executeEveningReview(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	data := s.collectBriefData(s.now())
+	markdown, err := s.renderBrief("evening_review", data)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to render evening review template: %v", err))
+	}
+	s.saveBrief(markdown, "evening-review", data.Date)
+	s.saveSnapshot(data, true)
 
-// getNewsHighlights reads Monitor's RFC news cache for today / yesterday.
-// Caps at K=20 entries. Passively GCs expired files (TTL=6h).
-func (s *ChiefSkill) getNewsHighlights(now time.Time) string {
-	for _, d := range []string{now.Format("20060102"), now.AddDate(0, 0, -1).Format("20060102")} {
-		path := filepath.Join(s.workspace, "memory", "news-"+d+".md")
-		lines, err := skills.ParseRFCFile(path, 20)
-		if err == nil && len(lines) > 0 {
-			return strings.Join(lines, "\n") + "\n"
-		}
+	format, _ := args["format"].(string)
+	output, err := render.Render(markdown, format)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
 	}
-	return "- No news cache found. Run 'fetch news' to populate.\n"
-}
 
-// getResearchUpdates reads Research's RFC paper cache for today / yesterday.
-// Caps at K=15 entries. Passively GCs expired files (TTL=24h).
-func (s *ChiefSkill) getResearchUpdates(now time.Time) string {
-	for _, d := range []string{now.Format("20060102"), now.AddDate(0, 0, -1).Format("20060102")} {
-		path := filepath.Join(s.workspace, "memory", "research-"+d+".md")
-		lines, err := skills.ParseRFCFile(path, 15)
-		if err == nil && len(lines) > 0 {
-			return strings.Join(lines, "\n") + "\n"
-		}
+	forLLM := output
+	if note := s.emailBrief("evening_review", markdown, data.Events, data.Date); note != "" {
+		forLLM += "\n\n" + note
 	}
-	return "- No research cache found. Run 'search papers' to populate.\n"
+	return &tools.ToolResult{ForLLM: forLLM, ForUser: output}
 }
 
 // ----------------------------------------------------------------------------
-// EVENING REVIEW
+// WEEKLY / MONTHLY REVIEW
 // ----------------------------------------------------------------------------
 
-func (s *ChiefSkill) executeEveningReview(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	now := time.Now()
-	var review strings.Builder
-
-	review.WriteString(fmt.Sprintf("# 🌙 Evening Review — %s\n\n", now.Format("Monday, January 2, 2006")))
-
-	review.WriteString("## ✅ Completed Tasks (ATC)\n")
-	review.WriteString(s.getCompletedTasks())
-	review.WriteString("\n\n")
-
-	review.WriteString("## 📚 Learning (Coach)\n")
-	review.WriteString(s.getLearningFile("learning-today.md"))
-	review.WriteString("\n\n")
-
-	review.WriteString("## 📊 Productivity Stats (ATC)\n")
-	review.WriteString(s.readMemoryFile("stats-today.md", "- No stats yet. ATC will write during evening roll-over.\n"))
-	review.WriteString("\n\n")
-
-	review.WriteString("## 🔬 Tomorrow's Research\n")
-	review.WriteString(s.readMemoryFile("tomorrow/research.md", "- Not pre-fetched yet.\n"))
-	review.WriteString("\n\n")
-
-	review.WriteString("## 🌍 Tomorrow's News\n")
-	review.WriteString(s.readMemoryFile("tomorrow/news.md", "- Not pre-fetched yet.\n"))
-	review.WriteString("\n\n")
-
-	review.WriteString("---\n**Good work today. Rest well. 🌙**\n")
-
-	output := review.String()
-	s.saveBrief(output, "evening-review")
-
-	return &tools.ToolResult{ForLLM: output, ForUser: output}
-}
-
-// getCompletedTasks parses ATC tasks.xml for COMPLETED items.
-func (s *ChiefSkill) getCompletedTasks() string {
-	tasksPath := filepath.Join(s.workspace, "..", "atc", "memory", "tasks.xml")
-	data, err := os.ReadFile(tasksPath)
-	if err != nil {
-		return "- ⚠️ ATC tasks.xml not found.\n"
+func (s *ChiefSkill) executeReview(ctx context.Context, args map[string]interface{}, kind string) *tools.ToolResult {
+	end := s.now()
+	if d, _ := args["date"].(string); d != "" {
+		t, err := time.ParseInLocation("2006-01-02", d, end.Location())
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD", d))
+		}
+		end = t
 	}
 
-	type prop struct {
-		Text string `xml:",chardata"`
-	}
-	type vtodoProp struct {
-		Summary prop `xml:"summary>text"`
-		Status  prop `xml:"status>text"`
-	}
-	type vtodo struct {
-		Properties vtodoProp `xml:"properties"`
-	}
-	type components struct {
-		VTodos []vtodo `xml:"vtodo"`
-	}
-	type vcal struct {
-		Components components `xml:"components"`
-	}
-	type ical struct {
-		VCal vcal `xml:"vcalendar"`
+	name := kind + "_review"
+	markdown, err := s.renderBrief(name, s.collectReviewData(kind, end))
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to render %s review template: %v", kind, err))
 	}
+	s.saveBrief(markdown, kind+"-review", end)
 
-	var cal ical
-	if err := xml.Unmarshal(data, &cal); err != nil {
-		return fmt.Sprintf("- ⚠️ Failed to parse tasks.xml: %v\n", err)
+	format, _ := args["format"].(string)
+	output, err := render.Render(markdown, format)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
 	}
 
-	var sb strings.Builder
-	count := 0
-	for _, todo := range cal.VCal.Components.VTodos {
-		if strings.EqualFold(todo.Properties.Status.Text, "completed") {
-			sb.WriteString(fmt.Sprintf("- ✅ %s\n", todo.Properties.Summary.Text))
-			count++
-		}
+	forLLM := output
+	if note := s.emailBrief(name, markdown, nil, end); note != "" {
+		forLLM += "\n\n" + note
 	}
-	if count == 0 {
-		return "- No completed tasks yet today.\n"
-	}
-	return sb.String()
+	return &tools.ToolResult{ForLLM: forLLM, ForUser: output}
 }
 
 // ----------------------------------------------------------------------------
@@ -337,44 +334,81 @@ func (s *ChiefSkill) getCompletedTasks() string {
 // ----------------------------------------------------------------------------
 
 func (s *ChiefSkill) executeUrgentDeadlines(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	content := s.readMemoryFile("deadlines-today.md", "")
-	if content == "" {
-		msg := "✅ No deadlines file found. Silent OK."
+	feed, err := s.loadDeadlineFeed()
+	if err != nil {
+		msg := "✅ No deadlines feed found (run architect sync_deadlines). Silent OK."
 		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
 	}
 
-	now := time.Now()
-	var urgent []string
+	policy := deadlines.LoadPolicy()
+	if m, ok := args["window_minutes"].(float64); ok && m > 0 {
+		policy.Window = time.Duration(m) * time.Minute
+	}
 
-	for _, line := range strings.Split(content, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		// Look for ISO timestamps in the line, e.g. 2026-02-20T17:00:00
-		if idx := strings.Index(line, "20"); idx >= 0 {
-			sub := line[idx:]
-			if len(sub) >= 16 {
-				candidate := sub[:16] // "2026-02-20T17:00"
-				if t, err := time.ParseInLocation("2006-01-02T15:04", candidate, now.Location()); err == nil {
-					hoursLeft := t.Sub(now).Hours()
-					if hoursLeft >= 0 && hoursLeft < 2 {
-						urgent = append(urgent, fmt.Sprintf("  • %s — due in %.0f min", line, t.Sub(now).Minutes()))
-					}
-				}
-			}
-		}
+	store, err := s.openAlerts()
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to open alert store: %v", err))
+	}
+	defer store.Close()
+
+	now := s.now()
+	pending, err := store.Pending(feed, now, policy, true)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to update alert store: %v", err))
 	}
 
-	if len(urgent) == 0 {
-		msg := "✅ No urgent deadlines (all ≥ 2h away). Silent OK."
+	if len(pending) == 0 {
+		msg := fmt.Sprintf("✅ No new or escalated deadlines (window %s). Silent OK.", formatWindow(policy.Window))
 		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
 	}
 
-	alert := "⚠️ URGENT DEADLINES:\n" + strings.Join(urgent, "\n") + "\n\nTime to focus! 🎯"
+	loc := feed.Location()
+	var urgent []string
+	for _, a := range pending {
+		line := "  • " + describeDeadline(a.Deadline, now, loc)
+		if a.Escalated {
+			line += " 🔺"
+		}
+		urgent = append(urgent, line+" ["+a.Ref+"]")
+	}
+
+	alert := "⚠️ URGENT DEADLINES:\n" + strings.Join(urgent, "\n") +
+		"\n\nTime to focus! 🎯\nReply \"ack <id>\", \"snooze <id> 1h\" or \"mute <id>\" to quiet an alert."
 	return &tools.ToolResult{ForLLM: alert, ForUser: alert}
 }
 
+// loadDeadlineFeed reads Architect's deadlines.json, preferring a copy in
+// Chief's own memory.
+func (s *ChiefSkill) loadDeadlineFeed() (*deadlines.Feed, error) {
+	return deadlines.Find(
+		filepath.Join(s.workspace, "memory"),
+		filepath.Join(s.workspace, "..", "architect", "memory"),
+	)
+}
+
+// describeDeadline renders one deadline with how long is left.
+func describeDeadline(d deadlines.Deadline, now time.Time, loc *time.Location) string {
+	label := d.Summary
+	if d.Priority >= 1 && d.Priority <= 4 {
+		label += " ❗" // RFC 5545 high priority
+	}
+	switch {
+	case d.Overdue(now, loc):
+		return fmt.Sprintf("%s — OVERDUE since %s", label, d.Due.In(loc).Format("Jan 2 15:04"))
+	case d.AllDay:
+		return fmt.Sprintf("%s — due today", label)
+	default:
+		return fmt.Sprintf("%s — due in %.0f min (%s)", label, d.Due.Sub(now).Minutes(), d.Due.In(loc).Format("15:04"))
+	}
+}
+
+func formatWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d min", int(d.Minutes()))
+}
+
 // ----------------------------------------------------------------------------
 // DELEGATE
 // ----------------------------------------------------------------------------
@@ -386,57 +420,42 @@ func (s *ChiefSkill) executeDelegate(ctx context.Context, args map[string]interf
 	if task == "" {
 		return tools.ErrorResult("task is required for delegate command")
 	}
+
+	var routing string
 	if agent == "" {
-		agent = s.detectAgent(task)
+		route := subagent.RouteTask(task, filepath.Dir(s.workspace))
+		agent = string(route.Agent)
+		if route.Fallback {
+			routing = fmt.Sprintf("no clear match, defaulted to %s", agent)
+		} else {
+			routing = fmt.Sprintf("routed to %s, confidence %.0f%%", agent, route.Confidence*100)
+		}
+	} else {
+		if _, ok := subagent.ValidAgents[subagent.AgentType(agent)]; !ok || agent == string(subagent.AgentChief) {
+			return tools.ErrorResult(fmt.Sprintf("Cannot delegate to %q", agent))
+		}
+		routing = "requested agent"
 	}
 
-	result := fmt.Sprintf("**Delegating to %s:** %s\n\nUse the subagent tool to spawn `%s` with this task message.", agent, task, agent)
-	return &tools.ToolResult{ForLLM: result, ForUser: result}
-}
-
-func (s *ChiefSkill) detectAgent(task string) string {
-	taskLower := strings.ToLower(task)
-	if strings.Contains(taskLower, "paper") || strings.Contains(taskLower, "research") || strings.Contains(taskLower, "arxiv") {
-		return "research"
+	if s.subagents == nil || subagent.InSubagent(ctx) {
+		result := fmt.Sprintf("**Delegating to %s** (%s): %s\n\nUse the subagent tool to spawn `%s` with this task message.", agent, routing, task, agent)
+		return &tools.ToolResult{ForLLM: result, ForUser: result}
 	}
-	if strings.Contains(taskLower, "news") || strings.Contains(taskLower, "monitor") || strings.Contains(taskLower, "bangladesh") {
-		return "monitor"
-	}
-	if strings.Contains(taskLower, "task") || strings.Contains(taskLower, "priority") || strings.Contains(taskLower, "calendar") {
-		return "atc"
-	}
-	if strings.Contains(taskLower, "learn") || strings.Contains(taskLower, "ielts") || strings.Contains(taskLower, "study") {
-		return "coach"
+
+	channel, chatID := s.originChannel, s.originChatID
+	if chat, ok := users.ChatFrom(ctx); ok {
+		channel, chatID = chat.Channel, chat.ChatID
 	}
-	if strings.Contains(taskLower, "bill") || strings.Contains(taskLower, "deadline") || strings.Contains(taskLower, "medicine") {
-		return "architect"
+	if channel == "" {
+		channel, chatID = "cli", "direct"
 	}
-	return "atc"
-}
-
-// ----------------------------------------------------------------------------
-// STATUS
-// ----------------------------------------------------------------------------
-
-func (s *ChiefSkill) executeStatus(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	var sb strings.Builder
-	sb.WriteString("# 🎯 System Status\n\n")
-
-	agents := []string{"architect", "atc", "chief", "coach", "monitor", "research"}
-	for _, agent := range agents {
-		agentPath := filepath.Join("workspaces", agent)
-		if _, err := os.Stat(agentPath); err == nil {
-			// Check if memory dir has recent files
-			memPath := filepath.Join(agentPath, "memory")
-			entries, _ := os.ReadDir(memPath)
-			sb.WriteString(fmt.Sprintf("- ✅ **%s**: active (%d memory files)\n", agent, len(entries)))
-		} else {
-			sb.WriteString(fmt.Sprintf("- ⏳ **%s**: workspace not found\n", agent))
-		}
+	reply, err := s.subagents.Spawn(ctx, task, "chief-delegate", subagent.AgentType(agent), channel, chatID)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Delegation to %s failed: %v", agent, err))
 	}
 
-	out := sb.String()
-	return &tools.ToolResult{ForLLM: out, ForUser: out}
+	result := fmt.Sprintf("**Delegated to %s** (%s)\n\n%s", agent, routing, reply)
+	return &tools.ToolResult{ForLLM: result, ForUser: result}
 }
 
 // ----------------------------------------------------------------------------
@@ -471,14 +490,15 @@ func (s *ChiefSkill) readMemoryFile(name, fallback string) string {
 	return content + "\n"
 }
 
-// saveBrief writes the brief to chief/memory/TYPE-YYYY-MM-DD.md.
-func (s *ChiefSkill) saveBrief(content, briefType string) {
+// saveBrief writes the brief to chief/memory/TYPE-YYYY-MM-DD.md, dated by
+// the day the brief covers (the last day for reviews).
+func (s *ChiefSkill) saveBrief(content, briefType string, date time.Time) {
 	if s.workspace == "" {
 		return
 	}
 	memoryDir := filepath.Join(s.workspace, "memory")
 	os.MkdirAll(memoryDir, 0755)
-	filename := fmt.Sprintf("%s-%s.md", briefType, time.Now().Format("2006-01-02"))
+	filename := fmt.Sprintf("%s-%s.md", briefType, date.Format("2006-01-02"))
 	path := filepath.Join(memoryDir, filename)
 	os.WriteFile(path, []byte(content), 0644)
 }
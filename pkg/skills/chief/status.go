@@ -0,0 +1,332 @@
+package chief
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/jony/son-of-anthon/pkg/skills/health"
+	"github.com/jony/son-of-anthon/pkg/skills/monitor"
+	"github.com/jony/son-of-anthon/pkg/users"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Freshness limits for caches that carry no TTL of their own.
+const (
+	deadlinesTTL = 24 * time.Hour
+	eventsTTL    = 24 * time.Hour
+	probeTimeout = 10 * time.Second
+)
+
+var statusAgents = []string{"architect", "atc", "chief", "coach", "monitor", "research", "search"}
+
+// ----------------------------------------------------------------------------
+// STATUS
+// ----------------------------------------------------------------------------
+
+func (s *ChiefSkill) executeStatus(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	offline, _ := args["offline"].(bool)
+	out := s.statusReport(ctx, offline)
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
+
+// StatusReport renders the status report; the CLI calls it directly.
+// offline skips the Nextcloud and feed reachability probes.
+func (s *ChiefSkill) StatusReport(ctx context.Context, offline bool) string {
+	return s.statusReport(ctx, offline)
+}
+
+func (s *ChiefSkill) statusReport(ctx context.Context, offline bool) string {
+	now := s.now()
+	base := filepath.Dir(s.workspace)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# 🎯 System Status — %s\n\n", now.Format("Jan 2 15:04"))
+
+	sb.WriteString("## Skills\n")
+	for _, agent := range statusAgents {
+		sb.WriteString(skillStatusLine(agent, filepath.Join(base, agent), now) + "\n")
+	}
+
+	sb.WriteString("\n## Caches\n")
+	memDir := filepath.Join(s.workspace, "memory")
+	sb.WriteString(rfcStatusLine("News (Monitor)", latestFile(memDir, "news-"), now) + "\n")
+	sb.WriteString(rfcStatusLine("Research (Research)", latestFile(memDir, "research-"), now) + "\n")
+	sb.WriteString(s.deadlinesStatusLine(now) + "\n")
+	sb.WriteString(fileStatusLine("Calendar (events.xml)", filepath.Join(base, "atc", "memory", "events.xml"), eventsTTL, now) + "\n")
+
+	sb.WriteString("\n## Connectivity\n")
+	if offline {
+		sb.WriteString("- ⏭️ Skipped (offline)\n")
+	} else {
+		sb.WriteString(s.nextcloudStatusLine(ctx) + "\n")
+		sb.WriteString(feedsStatusLine(ctx, filepath.Join(base, "monitor")) + "\n")
+	}
+
+	sb.WriteString("\n## Databases\n")
+	for _, db := range []struct{ name, path string }{
+		{"momentum.db (Coach)", filepath.Join(base, "coach", "memory", "momentum.db")},
+		{"monitor.db (Monitor)", filepath.Join(base, "monitor", "monitor.db")},
+		{"stats.db (ATC)", filepath.Join(base, "atc", "memory", "stats.db")},
+		{"sync.db (ATC)", filepath.Join(base, "atc", "memory", "sync.db")},
+		{"alerts.db (Chief)", filepath.Join(memDir, deadlines.AlertsFileName)},
+		{"journal.db (Chief)", filepath.Join(memDir, journalDBName)},
+		{"search.db (Search)", filepath.Join(base, "search", "search.db")},
+	} {
+		sb.WriteString(dbStatusLine(db.name, db.path) + "\n")
+	}
+
+	sb.WriteString("\n## Subagents\n")
+	sb.WriteString(s.subagentStatusLine() + "\n")
+
+	return sb.String()
+}
+
+func skillStatusLine(agent, ws string, now time.Time) string {
+	if _, err := os.Stat(ws); err != nil {
+		return fmt.Sprintf("- ❌ **%s**: workspace not found (%s)", agent, ws)
+	}
+	log, err := health.Load(ws)
+	if err != nil {
+		return fmt.Sprintf("- ⚠️ **%s**: unreadable run log: %v", agent, err)
+	}
+	if len(log.Commands) == 0 {
+		return fmt.Sprintf("- ⏳ **%s**: no runs recorded yet", agent)
+	}
+
+	icon := "✅"
+	cmd, at := log.LastSuccess()
+	line := "never succeeded"
+	if !at.IsZero() {
+		line = fmt.Sprintf("last success %s (%s)", ago(now, at), cmd)
+	}
+	if ecmd, msg, eat := log.LastError(); !eat.IsZero() {
+		if eat.After(at) {
+			icon = "⚠️"
+		}
+		line += fmt.Sprintf("; last error %s (%s): %s", ago(now, eat), ecmd, firstLine(msg))
+	}
+	return fmt.Sprintf("- %s **%s**: %s", icon, agent, line)
+}
+
+func rfcStatusLine(label, path string, now time.Time) string {
+	if path == "" {
+		return fmt.Sprintf("- ❌ %s: no cache file", label)
+	}
+	h, err := skills.ReadRFCHeader(path)
+	if err != nil || h.TS.IsZero() {
+		return fmt.Sprintf("- ❌ %s: unreadable %s", label, filepath.Base(path))
+	}
+	return freshnessLine(label, h.TS, h.TTL, now, fmt.Sprintf(", %d records", h.Count))
+}
+
+func (s *ChiefSkill) deadlinesStatusLine(now time.Time) string {
+	label := "Deadlines (deadlines.json)"
+	feed, err := s.loadDeadlineFeed()
+	if err != nil {
+		return fmt.Sprintf("- ❌ %s: missing (run architect sync_deadlines)", label)
+	}
+	return freshnessLine(label, feed.Generated, deadlinesTTL, now, fmt.Sprintf(", %d deadlines", len(feed.Deadlines)))
+}
+
+func fileStatusLine(label, path string, ttl time.Duration, now time.Time) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Sprintf("- ❌ %s: missing", label)
+	}
+	return freshnessLine(label, info.ModTime(), ttl, now, "")
+}
+
+func freshnessLine(label string, at time.Time, ttl time.Duration, now time.Time, extra string) string {
+	icon, state := "✅", "fresh"
+	if now.Sub(at) > ttl {
+		icon, state = "⚠️", "stale"
+	}
+	return fmt.Sprintf("- %s %s: %s, updated %s (TTL %s%s)", icon, label, state, ago(now, at), formatWindow(ttl), extra)
+}
+
+// latestFile returns the newest file in dir whose name starts with prefix.
+func latestFile(dir, prefix string) string {
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) && strings.HasSuffix(e.Name(), ".md") {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return filepath.Join(dir, names[len(names)-1])
+}
+
+// loadNextcloud returns the bound user's Nextcloud account, or
+// tools.nextcloud in single-user mode.
+func (s *ChiefSkill) loadNextcloud() users.Nextcloud {
+	if s.user != nil {
+		return s.user.Nextcloud
+	}
+	var cfg struct {
+		Tools struct {
+			Nextcloud users.Nextcloud `json:"nextcloud"`
+		} `json:"tools"`
+	}
+	readConfig(&cfg)
+	return cfg.Tools.Nextcloud
+}
+
+func (s *ChiefSkill) nextcloudStatusLine(ctx context.Context) string {
+	nc := s.loadNextcloud()
+	if nc.Host == "" {
+		return "- ⏳ Nextcloud: not configured"
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", caldav.BuildTasksURL(nc.Host, nc.Username), nil)
+	if err != nil {
+		return fmt.Sprintf("- ❌ Nextcloud: %v", err)
+	}
+	req.SetBasicAuth(nc.Username, nc.Password)
+	req.Header.Set("Depth", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Sprintf("- ❌ Nextcloud: unreachable (%v)", err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return "- ❌ Nextcloud: credentials rejected (HTTP 401)"
+	case resp.StatusCode >= 400:
+		return fmt.Sprintf("- ❌ Nextcloud: HTTP %d", resp.StatusCode)
+	}
+	return fmt.Sprintf("- ✅ Nextcloud: reachable (HTTP %d)", resp.StatusCode)
+}
+
+func feedsStatusLine(ctx context.Context, monitorWS string) string {
+	m := monitor.NewSkill()
+	m.SetWorkspace(monitorWS)
+	feeds := m.Feeds()
+	if len(feeds) == 0 {
+		return "- ⏳ Feeds: none configured"
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+		sem    = make(chan struct{}, 8)
+	)
+	client := &http.Client{Timeout: probeTimeout}
+	for _, f := range feeds {
+		wg.Add(1)
+		go func(f monitor.Feed) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			problem := ""
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = client.Do(req); err == nil {
+					resp.Body.Close()
+					if resp.StatusCode >= 400 {
+						problem = fmt.Sprintf("HTTP %d", resp.StatusCode)
+					}
+				}
+			}
+			if err != nil {
+				problem = err.Error()
+			}
+			if problem != "" {
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s (%s)", f.Name, problem))
+				mu.Unlock()
+			}
+		}(f)
+	}
+	wg.Wait()
+
+	ok := len(feeds) - len(failed)
+	if len(failed) == 0 {
+		return fmt.Sprintf("- ✅ Feeds: %d/%d reachable", ok, len(feeds))
+	}
+	sort.Strings(failed)
+	return fmt.Sprintf("- ⚠️ Feeds: %d/%d reachable; failing: %s", ok, len(feeds), strings.Join(failed, ", "))
+}
+
+func dbStatusLine(label, path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Sprintf("- ⏳ %s: not created yet", label)
+	}
+	size := info.Size()
+	if wal, err := os.Stat(path + "-wal"); err == nil {
+		size += wal.Size()
+	}
+	return fmt.Sprintf("- %s: %s", label, formatBytes(size))
+}
+
+func (s *ChiefSkill) subagentStatusLine() string {
+	if s.subagents == nil {
+		return "- ⏳ Not available in this process (subagents run inside the gateway)"
+	}
+	var pending []string
+	for _, t := range s.subagents.ListTasks() {
+		if t.Status == "running" {
+			pending = append(pending, fmt.Sprintf("%s (%s): %s", t.ID, t.AgentType, firstLine(t.Task)))
+		}
+	}
+	if len(pending) == 0 {
+		return "- ✅ No pending subagent tasks"
+	}
+	sort.Strings(pending)
+	return fmt.Sprintf("- ⏳ %d running: %s", len(pending), strings.Join(pending, "; "))
+}
+
+func ago(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 120 {
+		s = s[:120] + "…"
+	}
+	return s
+}
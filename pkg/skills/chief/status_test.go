@@ -0,0 +1,49 @@
+package chief
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+)
+
+func TestStatusReportOffline(t *testing.T) {
+	root := t.TempDir()
+	ws := filepath.Join(root, "chief")
+	s := &ChiefSkill{workspace: ws}
+	os.MkdirAll(filepath.Join(root, "atc", "memory"), 0755)
+
+	// Recorded runs: one failure, then a success.
+	s.Execute(context.Background(), map[string]interface{}{"command": "bogus"})
+	s.Execute(context.Background(), map[string]interface{}{"command": "alerts"})
+
+	news := filepath.Join(ws, "memory", "news-"+time.Now().Format("20060102")+".md")
+	if err := skills.WriteRFCFile(news, "monitor", "6h", []string{skills.EncodeRecord("news", "https://example.com/a", "A", "tech", "")}); err != nil {
+		t.Fatal(err)
+	}
+	events := filepath.Join(root, "atc", "memory", "events.xml")
+	os.WriteFile(events, []byte("<icalendar/>"), 0644)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(events, old, old)
+
+	out := s.StatusReport(context.Background(), true)
+	for _, want := range []string{
+		"**chief**: last success just now (alerts); last error just now (bogus): Unknown command: bogus",
+		"❌ **coach**: workspace not found",
+		"✅ News (Monitor): fresh, updated just now (TTL 6h, 1 records)",
+		"❌ Research (Research): no cache file",
+		"❌ Deadlines (deadlines.json): missing",
+		"⚠️ Calendar (events.xml): stale, updated 2d ago (TTL 24h)",
+		"Skipped (offline)",
+		"alerts.db (Chief):",
+		"Not available in this process",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package chief
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jony/son-of-anthon/workspaces"
+)
+
+// ----------------------------------------------------------------------------
+// Brief templates
+//
+// Every brief and review renders <workspace>/templates/<name>.md.tmpl.
+// When the user has no such file, the copy embedded from
+// workspaces/chief/templates is used, so a fresh binary always works.
+// ----------------------------------------------------------------------------
+
+// templateFuncs are available to every brief template.
+var templateFuncs = template.FuncMap{
+	// limit returns at most n items of a slice: {{range limit 5 .News}}.
+	// n <= 0 means no limit.
+	"limit": func(n int, list interface{}) (interface{}, error) {
+		v := reflect.ValueOf(list)
+		if !v.IsValid() {
+			return list, nil
+		}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("limit: expected a list, got %T", list)
+		}
+		if n <= 0 || v.Len() <= n {
+			return list, nil
+		}
+		return v.Slice(0, n).Interface(), nil
+	},
+	// date formats a time with a Go layout: {{date "Mon 2 Jan" .Date}}.
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	// clock renders a time as 15:04.
+	"clock": func(t time.Time) string {
+		return t.Format("15:04")
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join": func(sep string, list []string) string {
+		return strings.Join(list, sep)
+	},
+	// trend compares a total with the previous period: "↑ 3", "↓ 2" or "→".
+	"trend": func(cur, prev int) string {
+		switch {
+		case cur > prev:
+			return fmt.Sprintf("↑ %d", cur-prev)
+		case cur < prev:
+			return fmt.Sprintf("↓ %d", prev-cur)
+		default:
+			return "→"
+		}
+	},
+	// default returns def when val is empty: {{default "none" .User}}.
+	"default": func(def, val string) string {
+		if strings.TrimSpace(val) == "" {
+			return def
+		}
+		return val
+	},
+}
+
+// loadTemplate reads a brief template from the workspace, falling back to
+// the embedded default.
+func (s *ChiefSkill) loadTemplate(name string) (*template.Template, error) {
+	file := name + ".md.tmpl"
+
+	var src []byte
+	if s.workspace != "" {
+		src, _ = os.ReadFile(filepath.Join(s.workspace, "templates", file))
+	}
+	if len(src) == 0 {
+		var err error
+		src, err = workspaces.FS.ReadFile("chief/templates/" + file)
+		if err != nil {
+			return nil, fmt.Errorf("no template %s: %v", file, err)
+		}
+	}
+
+	return template.New(file).Funcs(templateFuncs).Option("missingkey=zero").Parse(string(src))
+}
+
+// renderBrief executes the named template against data (*BriefData or *ReviewData).
+func (s *ChiefSkill) renderBrief(name string, data interface{}) (string, error) {
+	tmpl, err := s.loadTemplate(name)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(sb.String(), "\n"), nil
+}
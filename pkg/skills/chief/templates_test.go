@@ -0,0 +1,82 @@
+package chief
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+)
+
+func TestDefaultMorningTemplate(t *testing.T) {
+	s := &ChiefSkill{workspace: t.TempDir()}
+	data := &BriefData{
+		Date:   time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+		Tasks:  []Task{{Summary: "Write report"}},
+		Errors: map[string]string{},
+	}
+
+	out, err := s.renderBrief("morning_brief", data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	for _, want := range []string{
+		"# 🎯 Morning Brief — Monday, March 2, 2026\n\n## ✈️ Today's Tasks (ATC)\n- Write report\n\n",
+		"## 📋 Urgent Deadlines (Architect)\n- No deadlines file found.",
+		"- No news cache found.",
+		"---\n**Ready to roll? 🚀**\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTasksErrorShownInBrief(t *testing.T) {
+	s := &ChiefSkill{workspace: t.TempDir()}
+	data := &BriefData{Errors: map[string]string{"tasks": "tasks.xml not found"}}
+
+	out, err := s.renderBrief("evening_review", data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(out, "## ✅ Completed Tasks (ATC)\n- ⚠️ tasks.xml not found\n") {
+		t.Errorf("error not rendered:\n%s", out)
+	}
+}
+
+func TestWorkspaceTemplateOverridesDefault(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "templates"), 0755)
+	custom := `{{range limit 2 .News}}{{.Title}};{{end}}`
+	os.WriteFile(filepath.Join(ws, "templates", "morning_brief.md.tmpl"), []byte(custom), 0644)
+
+	s := &ChiefSkill{workspace: ws}
+	data := &BriefData{News: []skills.Record{
+		skills.DecodeRecord(skills.EncodeRecord("news", "https://a.example", "One", "world", "20260302")),
+		skills.DecodeRecord(skills.EncodeRecord("news", "https://b.example", "Two", "world", "20260302")),
+		skills.DecodeRecord(skills.EncodeRecord("news", "https://c.example", "Three", "world", "20260302")),
+	}}
+
+	out, err := s.renderBrief("morning_brief", data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if out != "One;Two;" {
+		t.Errorf("got %q, want %q", out, "One;Two;")
+	}
+}
+
+func TestBrokenTemplateIsReported(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "templates"), 0755)
+	os.WriteFile(filepath.Join(ws, "templates", "evening_review.md.tmpl"), []byte("{{range .Tasks}"), 0644)
+
+	s := &ChiefSkill{workspace: ws}
+	if _, err := s.renderBrief("evening_review", &BriefData{}); err == nil {
+		t.Fatal("expected parse error for broken template")
+	}
+}
@@ -14,7 +14,10 @@ import (
 
 // executeCheckHabits implements the CalDAV PROPFIND + GET check.
 func (s *CoachSkill) executeCheckHabits(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	cfg := loadCoachConfig()
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	if cfg.Host == "" {
 		return tools.ErrorResult("coach.host not configured in config.json")
 	}
@@ -24,7 +27,7 @@ func (s *CoachSkill) executeCheckHabits(ctx context.Context, args map[string]int
 		return tools.ErrorResult(fmt.Sprintf("Failed to list tasks: %v", err))
 	}
 
-	todayStr := time.Now().Format("20060102") // e.g. 20260221
+	todayStr := s.today().Format("20060102") // e.g. 20260221
 
 	habitCompleted := map[string]bool{
 		"IELTS":    false,
@@ -79,8 +82,9 @@ func (s *CoachSkill) updateStreaks(completed map[string]bool) string {
 		return "⚠️ SQLite DB not initialized."
 	}
 
-	today := time.Now().Format("2006-01-02")
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	now := s.today()
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
 
 	var sb strings.Builder
 	sb.WriteString("Habit Check Results:\n")
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jony/son-of-anthon/pkg/render"
 	"github.com/jony/son-of-anthon/pkg/skills/caldav"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
@@ -26,7 +27,10 @@ func buildDeckURL(cfg CoachConfig) string {
 
 // executeGeneratePractice pulls a random file from WebDAV
 func (s *CoachSkill) executeGeneratePractice(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	cfg := loadCoachConfig()
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	if cfg.Host == "" {
 		return tools.ErrorResult("coach.host not configured in config.json")
 	}
@@ -96,7 +100,10 @@ func (s *CoachSkill) executeGeneratePractice(ctx context.Context, args map[strin
 }
 
 func (s *CoachSkill) executeUpdateDeck(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	cfg := loadCoachConfig()
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
 	cardID, _ := args["card_id"].(string)
 	colID, _ := args["column_id"].(string)
 
@@ -140,7 +147,7 @@ func (s *CoachSkill) executeUpdateDeck(ctx context.Context, args map[string]inte
 
 // executeNudgeTelegram sends a message to the unified Telegram chat
 func (s *CoachSkill) executeNudgeTelegram(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	tgCfg := loadTelegramConfig()
+	tgCfg := s.loadTelegram()
 	msg, _ := args["message"].(string)
 
 	if tgCfg.BotToken == "" || tgCfg.ChatID == "" || msg == "" {
@@ -149,27 +156,31 @@ func (s *CoachSkill) executeNudgeTelegram(ctx context.Context, args map[string]i
 
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", tgCfg.BotToken)
 
-	payloadMap := map[string]interface{}{
-		"chat_id":    tgCfg.ChatID,
-		"text":       msg,
-		"parse_mode": "Markdown",
-	}
-	payloadBytes, _ := json.Marshal(payloadMap)
-
 	timeout := 10 * time.Second
 	if tgCfg.Timeout > 0 {
 		timeout = time.Duration(tgCfg.Timeout) * time.Second
 	}
 	client := &http.Client{Timeout: timeout}
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to send Telegram message: %v", err))
-	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return tools.ErrorResult(fmt.Sprintf("Telegram API returned %d: %s", resp.StatusCode, string(body)))
+	// Escape for MarkdownV2 and split at Telegram's 4096-character limit.
+	for _, part := range render.Telegram(msg) {
+		payloadMap := map[string]interface{}{
+			"chat_id":    tgCfg.ChatID,
+			"text":       part,
+			"parse_mode": "MarkdownV2",
+		}
+		payloadBytes, _ := json.Marshal(payloadMap)
+
+		resp, err := client.Post(url, "application/json", bytes.NewBuffer(payloadBytes))
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to send Telegram message: %v", err))
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return tools.ErrorResult(fmt.Sprintf("Telegram API returned %d: %s", resp.StatusCode, string(body)))
+		}
+		resp.Body.Close()
 	}
 
 	result := "Telegram nudge sent successfully 🚀"
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/jony/son-of-anthon/pkg/skills/health"
 	"github.com/jony/son-of-anthon/pkg/sqlite"
+	"github.com/jony/son-of-anthon/pkg/users"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
@@ -28,6 +31,7 @@ type TelegramConfig struct {
 type CoachSkill struct {
 	workspace string
 	db        *sql.DB
+	user      *users.Profile // nil in single-user mode
 }
 
 func NewSkill() *CoachSkill {
@@ -85,6 +89,20 @@ func (s *CoachSkill) SetWorkspace(ws string) {
 	s.initWorkspace()
 }
 
+// SetUser scopes the skill to one user's Nextcloud account, Telegram chat
+// and timezone.
+func (s *CoachSkill) SetUser(p *users.Profile) {
+	s.user = p
+}
+
+// today returns the current time in the bound user's timezone.
+func (s *CoachSkill) today() time.Time {
+	if s.user != nil {
+		return time.Now().In(s.user.Location())
+	}
+	return time.Now()
+}
+
 func (s *CoachSkill) initWorkspace() {
 	if s.workspace == "" {
 		return
@@ -112,8 +130,9 @@ func (s *CoachSkill) initWorkspace() {
 	}
 }
 
-func (s *CoachSkill) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+func (s *CoachSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
 	command, _ := args["command"].(string)
+	defer func() { health.Record(s.workspace, command, result) }()
 
 	switch command {
 	case "check_habits":
@@ -185,6 +204,30 @@ func loadCoachConfig() CoachConfig {
 	return cfg.Tools.Nextcloud
 }
 
+// loadConfig returns the bound user's Nextcloud credentials, or the
+// global tools.nextcloud section when running single-user. A bound user
+// without an account of their own gets users.Profile.ErrNoNextcloud.
+func (s *CoachSkill) loadConfig() (CoachConfig, error) {
+	if s.user != nil {
+		nc := s.user.Nextcloud
+		if nc.Host == "" {
+			return CoachConfig{}, s.user.ErrNoNextcloud()
+		}
+		return CoachConfig{Host: nc.Host, Username: nc.Username, Password: nc.Password, Timeout: nc.Timeout}, nil
+	}
+	return loadCoachConfig(), nil
+}
+
+// loadTelegram returns the shared bot token, addressed to the bound
+// user's own chat instead of the admin chat.
+func (s *CoachSkill) loadTelegram() TelegramConfig {
+	cfg := loadTelegramConfig()
+	if s.user != nil && s.user.ChatID() != "" {
+		cfg.ChatID = s.user.ChatID()
+	}
+	return cfg
+}
+
 func loadTelegramConfig() TelegramConfig {
 	var cfg struct {
 		Tools struct {
@@ -0,0 +1,397 @@
+package deadlines
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+)
+
+// ----------------------------------------------------------------------------
+// Alert store
+//
+// Remembers which deadline occurrences (UID + due time) were announced, at
+// which escalation stage, and whether the user acknowledged, snoozed or
+// muted them, so the heartbeat only speaks up when something changed.
+// ----------------------------------------------------------------------------
+
+// AlertsFileName is the alert database inside Chief's memory directory.
+const AlertsFileName = "alerts.db"
+
+// Policy controls when a deadline is (re-)announced.
+type Policy struct {
+	Window        time.Duration   // first alert when due within this window
+	Escalate      []time.Duration // re-alert when time left drops below each, longest first
+	RepeatOverdue time.Duration   // re-alert overdue items this often; 0 = once
+}
+
+// configPath is the config file the deadline alerts read reads: $PERSONAL_OS_CONFIG, else
+// ~/.picoclaw/config.json.
+func configPath() string {
+	if path := os.Getenv("PERSONAL_OS_CONFIG"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".picoclaw", "config.json")
+}
+
+// readConfig unmarshals the config file into v, which mirrors the parts of
+// config.json the caller needs. A missing file leaves v as it is.
+func readConfig(v interface{}) error {
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// LoadPolicy reads tools.deadlines from config.json:
+//
+//	"deadlines": {"alert_window_minutes": 120, "escalate_minutes": [60, 15], "repeat_overdue_minutes": 1440}
+func LoadPolicy() Policy {
+	var cfg struct {
+		Tools struct {
+			Deadlines struct {
+				AlertWindowMinutes   int   `json:"alert_window_minutes"`
+				EscalateMinutes      []int `json:"escalate_minutes"`
+				RepeatOverdueMinutes *int  `json:"repeat_overdue_minutes"`
+			} `json:"deadlines"`
+		} `json:"tools"`
+	}
+	readConfig(&cfg)
+	c := cfg.Tools.Deadlines
+
+	p := Policy{
+		Window:        DefaultAlertWindow,
+		Escalate:      []time.Duration{time.Hour, 15 * time.Minute},
+		RepeatOverdue: 24 * time.Hour,
+	}
+	if c.AlertWindowMinutes > 0 {
+		p.Window = time.Duration(c.AlertWindowMinutes) * time.Minute
+	}
+	if c.EscalateMinutes != nil {
+		p.Escalate = nil
+		for _, m := range c.EscalateMinutes {
+			if m > 0 {
+				p.Escalate = append(p.Escalate, time.Duration(m)*time.Minute)
+			}
+		}
+	}
+	if c.RepeatOverdueMinutes != nil {
+		p.RepeatOverdue = time.Duration(*c.RepeatOverdueMinutes) * time.Minute
+	}
+	sort.Slice(p.Escalate, func(i, j int) bool { return p.Escalate[i] > p.Escalate[j] })
+	return p
+}
+
+// AlertWindow returns the configured alert window.
+func AlertWindow() time.Duration {
+	return LoadPolicy().Window
+}
+
+// stage is how far a deadline has escalated: 0 inside the window,
+// 1..len(Escalate) past each escalation point, len(Escalate)+1 once overdue.
+func (p Policy) stage(d Deadline, now time.Time, loc *time.Location) int {
+	if d.Overdue(now, loc) {
+		return len(p.Escalate) + 1
+	}
+	if d.AllDay {
+		return 0
+	}
+	left := d.Due.Sub(now)
+	st := 0
+	for i, e := range p.Escalate {
+		if left <= e {
+			st = i + 1
+		}
+	}
+	return st
+}
+
+// Alert is a deadline that should be announced now.
+type Alert struct {
+	Deadline
+	Ref       string // short reference for ack / snooze / mute
+	Overdue   bool
+	Escalated bool // announced before at a lower stage, or a repeat while overdue
+}
+
+// AlertState is a stored alert as shown by the "alerts" command.
+type AlertState struct {
+	Ref          string
+	UID          string
+	Summary      string
+	Due          time.Time
+	LastAlerted  time.Time
+	Acked        bool
+	SnoozedUntil time.Time
+	Muted        bool
+}
+
+// Store is the SQLite-backed alert state.
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens (creating if needed) the alert database.
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := `
+	CREATE TABLE IF NOT EXISTS alerts (
+		key TEXT PRIMARY KEY,
+		uid TEXT NOT NULL,
+		summary TEXT,
+		due INTEGER,
+		stage INTEGER DEFAULT -1,
+		last_alerted INTEGER DEFAULT 0,
+		acked_at INTEGER DEFAULT 0,
+		snoozed_until INTEGER DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_alerts_uid ON alerts(uid);
+
+	CREATE TABLE IF NOT EXISTS mutes (
+		uid TEXT PRIMARY KEY,
+		summary TEXT,
+		muted_at INTEGER
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ShortRef is the reference users type to act on an alert: the first
+// eight hex digits of the UID's SHA-1. UIDs often share a long prefix
+// (every ATC task is atc-task-<nanos>), so a truncated UID would not tell
+// them apart.
+func ShortRef(uid string) string {
+	sum := sha1.Sum([]byte(strings.TrimSuffix(uid, ".ics")))
+	return hex.EncodeToString(sum[:4])
+}
+
+func occurrenceKey(d Deadline) string {
+	return d.UID + "@" + d.Due.UTC().Format(time.RFC3339)
+}
+
+// Pending returns the urgent deadlines that are new or escalated. With
+// commit=false the store is left untouched (used for cheap pre-checks).
+func (s *Store) Pending(feed *Feed, now time.Time, p Policy, commit bool) ([]Alert, error) {
+	loc := feed.Location()
+	var out []Alert
+	for _, d := range feed.Urgent(now, p.Window) {
+		var muted int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM mutes WHERE uid = ?", d.UID).Scan(&muted); err != nil {
+			return nil, err
+		}
+		if muted > 0 {
+			continue
+		}
+
+		key := occurrenceKey(d)
+		stage, lastAlerted, ackedAt, snoozedUntil := -1, int64(0), int64(0), int64(0)
+		err := s.db.QueryRow("SELECT stage, last_alerted, acked_at, snoozed_until FROM alerts WHERE key = ?", key).
+			Scan(&stage, &lastAlerted, &ackedAt, &snoozedUntil)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if ackedAt > 0 || snoozedUntil > now.Unix() {
+			continue
+		}
+
+		cur := p.stage(d, now, loc)
+		overdue := cur == len(p.Escalate)+1
+		snoozeExpired := snoozedUntil > 0 && snoozedUntil <= now.Unix() && lastAlerted < snoozedUntil
+		repeat := overdue && stage == cur && p.RepeatOverdue > 0 && now.Sub(time.Unix(lastAlerted, 0)) >= p.RepeatOverdue
+		if cur <= stage && !snoozeExpired && !repeat {
+			continue
+		}
+
+		out = append(out, Alert{Deadline: d, Ref: ShortRef(d.UID), Overdue: overdue, Escalated: stage >= 0})
+		if commit {
+			_, err := s.db.Exec(`INSERT INTO alerts (key, uid, summary, due, stage, last_alerted) VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(key) DO UPDATE SET summary = excluded.summary, stage = excluded.stage, last_alerted = excluded.last_alerted`,
+				key, d.UID, d.Summary, d.Due.Unix(), cur, now.Unix())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// resolve finds the UIDs matching ref: a short ref, full UID, or a
+// case-insensitive piece of the summary. Ambiguous matches are an error.
+func (s *Store) resolve(ref string) (map[string]string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("ref is required (alert id or part of the title)")
+	}
+	rows, err := s.db.Query("SELECT DISTINCT uid, summary FROM alerts UNION SELECT uid, summary FROM mutes")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lower := strings.ToLower(ref)
+	matches := map[string]string{}
+	for rows.Next() {
+		var uid, summary string
+		if err := rows.Scan(&uid, &summary); err != nil {
+			return nil, err
+		}
+		if uid == ref || ShortRef(uid) == lower || strings.Contains(strings.ToLower(summary), lower) {
+			matches[uid] = summary
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no alert matches %q", ref)
+	}
+	if len(matches) > 1 {
+		var names []string
+		for uid, summary := range matches {
+			names = append(names, fmt.Sprintf("%s [%s]", summary, ShortRef(uid)))
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%q matches several alerts: %s", ref, strings.Join(names, ", "))
+	}
+	return matches, nil
+}
+
+// Ack silences every announced occurrence of the matching deadline.
+// Future occurrences of a recurring task still alert.
+func (s *Store) Ack(ref string, now time.Time) (string, error) {
+	m, err := s.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	for uid, summary := range m {
+		if _, err := s.db.Exec("UPDATE alerts SET acked_at = ? WHERE uid = ? AND acked_at = 0", now.Unix(), uid); err != nil {
+			return "", err
+		}
+		return summary, nil
+	}
+	return "", nil
+}
+
+// Snooze suppresses the matching deadline until the given time.
+func (s *Store) Snooze(ref string, until time.Time) (string, error) {
+	m, err := s.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	for uid, summary := range m {
+		if _, err := s.db.Exec("UPDATE alerts SET snoozed_until = ? WHERE uid = ? AND acked_at = 0", until.Unix(), uid); err != nil {
+			return "", err
+		}
+		return summary, nil
+	}
+	return "", nil
+}
+
+// Mute silences a deadline UID permanently, including future occurrences.
+func (s *Store) Mute(ref string, now time.Time) (string, error) {
+	m, err := s.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	for uid, summary := range m {
+		if _, err := s.db.Exec("INSERT OR REPLACE INTO mutes (uid, summary, muted_at) VALUES (?, ?, ?)", uid, summary, now.Unix()); err != nil {
+			return "", err
+		}
+		return summary, nil
+	}
+	return "", nil
+}
+
+// Unmute reverses Mute.
+func (s *Store) Unmute(ref string) (string, error) {
+	m, err := s.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	for uid, summary := range m {
+		if _, err := s.db.Exec("DELETE FROM mutes WHERE uid = ?", uid); err != nil {
+			return "", err
+		}
+		return summary, nil
+	}
+	return "", nil
+}
+
+// List returns the latest occurrence of every announced deadline plus muted UIDs.
+func (s *Store) List() ([]AlertState, error) {
+	rows, err := s.db.Query(`
+		SELECT a.uid, a.summary, a.due, a.last_alerted, a.acked_at, a.snoozed_until, m.uid IS NOT NULL
+		FROM alerts a LEFT JOIN mutes m ON m.uid = a.uid
+		WHERE a.due = (SELECT MAX(due) FROM alerts b WHERE b.uid = a.uid)
+		UNION ALL
+		SELECT m.uid, m.summary, 0, 0, 0, 0, 1 FROM mutes m
+		WHERE NOT EXISTS (SELECT 1 FROM alerts a WHERE a.uid = m.uid)
+		ORDER BY 3`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AlertState
+	for rows.Next() {
+		var st AlertState
+		var due, last, acked, snoozed int64
+		var muted bool
+		if err := rows.Scan(&st.UID, &st.Summary, &due, &last, &acked, &snoozed, &muted); err != nil {
+			return nil, err
+		}
+		st.Ref = ShortRef(st.UID)
+		if due > 0 {
+			st.Due = time.Unix(due, 0)
+		}
+		if last > 0 {
+			st.LastAlerted = time.Unix(last, 0)
+		}
+		st.Acked = acked > 0
+		if snoozed > 0 {
+			st.SnoozedUntil = time.Unix(snoozed, 0)
+		}
+		st.Muted = muted
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// ParseSnooze accepts Go durations ("90m", "1h30m") plus days ("2d").
+func ParseSnooze(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30m, 2h, 1d)", s)
+	}
+	return d, nil
+}
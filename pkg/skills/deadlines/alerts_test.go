@@ -0,0 +1,110 @@
+package deadlines
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertStoreEscalationAndAck(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), AlertsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	feed := &Feed{Timezone: "UTC", Deadlines: []Deadline{
+		{UID: "bill-1234-abcd", Summary: "Pay electricity bill", Due: start.Add(90 * time.Minute)},
+		{UID: "rent-5678", Summary: "Pay rent", Due: start.Add(100 * time.Minute)},
+	}}
+	p := Policy{Window: 2 * time.Hour, Escalate: []time.Duration{time.Hour, 15 * time.Minute}, RepeatOverdue: 24 * time.Hour}
+
+	pending := func(now time.Time) []string {
+		t.Helper()
+		alerts, err := store.Pending(feed, now, p, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var uids []string
+		for _, a := range alerts {
+			uids = append(uids, a.UID)
+		}
+		return uids
+	}
+
+	if got := pending(start); len(got) != 2 {
+		t.Fatalf("first heartbeat: %v, want both", got)
+	}
+	if got := pending(start.Add(10 * time.Minute)); len(got) != 0 {
+		t.Fatalf("repeat heartbeat should be quiet, got %v", got)
+	}
+	if got := pending(start.Add(31 * time.Minute)); len(got) != 1 || got[0] != "bill-1234-abcd" {
+		t.Fatalf("1h escalation: %v", got)
+	}
+
+	if _, err := store.Ack("electricity", start.Add(32*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Snooze(ShortRef("rent-5678"), start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := pending(start.Add(80 * time.Minute)); len(got) != 0 {
+		t.Fatalf("acked and snoozed alerts should be quiet, got %v", got)
+	}
+	if got := pending(start.Add(2*time.Hour + time.Minute)); len(got) != 1 || got[0] != "rent-5678" {
+		t.Fatalf("expired snooze should re-alert: %v", got)
+	}
+	if got := pending(start.Add(3 * time.Hour)); len(got) != 0 {
+		t.Fatalf("overdue already announced, got %v", got)
+	}
+
+	if _, err := store.Ack("pay", start); err == nil {
+		t.Error("ambiguous ref should be an error")
+	}
+	if _, err := store.Mute("rent", start); err != nil {
+		t.Fatal(err)
+	}
+	if got := pending(start.Add(30 * time.Hour)); len(got) != 0 {
+		t.Fatalf("muted overdue item repeated: %v", got)
+	}
+}
+
+func TestParseSnooze(t *testing.T) {
+	for in, want := range map[string]time.Duration{"30m": 30 * time.Minute, "2h": 2 * time.Hour, "1d": 24 * time.Hour} {
+		if got, err := ParseSnooze(in); err != nil || got != want {
+			t.Errorf("ParseSnooze(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseSnooze("soon"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestShortRefTellsATCTasksApart(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), AlertsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	feed := &Feed{Timezone: "UTC", Deadlines: []Deadline{
+		{UID: "atc-task-1772445600000000001", Summary: "Submit visa form", Due: now.Add(30 * time.Minute)},
+		{UID: "atc-task-1772445600000000002", Summary: "Submit expense report", Due: now.Add(40 * time.Minute)},
+	}}
+	alerts, err := store.Pending(feed, now, Policy{Window: 2 * time.Hour}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 || alerts[0].Ref == alerts[1].Ref {
+		t.Fatalf("refs not distinct: %+v", alerts)
+	}
+	summary, err := store.Ack(alerts[1].Ref, now)
+	if err != nil {
+		t.Fatalf("ack by ref: %v", err)
+	}
+	if summary != "Submit expense report" {
+		t.Errorf("acked %q", summary)
+	}
+}
@@ -0,0 +1,122 @@
+// Package deadlines defines the machine-readable deadlines feed that
+// Architect writes (memory/deadlines.json) and Chief and the gateway
+// heartbeat read.
+package deadlines
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileName is the feed's name inside an agent's memory directory.
+const FileName = "deadlines.json"
+
+// DefaultAlertWindow is used when tools.deadlines.alert_window_minutes is unset.
+const DefaultAlertWindow = 2 * time.Hour
+
+// Deadline is one open task or event with a due time.
+type Deadline struct {
+	UID      string    `json:"uid"`
+	Summary  string    `json:"summary"`
+	Due      time.Time `json:"due"`
+	AllDay   bool      `json:"all_day"`  // Due is midnight of the due date in the feed timezone
+	Priority int       `json:"priority"` // RFC 5545: 0 undefined, 1 highest … 9 lowest
+	Status   string    `json:"status"`   // NEEDS-ACTION, IN-PROCESS …
+	Source   string    `json:"source"`   // "tasks" or "calendar"
+}
+
+// Feed is the content of deadlines.json.
+type Feed struct {
+	Generated time.Time  `json:"generated"`
+	Timezone  string     `json:"timezone"`
+	Deadlines []Deadline `json:"deadlines"` // sorted by Due
+}
+
+// Write saves the feed atomically.
+func Write(path string, f *Feed) error {
+	sort.SliceStable(f.Deadlines, func(i, j int) bool { return f.Deadlines[i].Due.Before(f.Deadlines[j].Due) })
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Read loads a feed from disk.
+func Read(path string) (*Feed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Feed
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Find reads the feed from the first of the given memory directories that has one.
+func Find(memDirs ...string) (*Feed, error) {
+	var firstErr error
+	for _, dir := range memDirs {
+		f, err := Read(filepath.Join(dir, FileName))
+		if err == nil {
+			return f, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// Location returns the timezone all-day deadlines were computed in.
+func (f *Feed) Location() *time.Location {
+	if f.Timezone != "" {
+		if loc, err := time.LoadLocation(f.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// Urgent returns deadlines that are overdue or due within window of now.
+// All-day deadlines are urgent for their whole due date.
+func (f *Feed) Urgent(now time.Time, window time.Duration) []Deadline {
+	loc := f.Location()
+	today := now.In(loc).Format("2006-01-02")
+	limit := now.Add(window)
+
+	var out []Deadline
+	for _, d := range f.Deadlines {
+		if d.AllDay {
+			if d.Due.In(loc).Format("2006-01-02") <= today {
+				out = append(out, d)
+			}
+			continue
+		}
+		if !d.Due.After(limit) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Overdue reports whether d was due before now. An all-day deadline is
+// overdue only once its date has passed.
+func (d Deadline) Overdue(now time.Time, loc *time.Location) bool {
+	if d.AllDay {
+		return d.Due.In(loc).Format("2006-01-02") < now.In(loc).Format("2006-01-02")
+	}
+	return d.Due.Before(now)
+}
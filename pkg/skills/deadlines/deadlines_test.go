@@ -0,0 +1,92 @@
+package deadlines
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/caldav"
+)
+
+func TestUrgentWindow(t *testing.T) {
+	dhaka, _ := time.LoadLocation("Asia/Dhaka")
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, dhaka)
+
+	feed := &Feed{Timezone: "Asia/Dhaka", Deadlines: []Deadline{
+		{UID: "overdue", Due: now.Add(-26 * time.Hour)},
+		{UID: "soon", Due: now.Add(90 * time.Minute)},
+		{UID: "later", Due: now.Add(3 * time.Hour)},
+		{UID: "today-allday", Due: time.Date(2026, 3, 2, 0, 0, 0, 0, dhaka), AllDay: true},
+		{UID: "tomorrow-allday", Due: time.Date(2026, 3, 3, 0, 0, 0, 0, dhaka), AllDay: true},
+	}}
+
+	got := map[string]bool{}
+	for _, d := range feed.Urgent(now, 2*time.Hour) {
+		got[d.UID] = true
+	}
+	for _, uid := range []string{"overdue", "soon", "today-allday"} {
+		if !got[uid] {
+			t.Errorf("%s should be urgent", uid)
+		}
+	}
+	for _, uid := range []string{"later", "tomorrow-allday"} {
+		if got[uid] {
+			t.Errorf("%s should not be urgent with a 2h window", uid)
+		}
+	}
+
+	if n := len(feed.Urgent(now, 4*time.Hour)); n != 4 {
+		t.Errorf("4h window: got %d urgent, want 4", n)
+	}
+	if feed.Deadlines[3].Overdue(now, dhaka) {
+		t.Error("an all-day deadline is not overdue on its own date")
+	}
+}
+
+func TestWriteAndFind(t *testing.T) {
+	dir := t.TempDir()
+	due := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	feed := &Feed{Deadlines: []Deadline{
+		{UID: "b", Due: due.Add(time.Hour)},
+		{UID: "a", Due: due, Priority: 1},
+	}}
+	if err := Write(filepath.Join(dir, "arch", FileName), feed); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Find(filepath.Join(dir, "missing"), filepath.Join(dir, "arch"))
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(got.Deadlines) != 2 || got.Deadlines[0].UID != "a" || !got.Deadlines[0].Due.Equal(due) {
+		t.Errorf("round trip = %+v", got.Deadlines)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "arch", FileName+".tmp")); !os.IsNotExist(err) {
+		t.Error("temporary file left behind")
+	}
+}
+
+func TestParseICSTime(t *testing.T) {
+	dhaka, _ := time.LoadLocation("Asia/Dhaka")
+	cases := []struct {
+		value, params string
+		want          time.Time
+		allDay        bool
+	}{
+		{"20260302", "VALUE=DATE", time.Date(2026, 3, 2, 0, 0, 0, 0, dhaka), true},
+		{"20260302T150000Z", "", time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC), false},
+		{"20260302T150000", "TZID=Europe/Berlin", time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC), false},
+		{"20260302T150000", "", time.Date(2026, 3, 2, 15, 0, 0, 0, dhaka), false},
+	}
+	for _, c := range cases {
+		got, allDay, err := caldav.ParseICSTime(c.value, c.params, dhaka)
+		if err != nil {
+			t.Errorf("%s;%s: %v", c.value, c.params, err)
+			continue
+		}
+		if !got.Equal(c.want) || allDay != c.allDay {
+			t.Errorf("%s;%s = %v (all-day %v), want %v (all-day %v)", c.value, c.params, got, allDay, c.want, c.allDay)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package health records the outcome of every skill command so the status
+// report can show when each skill last succeeded and what last went wrong.
+package health
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// maxErrorLen caps the stored error message, in bytes.
+const maxErrorLen = 300
+
+// FileName is the run log inside an agent's memory directory.
+const FileName = "runs.json"
+
+// CommandRuns summarises one command's history.
+type CommandRuns struct {
+	LastRun     time.Time `json:"last_run"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+	Runs        int       `json:"runs"`
+	Failures    int       `json:"failures"`
+}
+
+// Log is the content of runs.json, keyed by command.
+type Log struct {
+	Commands map[string]*CommandRuns `json:"commands"`
+}
+
+var mu sync.Mutex
+
+// Record notes the result of a command in workspace/memory/runs.json.
+// It never fails the command: write errors are ignored.
+func Record(workspace, command string, result *tools.ToolResult) {
+	if workspace == "" || command == "" || result == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	log, _ := Load(workspace)
+	c := log.Commands[command]
+	if c == nil {
+		c = &CommandRuns{}
+		log.Commands[command] = c
+	}
+
+	now := time.Now()
+	c.LastRun = now
+	c.Runs++
+	if result.IsError {
+		c.Failures++
+		c.LastError = result.ForLLM
+		if len(c.LastError) > maxErrorLen {
+			n := maxErrorLen
+			for n > 0 && !utf8.RuneStart(c.LastError[n]) {
+				n-- // don't cut a multi-byte character in half
+			}
+			c.LastError = c.LastError[:n] + "…"
+		}
+		c.LastErrorAt = now
+	} else {
+		c.LastSuccess = now
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(workspace, "memory", FileName)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// Load reads a workspace's run log. A missing file yields an empty log.
+func Load(workspace string) (*Log, error) {
+	log := &Log{Commands: map[string]*CommandRuns{}}
+	data, err := os.ReadFile(filepath.Join(workspace, "memory", FileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return log, nil
+		}
+		return log, err
+	}
+	if err := json.Unmarshal(data, log); err != nil {
+		return &Log{Commands: map[string]*CommandRuns{}}, err
+	}
+	if log.Commands == nil {
+		log.Commands = map[string]*CommandRuns{}
+	}
+	return log, nil
+}
+
+// LastSuccess returns the most recent successful command and when it ran.
+func (l *Log) LastSuccess() (string, time.Time) {
+	var name string
+	var at time.Time
+	for cmd, c := range l.Commands {
+		if c.LastSuccess.After(at) {
+			name, at = cmd, c.LastSuccess
+		}
+	}
+	return name, at
+}
+
+// LastError returns the most recent failing command, its message and time.
+func (l *Log) LastError() (string, string, time.Time) {
+	var name, msg string
+	var at time.Time
+	for cmd, c := range l.Commands {
+		if c.LastErrorAt.After(at) {
+			name, msg, at = cmd, c.LastError, c.LastErrorAt
+		}
+	}
+	return name, msg, at
+}
@@ -0,0 +1,27 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestRecordTruncatesOnRuneBoundary(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "memory"), 0755)
+	msg := "❌" + strings.Repeat("é", 200) // 3 + 400 bytes; byte 300 is mid-rune
+	Record(ws, "sync_tasks", tools.ErrorResult(msg))
+
+	log, err := Load(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, got, _ := log.LastError()
+	if !utf8.ValidString(got) || len(got) > maxErrorLen+len("…") || !strings.HasPrefix(msg, strings.TrimSuffix(got, "…")) {
+		t.Errorf("last error = %q (%d bytes)", got, len(got))
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/hbollon/go-edlib"
 	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/health"
 	"github.com/mmcdole/gofeed"
 	"github.com/sipeed/picoclaw/pkg/tools"
 	"golang.org/x/sync/errgroup"
@@ -254,8 +255,9 @@ func (s *MonitorSkill) Parameters() map[string]interface{} {
 }
 
 // Execute runs the monitor command
-func (s *MonitorSkill) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+func (s *MonitorSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
 	command, _ := args["command"].(string)
+	defer func() { health.Record(s.workspace, command, result) }()
 
 	switch command {
 	case "fetch":
@@ -1138,6 +1140,21 @@ func (s *MonitorSkill) loadFeeds() {
 	}
 }
 
+// Feeds returns the active feeds from config.json, feeds.opml or the defaults.
+func (s *MonitorSkill) Feeds() []Feed {
+	s.loadFeeds()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []Feed
+	for _, f := range s.feeds {
+		if f.Active {
+			active = append(active, f)
+		}
+	}
+	return active
+}
+
 func getString(m map[string]interface{}, key, def string) string {
 	if v, ok := m[key].(string); ok {
 		return v
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/health"
 	"github.com/mtreilly/goarxiv"
 	"github.com/sipeed/picoclaw/pkg/tools"
 	"golang.org/x/net/html"
@@ -209,8 +210,9 @@ func (s *ResearchSkill) initWorkspace() {
 	}
 }
 
-func (s *ResearchSkill) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+func (s *ResearchSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
 	command, _ := args["command"].(string)
+	defer func() { health.Record(s.workspace, command, result) }()
 
 	switch command {
 	case "fetch":
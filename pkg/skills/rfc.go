@@ -187,6 +187,45 @@ func WriteRFCFile(path, agent, ttl string, newLines []string) error {
 	return os.Rename(tmp, path)
 }
 
+// RFCHeader is the metadata block at the top of an RFC cache file.
+type RFCHeader struct {
+	Agent string
+	TS    time.Time
+	TTL   time.Duration
+	Count int
+}
+
+// ReadRFCHeader reads an RFC cache file's header without TTL-checking or
+// garbage-collecting it.
+func ReadRFCHeader(path string) (RFCHeader, error) {
+	var h RFCHeader
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, val, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch key {
+		case "AGENT":
+			h.Agent = val
+		case "TS":
+			h.TS, _ = time.Parse(time.RFC3339, val)
+		case "TTL":
+			h.TTL = ParseTTL(val)
+		case "COUNT":
+			h.Count, _ = strconv.Atoi(val)
+		}
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+	}
+	return h, nil
+}
+
 // extractUUID12 pulls the uuid12 from a record line like "[type:uuid12:tag] ..."
 func extractUUID12(line string) string {
 	end := strings.Index(line, "]")
@@ -199,3 +238,43 @@ func extractUUID12(line string) string {
 	}
 	return parts[1]
 }
+
+// Record is one decoded RFC cache line.
+type Record struct {
+	Type  string
+	ID    string
+	Tag   string
+	Title string
+	Date  string // YYYYMMDD
+	URL   string
+	Line  string // the original encoded line
+}
+
+// DecodeRecord parses a line produced by EncodeRecord. Malformed lines
+// still return a Record carrying the raw line as its title.
+func DecodeRecord(line string) Record {
+	rec := Record{Line: line, Title: line}
+	end := strings.Index(line, "]")
+	if end < 0 || !strings.HasPrefix(line, "[") {
+		return rec
+	}
+	head := strings.SplitN(line[1:end], ":", 3)
+	rec.Type = head[0]
+	if len(head) > 1 {
+		rec.ID = head[1]
+	}
+	if len(head) > 2 {
+		rec.Tag = head[2]
+	}
+
+	// Title may not contain '|' (sanitizeField), but the URL may.
+	body := strings.SplitN(strings.TrimSpace(line[end+1:]), " | ", 3)
+	rec.Title = strings.TrimSpace(body[0])
+	if len(body) > 1 {
+		rec.Date = strings.TrimSpace(body[1])
+	}
+	if len(body) > 2 {
+		rec.URL = strings.TrimSpace(body[2])
+	}
+	return rec
+}
@@ -0,0 +1,568 @@
+package search
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+	"github.com/jony/son-of-anthon/pkg/sqlite"
+)
+
+// Document types.
+const (
+	TypeBrief    = "brief"
+	TypeMemory   = "memory"
+	TypeNews     = "news"
+	TypePaper    = "paper"
+	TypeDeadline = "deadline"
+	TypeSnapshot = "snapshot"
+)
+
+// Types lists every document type, for the tool schema.
+var Types = []string{TypeBrief, TypeMemory, TypeNews, TypePaper, TypeDeadline, TypeSnapshot}
+
+// IndexFileName is the index database, kept in <root>/search/.
+const IndexFileName = "search.db"
+
+// Index is an SQLite FTS5 index over a workspace root (the directory that
+// holds chief/, atc/, monitor/ …).
+type Index struct {
+	db   *sql.DB
+	root string
+}
+
+// Doc is one indexed document.
+type Doc struct {
+	Key    string // unique: file path, path#record or news:url
+	Source string // file the document came from, relative to root
+	Agent  string
+	Type   string
+	Date   string // YYYY-MM-DD
+	Title  string
+	Body   string
+	URL    string
+}
+
+// Hit is a search result.
+type Hit struct {
+	Doc
+	Snippet string
+}
+
+// Query filters a search. Empty fields do not filter.
+type Query struct {
+	Text  string
+	Agent string
+	Type  string
+	From  string // YYYY-MM-DD inclusive
+	To    string // YYYY-MM-DD inclusive
+	Limit int
+}
+
+// Open opens (creating if needed) the index for root.
+func Open(root string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Join(root, "search"), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.Open(filepath.Join(root, "search", IndexFileName))
+	if err != nil {
+		return nil, err
+	}
+	schema := `
+	CREATE TABLE IF NOT EXISTS docs (
+		id INTEGER PRIMARY KEY,
+		key TEXT UNIQUE NOT NULL,
+		source TEXT NOT NULL,
+		agent TEXT,
+		type TEXT,
+		date TEXT,
+		title TEXT,
+		body TEXT,
+		url TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_docs_source ON docs(source);
+	CREATE INDEX IF NOT EXISTS idx_docs_date ON docs(date);
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS docs_fts USING fts5(
+		title, body, content='docs', content_rowid='id', tokenize='unicode61'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS docs_ai AFTER INSERT ON docs BEGIN
+		INSERT INTO docs_fts(rowid, title, body) VALUES (new.id, new.title, new.body);
+	END;
+	CREATE TRIGGER IF NOT EXISTS docs_ad AFTER DELETE ON docs BEGIN
+		INSERT INTO docs_fts(docs_fts, rowid, title, body) VALUES ('delete', old.id, old.title, old.body);
+	END;
+
+	CREATE TABLE IF NOT EXISTS files (
+		path TEXT PRIMARY KEY,
+		mtime INTEGER,
+		size INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS meta (
+		key TEXT PRIMARY KEY,
+		value TEXT
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create search index (FTS5 required): %w", err)
+	}
+	return &Index{db: db, root: root}, nil
+}
+
+// Close releases the database.
+func (ix *Index) Close() error {
+	return ix.db.Close()
+}
+
+// Stats reports the number of indexed documents per type.
+func (ix *Index) Stats() (map[string]int, error) {
+	rows, err := ix.db.Query("SELECT type, COUNT(*) FROM docs GROUP BY type")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var t string
+		var n int
+		if err := rows.Scan(&t, &n); err != nil {
+			return nil, err
+		}
+		out[t] = n
+	}
+	return out, rows.Err()
+}
+
+// Reset drops every document so the next Update rebuilds from scratch.
+func (ix *Index) Reset() error {
+	_, err := ix.db.Exec("DELETE FROM docs; DELETE FROM files; DELETE FROM meta; INSERT INTO docs_fts(docs_fts) VALUES ('rebuild');")
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// Indexing
+// ----------------------------------------------------------------------------
+
+// Update indexes new and changed files under <agent>/memory/, every
+// MEMORY.md, and new Monitor items, and forgets files that disappeared.
+// It returns the number of files (re)indexed.
+func (ix *Index) Update() (int, error) {
+	seen := map[string]bool{}
+	changed := 0
+
+	err := filepath.WalkDir(ix.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(ix.root, path)
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && rel != "." {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !indexable(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[rel] = true
+
+		var mtime, size int64
+		err = ix.db.QueryRow("SELECT mtime, size FROM files WHERE path = ?", rel).Scan(&mtime, &size)
+		if err == nil && mtime == info.ModTime().UnixNano() && size == info.Size() {
+			return nil
+		}
+		if err := ix.indexFile(rel, info.ModTime()); err != nil {
+			return err
+		}
+		changed++
+		_, err = ix.db.Exec("INSERT OR REPLACE INTO files (path, mtime, size) VALUES (?, ?, ?)", rel, info.ModTime().UnixNano(), info.Size())
+		return err
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	rows, err := ix.db.Query("SELECT path FROM files")
+	if err != nil {
+		return changed, err
+	}
+	var gone []string
+	for rows.Next() {
+		var p string
+		rows.Scan(&p)
+		if !seen[p] {
+			gone = append(gone, p)
+		}
+	}
+	rows.Close()
+	for _, p := range gone {
+		ix.db.Exec("DELETE FROM docs WHERE source = ? AND "+keepHistory, p)
+		ix.db.Exec("DELETE FROM files WHERE path = ?", p)
+	}
+
+	if err := ix.indexMonitorDB(); err != nil {
+		return changed, err
+	}
+	return changed, nil
+}
+
+// keepHistory excludes news and paper records from per-file deletes: RFC
+// caches are garbage-collected after their TTL but the records stay searchable.
+const keepHistory = "type NOT IN ('news', 'paper')"
+
+// indexable reports whether a path relative to root belongs in the index.
+func indexable(rel string) bool {
+	name := filepath.Base(rel)
+	if name == "MEMORY.md" {
+		return true
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 3 || parts[1] != "memory" {
+		return false
+	}
+	if strings.HasSuffix(name, ".tmp") || name == "runs.json" {
+		return false
+	}
+	switch filepath.Ext(name) {
+	case ".md", ".txt", ".json":
+		return true
+	}
+	return false
+}
+
+var (
+	dashDate    = regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})`)
+	compactDate = regexp.MustCompile(`(\d{4})(\d{2})(\d{2})`)
+)
+
+// dateFromName extracts YYYY-MM-DD or YYYYMMDD from a file name.
+func dateFromName(name string) string {
+	if m := dashDate.FindStringSubmatch(name); m != nil {
+		return m[0]
+	}
+	if m := compactDate.FindStringSubmatch(name); m != nil {
+		return m[1] + "-" + m[2] + "-" + m[3]
+	}
+	return ""
+}
+
+func (ix *Index) indexFile(rel string, mtime time.Time) error {
+	if _, err := ix.db.Exec("DELETE FROM docs WHERE source = ? AND "+keepHistory, rel); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(ix.root, rel))
+	if err != nil {
+		return nil
+	}
+
+	agent := strings.Split(filepath.ToSlash(rel), "/")[0]
+	name := filepath.Base(rel)
+	date := dateFromName(name)
+	if date == "" {
+		date = mtime.Format("2006-01-02")
+	}
+	base := Doc{Key: rel, Source: rel, Agent: agent, Date: date, Title: name}
+
+	switch {
+	case strings.HasPrefix(name, "news-") && strings.HasSuffix(name, ".md"):
+		return ix.indexRecords(base, data, TypeNews)
+	case strings.HasPrefix(name, "research-") && strings.HasSuffix(name, ".md"):
+		return ix.indexRecords(base, data, TypePaper)
+	case name == deadlines.FileName:
+		return ix.indexDeadlines(base, filepath.Join(ix.root, rel))
+	case filepath.Ext(name) == ".json":
+		base.Type = TypeMemory
+		if filepath.Base(filepath.Dir(rel)) == "snapshots" {
+			base.Type = TypeSnapshot
+			base.Title = "Day snapshot " + date
+		}
+		var v interface{}
+		if json.Unmarshal(data, &v) != nil {
+			return nil
+		}
+		base.Body = strings.Join(jsonStrings(v, nil), "\n")
+		return ix.insert(base)
+	}
+
+	text := string(data)
+	base.Type = TypeMemory
+	switch {
+	case isBrief(name):
+		base.Type = TypeBrief
+	case strings.HasPrefix(name, "deadlines"):
+		base.Type = TypeDeadline
+	}
+	if h := firstHeading(text); h != "" {
+		base.Title = h
+	}
+	base.Body = text
+	return ix.insert(base)
+}
+
+func isBrief(name string) bool {
+	for _, p := range []string{"morning-brief-", "evening-review-", "weekly-review-", "monthly-review-"} {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstHeading(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+	}
+	return ""
+}
+
+// jsonStrings collects every string value (and map key with a scalar
+// value) from decoded JSON.
+func jsonStrings(v interface{}, out []string) []string {
+	switch t := v.(type) {
+	case string:
+		if t != "" {
+			out = append(out, t)
+		}
+	case []interface{}:
+		for _, e := range t {
+			out = jsonStrings(e, out)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch t[k].(type) {
+			case float64, bool:
+				out = append(out, k)
+			}
+			out = jsonStrings(t[k], out)
+		}
+	}
+	return out
+}
+
+// indexRecords stores each RFC record line as its own document, keyed by
+// URL so news seen in both the RFC cache and monitor.db is indexed once.
+func (ix *Index) indexRecords(base Doc, data []byte, typ string) error {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+		r := skills.DecodeRecord(line)
+		d := base
+		d.Type = typ
+		d.Key = typ + ":" + r.URL
+		if r.URL == "" {
+			d.Key = base.Source + "#" + r.ID
+		}
+		d.Title = r.Title
+		d.Body = r.Title + "\n" + r.Tag
+		d.URL = r.URL
+		if len(r.Date) == 8 {
+			d.Date = r.Date[:4] + "-" + r.Date[4:6] + "-" + r.Date[6:]
+		}
+		if err := ix.insert(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ix *Index) indexDeadlines(base Doc, path string) error {
+	feed, err := deadlines.Read(path)
+	if err != nil {
+		return nil
+	}
+	loc := feed.Location()
+	for _, dl := range feed.Deadlines {
+		d := base
+		d.Type = TypeDeadline
+		d.Key = base.Source + "#" + dl.UID
+		d.Title = dl.Summary
+		d.Body = fmt.Sprintf("%s\n%s %s", dl.Summary, dl.Status, dl.Source)
+		d.Date = dl.Due.In(loc).Format("2006-01-02")
+		if err := ix.insert(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexMonitorDB adds Monitor items ingested since the last update.
+func (ix *Index) indexMonitorDB() error {
+	path := filepath.Join(ix.root, "monitor", "monitor.db")
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	var since int64
+	var v string
+	if ix.db.QueryRow("SELECT value FROM meta WHERE key = 'monitor_ingested_at'").Scan(&v) == nil {
+		fmt.Sscan(v, &since)
+	}
+
+	mdb, err := sqlite.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer mdb.Close()
+	rows, err := mdb.Query(`SELECT id, COALESCE(source,''), COALESCE(category,''), COALESCE(url,''), COALESCE(title,''),
+		COALESCE(summary,''), COALESCE(published_at,0), COALESCE(ingested_at,0)
+		FROM items WHERE ingested_at > ? ORDER BY ingested_at`, since)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	latest := since
+	for rows.Next() {
+		var id, source, category, url, title, summary string
+		var published, ingested int64
+		if err := rows.Scan(&id, &source, &category, &url, &title, &summary, &published, &ingested); err != nil {
+			return err
+		}
+		ts := published
+		if ts <= 0 {
+			ts = ingested
+		}
+		key := TypeNews + ":" + url
+		if url == "" {
+			key = "monitor.db#" + id
+		}
+		d := Doc{
+			Key: key, Source: "monitor/monitor.db", Agent: "monitor", Type: TypeNews,
+			Date: time.Unix(ts, 0).Format("2006-01-02"), Title: title,
+			Body: strings.Join([]string{title, summary, source, category}, "\n"), URL: url,
+		}
+		if err := ix.insert(d); err != nil {
+			return err
+		}
+		if ingested > latest {
+			latest = ingested
+		}
+	}
+	if latest > since {
+		_, err = ix.db.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('monitor_ingested_at', ?)", fmt.Sprint(latest))
+	}
+	return err
+}
+
+func (ix *Index) insert(d Doc) error {
+	// REPLACE would bypass the delete trigger, so remove explicitly.
+	if _, err := ix.db.Exec("DELETE FROM docs WHERE key = ?", d.Key); err != nil {
+		return err
+	}
+	_, err := ix.db.Exec(`INSERT INTO docs (key, source, agent, type, date, title, body, url) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.Key, d.Source, d.Agent, d.Type, d.Date, d.Title, d.Body, d.URL)
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// Searching
+// ----------------------------------------------------------------------------
+
+// Search runs q against the index, best matches first.
+func (ix *Index) Search(q Query) ([]Hit, error) {
+	terms := queryTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query is empty")
+	}
+	hits, err := ix.search(q, strings.Join(terms, " "))
+	if err != nil || len(hits) > 0 || len(terms) == 1 {
+		return hits, err
+	}
+	// Nothing contains every word: fall back to any word, best ranked first.
+	return ix.search(q, strings.Join(terms, " OR "))
+}
+
+func (ix *Index) search(q Query, match string) ([]Hit, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	sqlText := `SELECT d.key, d.source, d.agent, d.type, d.date, d.title, d.url,
+		snippet(docs_fts, 1, '**', '**', '…', 16)
+		FROM docs_fts JOIN docs d ON d.id = docs_fts.rowid
+		WHERE docs_fts MATCH ?`
+	args := []interface{}{match}
+	if q.Agent != "" {
+		sqlText += " AND d.agent = ?"
+		args = append(args, q.Agent)
+	}
+	if q.Type != "" {
+		sqlText += " AND d.type = ?"
+		args = append(args, q.Type)
+	}
+	if q.From != "" {
+		sqlText += " AND d.date >= ?"
+		args = append(args, q.From)
+	}
+	if q.To != "" {
+		sqlText += " AND d.date <= ?"
+		args = append(args, q.To)
+	}
+	sqlText += " ORDER BY bm25(docs_fts, 5.0, 1.0), d.date DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := ix.db.Query(sqlText, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []Hit
+	for rows.Next() {
+		var h Hit
+		if err := rows.Scan(&h.Key, &h.Source, &h.Agent, &h.Type, &h.Date, &h.Title, &h.URL, &h.Snippet); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "at": true, "for": true, "about": true, "with": true,
+	"i": true, "me": true, "my": true, "we": true, "you": true, "it": true,
+	"did": true, "do": true, "does": true, "was": true, "is": true, "are": true, "were": true,
+	"when": true, "what": true, "which": true, "where": true, "who": true, "how": true,
+	"last": true, "see": true, "saw": true, "that": true, "this": true,
+}
+
+// queryTerms turns free text into FTS5 prefix terms ("bill" also finds
+// "bills" and "billing"), dropping question words such as "when did I".
+func queryTerms(text string) []string {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || r > 127)
+	}) {
+		if stopWords[w] {
+			continue
+		}
+		terms = append(terms, `"`+w+`"*`)
+	}
+	return terms
+}
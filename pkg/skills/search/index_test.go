@@ -0,0 +1,95 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills"
+	"github.com/jony/son-of-anthon/pkg/skills/deadlines"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexAndSearch(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "chief", "memory", "evening-review-2026-03-04.md"),
+		"# 🌙 Evening Review\n\n## Completed\n- Pay internet bill\n- Call mom\n")
+	write(t, filepath.Join(root, "chief", "memory", "snapshots", "2026-02-03.json"),
+		`{"date":"2026-02-03","completed":["Pay internet bill"],"evening":true}`)
+	write(t, filepath.Join(root, "coach", "memory", "MEMORY.md"), "IELTS band target is 7.5\n")
+	write(t, filepath.Join(root, "chief", "IDENTITY.md"), "internet bill identity noise\n")
+	if err := skills.WriteRFCFile(filepath.Join(root, "chief", "memory", "research-20260312.md"), "research", "24h", []string{
+		skills.EncodeRecord("paper", "https://arxiv.org/abs/2603.00001", "Adaptive RAG for long documents", "rag", "20260312"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	deadlines.Write(filepath.Join(root, "architect", "memory", deadlines.FileName), &deadlines.Feed{
+		Timezone:  "UTC",
+		Deadlines: []deadlines.Deadline{{UID: "u1", Summary: "Renew passport", Due: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), AllDay: true}},
+	})
+
+	ix, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	if n, err := ix.Update(); err != nil || n != 5 {
+		t.Fatalf("Update = %d, %v; want 5 files", n, err)
+	}
+
+	hits, err := ix.Search(Query{Text: "when did I last pay the internet bill?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want brief + snapshot: %+v", len(hits), hits)
+	}
+	for _, h := range hits {
+		if h.Agent != "chief" || h.Snippet == "" {
+			t.Errorf("hit = %+v", h)
+		}
+	}
+
+	hits, _ = ix.Search(Query{Text: "internet bill", Type: TypeBrief, From: "2026-03-01", To: "2026-03-31"})
+	if len(hits) != 1 || hits[0].Date != "2026-03-04" || hits[0].Title != "🌙 Evening Review" {
+		t.Errorf("filtered hits = %+v", hits)
+	}
+
+	hits, _ = ix.Search(Query{Text: "RAG", Type: TypePaper})
+	if len(hits) != 1 || hits[0].URL != "https://arxiv.org/abs/2603.00001" || hits[0].Date != "2026-03-12" {
+		t.Errorf("paper hits = %+v", hits)
+	}
+
+	hits, _ = ix.Search(Query{Text: "passport", Agent: "architect"})
+	if len(hits) != 1 || hits[0].Type != TypeDeadline || hits[0].Date != "2026-05-01" {
+		t.Errorf("deadline hits = %+v", hits)
+	}
+
+	// Unchanged files are skipped; a deleted RFC cache keeps its records.
+	os.Remove(filepath.Join(root, "chief", "memory", "research-20260312.md"))
+	os.Remove(filepath.Join(root, "coach", "memory", "MEMORY.md"))
+	if n, _ := ix.Update(); n != 0 {
+		t.Errorf("second Update reindexed %d files, want 0", n)
+	}
+	if hits, _ := ix.Search(Query{Text: "RAG"}); len(hits) != 1 {
+		t.Errorf("paper record lost after cache GC: %+v", hits)
+	}
+	if hits, _ := ix.Search(Query{Text: "IELTS"}); len(hits) != 0 {
+		t.Errorf("deleted MEMORY.md still indexed: %+v", hits)
+	}
+
+	if err := ix.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := ix.Search(Query{Text: "passport"}); len(hits) != 0 {
+		t.Errorf("Reset left %d hits", len(hits))
+	}
+}
@@ -0,0 +1,251 @@
+// Package search keeps a full-text index over every agent's memory, the
+// news and research caches and Monitor's database, and exposes it as the
+// "search" tool.
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jony/son-of-anthon/pkg/skills/health"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+type SearchSkill struct {
+	workspace string // workspace root holding chief/, atc/, monitor/ …
+	mu        sync.Mutex
+}
+
+func NewSkill() *SearchSkill {
+	return &SearchSkill{}
+}
+
+func (s *SearchSkill) Name() string {
+	return "search"
+}
+
+func (s *SearchSkill) Description() string {
+	return `Search - Full-text search across briefs, reviews, agent memory files, deadlines, day snapshots, news and research papers.
+
+Commands:
+- query (default): Search for text, e.g. "internet bill" or "RAG paper". Filters: agent, type (brief, memory, news, paper, deadline, snapshot), from / to (YYYY-MM-DD), limit. Returns snippets, dates and file paths, best matches first. The index refreshes itself before every query.
+- reindex: Rebuild the index from scratch.
+- stats: Show how many documents of each type are indexed.`
+}
+
+func (s *SearchSkill) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "Command to execute (default query)",
+				"enum":        []string{"query", "reindex", "stats"},
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words to search for",
+			},
+			"agent": map[string]interface{}{
+				"type":        "string",
+				"description": "Only results from this agent's workspace",
+				"enum":        []string{"architect", "atc", "chief", "coach", "monitor", "research"},
+			},
+			"type": map[string]interface{}{
+				"type":        "string",
+				"description": "Only results of this type",
+				"enum":        Types,
+			},
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "Earliest date, YYYY-MM-DD",
+			},
+			"to": map[string]interface{}{
+				"type":        "string",
+				"description": "Latest date, YYYY-MM-DD",
+			},
+			"limit": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum results (default 10)",
+			},
+		},
+	}
+}
+
+// SetWorkspace takes the workspace root, the parent of the agent workspaces.
+func (s *SearchSkill) SetWorkspace(ws string) {
+	s.workspace = ws
+	os.MkdirAll(ws, 0755)
+}
+
+func (s *SearchSkill) Execute(ctx context.Context, args map[string]interface{}) (result *tools.ToolResult) {
+	command, _ := args["command"].(string)
+	if command == "" {
+		command = "query"
+	}
+	defer func() { health.Record(filepath.Join(s.workspace, "search"), command, result) }()
+
+	switch command {
+	case "query":
+		return s.executeQuery(ctx, args)
+	case "reindex":
+		return s.executeReindex(ctx, args)
+	case "stats":
+		return s.executeStats(ctx, args)
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown command: %s", command))
+	}
+}
+
+// open returns an up-to-date index. Calls are serialised so concurrent
+// queries do not index the same files twice.
+func (s *SearchSkill) open(reset bool) (*Index, int, error) {
+	if s.workspace == "" {
+		return nil, 0, fmt.Errorf("search workspace not set")
+	}
+	ix, err := Open(s.workspace)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reset {
+		if err := ix.Reset(); err != nil {
+			ix.Close()
+			return nil, 0, err
+		}
+	}
+	n, err := ix.Update()
+	if err != nil {
+		ix.Close()
+		return nil, 0, err
+	}
+	return ix, n, nil
+}
+
+// ----------------------------------------------------------------------------
+// QUERY
+// ----------------------------------------------------------------------------
+
+func (s *SearchSkill) executeQuery(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	q := Query{}
+	q.Text, _ = args["query"].(string)
+	q.Agent, _ = args["agent"].(string)
+	q.Type, _ = args["type"].(string)
+	q.From, _ = args["from"].(string)
+	q.To, _ = args["to"].(string)
+	if l, ok := args["limit"].(float64); ok {
+		q.Limit = int(l)
+	}
+	if strings.TrimSpace(q.Text) == "" {
+		return tools.ErrorResult("query is required")
+	}
+	for _, d := range []string{q.From, q.To} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD", d))
+		}
+	}
+
+	ix, _, err := s.open(false)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Search index unavailable: %v", err))
+	}
+	defer ix.Close()
+
+	hits, err := ix.Search(q)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Search failed: %v", err))
+	}
+	if len(hits) == 0 {
+		msg := fmt.Sprintf("🔎 No results for %q%s.", q.Text, describeFilters(q))
+		return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔎 %d result(s) for %q%s:\n", len(hits), q.Text, describeFilters(q))
+	for i, h := range hits {
+		fmt.Fprintf(&sb, "\n%d. **%s** — %s · %s · %s\n", i+1, h.Title, h.Type, h.Agent, h.Date)
+		if snip := strings.Join(strings.Fields(h.Snippet), " "); snip != "" && snip != h.Title {
+			fmt.Fprintf(&sb, "   %s\n", snip)
+		}
+		fmt.Fprintf(&sb, "   📄 %s\n", filepath.Join(s.workspace, h.Source))
+		if h.URL != "" {
+			fmt.Fprintf(&sb, "   🔗 %s\n", h.URL)
+		}
+	}
+	out := sb.String()
+	return &tools.ToolResult{ForLLM: out, ForUser: out}
+}
+
+func describeFilters(q Query) string {
+	var f []string
+	if q.Agent != "" {
+		f = append(f, "agent "+q.Agent)
+	}
+	if q.Type != "" {
+		f = append(f, "type "+q.Type)
+	}
+	if q.From != "" {
+		f = append(f, "from "+q.From)
+	}
+	if q.To != "" {
+		f = append(f, "to "+q.To)
+	}
+	if len(f) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(f, ", ") + ")"
+}
+
+// ----------------------------------------------------------------------------
+// REINDEX / STATS
+// ----------------------------------------------------------------------------
+
+func (s *SearchSkill) executeReindex(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	ix, n, err := s.open(true)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Reindex failed: %v", err))
+	}
+	defer ix.Close()
+	msg := fmt.Sprintf("✅ Rebuilt search index from %d files.\n\n%s", n, formatStats(ix))
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func (s *SearchSkill) executeStats(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	ix, _, err := s.open(false)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Search index unavailable: %v", err))
+	}
+	defer ix.Close()
+	msg := formatStats(ix)
+	return &tools.ToolResult{ForLLM: msg, ForUser: msg}
+}
+
+func formatStats(ix *Index) string {
+	stats, err := ix.Stats()
+	if err != nil {
+		return fmt.Sprintf("Stats unavailable: %v", err)
+	}
+	types := make([]string, 0, len(stats))
+	total := 0
+	for t, n := range stats {
+		types = append(types, t)
+		total += n
+	}
+	sort.Strings(types)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📚 Search index: %d documents\n", total)
+	for _, t := range types {
+		fmt.Fprintf(&sb, "- %s: %d\n", t, stats[t])
+	}
+	return sb.String()
+}
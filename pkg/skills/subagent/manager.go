@@ -124,16 +124,21 @@ func (sm *SubagentManager) Spawn(ctx context.Context, task, label string, agentT
 	sm.tasks[taskID] = subagentTask
 	sm.mu.Unlock()
 
-	sm.runTask(ctx, subagentTask)
+	err := sm.runTask(context.WithValue(ctx, spawnedKey{}, agentType), subagentTask)
 
 	sm.mu.RLock()
 	finalResult := sm.tasks[taskID].Result
 	sm.mu.RUnlock()
 
+	if err != nil {
+		return finalResult, fmt.Errorf("%s (%s) failed: %w", taskID, agentType, err)
+	}
 	return finalResult, nil
 }
 
-func (sm *SubagentManager) runTask(ctx context.Context, task *SubagentTask) {
+// runTask runs the subagent's tool loop, records the outcome on task and
+// returns the loop's error, if any.
+func (sm *SubagentManager) runTask(ctx context.Context, task *SubagentTask) error {
 	task.Status = "running"
 
 	workspacePath := sm.getWorkspacePath(task.AgentType)
@@ -190,6 +195,7 @@ func (sm *SubagentManager) runTask(ctx context.Context, task *SubagentTask) {
 			Content:  announceContent,
 		})
 	}
+	return err
 }
 
 func (sm *SubagentManager) getWorkspacePath(agentType AgentType) string {
@@ -0,0 +1,135 @@
+package subagent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jony/son-of-anthon/workspaces"
+)
+
+// FallbackAgent handles tasks that match no specialist.
+const FallbackAgent = AgentATC
+
+// Route is the outcome of matching a task against the specialist agents.
+type Route struct {
+	Agent      AgentType
+	Confidence float64 // share of the total score held by Agent, 0..1
+	Fallback   bool    // no clear winner; Agent is FallbackAgent
+	Scores     map[AgentType]float64
+}
+
+// Route picks the specialist for task using the manager's workspaces.
+func (sm *SubagentManager) Route(task string) Route {
+	return RouteTask(task, sm.workspaceBase)
+}
+
+// RouteTask scores task against each agent's ValidAgents description
+// (weight 3 per matching word) and its TOOLS.md (weight 1), read from
+// workspaceBase/<agent>/TOOLS.md or the embedded default. Chief is never a
+// target. Ties and tasks without any match go to FallbackAgent.
+func RouteTask(task, workspaceBase string) Route {
+	words := tokenize(task)
+	r := Route{Scores: map[AgentType]float64{}}
+
+	var total float64
+	for agent, desc := range ValidAgents {
+		if agent == AgentChief {
+			continue
+		}
+		descWords := wordSet(desc)
+		toolWords := wordSet(agentTools(agent, workspaceBase))
+
+		var score float64
+		for w := range words {
+			if descWords[w] {
+				score += 3
+			} else if toolWords[w] {
+				score++
+			}
+		}
+		r.Scores[agent] = score
+		total += score
+	}
+
+	ranked := make([]AgentType, 0, len(r.Scores))
+	for agent := range r.Scores {
+		ranked = append(ranked, agent)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if r.Scores[ranked[i]] != r.Scores[ranked[j]] {
+			return r.Scores[ranked[i]] > r.Scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	best := ranked[0]
+	if total == 0 || r.Scores[best] == r.Scores[ranked[1]] {
+		r.Agent, r.Fallback = FallbackAgent, true
+		if total > 0 {
+			r.Confidence = r.Scores[FallbackAgent] / total
+		}
+		return r
+	}
+	r.Agent = best
+	r.Confidence = r.Scores[best] / total
+	return r
+}
+
+func agentTools(agent AgentType, workspaceBase string) string {
+	if workspaceBase != "" {
+		if data, err := os.ReadFile(filepath.Join(workspaceBase, string(agent), "TOOLS.md")); err == nil {
+			return string(data)
+		}
+	}
+	data, _ := workspaces.FS.ReadFile(string(agent) + "/TOOLS.md")
+	return string(data)
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "this": true,
+	"that": true, "you": true, "your": true, "are": true, "use": true, "all": true,
+	"can": true, "into": true, "about": true, "what": true, "when": true, "how": true,
+	"please": true, "need": true, "want": true, "some": true, "any": true, "not": true,
+	"tool": true, "tools": true, "available": true, "command": true, "commands": true,
+}
+
+func tokenize(text string) map[string]bool {
+	out := map[string]bool{}
+	for _, f := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(f) < 3 || stopWords[f] {
+			continue
+		}
+		out[stem(f)] = true
+	}
+	return out
+}
+
+func wordSet(text string) map[string]bool {
+	return tokenize(text)
+}
+
+// stem folds simple English plurals so "papers" matches "paper".
+func stem(w string) string {
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && len(w) > 3:
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+type spawnedKey struct{}
+
+// InSubagent reports whether ctx belongs to a running subagent. Delegating
+// from inside a subagent is refused to avoid agents spawning each other.
+func InSubagent(ctx context.Context) bool {
+	_, ok := ctx.Value(spawnedKey{}).(AgentType)
+	return ok
+}
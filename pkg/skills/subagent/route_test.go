@@ -0,0 +1,39 @@
+package subagent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRouteTask(t *testing.T) {
+	cases := map[string]AgentType{
+		"Find recent papers on GraphRAG":         AgentResearch,
+		"Pay the electricity bills this week":    AgentArchitect,
+		"Plan my IELTS study habit for the week": AgentCoach,
+		"What's the latest tech news?":           AgentMonitor,
+	}
+	for task, want := range cases {
+		r := RouteTask(task, "")
+		if r.Agent != want || r.Fallback {
+			t.Errorf("%q routed to %s (fallback %v, scores %v), want %s", task, r.Agent, r.Fallback, r.Scores, want)
+		}
+		if r.Confidence <= 0 || r.Confidence > 1 {
+			t.Errorf("%q confidence = %v", task, r.Confidence)
+		}
+	}
+
+	if r := RouteTask("zzz qqq", ""); !r.Fallback || r.Agent != FallbackAgent || r.Confidence != 0 {
+		t.Errorf("unmatched task = %+v, want fallback to %s", r, FallbackAgent)
+	}
+}
+
+func TestRouteTaskReadsWorkspaceTools(t *testing.T) {
+	base := t.TempDir()
+	os.MkdirAll(filepath.Join(base, "monitor"), 0755)
+	os.WriteFile(filepath.Join(base, "monitor", "TOOLS.md"), []byte("Track cricket scores"), 0644)
+
+	if r := RouteTask("cricket scores", base); r.Agent != AgentMonitor {
+		t.Errorf("routed to %s, want monitor from workspace TOOLS.md (scores %v)", r.Agent, r.Scores)
+	}
+}
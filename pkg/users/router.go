@@ -0,0 +1,163 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Builder creates a fresh, user-scoped set of tools for one profile.
+// It is called at most once per profile.
+type Builder func(p *Profile) []tools.Tool
+
+// Sessions lazily builds and caches one toolset per user.
+type Sessions struct {
+	dir      *Directory
+	build    Builder
+	mu       sync.Mutex
+	toolsets map[string]map[string]tools.Tool // profile name -> tool name -> tool
+}
+
+// NewSessions returns a session cache backed by the given directory.
+func NewSessions(dir *Directory, build Builder) *Sessions {
+	return &Sessions{
+		dir:      dir,
+		build:    build,
+		toolsets: make(map[string]map[string]tools.Tool),
+	}
+}
+
+// Directory returns the user directory the sessions resolve against.
+func (s *Sessions) Directory() *Directory {
+	return s.dir
+}
+
+// Tool returns the named tool bound to the given user, building the
+// user's toolset on first use.
+func (s *Sessions) Tool(p *Profile, name string) (tools.Tool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, ok := s.toolsets[p.Name]
+	if !ok {
+		set = make(map[string]tools.Tool)
+		for _, t := range s.build(p) {
+			set[t.Name()] = t
+		}
+		s.toolsets[p.Name] = set
+	}
+	t, ok := set[name]
+	return t, ok
+}
+
+// Wrap returns a Router that exposes fallback's schema but executes
+// against the calling user's own instance of the tool.
+func (s *Sessions) Wrap(fallback tools.Tool) *Router {
+	return &Router{fallback: fallback, sessions: s}
+}
+
+// chatKey is the context key for the chat a tool call came from.
+type chatKey struct{}
+
+// Chat identifies the conversation a tool call belongs to.
+type Chat struct {
+	Channel string
+	ChatID  string
+}
+
+// WithChat returns a context that routes tool calls to the user mapped to
+// the given chat.
+func WithChat(ctx context.Context, channel, chatID string) context.Context {
+	return context.WithValue(ctx, chatKey{}, Chat{Channel: channel, ChatID: chatID})
+}
+
+// ChatFrom returns the chat stored by WithChat, if any.
+func ChatFrom(ctx context.Context) (Chat, bool) {
+	c, ok := ctx.Value(chatKey{}).(Chat)
+	return c, ok
+}
+
+// Router is a tools.ContextualTool that dispatches each call to the
+// per-user instance of a skill based on the chat that triggered it.
+//
+// picoclaw's tool registry reports the chat through SetContext and then
+// calls Execute, both on the goroutine running the tool call. The router
+// parks the chat under that goroutine until its Execute picks it up, so
+// concurrent calls from different chats never see each other's chat and
+// never wait on each other. A chat already in the context (WithChat) wins.
+// The user's own skill receives the chat in the context, not through its
+// SetContext, so two calls from the same user do not race either.
+//
+// Local CLI calls (channel "cli") run against the fallback instance so the
+// operator keeps single-user behaviour. Calls without a chat, and chats that
+// are not mapped to any user, are rejected rather than silently sharing the
+// fallback data.
+type Router struct {
+	fallback tools.Tool
+	sessions *Sessions
+	pending  sync.Map // goroutine id -> Chat, from SetContext until Execute
+}
+
+func (r *Router) Name() string {
+	return r.fallback.Name()
+}
+
+func (r *Router) Description() string {
+	return r.fallback.Description()
+}
+
+func (r *Router) Parameters() map[string]interface{} {
+	return r.fallback.Parameters()
+}
+
+// SetContext records the chat for the Execute that follows on this goroutine.
+func (r *Router) SetContext(channel, chatID string) {
+	r.pending.Store(goroutineID(), Chat{Channel: channel, ChatID: chatID})
+}
+
+// Execute resolves the user from the call's chat and runs their tool.
+func (r *Router) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	parked, hasParked := r.pending.LoadAndDelete(goroutineID())
+	chat, ok := ChatFrom(ctx)
+	if !ok && hasParked {
+		chat = parked.(Chat)
+		ctx = WithChat(ctx, chat.Channel, chat.ChatID)
+	}
+	target, err := r.resolve(chat)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	return target.Execute(ctx, args)
+}
+
+// goroutineID parses the current goroutine's id from its stack header,
+// "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	head := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	id, _ := strconv.ParseUint(head[:strings.IndexByte(head, ' ')], 10, 64)
+	return id
+}
+
+func (r *Router) resolve(chat Chat) (tools.Tool, error) {
+	if r.sessions.dir.Len() == 0 || chat.Channel == "cli" {
+		return r.fallback, nil
+	}
+	if chat.Channel == "" {
+		return nil, fmt.Errorf("tool %s was called without a chat; cannot tell which user it is for", r.Name())
+	}
+	p, ok := r.sessions.dir.Lookup(chat.ChatID)
+	if !ok {
+		return nil, fmt.Errorf("chat %s is not mapped to any user in config.json users", chat.ChatID)
+	}
+	t, ok := r.sessions.Tool(p, r.Name())
+	if !ok {
+		return nil, fmt.Errorf("tool %s is not available for user %s", r.Name(), p.Name)
+	}
+	return t, nil
+}
@@ -0,0 +1,152 @@
+// Package users maps Telegram chats to isolated per-person contexts so that
+// several people can share one gateway without sharing tasks, briefs or streaks.
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Nextcloud holds one user's CalDAV/WebDAV credentials.
+type Nextcloud struct {
+	Host     string `json:"host"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Timeout  int    `json:"timeout_seconds"`
+}
+
+// Profile is a single user's context: where their data lives, which
+// Nextcloud account they sync with and which timezone their day runs in.
+type Profile struct {
+	Name      string    `json:"name"`
+	IDs       []string  `json:"ids"`       // Telegram chat or user IDs that belong to this user
+	Workspace string    `json:"workspace"` // Root holding chief/, atc/, coach/ ... for this user
+	Timezone  string    `json:"timezone"`  // IANA name, e.g. Asia/Dhaka
+	Nextcloud Nextcloud `json:"nextcloud"`
+	Email     string    `json:"email"` // Recipient of this user's emailed briefs
+}
+
+// Location returns the user's timezone, falling back to the process local zone.
+func (p *Profile) Location() *time.Location {
+	if p == nil || p.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ChatID returns the primary chat ID used for outbound nudges.
+func (p *Profile) ChatID() string {
+	if p == nil || len(p.IDs) == 0 {
+		return ""
+	}
+	return p.IDs[0]
+}
+
+// ErrNoNextcloud is the error for a Nextcloud command run by a user without
+// their own account. The shared tools.nextcloud account belongs to the
+// operator, so it is never used on another user's behalf.
+func (p *Profile) ErrNoNextcloud() error {
+	return fmt.Errorf("user %s has no Nextcloud account; add \"nextcloud\" to their entry under users in config.json", p.Name)
+}
+
+// AgentWorkspace returns the workspace directory of one agent for this user.
+func (p *Profile) AgentWorkspace(agent string) string {
+	return filepath.Join(p.Workspace, agent)
+}
+
+// Directory is the set of configured users indexed by chat/user ID.
+type Directory struct {
+	profiles []*Profile
+	byID     map[string]*Profile
+}
+
+// Load reads the "users" section from config.json. A missing file or an
+// empty section yields an empty directory, which keeps single-user mode.
+func Load(configPath string) (*Directory, error) {
+	d := &Directory{byID: make(map[string]*Profile)}
+
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		Users []*Profile `json:"users"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse users: %w", err)
+	}
+
+	home, _ := os.UserHomeDir()
+	for _, p := range cfg.Users {
+		if p.Name == "" {
+			return nil, fmt.Errorf("user entry without name")
+		}
+		if len(p.IDs) == 0 {
+			return nil, fmt.Errorf("user %s has no ids", p.Name)
+		}
+		if p.Workspace == "" {
+			p.Workspace = filepath.Join(home, ".picoclaw", "users", p.Name)
+		} else if strings.HasPrefix(p.Workspace, "~/") {
+			p.Workspace = filepath.Join(home, p.Workspace[2:])
+		}
+		if p.Timezone != "" {
+			if _, err := time.LoadLocation(p.Timezone); err != nil {
+				return nil, fmt.Errorf("user %s: invalid timezone %q: %w", p.Name, p.Timezone, err)
+			}
+		}
+		for _, id := range p.IDs {
+			if other, dup := d.byID[id]; dup {
+				return nil, fmt.Errorf("id %s is mapped to both %s and %s", id, other.Name, p.Name)
+			}
+			d.byID[id] = p
+		}
+		d.profiles = append(d.profiles, p)
+	}
+	return d, nil
+}
+
+// Lookup resolves a chat or user ID to its profile.
+// picoclaw chat IDs may carry a "chatID|userID" or "channel:chatID" shape,
+// so every segment is tried.
+func (d *Directory) Lookup(id string) (*Profile, bool) {
+	if d == nil || id == "" {
+		return nil, false
+	}
+	if p, ok := d.byID[id]; ok {
+		return p, true
+	}
+	for _, part := range strings.FieldsFunc(id, func(r rune) bool { return r == '|' || r == ':' }) {
+		if p, ok := d.byID[part]; ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Profiles returns all configured users in config order.
+func (d *Directory) Profiles() []*Profile {
+	if d == nil {
+		return nil
+	}
+	return d.profiles
+}
+
+// Len reports how many users are configured.
+func (d *Directory) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.profiles)
+}
@@ -0,0 +1,222 @@
+package users
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// echoTool reports which user it was built for.
+type echoTool struct {
+	owner string
+}
+
+func (t *echoTool) Name() string                       { return "atc" }
+func (t *echoTool) Description() string                { return "echo" }
+func (t *echoTool) Parameters() map[string]interface{} { return map[string]interface{}{} }
+func (t *echoTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	return &tools.ToolResult{ForLLM: t.owner, ForUser: t.owner}
+}
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadAndLookup(t *testing.T) {
+	path := writeConfig(t, `{
+		"users": [
+			{"name": "jony", "ids": ["111", "222"], "workspace": "/tmp/jony", "timezone": "Asia/Dhaka"},
+			{"name": "rafi", "ids": ["333"]}
+		]
+	}`)
+
+	dir, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if dir.Len() != 2 {
+		t.Fatalf("expected 2 users, got %d", dir.Len())
+	}
+
+	cases := []struct {
+		id   string
+		want string
+	}{
+		{"111", "jony"},
+		{"222", "jony"},
+		{"333", "rafi"},
+		{"333|rafi_handle", "rafi"},
+		{"telegram:111", "jony"},
+	}
+	for _, c := range cases {
+		p, ok := dir.Lookup(c.id)
+		if !ok || p.Name != c.want {
+			t.Errorf("Lookup(%q) = %v, want %s", c.id, p, c.want)
+		}
+	}
+	if _, ok := dir.Lookup("999"); ok {
+		t.Error("unknown id should not resolve")
+	}
+
+	rafi, _ := dir.Lookup("333")
+	if filepath.Base(rafi.Workspace) != "rafi" {
+		t.Errorf("default workspace should end in user name, got %s", rafi.Workspace)
+	}
+	jony, _ := dir.Lookup("111")
+	if jony.Location().String() != "Asia/Dhaka" {
+		t.Errorf("expected Asia/Dhaka, got %s", jony.Location())
+	}
+}
+
+func TestLoadRejectsSharedID(t *testing.T) {
+	path := writeConfig(t, `{"users": [
+		{"name": "a", "ids": ["1"]},
+		{"name": "b", "ids": ["1"]}
+	]}`)
+	if _, err := Load(path); err == nil {
+		t.Fatal("an id mapped to two users must be rejected")
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	dir, err := Load(filepath.Join(t.TempDir(), "nope.json"))
+	if err != nil {
+		t.Fatalf("missing config should not error: %v", err)
+	}
+	if dir.Len() != 0 {
+		t.Fatal("missing config should yield empty directory")
+	}
+}
+
+func TestRouterIsolatesUsers(t *testing.T) {
+	path := writeConfig(t, `{"users": [
+		{"name": "jony", "ids": ["111"]},
+		{"name": "rafi", "ids": ["333"]}
+	]}`)
+	dir, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	builds := 0
+	sessions := NewSessions(dir, func(p *Profile) []tools.Tool {
+		builds++
+		return []tools.Tool{&echoTool{owner: p.Name}}
+	})
+	// Calls go through picoclaw's registry, which hands the chat to the
+	// router with SetContext right before Execute.
+	registry := tools.NewToolRegistry()
+	registry.Register(sessions.Wrap(&echoTool{owner: "fallback"}))
+
+	run := func(channel, chatID string) *tools.ToolResult {
+		return registry.ExecuteWithContext(context.Background(), "atc", nil, channel, chatID, nil)
+	}
+
+	if got := run("telegram", "111").ForLLM; got != "jony" {
+		t.Errorf("chat 111 routed to %s", got)
+	}
+	if got := run("telegram", "333").ForLLM; got != "rafi" {
+		t.Errorf("chat 333 routed to %s", got)
+	}
+	if got := run("telegram", "111").ForLLM; got != "jony" {
+		t.Errorf("chat 111 routed to %s on second call", got)
+	}
+	if builds != 2 {
+		t.Errorf("expected one toolset per user, built %d", builds)
+	}
+	if got := run("cli", "direct").ForLLM; got != "fallback" {
+		t.Errorf("cli should use fallback, got %s", got)
+	}
+	if res := run("telegram", "999"); !res.IsError {
+		t.Error("unmapped chat must be rejected")
+	}
+	if res := registry.Execute(context.Background(), "atc", nil); !res.IsError {
+		t.Error("a call without a chat must be rejected")
+	}
+
+	// Concurrent chats each get their own user's tool.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		chatID, want := "111", "jony"
+		if i%2 == 1 {
+			chatID, want = "333", "rafi"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := run("telegram", chatID).ForLLM; got != want {
+				t.Errorf("chat %s routed to %s", chatID, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// blockingTool reports its owner and the chat it was called for. A call
+// from blockChat waits until release is closed.
+type blockingTool struct {
+	owner     string
+	blockChat string
+	started   chan struct{}
+	release   chan struct{}
+}
+
+func (t *blockingTool) Name() string                       { return "chief" }
+func (t *blockingTool) Description() string                { return "block" }
+func (t *blockingTool) Parameters() map[string]interface{} { return map[string]interface{}{} }
+func (t *blockingTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	chat, _ := ChatFrom(ctx)
+	if t.blockChat != "" && chat.ChatID == t.blockChat {
+		close(t.started)
+		<-t.release
+	}
+	return &tools.ToolResult{ForLLM: t.owner + "@" + chat.ChatID, ForUser: t.owner}
+}
+
+func TestRouterDoesNotSerializeChats(t *testing.T) {
+	dir, err := Load(writeConfig(t, `{"users": [
+		{"name": "jony", "ids": ["111", "112"]},
+		{"name": "rafi", "ids": ["333"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	slow := &blockingTool{owner: "jony", blockChat: "111", started: make(chan struct{}), release: make(chan struct{})}
+	sessions := NewSessions(dir, func(p *Profile) []tools.Tool {
+		if p.Name == "jony" {
+			return []tools.Tool{slow}
+		}
+		return []tools.Tool{&blockingTool{owner: p.Name}}
+	})
+	registry := tools.NewToolRegistry()
+	registry.Register(sessions.Wrap(&blockingTool{owner: "fallback"}))
+	run := func(chatID string) string {
+		return registry.ExecuteWithContext(context.Background(), "chief", nil, "telegram", chatID, nil).ForLLM
+	}
+
+	// jony's call from chat 111 is still running while rafi's call, and
+	// jony's own call from chat 112, arrive and finish with their own chats.
+	done := make(chan string)
+	go func() { done <- run("111") }()
+	<-slow.started
+
+	if got := run("333"); got != "rafi@333" {
+		t.Errorf("chat 333 got %s while chat 111 was running", got)
+	}
+	if got := run("112"); got != "jony@112" {
+		t.Errorf("chat 112 got %s while chat 111 was running", got)
+	}
+	close(slow.release)
+	if got := <-done; got != "jony@111" {
+		t.Errorf("chat 111 got %s", got)
+	}
+}